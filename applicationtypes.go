@@ -0,0 +1,104 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// GetApplicationTypes returns every provisioned application type version
+func (c ServiceFabricClient) GetApplicationTypes(ctx context.Context) (page *ApplicationTypeItemsPage, err error) {
+	ctx, call := c.startCall(ctx, "GetApplicationTypes")
+	defer func() { call.finish(err) }()
+
+	return c.getApplicationTypes(ctx, "ApplicationTypes/")
+}
+
+// GetApplicationTypeVersions returns the provisioned versions of an application type
+func (c ServiceFabricClient) GetApplicationTypeVersions(ctx context.Context, typeName string) (page *ApplicationTypeItemsPage, err error) {
+	ctx, call := c.startCall(ctx, "GetApplicationTypeVersions")
+	defer func() { call.finish(err) }()
+
+	return c.getApplicationTypes(ctx, "ApplicationTypes/"+typeName)
+}
+
+func (c ServiceFabricClient) getApplicationTypes(ctx context.Context, basePath string) (*ApplicationTypeItemsPage, error) {
+	var aggregateTypeItemsPages ApplicationTypeItemsPage
+	var continueToken string
+	for {
+		res, _, err := c.getHTTP(ctx, basePath, withContinue(continueToken))
+		if err != nil {
+			return nil, err
+		}
+
+		var typeItemsPage ApplicationTypeItemsPage
+		err = json.Unmarshal(res, &typeItemsPage)
+		if err != nil {
+			return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+		}
+
+		aggregateTypeItemsPages.Items = append(aggregateTypeItemsPages.Items, typeItemsPage.Items...)
+
+		continueToken = getString(typeItemsPage.ContinuationToken)
+		if continueToken == "" {
+			break
+		}
+	}
+	return &aggregateTypeItemsPages, nil
+}
+
+// GetApplicationsByType returns the applications of an application type
+// together with the type versions they run
+func (c ServiceFabricClient) GetApplicationsByType(ctx context.Context, typeName string) (byType *ApplicationsByType, err error) {
+	ctx, call := c.startCall(ctx, "GetApplicationsByType")
+	defer func() { call.finish(err) }()
+
+	apps, err := c.getApplications(ctx, func(app *ApplicationItem) bool {
+		// older API versions ignore the type filter
+		return app.TypeName == typeName
+	}, withParam("ApplicationTypeName", typeName))
+	if err != nil {
+		return nil, err
+	}
+
+	byType = &ApplicationsByType{
+		TypeName:     typeName,
+		Applications: apps.Items,
+		Versions:     map[string][]string{},
+	}
+	for _, app := range apps.Items {
+		byType.Versions[app.TypeVersion] = append(byType.Versions[app.TypeVersion], app.Name)
+	}
+	return byType, nil
+}
+
+// TypeUsageReport lists every provisioned application type version
+// along with the applications still running it
+func (c ServiceFabricClient) TypeUsageReport(ctx context.Context) ([]TypeVersionUsage, error) {
+	types, err := c.GetApplicationTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	apps, err := c.GetApplications(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	inUse := map[[2]string][]string{}
+	for _, app := range apps.Items {
+		key := [2]string{app.TypeName, app.TypeVersion}
+		inUse[key] = append(inUse[key], app.Name)
+	}
+
+	report := make([]TypeVersionUsage, 0, len(types.Items))
+	for _, appType := range types.Items {
+		report = append(report, TypeVersionUsage{
+			TypeName:     appType.Name,
+			TypeVersion:  appType.Version,
+			Status:       appType.Status,
+			Applications: inUse[[2]string{appType.Name, appType.Version}],
+		})
+	}
+	return report, nil
+}
@@ -0,0 +1,342 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Application upgrade states
+const (
+	UpgradeStateRollingForwardPending    = "RollingForwardPending"
+	UpgradeStateRollingForwardInProgress = "RollingForwardInProgress"
+	UpgradeStateRollingForwardCompleted  = "RollingForwardCompleted"
+	UpgradeStateRollingBackInProgress    = "RollingBackInProgress"
+	UpgradeStateRollingBackCompleted     = "RollingBackCompleted"
+	UpgradeStateFailed                   = "Failed"
+)
+
+const defaultUpgradePollInterval = 10 * time.Second
+
+// ErrUpgradeRolledBack is returned by WaitForUpgradeCompletion
+// when the upgrade rolled back instead of completing
+var ErrUpgradeRolledBack = errors.New("application upgrade rolled back")
+
+// ErrUpgradeFailed is returned by WaitForUpgradeCompletion when the upgrade
+// failed and waits for a manual rollback or update, see FailureAction
+var ErrUpgradeFailed = errors.New("application upgrade failed")
+
+// ApplicationUpgradeDescription describes an application upgrade to
+// another provisioned version of its application type. Parameters not
+// listed are reset to their default values in the target version, so
+// callers keeping overrides must list them again.
+type ApplicationUpgradeDescription struct {
+	Name                         string         `json:"Name"`
+	TargetApplicationTypeVersion string         `json:"TargetApplicationTypeVersion"`
+	Parameters                   []AppParameter `json:"Parameters"`
+	UpgradeKind                  string         `json:"UpgradeKind,omitempty"`
+	RollingUpgradeMode           string         `json:"RollingUpgradeMode,omitempty"`
+	// UpgradeReplicaSetCheckTimeoutInSeconds bounds how long an upgrade domain
+	// waits for replica sets to become available, nil taking the cluster default
+	UpgradeReplicaSetCheckTimeoutInSeconds *int64 `json:"UpgradeReplicaSetCheckTimeoutInSeconds,omitempty"`
+	// ForceRestart restarts code packages even when only config or data changed
+	ForceRestart bool `json:"ForceRestart,omitempty"`
+	// SortOrder is the order upgrade domains are upgraded in, see UpgradeSortOrderDefault
+	SortOrder string `json:"SortOrder,omitempty"`
+	// InstanceCloseDelayDurationInSeconds overrides the instance close delay of
+	// stateless services during the upgrade, nil keeping each service's own
+	InstanceCloseDelayDurationInSeconds *int64                       `json:"InstanceCloseDelayDurationInSeconds,omitempty"`
+	MonitoringPolicy                    *MonitoringPolicyDescription `json:"MonitoringPolicy,omitempty"`
+	// ApplicationHealthPolicy evaluates the application health in monitored
+	// upgrades, nil taking the policy of the application manifest
+	ApplicationHealthPolicy *ApplicationHealthPolicy `json:"ApplicationHealthPolicy,omitempty"`
+}
+
+// validate checks the names, the mode and the policies of the upgrade
+func (d *ApplicationUpgradeDescription) validate() error {
+	if !strings.HasPrefix(d.Name, fabricScheme) {
+		return fmt.Errorf("application name %q must start with %s", d.Name, fabricScheme)
+	}
+	if d.TargetApplicationTypeVersion == "" {
+		return errors.New("target application type version is required")
+	}
+	switch d.RollingUpgradeMode {
+	case "", UpgradeModeUnmonitoredAuto, UpgradeModeUnmonitoredManual, UpgradeModeMonitored:
+	default:
+		return fmt.Errorf("invalid rolling upgrade mode %q", d.RollingUpgradeMode)
+	}
+	if d.RollingUpgradeMode != UpgradeModeMonitored && (d.MonitoringPolicy != nil || d.ApplicationHealthPolicy != nil) {
+		return errors.New("monitoring and health policies only apply to monitored upgrades")
+	}
+	if err := validateSortOrder(d.SortOrder); err != nil {
+		return err
+	}
+	if d.MonitoringPolicy != nil {
+		if err := d.MonitoringPolicy.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartApplicationUpgrade starts upgrading the application appID to another
+// version of its application type, or to new parameter values. The upgrade
+// kind defaults to Rolling and the mode to UnmonitoredAuto.
+func (c ServiceFabricClient) StartApplicationUpgrade(ctx context.Context, appID string, description ApplicationUpgradeDescription) (err error) {
+	ctx, call := c.startCall(ctx, "StartApplicationUpgrade")
+	defer func() { call.finish(err) }()
+
+	if err := description.validate(); err != nil {
+		return err
+	}
+	if description.UpgradeKind == "" {
+		description.UpgradeKind = "Rolling"
+	}
+	if description.Parameters == nil {
+		description.Parameters = []AppParameter{}
+	}
+
+	body, err := json.Marshal(description)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.postHTTP(ctx, opStartApplicationUpgrade.on(appID), "Applications/"+appID+"/$/Upgrade", body)
+	if err != nil {
+		return errors.Wrap(err, "failed starting application upgrade")
+	}
+	return nil
+}
+
+// ApplicationUpgradeUpdateDescription changes the parameters of the
+// upgrade of an application in progress, nil fields being left unchanged
+type ApplicationUpgradeUpdateDescription struct {
+	Name                    string                           `json:"Name"`
+	UpgradeKind             string                           `json:"UpgradeKind"`
+	ApplicationHealthPolicy *ApplicationHealthPolicy         `json:"ApplicationHealthPolicy,omitempty"`
+	UpdateDescription       *RollingUpgradeUpdateDescription `json:"UpdateDescription,omitempty"`
+}
+
+// UpdateApplicationUpgrade changes the health policy or the rolling
+// parameters of the upgrade of the application appID in progress
+func (c ServiceFabricClient) UpdateApplicationUpgrade(ctx context.Context, appID string, update ApplicationUpgradeUpdateDescription) (err error) {
+	ctx, call := c.startCall(ctx, "UpdateApplicationUpgrade")
+	defer func() { call.finish(err) }()
+
+	if !strings.HasPrefix(update.Name, fabricScheme) {
+		return fmt.Errorf("application name %q must start with %s", update.Name, fabricScheme)
+	}
+	if update.ApplicationHealthPolicy == nil && update.UpdateDescription == nil {
+		return errors.New("application upgrade update changes nothing")
+	}
+	if update.UpdateDescription != nil {
+		if update.UpdateDescription.RollingUpgradeMode == "" {
+			return errors.New("rolling upgrade mode is required to update an upgrade")
+		}
+		if err := update.UpdateDescription.MonitoringPolicyDescription.Validate(); err != nil {
+			return err
+		}
+	}
+	if update.UpgradeKind == "" {
+		update.UpgradeKind = "Rolling"
+	}
+
+	body, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.postHTTP(ctx, opUpdateApplicationUpgrade.on(appID), "Applications/"+appID+"/$/UpdateUpgrade", body)
+	if err != nil {
+		return errors.Wrap(err, "failed updating application upgrade")
+	}
+	return nil
+}
+
+// ResumeApplicationUpgrade starts upgrading upgradeDomain, for upgrades in
+// UnmonitoredManual mode or whose monitoring policy failure action is
+// Manual. An empty upgradeDomain resumes the next pending upgrade domain.
+func (c ServiceFabricClient) ResumeApplicationUpgrade(ctx context.Context, appID, upgradeDomain string) (err error) {
+	ctx, call := c.startCall(ctx, "ResumeApplicationUpgrade")
+	defer func() { call.finish(err) }()
+
+	if upgradeDomain == "" {
+		progress, err := c.getApplicationUpgradeProgress(ctx, appID)
+		if err != nil {
+			return err
+		}
+		if progress.NextUpgradeDomain == "" {
+			return fmt.Errorf("upgrade of %s has no pending upgrade domain, state %s", appID, progress.UpgradeState)
+		}
+		upgradeDomain = progress.NextUpgradeDomain
+	}
+
+	body, err := json.Marshal(struct {
+		UpgradeDomainName string `json:"UpgradeDomainName"`
+	}{upgradeDomain})
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.postHTTP(ctx, opResumeApplicationUpgrade.on(appID), "Applications/"+appID+"/$/MoveToNextUpgradeDomain", body)
+	if err != nil {
+		return errors.Wrap(err, "failed resuming application upgrade")
+	}
+	return nil
+}
+
+// RollbackApplicationUpgrade rolls the upgrade of the application appID
+// in progress back to the previous version
+func (c ServiceFabricClient) RollbackApplicationUpgrade(ctx context.Context, appID string) (err error) {
+	ctx, call := c.startCall(ctx, "RollbackApplicationUpgrade")
+	defer func() { call.finish(err) }()
+
+	_, _, err = c.postHTTP(ctx, opRollbackApplicationUpgrade.on(appID), "Applications/"+appID+"/$/RollbackUpgrade", []byte{})
+	if err != nil {
+		return errors.Wrap(err, "failed rolling back application upgrade")
+	}
+	return nil
+}
+
+// NodeUpgradeProgress reports the upgrade of one node of an upgrade domain
+type NodeUpgradeProgress struct {
+	NodeName     string `json:"NodeName"`
+	UpgradePhase string `json:"UpgradePhase"`
+	// PendingSafetyChecks are the checks the node waits for
+	// before it is restarted, such as EnsurePartitionQuorum
+	PendingSafetyChecks []struct {
+		SafetyCheck struct {
+			Kind        string `json:"Kind"`
+			PartitionID string `json:"PartitionId,omitempty"`
+		} `json:"SafetyCheck"`
+	} `json:"PendingSafetyChecks"`
+}
+
+// UpgradeDomainProgress reports the upgrade of the nodes of an upgrade domain
+type UpgradeDomainProgress struct {
+	DomainName              string                `json:"DomainName"`
+	NodeUpgradeProgressList []NodeUpgradeProgress `json:"NodeUpgradeProgressList"`
+}
+
+// ApplicationUpgradeProgress reports the progress of the current or last
+// upgrade of an application, with the evaluations that failed it when
+// health checks did not pass
+type ApplicationUpgradeProgress struct {
+	Name                           string                         `json:"Name"`
+	TypeName                       string                         `json:"TypeName"`
+	TargetApplicationTypeVersion   string                         `json:"TargetApplicationTypeVersion"`
+	UpgradeDomains                 []UpgradeDomainInfo            `json:"UpgradeDomains"`
+	UpgradeState                   string                         `json:"UpgradeState"`
+	NextUpgradeDomain              string                         `json:"NextUpgradeDomain"`
+	RollingUpgradeMode             string                         `json:"RollingUpgradeMode"`
+	UpgradeDescription             *ApplicationUpgradeDescription `json:"UpgradeDescription"`
+	UpgradeDurationInMilliseconds  string                         `json:"UpgradeDurationInMilliseconds"`
+	UnhealthyEvaluations           []HealthEvaluationWrapper      `json:"UnhealthyEvaluations"`
+	CurrentUpgradeDomainProgress   UpgradeDomainProgress          `json:"CurrentUpgradeDomainProgress"`
+	StartTimestampUtc              string                         `json:"StartTimestampUtc"`
+	FailureTimestampUtc            string                         `json:"FailureTimestampUtc"`
+	FailureReason                  string                         `json:"FailureReason"`
+	UpgradeDomainProgressAtFailure UpgradeDomainProgress          `json:"UpgradeDomainProgressAtFailure"`
+	UpgradeStatusDetails           string                         `json:"UpgradeStatusDetails"`
+}
+
+// Done reports whether the upgrade stopped progressing, having
+// completed, rolled back or failed
+func (p *ApplicationUpgradeProgress) Done() bool {
+	switch p.UpgradeState {
+	case UpgradeStateRollingForwardCompleted, UpgradeStateRollingBackCompleted, UpgradeStateFailed:
+		return true
+	}
+	return false
+}
+
+// failure describes why the upgrade rolled back or failed
+func (p *ApplicationUpgradeProgress) failure() string {
+	reasons := []string{p.FailureReason}
+	if domain := p.UpgradeDomainProgressAtFailure.DomainName; domain != "" {
+		reasons[0] += " in upgrade domain " + domain
+	}
+	for _, evaluation := range p.UnhealthyEvaluations {
+		reasons = append(reasons, evaluation.HealthEvaluation.Description)
+	}
+	if p.UpgradeStatusDetails != "" {
+		reasons = append(reasons, p.UpgradeStatusDetails)
+	}
+	return strings.Join(reasons, ": ")
+}
+
+// GetApplicationUpgradeProgress returns the progress of the current or last
+// upgrade of the application appID
+func (c ServiceFabricClient) GetApplicationUpgradeProgress(ctx context.Context, appID string) (progress *ApplicationUpgradeProgress, err error) {
+	ctx, call := c.startCall(ctx, "GetApplicationUpgradeProgress")
+	defer func() { call.finish(err) }()
+
+	return c.getApplicationUpgradeProgress(ctx, appID)
+}
+
+func (c ServiceFabricClient) getApplicationUpgradeProgress(ctx context.Context, appID string) (*ApplicationUpgradeProgress, error) {
+	res, _, err := c.getHTTP(ctx, "Applications/"+appID+"/$/GetUpgradeProgress")
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting application upgrade progress")
+	}
+
+	var progress ApplicationUpgradeProgress
+	if err := json.Unmarshal(res, &progress); err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return &progress, nil
+}
+
+// UpgradeWaitOptions tunes WaitForUpgradeCompletion
+type UpgradeWaitOptions struct {
+	// PollInterval between progress checks, defaults to 10 seconds
+	PollInterval time.Duration
+	// Progress, if set, is called with the progress of the upgrade
+	// every time it is polled
+	Progress func(*ApplicationUpgradeProgress)
+}
+
+// WaitForUpgradeCompletion polls the upgrade of the application appID until
+// it completes, returning its last progress. Upgrades that rolled back fail
+// with ErrUpgradeRolledBack and upgrades that failed with ErrUpgradeFailed,
+// along with the failure reason and the unhealthy evaluations. Bound the wait
+// with ctx, which upgrades in UnmonitoredManual mode outlast until every
+// upgrade domain is resumed. opts may be nil.
+func (c ServiceFabricClient) WaitForUpgradeCompletion(ctx context.Context, appID string, opts *UpgradeWaitOptions) (progress *ApplicationUpgradeProgress, err error) {
+	ctx, call := c.startCall(ctx, "WaitForUpgradeCompletion")
+	defer func() { call.finish(err) }()
+
+	interval := defaultUpgradePollInterval
+	if opts != nil && opts.PollInterval > 0 {
+		interval = opts.PollInterval
+	}
+
+	for {
+		progress, err := c.getApplicationUpgradeProgress(ctx, appID)
+		if err != nil {
+			return nil, err
+		}
+		if opts != nil && opts.Progress != nil {
+			opts.Progress(progress)
+		}
+
+		switch progress.UpgradeState {
+		case UpgradeStateRollingForwardCompleted:
+			return progress, nil
+		case UpgradeStateRollingBackCompleted:
+			return progress, errors.Wrapf(ErrUpgradeRolledBack, "%s to version %s: %s", progress.Name, progress.TargetApplicationTypeVersion, progress.failure())
+		case UpgradeStateFailed:
+			return progress, errors.Wrapf(ErrUpgradeFailed, "%s to version %s: %s", progress.Name, progress.TargetApplicationTypeVersion, progress.failure())
+		}
+
+		select {
+		case <-ctx.Done():
+			return progress, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
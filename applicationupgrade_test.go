@@ -0,0 +1,173 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStartApplicationUpgrade(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/Applications/TestApplication/$/Upgrade" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Exception thrown %v", err)
+		}
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	policy, err := NewMonitoringPolicy(FailureActionRollback).HealthCheckRetryTimeout(10 * time.Minute).Build()
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	err = sfClient.StartApplicationUpgrade(context.Background(), "TestApplication", ApplicationUpgradeDescription{
+		Name:                         "fabric:/TestApplication",
+		TargetApplicationTypeVersion: "2.0.0",
+		Parameters:                   []AppParameter{{Key: "InstanceCount", Value: "3"}},
+		RollingUpgradeMode:           UpgradeModeMonitored,
+		MonitoringPolicy:             policy,
+		ApplicationHealthPolicy:      &ApplicationHealthPolicy{ConsiderWarningAsError: true},
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"Name":                         "fabric:/TestApplication",
+		"TargetApplicationTypeVersion": "2.0.0",
+		"Parameters": []interface{}{
+			map[string]interface{}{"Key": "InstanceCount", "Value": "3"},
+		},
+		"UpgradeKind":        "Rolling",
+		"RollingUpgradeMode": "Monitored",
+		"MonitoringPolicy": map[string]interface{}{
+			"FailureAction":                         "Rollback",
+			"HealthCheckRetryTimeoutInMilliseconds": "PT0H10M0S",
+		},
+		"ApplicationHealthPolicy": map[string]interface{}{
+			"ConsiderWarningAsError":                  true,
+			"MaxPercentUnhealthyDeployedApplications": float64(0),
+		},
+	}
+	if !reflect.DeepEqual(body, expected) {
+		t.Errorf("Got %+v, want %+v", body, expected)
+	}
+
+	invalid := []ApplicationUpgradeDescription{
+		{Name: "TestApplication", TargetApplicationTypeVersion: "2.0.0"},
+		{Name: "fabric:/TestApplication"},
+		{Name: "fabric:/TestApplication", TargetApplicationTypeVersion: "2.0.0", RollingUpgradeMode: "Monitoring"},
+		{Name: "fabric:/TestApplication", TargetApplicationTypeVersion: "2.0.0", MonitoringPolicy: policy},
+	}
+	for _, description := range invalid {
+		if err := sfClient.StartApplicationUpgrade(context.Background(), "TestApplication", description); err == nil {
+			t.Errorf("Error should have been returned for %+v", description)
+		}
+	}
+}
+
+func TestWaitForUpgradeCompletion(t *testing.T) {
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/Applications/TestApplication/$/GetUpgradeProgress" {
+			http.NotFound(w, r)
+			return
+		}
+		polls++
+		if polls == 1 {
+			w.Write([]byte(`{"Name":"fabric:/TestApplication","UpgradeState":"RollingForwardInProgress","CurrentUpgradeDomainProgress":{"DomainName":"1"}}`))
+			return
+		}
+		writeFixture(w, "application_upgrade_progress.json")
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	var domains []string
+	progress, err := sfClient.WaitForUpgradeCompletion(context.Background(), "TestApplication", &UpgradeWaitOptions{
+		PollInterval: time.Millisecond,
+		Progress: func(p *ApplicationUpgradeProgress) {
+			domains = append(domains, p.CurrentUpgradeDomainProgress.DomainName)
+		},
+	})
+	if !errors.Is(err, ErrUpgradeRolledBack) {
+		t.Fatalf("Got %v, want %v", err, ErrUpgradeRolledBack)
+	}
+	if !strings.Contains(err.Error(), "HealthCheck in upgrade domain 1: 100% (1/1) services") {
+		t.Errorf("Got %v, want the failure reason and unhealthy evaluations", err)
+	}
+	if !reflect.DeepEqual(domains, []string{"1", ""}) {
+		t.Errorf("Got %+v, want %+v", domains, []string{"1", ""})
+	}
+
+	checks := progress.UpgradeDomainProgressAtFailure.NodeUpgradeProgressList[0].PendingSafetyChecks
+	if len(checks) != 1 || checks[0].SafetyCheck.Kind != "EnsurePartitionQuorum" {
+		t.Errorf("Got %+v, want a pending EnsurePartitionQuorum check", checks)
+	}
+	if !progress.Done() {
+		t.Error("Rolled back upgrade should be done")
+	}
+}
+
+func TestApplicationUpgradeControl(t *testing.T) {
+	bodies := map[string]map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			w.Write([]byte(`{"UpgradeState":"RollingForwardPending","NextUpgradeDomain":"2"}`))
+			return
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies[strings.TrimPrefix(r.URL.Path, "/Applications/TestApplication/$/")] = body
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	if err := sfClient.ResumeApplicationUpgrade(context.Background(), "TestApplication", ""); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if err := sfClient.RollbackApplicationUpgrade(context.Background(), "TestApplication"); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	err := sfClient.UpdateApplicationUpgrade(context.Background(), "TestApplication", ApplicationUpgradeUpdateDescription{
+		Name:                    "fabric:/TestApplication",
+		ApplicationHealthPolicy: &ApplicationHealthPolicy{MaxPercentUnhealthyDeployedApplications: 20},
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := map[string]map[string]interface{}{
+		"MoveToNextUpgradeDomain": {"UpgradeDomainName": "2"},
+		"RollbackUpgrade":         nil,
+		"UpdateUpgrade": {
+			"Name":        "fabric:/TestApplication",
+			"UpgradeKind": "Rolling",
+			"ApplicationHealthPolicy": map[string]interface{}{
+				"ConsiderWarningAsError":                  false,
+				"MaxPercentUnhealthyDeployedApplications": float64(20),
+			},
+		},
+	}
+	if !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Got %+v, want %+v", bodies, expected)
+	}
+
+	err = sfClient.UpdateApplicationUpgrade(context.Background(), "TestApplication", ApplicationUpgradeUpdateDescription{Name: "fabric:/TestApplication"})
+	if err == nil {
+		t.Error("Error should have been returned")
+	}
+}
@@ -0,0 +1,67 @@
+package servicefabric
+
+import (
+	"strings"
+	"time"
+)
+
+// AuditSink receives an AuditEvent for every mutating call the client
+// attempts, including calls refused client side
+type AuditSink interface {
+	Audit(event AuditEvent)
+}
+
+// AuditSinkFunc adapts a function to the AuditSink interface
+type AuditSinkFunc func(event AuditEvent)
+
+// Audit calls f(event)
+func (f AuditSinkFunc) Audit(event AuditEvent) {
+	f(event)
+}
+
+// AuditEvent describes the outcome of a mutating call
+type AuditEvent struct {
+	// Operation names the call and the entity it acted on
+	Operation Operation
+	// Parameters holds the query parameters sent, except api-version,
+	// with secrets masked, see WithRedactionPatterns
+	Parameters map[string]string
+	// Body is the request body sent, if any, with secrets masked
+	Body []byte
+	// Caller identifies who drives the client, see WithAuditSink
+	Caller string
+	Time   time.Time
+	// StatusCode is zero when no response was received
+	StatusCode int
+	// Err is nil when the call succeeded
+	Err error
+}
+
+func (c ServiceFabricClient) audit(op Operation, params []string, body []byte, status int, err error) {
+	if c.auditSink == nil {
+		return
+	}
+
+	parameters := map[string]string{}
+	for _, param := range params {
+		kv := strings.SplitN(param, "=", 2)
+		if kv[0] == "api-version" {
+			continue
+		}
+		if len(kv) == 2 {
+			parameters[kv[0]] = kv[1]
+		} else {
+			parameters[kv[0]] = ""
+		}
+	}
+
+	c.auditSink.Audit(AuditEvent{
+		Operation:  op,
+		Parameters: c.redactor.Parameters(parameters),
+		Body:       c.redactor.Body(body),
+		Caller:     c.auditCaller,
+		Time:       time.Now().UTC(),
+		StatusCode: status,
+		Err:        err,
+	})
+}
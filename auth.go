@@ -0,0 +1,126 @@
+package servicefabric
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// tokenRefreshMargin is how long before it expires a token is refreshed,
+// so that requests in flight do not reach the gateway with an expired token
+const tokenRefreshMargin = 5 * time.Minute
+
+// Token is a bearer token and the time it expires at
+type Token struct {
+	AccessToken string
+	ExpiresOn   time.Time
+}
+
+// TokenCredential obtains bearer tokens for clusters secured with
+// Azure Active Directory, scoped to the cluster application
+type TokenCredential interface {
+	GetToken(ctx context.Context) (Token, error)
+}
+
+// TokenCredentialFunc adapts a function to the TokenCredential interface
+type TokenCredentialFunc func(ctx context.Context) (Token, error)
+
+// GetToken calls f(ctx)
+func (f TokenCredentialFunc) GetToken(ctx context.Context) (Token, error) {
+	return f(ctx)
+}
+
+// CredentialRefresher is implemented by credentials that can be renewed
+// out of band, such as a token credential bypassing its own cache or TLS
+// credentials reloading a rotated certificate. The client refreshes its
+// credentials once when the cluster rejects them with 401 Unauthorized or
+// 403 Forbidden, and sends the rejected request again.
+type CredentialRefresher interface {
+	Refresh(ctx context.Context) error
+}
+
+// tokenCache holds the last token obtained from a credential until it nears expiry
+type tokenCache struct {
+	credential TokenCredential
+
+	mu    sync.Mutex
+	token Token
+	// refreshes counts the tokens dropped by refresh
+	refreshes int
+}
+
+// get returns the cached token, obtaining a new one
+// when none is cached or the cached one nears expiry
+func (t *tokenCache) get(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token.AccessToken != "" && time.Now().Add(tokenRefreshMargin).Before(t.token.ExpiresOn) {
+		return t.token.AccessToken, nil
+	}
+
+	token, err := t.credential.GetToken(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "failed getting access token")
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("credential returned an empty access token")
+	}
+	t.token = token
+	return token.AccessToken, nil
+}
+
+// generation identifies the cached token, for refresh
+func (t *tokenCache) generation() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.refreshes
+}
+
+// refresh drops the cached token, unless it was already dropped since
+// generation, and refreshes the credential when it is a CredentialRefresher
+func (t *tokenCache) refresh(ctx context.Context, generation int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if generation != t.refreshes {
+		// a concurrent request refreshed the token already
+		return nil
+	}
+	t.token = Token{}
+	t.refreshes++
+
+	if refresher, ok := t.credential.(CredentialRefresher); ok {
+		if err := refresher.Refresh(ctx); err != nil {
+			return errors.Wrap(err, "failed refreshing token credential")
+		}
+	}
+	return nil
+}
+
+// canRefreshCredentials reports whether the client has credentials to refresh
+func (c ServiceFabricClient) canRefreshCredentials() bool {
+	return c.tokens != nil || c.refresher != nil
+}
+
+// refreshCredentials refreshes the token credential and the refresher of
+// the client, and drops idle connections so new ones present the refreshed
+// client certificate. generation is the token generation of the request.
+func (c ServiceFabricClient) refreshCredentials(ctx context.Context, generation int) error {
+	if c.tokens != nil {
+		if err := c.tokens.refresh(ctx, generation); err != nil {
+			return err
+		}
+	}
+	if c.refresher != nil {
+		if err := c.refresher.Refresh(ctx); err != nil {
+			return errors.Wrap(err, "failed refreshing credentials")
+		}
+		if client, ok := c.httpClient.(interface{ CloseIdleConnections() }); ok {
+			client.CloseIdleConnections()
+		}
+	}
+	return nil
+}
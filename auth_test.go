@@ -0,0 +1,137 @@
+package servicefabric
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithTokenCredential(t *testing.T) {
+	var authorization []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+		handleApplications(w, r)
+	}))
+	defer server.Close()
+
+	issued := 0
+	expiresOn := time.Now().Add(time.Hour)
+	credential := TokenCredentialFunc(func(ctx context.Context) (Token, error) {
+		issued++
+		return Token{AccessToken: "token" + string(rune('0'+issued)), ExpiresOn: expiresOn}, nil
+	})
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil, WithTokenCredential(credential))
+
+	_, err := sfClient.GetApplications(context.Background())
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if issued != 1 {
+		t.Errorf("Got %d tokens issued, want 1 for both pages", issued)
+	}
+
+	// tokens nearing expiry are refreshed before being sent
+	expiresOn = time.Now().Add(time.Minute)
+	sfClient.tokens.token.ExpiresOn = expiresOn
+	_, err = sfClient.GetApplications(context.Background())
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := []string{"Bearer token1", "Bearer token1", "Bearer token2", "Bearer token3"}
+	if len(authorization) != len(expected) {
+		t.Fatalf("Got %+v, want %+v", authorization, expected)
+	}
+	for i := range expected {
+		if authorization[i] != expected[i] {
+			t.Errorf("Got %+v, want %+v", authorization, expected)
+			break
+		}
+	}
+}
+
+func TestWithTokenCredentialReturnsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected request to %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	credential := TokenCredentialFunc(func(ctx context.Context) (Token, error) {
+		return Token{}, errors.New("no identity")
+	})
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil, WithTokenCredential(credential))
+
+	_, err := sfClient.GetApplications(context.Background())
+	if err == nil {
+		t.Fatal("Error should have been returned")
+	}
+}
+
+// refreshingCredential issues a new token on every call and counts refreshes
+type refreshingCredential struct {
+	issued    int
+	refreshed int
+}
+
+func (c *refreshingCredential) GetToken(ctx context.Context) (Token, error) {
+	c.issued++
+	return Token{AccessToken: fmt.Sprintf("token%d", c.issued), ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func (c *refreshingCredential) Refresh(ctx context.Context) error {
+	c.refreshed++
+	return nil
+}
+
+func TestTokenRefreshedOnUnauthorized(t *testing.T) {
+	var authorization []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") == "Bearer token1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handleApplications(w, r)
+	}))
+	defer server.Close()
+
+	credential := &refreshingCredential{}
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil, WithTokenCredential(credential))
+
+	if _, err := sfClient.GetApplications(context.Background()); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	expected := []string{"Bearer token1", "Bearer token2"}
+	if !reflect.DeepEqual(authorization[:2], expected) {
+		t.Errorf("Got %+v, want %+v", authorization[:2], expected)
+	}
+	if credential.refreshed != 1 {
+		t.Errorf("Got %d refreshes, want 1", credential.refreshed)
+	}
+}
+
+func TestRejectedCredentialsRefreshedOnce(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	credential := &refreshingCredential{}
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil, WithTokenCredential(credential))
+
+	_, err := sfClient.GetNodes(context.Background())
+	if ClassifyError(err) != ErrorClassAuthFailure {
+		t.Errorf("Got %v, want an auth failure", err)
+	}
+	if requests != 2 || credential.refreshed != 1 {
+		t.Errorf("Got %d requests and %d refreshes, want 2 and 1", requests, credential.refreshed)
+	}
+}
@@ -0,0 +1,106 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// NodeLoadMetricInformation reports the load of a node for one metric
+type NodeLoadMetricInformation struct {
+	Name                          string `json:"Name"`
+	NodeCapacity                  int64  `json:"NodeCapacity,string"`
+	NodeLoad                      int64  `json:"NodeLoad,string"`
+	NodeRemainingCapacity         int64  `json:"NodeRemainingCapacity,string"`
+	IsCapacityViolation           bool   `json:"IsCapacityViolation"`
+	NodeBufferedCapacity          int64  `json:"NodeBufferedCapacity,string"`
+	NodeRemainingBufferedCapacity int64  `json:"NodeRemainingBufferedCapacity,string"`
+}
+
+// NodeLoadInfo reports the load of a node for every metric
+type NodeLoadInfo struct {
+	NodeName                  string                      `json:"NodeName"`
+	NodeLoadMetricInformation []NodeLoadMetricInformation `json:"NodeLoadMetricInformation"`
+}
+
+// NodeMetricCapacity reports the capacity and load of a node for one metric.
+// A zero Capacity means the node capacity of the metric is not limited.
+type NodeMetricCapacity struct {
+	Name              string
+	Capacity          int64
+	Load              int64
+	Remaining         int64
+	BufferedCapacity  int64
+	RemainingBuffered int64
+	CapacityViolation bool
+}
+
+// NodeCapacityReport joins a node with its capacity and load per metric
+type NodeCapacityReport struct {
+	Node    NodeItem
+	Metrics []NodeMetricCapacity
+}
+
+// GetNodeLoadInformation returns the load of a node for every metric
+func (c ServiceFabricClient) GetNodeLoadInformation(ctx context.Context, nodeName string) (info *NodeLoadInfo, err error) {
+	ctx, call := c.startCall(ctx, "GetNodeLoadInformation")
+	defer func() { call.finish(err) }()
+
+	return c.getNodeLoadInformation(ctx, nodeName)
+}
+
+func (c ServiceFabricClient) getNodeLoadInformation(ctx context.Context, nodeName string) (*NodeLoadInfo, error) {
+	res, _, err := c.getHTTP(ctx, "Nodes/"+nodeName+"/$/GetLoadInformation")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting node load information")
+	}
+
+	var info NodeLoadInfo
+	err = json.Unmarshal(res, &info)
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return &info, nil
+}
+
+// GetNodeCapacityReport returns the capacity and load of every node, in the
+// order the cluster lists nodes. Load information is queried in parallel,
+// see WithConcurrency.
+func (c ServiceFabricClient) GetNodeCapacityReport(ctx context.Context) (reports []NodeCapacityReport, err error) {
+	ctx, call := c.startCall(ctx, "GetNodeCapacityReport")
+	defer func() { call.finish(err) }()
+
+	nodes, err := c.getNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reports = make([]NodeCapacityReport, len(nodes))
+	err = c.forEach(len(nodes), func(i int) error {
+		info, err := c.getNodeLoadInformation(ctx, nodes[i].Name)
+		if err != nil {
+			return err
+		}
+
+		report := NodeCapacityReport{Node: nodes[i]}
+		for _, metric := range info.NodeLoadMetricInformation {
+			report.Metrics = append(report.Metrics, NodeMetricCapacity{
+				Name:              metric.Name,
+				Capacity:          metric.NodeCapacity,
+				Load:              metric.NodeLoad,
+				Remaining:         metric.NodeRemainingCapacity,
+				BufferedCapacity:  metric.NodeBufferedCapacity,
+				RemainingBuffered: metric.NodeRemainingBufferedCapacity,
+				CapacityViolation: metric.IsCapacityViolation,
+			})
+		}
+		reports[i] = report
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
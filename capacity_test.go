@@ -0,0 +1,68 @@
+package servicefabric
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGetNodeCapacityReport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleNodes))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	expected := []NodeCapacityReport{
+		{
+			Node: NodeItem{
+				Name:            "_Node_0",
+				IPAddressOrFQDN: "10.0.0.4",
+				Type:            "NodeType0",
+				CodeVersion:     "7.2.457.9590",
+				ConfigVersion:   "1",
+				NodeStatus:      "Up",
+				HealthState:     "Ok",
+				IsSeedNode:      true,
+				UpgradeDomain:   "0",
+				FaultDomain:     "fd:/0",
+			},
+			Metrics: []NodeMetricCapacity{
+				{Name: "MemoryInMb", Capacity: 8192, Load: 2048, Remaining: 6144, BufferedCapacity: 7372, RemainingBuffered: 5324},
+				{Name: "Count", Load: 12},
+			},
+		},
+	}
+
+	actual, err := sfClient.GetNodeCapacityReport(context.Background())
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+}
+
+func TestGetNodeCapacityReportReturnsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/Nodes/" {
+			handleNodes(w, r)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetNodeCapacityReport(context.Background())
+	if err == nil {
+		t.Fatal("Error should have been returned")
+	}
+
+	if actual != nil {
+		t.Errorf("Got %+v, want nil", actual)
+	}
+}
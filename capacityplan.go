@@ -0,0 +1,218 @@
+package servicefabric
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ServiceLoadMetricDescription is the default load a service reports for
+// a metric, PrimaryDefaultLoad and SecondaryDefaultLoad applying to stateful
+// services and DefaultLoad to stateless ones
+type ServiceLoadMetricDescription struct {
+	Name                 string `json:"Name"`
+	Weight               string `json:"Weight,omitempty"`
+	PrimaryDefaultLoad   int64  `json:"PrimaryDefaultLoad,omitempty"`
+	SecondaryDefaultLoad int64  `json:"SecondaryDefaultLoad,omitempty"`
+	DefaultLoad          int64  `json:"DefaultLoad,omitempty"`
+}
+
+// PlacementProposal describes a service to be created, as far as placement is concerned
+type PlacementProposal struct {
+	Stateful       bool
+	PartitionCount int
+	// TargetReplicaSetSize is the number of replicas of each stateful partition
+	TargetReplicaSetSize int
+	// InstanceCount is the number of instances of each stateless
+	// partition, -1 placing one instance on every eligible node
+	InstanceCount int
+	LoadMetrics   []ServiceLoadMetricDescription
+	// NodeTypes restricts placement to nodes of these types, every node is eligible when empty
+	NodeTypes []string
+}
+
+// MetricFit compares the load a proposal requires for a metric with the remaining capacity
+type MetricFit struct {
+	Name     string
+	Required int64
+	// Remaining sums the remaining capacity of eligible nodes, buffered
+	// capacity taking precedence when the node defines one
+	Remaining int64
+	// Unlimited is set when an eligible node does not limit the metric
+	Unlimited bool
+	Fits      bool
+}
+
+// FaultDomainFit estimates the load a fault domain receives
+// once the replicas of every partition are spread evenly
+type FaultDomainFit struct {
+	FaultDomain string
+	Nodes       int
+	// Replicas is the largest number of replicas the fault domain hosts
+	Replicas int
+	Metrics  []MetricFit
+	Fits     bool
+}
+
+// PlacementEstimate reports whether a proposal is expected to fit the cluster
+type PlacementEstimate struct {
+	Fits                 bool
+	ReplicasPerPartition int
+	EligibleNodes        int
+	// FittingNodes counts the eligible nodes with room for
+	// one more replica on every metric of the proposal
+	FittingNodes int
+	Metrics      []MetricFit
+	FaultDomains []FaultDomainFit
+}
+
+// PlanPlacement estimates, from the reports of GetNodeCapacityReport, whether
+// the service described by proposal fits the remaining capacity of the cluster
+// per metric and per fault domain. Only nodes that are up are eligible, and the
+// estimate is conservative: primaries are counted at the larger of their primary
+// and secondary loads, and a fault domain is assumed to host the primary of
+// every partition it holds a replica of.
+func PlanPlacement(reports []NodeCapacityReport, proposal PlacementProposal) (*PlacementEstimate, error) {
+	if proposal.PartitionCount < 1 {
+		return nil, fmt.Errorf("partition count must be positive, got %d", proposal.PartitionCount)
+	}
+
+	nodeTypes := map[string]bool{}
+	for _, nodeType := range proposal.NodeTypes {
+		nodeTypes[nodeType] = true
+	}
+	var eligible []NodeCapacityReport
+	for _, report := range reports {
+		if report.Node.NodeStatus != "Up" {
+			continue
+		}
+		if len(nodeTypes) > 0 && !nodeTypes[report.Node.Type] {
+			continue
+		}
+		eligible = append(eligible, report)
+	}
+
+	replicas := proposal.TargetReplicaSetSize
+	if !proposal.Stateful {
+		replicas = proposal.InstanceCount
+		if replicas == -1 {
+			replicas = len(eligible)
+		}
+	}
+	if replicas < 1 && (proposal.Stateful || proposal.InstanceCount != -1) {
+		return nil, fmt.Errorf("replica count must be positive, got %d", replicas)
+	}
+
+	estimate := &PlacementEstimate{
+		ReplicasPerPartition: replicas,
+		EligibleNodes:        len(eligible),
+	}
+
+	for _, report := range eligible {
+		fits := true
+		for _, metric := range proposal.LoadMetrics {
+			remaining, unlimited := nodeRemaining(report, metric.Name)
+			if !unlimited && remaining < replicaLoad(proposal, metric) {
+				fits = false
+			}
+		}
+		if fits {
+			estimate.FittingNodes++
+		}
+	}
+
+	estimate.Metrics = metricFits(eligible, proposal, proposal.PartitionCount*replicas, replicas)
+
+	byFaultDomain := map[string][]NodeCapacityReport{}
+	for _, report := range eligible {
+		byFaultDomain[report.Node.FaultDomain] = append(byFaultDomain[report.Node.FaultDomain], report)
+	}
+	faultDomains := make([]string, 0, len(byFaultDomain))
+	for faultDomain := range byFaultDomain {
+		faultDomains = append(faultDomains, faultDomain)
+	}
+	sort.Strings(faultDomains)
+
+	// every partition places at most this many replicas in one fault domain
+	perPartition := 0
+	if len(faultDomains) > 0 {
+		perPartition = (replicas + len(faultDomains) - 1) / len(faultDomains)
+	}
+
+	estimate.Fits = replicas > 0 && estimate.FittingNodes >= replicas
+	for _, faultDomain := range faultDomains {
+		nodes := byFaultDomain[faultDomain]
+		fit := FaultDomainFit{
+			FaultDomain: faultDomain,
+			Nodes:       len(nodes),
+			Replicas:    proposal.PartitionCount * perPartition,
+			Metrics:     metricFits(nodes, proposal, proposal.PartitionCount*perPartition, perPartition),
+			Fits:        perPartition <= len(nodes),
+		}
+		for _, metric := range fit.Metrics {
+			fit.Fits = fit.Fits && metric.Fits
+		}
+		estimate.Fits = estimate.Fits && fit.Fits
+		estimate.FaultDomains = append(estimate.FaultDomains, fit)
+	}
+	for _, metric := range estimate.Metrics {
+		estimate.Fits = estimate.Fits && metric.Fits
+	}
+	return estimate, nil
+}
+
+// metricFits compares, for every metric of proposal, the load of total replicas,
+// perPartition of which belong to each partition, with the remaining capacity of nodes
+func metricFits(nodes []NodeCapacityReport, proposal PlacementProposal, total, perPartition int) []MetricFit {
+	var fits []MetricFit
+	for _, metric := range proposal.LoadMetrics {
+		fit := MetricFit{Name: metric.Name}
+		if perPartition > 0 {
+			fit.Required = int64(total) * replicaLoad(proposal, metric)
+			if proposal.Stateful {
+				secondaries := int64(total - proposal.PartitionCount)
+				fit.Required = int64(proposal.PartitionCount)*replicaLoad(proposal, metric) + secondaries*metric.SecondaryDefaultLoad
+			}
+		}
+		for _, node := range nodes {
+			remaining, unlimited := nodeRemaining(node, metric.Name)
+			fit.Unlimited = fit.Unlimited || unlimited
+			fit.Remaining += remaining
+		}
+		fit.Fits = fit.Unlimited || fit.Required <= fit.Remaining
+		fits = append(fits, fit)
+	}
+	return fits
+}
+
+// replicaLoad returns the largest load one replica of proposal reports for metric
+func replicaLoad(proposal PlacementProposal, metric ServiceLoadMetricDescription) int64 {
+	if !proposal.Stateful {
+		return metric.DefaultLoad
+	}
+	if metric.SecondaryDefaultLoad > metric.PrimaryDefaultLoad {
+		return metric.SecondaryDefaultLoad
+	}
+	return metric.PrimaryDefaultLoad
+}
+
+// nodeRemaining returns the remaining capacity of node for metric,
+// unlimited being set when the node defines no capacity for it
+func nodeRemaining(node NodeCapacityReport, metricName string) (remaining int64, unlimited bool) {
+	for _, metric := range node.Metrics {
+		if metric.Name != metricName {
+			continue
+		}
+		if metric.Capacity == 0 {
+			return 0, true
+		}
+		remaining = metric.Remaining
+		if metric.BufferedCapacity > 0 {
+			remaining = metric.RemainingBuffered
+		}
+		if remaining < 0 {
+			remaining = 0
+		}
+		return remaining, false
+	}
+	return 0, true
+}
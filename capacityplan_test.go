@@ -0,0 +1,156 @@
+package servicefabric
+
+import (
+	"reflect"
+	"testing"
+)
+
+func capacityReport(name, faultDomain string, capacity, load int64) NodeCapacityReport {
+	return NodeCapacityReport{
+		Node: NodeItem{Name: name, Type: "NodeType0", NodeStatus: "Up", FaultDomain: faultDomain},
+		Metrics: []NodeMetricCapacity{
+			{Name: "MemoryInMb", Capacity: capacity, Load: load, Remaining: capacity - load},
+		},
+	}
+}
+
+func TestPlanPlacement(t *testing.T) {
+	reports := []NodeCapacityReport{
+		capacityReport("_Node_0", "fd:/0", 1000, 200),
+		capacityReport("_Node_1", "fd:/1", 1000, 600),
+		capacityReport("_Node_2", "fd:/2", 1000, 900),
+	}
+
+	tests := []struct {
+		name     string
+		proposal PlacementProposal
+		fits     bool
+		fitting  int
+		required []int64
+	}{
+		{
+			name: "stateful fits",
+			proposal: PlacementProposal{
+				Stateful:             true,
+				PartitionCount:       1,
+				TargetReplicaSetSize: 3,
+				LoadMetrics:          []ServiceLoadMetricDescription{{Name: "MemoryInMb", PrimaryDefaultLoad: 100, SecondaryDefaultLoad: 50}},
+			},
+			fits:     true,
+			fitting:  3,
+			required: []int64{100 + 2*50, 100, 100, 100},
+		},
+		{
+			name: "replica too large for a node",
+			proposal: PlacementProposal{
+				Stateful:             true,
+				PartitionCount:       1,
+				TargetReplicaSetSize: 3,
+				LoadMetrics:          []ServiceLoadMetricDescription{{Name: "MemoryInMb", PrimaryDefaultLoad: 200, SecondaryDefaultLoad: 50}},
+			},
+			fits:     false,
+			fitting:  2,
+			required: []int64{200 + 2*50, 200, 200, 200},
+		},
+		{
+			name: "stateless on every node",
+			proposal: PlacementProposal{
+				PartitionCount: 1,
+				InstanceCount:  -1,
+				LoadMetrics:    []ServiceLoadMetricDescription{{Name: "MemoryInMb", DefaultLoad: 100}},
+			},
+			fits:     true,
+			fitting:  3,
+			required: []int64{300, 100, 100, 100},
+		},
+		{
+			name: "unlimited metric",
+			proposal: PlacementProposal{
+				PartitionCount: 2,
+				InstanceCount:  2,
+				LoadMetrics:    []ServiceLoadMetricDescription{{Name: "Count", DefaultLoad: 1000}},
+			},
+			fits:     true,
+			fitting:  3,
+			required: []int64{4000, 2000, 2000, 2000},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			estimate, err := PlanPlacement(reports, test.proposal)
+			if err != nil {
+				t.Fatalf("Exception thrown %v", err)
+			}
+
+			if estimate.Fits != test.fits {
+				t.Errorf("Got %+v, want %+v", estimate.Fits, test.fits)
+			}
+			if estimate.FittingNodes != test.fitting {
+				t.Errorf("Got %+v, want %+v", estimate.FittingNodes, test.fitting)
+			}
+
+			required := []int64{estimate.Metrics[0].Required}
+			for _, fd := range estimate.FaultDomains {
+				required = append(required, fd.Metrics[0].Required)
+			}
+			if !reflect.DeepEqual(required, test.required) {
+				t.Errorf("Got %+v, want %+v", required, test.required)
+			}
+		})
+	}
+}
+
+func TestPlanPlacementFaultDomains(t *testing.T) {
+	reports := []NodeCapacityReport{
+		capacityReport("_Node_0", "fd:/0", 1000, 0),
+		capacityReport("_Node_1", "fd:/0", 1000, 0),
+		capacityReport("_Node_2", "fd:/1", 1000, 950),
+		capacityReport("_Node_3", "fd:/1", 1000, 950),
+	}
+	reports[3].Node.NodeStatus = "Down"
+
+	estimate, err := PlanPlacement(reports, PlacementProposal{
+		Stateful:             true,
+		PartitionCount:       2,
+		TargetReplicaSetSize: 2,
+		LoadMetrics:          []ServiceLoadMetricDescription{{Name: "MemoryInMb", PrimaryDefaultLoad: 40, SecondaryDefaultLoad: 40}},
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := []FaultDomainFit{
+		{
+			FaultDomain: "fd:/0",
+			Nodes:       2,
+			Replicas:    2,
+			Metrics:     []MetricFit{{Name: "MemoryInMb", Required: 80, Remaining: 2000, Fits: true}},
+			Fits:        true,
+		},
+		{
+			FaultDomain: "fd:/1",
+			Nodes:       1,
+			Replicas:    2,
+			Metrics:     []MetricFit{{Name: "MemoryInMb", Required: 80, Remaining: 50, Fits: false}},
+			Fits:        false,
+		},
+	}
+	if !reflect.DeepEqual(estimate.FaultDomains, expected) {
+		t.Errorf("Got %+v, want %+v", estimate.FaultDomains, expected)
+	}
+	if estimate.Fits {
+		t.Error("Placement should not fit")
+	}
+	if estimate.EligibleNodes != 3 {
+		t.Errorf("Got %+v, want %+v", estimate.EligibleNodes, 3)
+	}
+}
+
+func TestPlanPlacementReturnsError(t *testing.T) {
+	_, err := PlanPlacement(nil, PlacementProposal{Stateful: true, PartitionCount: 1})
+	if err == nil {
+		t.Fatal("Error should have been returned")
+	}
+}
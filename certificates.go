@@ -0,0 +1,182 @@
+package servicefabric
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Find types of the certificates declared by the cluster manifest
+const (
+	X509FindTypeThumbprint  = "FindByThumbprint"
+	X509FindTypeSubjectName = "FindBySubjectName"
+)
+
+// GatewayCertificate is the certificate an HTTP gateway of the cluster presents
+type GatewayCertificate struct {
+	Endpoint   string
+	Thumbprint string
+	Subject    string
+	NotAfter   time.Time
+	// DaysToExpiry is negative once the certificate expired
+	DaysToExpiry int
+	// Declared is set when the cluster manifest declares the certificate,
+	// by thumbprint or subject name, for one of its node types
+	Declared bool
+	// Err is set when the gateway could not be probed
+	Err error
+}
+
+// CertificateThumbprints returns the upper case thumbprints, primary and
+// secondary, of the certificates the node types of the manifest declare
+func (m ClusterManifest) CertificateThumbprints() []string {
+	var thumbprints []string
+	for _, nodeType := range m.NodeTypes {
+		for _, cert := range nodeType.Certificates.Items {
+			if !strings.EqualFold(cert.X509FindType, X509FindTypeThumbprint) {
+				continue
+			}
+			for _, value := range []string{cert.X509FindValue, cert.X509FindValueSecondary} {
+				if value = normalizeThumbprint(value); value != "" {
+					thumbprints = append(thumbprints, value)
+				}
+			}
+		}
+	}
+	return sortedDistinct(thumbprints)
+}
+
+// certificateSubjectNames returns the subject names the node types of the manifest declare
+func (m ClusterManifest) certificateSubjectNames() []string {
+	var names []string
+	for _, nodeType := range m.NodeTypes {
+		for _, cert := range nodeType.Certificates.Items {
+			if strings.EqualFold(cert.X509FindType, X509FindTypeSubjectName) && cert.X509FindValue != "" {
+				names = append(names, cert.X509FindValue)
+			}
+		}
+	}
+	return names
+}
+
+// normalizeThumbprint strips the spaces thumbprints are
+// often copied with, and upper cases them
+func normalizeThumbprint(thumbprint string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(thumbprint), ""))
+}
+
+// InspectGatewayCertificates probes the TLS handshake of every management
+// endpoint, see WithFailoverEndpoints, and reports the certificate each
+// presents with the days left before it expires, and whether the cluster
+// manifest declares it. Gateways that cannot be probed report Err.
+func (c ServiceFabricClient) InspectGatewayCertificates(ctx context.Context) (certs []GatewayCertificate, err error) {
+	ctx, call := c.startCall(ctx, "InspectGatewayCertificates")
+	defer func() { call.finish(err) }()
+
+	manifest, err := c.GetClusterManifest(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting cluster manifest")
+	}
+	thumbprints := manifest.CertificateThumbprints()
+	subjectNames := manifest.certificateSubjectNames()
+
+	now := time.Now()
+	for _, endpoint := range c.EndpointHealth() {
+		cert := GatewayCertificate{Endpoint: endpoint.Endpoint}
+
+		presented, err := c.probeCertificate(ctx, endpoint.Endpoint)
+		if err != nil {
+			cert.Err = err
+			certs = append(certs, cert)
+			continue
+		}
+
+		sum := sha1.Sum(presented.Raw)
+		cert.Thumbprint = strings.ToUpper(hex.EncodeToString(sum[:]))
+		cert.Subject = presented.Subject.String()
+		cert.NotAfter = presented.NotAfter
+		cert.DaysToExpiry = int(presented.NotAfter.Sub(now).Hours() / 24)
+		cert.Declared = containsString(thumbprints, cert.Thumbprint)
+		for _, name := range subjectNames {
+			if strings.EqualFold(name, presented.Subject.CommonName) {
+				cert.Declared = true
+			}
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// probeCertificate returns the leaf certificate endpoint presents in its
+// TLS handshake. The certificate is read before it is verified, so that
+// expired and untrusted certificates are reported too.
+func (c ServiceFabricClient) probeCertificate(ctx context.Context, endpoint string) (*x509.Certificate, error) {
+	u, err := parseEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "https" {
+		return nil, errors.Errorf("endpoint %s is not secured", endpoint)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+
+	var presented *x509.Certificate
+	config := &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("gateway presented no certificate")
+			}
+			cert, err := x509.ParseCertificate(rawCerts[0])
+			if err != nil {
+				return err
+			}
+			presented = cert
+			return nil
+		},
+	}
+	if clientConfig := c.clientTLSConfig(); clientConfig != nil {
+		// gateways requiring a client certificate abort the handshake without one
+		config.Certificates = clientConfig.Certificates
+		config.GetClientCertificate = clientConfig.GetClientCertificate
+	}
+
+	dialer := &tls.Dialer{Config: config}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if presented != nil {
+		if conn != nil {
+			conn.Close()
+		}
+		return presented, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed probing %s", endpoint)
+	}
+	conn.Close()
+	return nil, errors.Errorf("%s presented no certificate", endpoint)
+}
+
+// clientTLSConfig returns the TLS configuration of the client transport, if any
+func (c ServiceFabricClient) clientTLSConfig() *tls.Config {
+	client, ok := c.httpClient.(*http.Client)
+	if !ok {
+		return nil
+	}
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		return nil
+	}
+	return transport.TLSClientConfig
+}
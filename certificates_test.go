@@ -0,0 +1,64 @@
+package servicefabric
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInspectGatewayCertificates(t *testing.T) {
+	var thumbprint string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/$/GetClusterManifest" {
+			http.NotFound(w, r)
+			return
+		}
+		manifest := `<ClusterManifest><NodeTypes><NodeType Name="NodeType0"><Certificates>` +
+			`<ClusterCertificate X509FindType="FindByThumbprint" X509FindValue="` + thumbprint + `" X509FindValueSecondary="aa bb cc" />` +
+			`<ClientCertificate X509FindType="FindBySubjectName" X509FindValue="client.example.com" />` +
+			`</Certificates></NodeType></NodeTypes></ClusterManifest>`
+		json.NewEncoder(w).Encode(ClusterManifestWrapper{Manifest: manifest})
+	}))
+	defer server.Close()
+
+	sum := sha1.Sum(server.Certificate().Raw)
+	thumbprint = strings.ToLower(hex.EncodeToString(sum[:]))
+
+	sfClient, _ := NewClient(server.Client(), server.URL, "1.0", nil, WithFailoverEndpoints("https://127.0.0.1:1"))
+
+	manifest, err := sfClient.GetClusterManifest(context.Background())
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	expectedThumbprints := []string{"AABBCC", strings.ToUpper(thumbprint)}
+	if actual := manifest.CertificateThumbprints(); !reflect.DeepEqual(actual, expectedThumbprints) {
+		t.Errorf("Got %+v, want %+v", actual, expectedThumbprints)
+	}
+
+	certs, err := sfClient.InspectGatewayCertificates(context.Background())
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if len(certs) != 2 {
+		t.Fatalf("Got %+v, want 2 gateways", certs)
+	}
+
+	actual := certs[0]
+	if actual.Err != nil {
+		t.Fatalf("Exception thrown %v", actual.Err)
+	}
+	if actual.Thumbprint != strings.ToUpper(thumbprint) || !actual.Declared || actual.DaysToExpiry <= 0 ||
+		!actual.NotAfter.Equal(server.Certificate().NotAfter) {
+		t.Errorf("Got %+v, want the declared server certificate", actual)
+	}
+
+	if certs[1].Endpoint != "https://127.0.0.1:1" || certs[1].Err == nil {
+		t.Errorf("Got %+v, want the unreachable gateway to report an error", certs[1])
+	}
+}
@@ -0,0 +1,193 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultChaosSegment is the time window a ChaosEventsPager queries at once
+const DefaultChaosSegment = time.Hour
+
+// fileTimeEpochOffset is the number of 100ns intervals from 1601-01-01 to
+// 1970-01-01, Chaos queries taking Windows file times
+const fileTimeEpochOffset = 116444736000000000
+
+// chaosAPIVersion is the first API version serving the Chaos events
+const chaosAPIVersion = "6.2"
+
+// ChaosEvent is an event of a Chaos run, such as a fault it induced
+type ChaosEvent struct {
+	// Kind is e.g. "Started", "ExecutingFaults", "Waiting",
+	// "ValidationFailed", "TestError" or "Stopped"
+	Kind         string `json:"Kind"`
+	TimeStampUtc string `json:"TimeStampUtc"`
+	// Reason explains Stopped, TestError and ValidationFailed events
+	Reason string `json:"Reason,omitempty"`
+	// Faults lists the faults an ExecutingFaults event induced
+	Faults []string `json:"Faults,omitempty"`
+}
+
+// Time returns the time stamp of the event, the zero time when invalid
+func (e ChaosEvent) Time() time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, e.TimeStampUtc)
+	return t
+}
+
+// ChaosEventsSegment is a page of Chaos events
+type ChaosEventsSegment struct {
+	ContinuationToken *string `json:"ContinuationToken"`
+	History           []struct {
+		ChaosEvent ChaosEvent `json:"ChaosEvent"`
+	} `json:"History"`
+}
+
+// GetChaosEvents returns a page of the Chaos events between start and end,
+// continuing from token unless empty, with at most maxResults events
+// unless zero
+func (c ServiceFabricClient) GetChaosEvents(ctx context.Context, start, end time.Time, token string, maxResults int) (segment *ChaosEventsSegment, err error) {
+	ctx, call := c.startCall(ctx, "GetChaosEvents")
+	defer func() { call.finish(err) }()
+
+	return c.getChaosEvents(ctx, start, end, token, maxResults)
+}
+
+func (c ServiceFabricClient) getChaosEvents(ctx context.Context, start, end time.Time, token string, maxResults int) (*ChaosEventsSegment, error) {
+	params := []queryParamsFunc{withMinAPIVersion(chaosAPIVersion), withContinue(token)}
+	if token == "" {
+		// the continuation token carries the time range of the query
+		params = append(params,
+			withParam("StartTimeUtc", strconv.FormatInt(chaosTicks(start), 10)),
+			withParam("EndTimeUtc", strconv.FormatInt(chaosTicks(end), 10)))
+	}
+	if maxResults > 0 {
+		params = append(params, withParam("MaxResults", strconv.Itoa(maxResults)))
+	}
+
+	res, _, err := c.getHTTP(ctx, "Tools/Chaos/Events", params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting chaos events")
+	}
+
+	var segment ChaosEventsSegment
+	err = json.Unmarshal(res, &segment)
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return &segment, nil
+}
+
+// chaosTicks returns t as a Windows file time, the number of 100ns
+// intervals since 1601-01-01 UTC
+func chaosTicks(t time.Time) int64 {
+	return t.UnixNano()/100 + fileTimeEpochOffset
+}
+
+// ChaosEventsPager streams the Chaos events of a long time window in time
+// order. It queries the window one segment at a time, following the
+// continuation tokens of every segment:
+//
+//	pager := client.NewChaosEventsPager(start, end, 0)
+//	for pager.Next(ctx) {
+//		event := pager.Event()
+//	}
+//	if err := pager.Err(); err != nil {
+//
+// A pager is not safe for concurrent use.
+type ChaosEventsPager struct {
+	client  ServiceFabricClient
+	end     time.Time
+	segment time.Duration
+
+	// MaxResults bounds the events of every page requested, unlimited when zero
+	MaxResults int
+
+	next   time.Time
+	events []ChaosEvent
+	event  ChaosEvent
+	err    error
+}
+
+// NewChaosEventsPager returns a pager over the Chaos events between start
+// and end, querying segment long windows, DefaultChaosSegment when zero
+func (c ServiceFabricClient) NewChaosEventsPager(start, end time.Time, segment time.Duration) *ChaosEventsPager {
+	if segment <= 0 {
+		segment = DefaultChaosSegment
+	}
+	return &ChaosEventsPager{client: c, next: start, end: end, segment: segment}
+}
+
+// Next advances to the next event, reporting false once every event was
+// returned or a query failed, see Err
+func (p *ChaosEventsPager) Next(ctx context.Context) bool {
+	for len(p.events) == 0 {
+		if p.err != nil || p.next.After(p.end) {
+			return false
+		}
+		p.err = p.fetchSegment(ctx)
+	}
+
+	p.event = p.events[0]
+	p.events = p.events[1:]
+	return true
+}
+
+// Event returns the event Next advanced to
+func (p *ChaosEventsPager) Event() ChaosEvent {
+	return p.event
+}
+
+// Err returns the error that stopped Next, if any
+func (p *ChaosEventsPager) Err() error {
+	return p.err
+}
+
+// fetchSegment queries every page of the next segment. Segment boundaries
+// belong to the later segment so that no event is returned twice.
+func (p *ChaosEventsPager) fetchSegment(ctx context.Context) (err error) {
+	ctx, call := p.client.startCall(ctx, "GetChaosEvents")
+	defer func() { call.finish(err) }()
+
+	start := p.next
+	end := start.Add(p.segment)
+	last := !end.Before(p.end)
+	if last {
+		end = p.end
+	}
+
+	var events []ChaosEvent
+	var token string
+	for {
+		segment, err := p.client.getChaosEvents(ctx, start, end, token, p.MaxResults)
+		if err != nil {
+			return err
+		}
+		for _, entry := range segment.History {
+			if last || entry.ChaosEvent.Time().Before(end) {
+				events = append(events, entry.ChaosEvent)
+			}
+		}
+
+		token = getString(segment.ContinuationToken)
+		if token == "" {
+			break
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time().Before(events[j].Time())
+	})
+	p.events = events
+	if last {
+		// past the end, ending the stream
+		p.next = p.end.Add(time.Nanosecond)
+	} else {
+		p.next = end
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestChaosTicks(t *testing.T) {
+	actual := chaosTicks(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	if actual != 132223104000000000 {
+		t.Errorf("Got %d, want %d", actual, int64(132223104000000000))
+	}
+}
+
+func TestChaosEventsPager(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := []ChaosEvent{
+		{Kind: "Started", TimeStampUtc: "2020-01-01T00:00:00Z"},
+		{Kind: "ExecutingFaults", TimeStampUtc: "2020-01-01T00:30:00Z", Faults: []string{"RestartNode _Node_0"}},
+		{Kind: "Waiting", TimeStampUtc: "2020-01-01T00:45:00Z"},
+		{Kind: "ValidationFailed", TimeStampUtc: "2020-01-01T01:00:00Z", Reason: "Partition quorum lost"},
+		{Kind: "Stopped", TimeStampUtc: "2020-01-01T02:30:00Z", Reason: "Time to run exceeded"},
+	}
+
+	var queries int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/Tools/Chaos/Events" {
+			http.NotFound(w, r)
+			return
+		}
+		queries++
+		query := r.URL.Query()
+		if query.Get("api-version") != "6.2" {
+			t.Errorf("Got api-version %s, want 6.2", query.Get("api-version"))
+		}
+
+		// continuation tokens encode the start, end and offset of the query
+		var state [3]int64
+		if token := query.Get("continue"); token != "" {
+			json.Unmarshal([]byte(token), &state)
+		} else {
+			state[0], _ = strconv.ParseInt(query.Get("StartTimeUtc"), 10, 64)
+			state[1], _ = strconv.ParseInt(query.Get("EndTimeUtc"), 10, 64)
+		}
+
+		var matching []ChaosEvent
+		for _, event := range events {
+			ticks := chaosTicks(event.Time())
+			if ticks >= state[0] && ticks <= state[1] {
+				matching = append(matching, event)
+			}
+		}
+		// pages of two events, the newest first
+		var page ChaosEventsSegment
+		for i := int(state[2]); i < len(matching) && i < int(state[2])+2; i++ {
+			page.History = append(page.History, struct {
+				ChaosEvent ChaosEvent `json:"ChaosEvent"`
+			}{matching[len(matching)-1-i]})
+		}
+		if int(state[2])+2 < len(matching) {
+			b, _ := json.Marshal([3]int64{state[0], state[1], state[2] + 2})
+			token := string(b)
+			page.ContinuationToken = &token
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	pager := sfClient.NewChaosEventsPager(start, start.Add(3*time.Hour), time.Hour)
+	var actual []ChaosEvent
+	for pager.Next(context.Background()) {
+		actual = append(actual, pager.Event())
+	}
+	if err := pager.Err(); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if !reflect.DeepEqual(actual, events) {
+		t.Errorf("Got %+v, want %+v", actual, events)
+	}
+	// the first segment spans two pages, the others one each
+	if queries != 4 {
+		t.Errorf("Got %d queries, want 4", queries)
+	}
+}
+
+func TestChaosEventsPagerReturnsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(http.NotFound))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	pager := sfClient.NewChaosEventsPager(start, start.Add(time.Hour), 0)
+	if pager.Next(context.Background()) {
+		t.Errorf("Got %+v, want no event", pager.Event())
+	}
+	if pager.Err() == nil {
+		t.Error("Error should have been returned")
+	}
+}
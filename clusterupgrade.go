@@ -0,0 +1,177 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Rolling upgrade modes
+const (
+	UpgradeModeUnmonitoredAuto   = "UnmonitoredAuto"
+	UpgradeModeUnmonitoredManual = "UnmonitoredManual"
+	UpgradeModeMonitored         = "Monitored"
+)
+
+// Upgrade domain sort orders
+const (
+	UpgradeSortOrderDefault                = "Default"
+	UpgradeSortOrderNumeric                = "Numeric"
+	UpgradeSortOrderLexicographical        = "Lexicographical"
+	UpgradeSortOrderReverseNumeric         = "ReverseNumeric"
+	UpgradeSortOrderReverseLexicographical = "ReverseLexicographical"
+)
+
+// ClusterUpgradeDescription describes a cluster upgrade. With
+// EnableDeltaHealthEvaluation set, node health is evaluated against the
+// baseline taken when the upgrade started, within the limits set by
+// ClusterUpgradeHealthPolicy, rather than in absolute terms.
+type ClusterUpgradeDescription struct {
+	CodeVersion        string `json:"CodeVersion,omitempty"`
+	ConfigVersion      string `json:"ConfigVersion,omitempty"`
+	UpgradeKind        string `json:"UpgradeKind,omitempty"`
+	RollingUpgradeMode string `json:"RollingUpgradeMode,omitempty"`
+	// UpgradeReplicaSetCheckTimeoutInSeconds bounds how long an upgrade domain
+	// waits for replica sets to become available, nil taking the cluster default
+	UpgradeReplicaSetCheckTimeoutInSeconds *int64 `json:"UpgradeReplicaSetCheckTimeoutInSeconds,omitempty"`
+	// ForceRestart restarts processes even when only config or data changed
+	ForceRestart bool `json:"ForceRestart,omitempty"`
+	// SortOrder is the order upgrade domains are upgraded in, see UpgradeSortOrderDefault
+	SortOrder string `json:"SortOrder,omitempty"`
+	// InstanceCloseDelayDurationInSeconds overrides the instance close delay of
+	// stateless services during the upgrade, nil keeping each service's own
+	InstanceCloseDelayDurationInSeconds *int64                       `json:"InstanceCloseDelayDurationInSeconds,omitempty"`
+	MonitoringPolicy                    *MonitoringPolicyDescription `json:"MonitoringPolicy,omitempty"`
+	EnableDeltaHealthEvaluation         bool                         `json:"EnableDeltaHealthEvaluation"`
+	ClusterUpgradeHealthPolicies
+}
+
+// ClusterUpgradeUpdateDescription changes the parameters of the
+// cluster upgrade in progress, nil fields being left unchanged
+type ClusterUpgradeUpdateDescription struct {
+	UpgradeKind                 string                           `json:"UpgradeKind"`
+	UpdateDescription           *RollingUpgradeUpdateDescription `json:"UpdateDescription,omitempty"`
+	EnableDeltaHealthEvaluation *bool                            `json:"EnableDeltaHealthEvaluation,omitempty"`
+	ClusterUpgradeHealthPolicies
+}
+
+// RollingUpgradeUpdateDescription changes the rolling parameters of an
+// upgrade in progress. Despite its name, ReplicaSetCheckTimeoutInMilliseconds
+// is expressed in seconds, as UpgradeReplicaSetCheckTimeoutInSeconds is.
+type RollingUpgradeUpdateDescription struct {
+	RollingUpgradeMode                   string `json:"RollingUpgradeMode"`
+	ForceRestart                         *bool  `json:"ForceRestart,omitempty"`
+	ReplicaSetCheckTimeoutInMilliseconds *int64 `json:"ReplicaSetCheckTimeoutInMilliseconds,omitempty"`
+	InstanceCloseDelayDurationInSeconds  *int64 `json:"InstanceCloseDelayDurationInSeconds,omitempty"`
+	MonitoringPolicyDescription
+}
+
+// validateSortOrder checks order is empty or one of the UpgradeSortOrder values
+func validateSortOrder(order string) error {
+	switch order {
+	case "", UpgradeSortOrderDefault, UpgradeSortOrderNumeric, UpgradeSortOrderLexicographical,
+		UpgradeSortOrderReverseNumeric, UpgradeSortOrderReverseLexicographical:
+		return nil
+	}
+	return fmt.Errorf("invalid upgrade sort order %q", order)
+}
+
+// StartClusterUpgrade starts upgrading the cluster code or configuration.
+// The upgrade kind defaults to Rolling and the mode to UnmonitoredAuto.
+func (c ServiceFabricClient) StartClusterUpgrade(ctx context.Context, description ClusterUpgradeDescription) (err error) {
+	ctx, call := c.startCall(ctx, "StartClusterUpgrade")
+	defer func() { call.finish(err) }()
+
+	if err := validateSortOrder(description.SortOrder); err != nil {
+		return err
+	}
+	if description.MonitoringPolicy != nil {
+		if err := description.MonitoringPolicy.Validate(); err != nil {
+			return err
+		}
+	}
+	if description.UpgradeKind == "" {
+		description.UpgradeKind = "Rolling"
+	}
+
+	body, err := json.Marshal(description)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.postHTTP(ctx, opStartClusterUpgrade, "$/Upgrade", body)
+	if err != nil {
+		return errors.Wrap(err, "failed starting cluster upgrade")
+	}
+	return nil
+}
+
+// UpdateClusterUpgrade changes the parameters of the cluster upgrade in progress
+func (c ServiceFabricClient) UpdateClusterUpgrade(ctx context.Context, update ClusterUpgradeUpdateDescription) (err error) {
+	ctx, call := c.startCall(ctx, "UpdateClusterUpgrade")
+	defer func() { call.finish(err) }()
+
+	if update.UpdateDescription != nil {
+		if update.UpdateDescription.RollingUpgradeMode == "" {
+			return errors.New("rolling upgrade mode is required to update an upgrade")
+		}
+		if err := update.UpdateDescription.MonitoringPolicyDescription.Validate(); err != nil {
+			return err
+		}
+	}
+	if update.UpgradeKind == "" {
+		update.UpgradeKind = "Rolling"
+	}
+
+	body, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.postHTTP(ctx, opUpdateClusterUpgrade, "$/UpdateUpgrade", body)
+	if err != nil {
+		return errors.Wrap(err, "failed updating cluster upgrade")
+	}
+	return nil
+}
+
+// UpgradeDomainInfo reports the upgrade state of one upgrade domain
+type UpgradeDomainInfo struct {
+	Name  string `json:"Name"`
+	State string `json:"State"`
+}
+
+// ClusterUpgradeProgress reports the progress of the current or last cluster
+// upgrade, with the evaluations that failed it when health checks did not pass
+type ClusterUpgradeProgress struct {
+	CodeVersion          string                     `json:"CodeVersion"`
+	ConfigVersion        string                     `json:"ConfigVersion"`
+	UpgradeDomains       []UpgradeDomainInfo        `json:"UpgradeDomains"`
+	UpgradeState         string                     `json:"UpgradeState"`
+	NextUpgradeDomain    string                     `json:"NextUpgradeDomain"`
+	RollingUpgradeMode   string                     `json:"RollingUpgradeMode"`
+	UpgradeDescription   *ClusterUpgradeDescription `json:"UpgradeDescription"`
+	UnhealthyEvaluations []HealthEvaluationWrapper  `json:"UnhealthyEvaluations"`
+	StartTimestampUtc    string                     `json:"StartTimestampUtc"`
+	FailureTimestampUtc  string                     `json:"FailureTimestampUtc"`
+	FailureReason        string                     `json:"FailureReason"`
+}
+
+// GetClusterUpgradeProgress returns the progress of the current or last cluster upgrade
+func (c ServiceFabricClient) GetClusterUpgradeProgress(ctx context.Context) (progress *ClusterUpgradeProgress, err error) {
+	ctx, call := c.startCall(ctx, "GetClusterUpgradeProgress")
+	defer func() { call.finish(err) }()
+
+	res, _, err := c.getHTTP(ctx, "$/GetUpgradeProgress")
+	if err != nil {
+		return nil, fmt.Errorf("error getting cluster upgrade progress: %v", err)
+	}
+
+	err = json.Unmarshal(res, &progress)
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return progress, nil
+}
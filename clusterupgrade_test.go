@@ -0,0 +1,189 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetClusterUpgradeProgressDeltaEvaluations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleClusterUpgradeProgress))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetClusterUpgradeProgress(context.Background())
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if !actual.UpgradeDescription.EnableDeltaHealthEvaluation {
+		t.Error("EnableDeltaHealthEvaluation should have been decoded")
+	}
+
+	expected := []HealthEvaluationWrapper{
+		{
+			HealthEvaluation: HealthEvaluation{
+				Kind:                          HealthEvaluationKindDeltaNodesCheck,
+				AggregatedHealthState:         "Error",
+				Description:                   "The cluster is considered unhealthy because the percentage of unhealthy nodes is 20% compared to the baseline of 0%.",
+				BaselineTotalCount:            5,
+				MaxPercentDeltaUnhealthyNodes: 10,
+				TotalCount:                    5,
+				UnhealthyEvaluations: []HealthEvaluationWrapper{
+					{
+						HealthEvaluation: HealthEvaluation{
+							Kind:                  "Node",
+							AggregatedHealthState: "Error",
+							Description:           "Node _Node_1 is unhealthy.",
+							NodeName:              "_Node_1",
+						},
+					},
+				},
+			},
+		},
+		{
+			HealthEvaluation: HealthEvaluation{
+				Kind:                  HealthEvaluationKindUpgradeDomainDeltaNodesCheck,
+				AggregatedHealthState: "Error",
+				Description:           "Upgrade domain 1 has too many unhealthy nodes.",
+				UpgradeDomainName:     "1",
+				BaselineTotalCount:    1,
+				MaxPercentUpgradeDomainDeltaUnhealthyNodes: 15,
+				TotalCount: 1,
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(actual.UnhealthyEvaluations, expected) {
+		t.Errorf("Got %+v, want %+v", actual.UnhealthyEvaluations, expected)
+	}
+}
+
+func TestGetClusterUpgradeProgressRollingParameters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleClusterUpgradeProgress))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetClusterUpgradeProgress(context.Background())
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	timeout := actual.UpgradeDescription.UpgradeReplicaSetCheckTimeoutInSeconds
+	if timeout == nil || *timeout != 4294967295 {
+		t.Errorf("Got %+v, want %+v", timeout, 4294967295)
+	}
+}
+
+func TestStartClusterUpgrade(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/$/Upgrade" || r.URL.RawQuery != "api-version=1.0" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Exception thrown %v", err)
+		}
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	timeout := int64(0)
+	policy, err := NewMonitoringPolicy(FailureActionRollback).HealthCheckStable(2 * time.Minute).Build()
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	err = sfClient.StartClusterUpgrade(context.Background(), ClusterUpgradeDescription{
+		CodeVersion:                            "7.2.457.9590",
+		RollingUpgradeMode:                     UpgradeModeMonitored,
+		UpgradeReplicaSetCheckTimeoutInSeconds: &timeout,
+		ForceRestart:                           true,
+		SortOrder:                              UpgradeSortOrderReverseNumeric,
+		MonitoringPolicy:                       policy,
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"CodeVersion":                            "7.2.457.9590",
+		"UpgradeKind":                            "Rolling",
+		"RollingUpgradeMode":                     "Monitored",
+		"UpgradeReplicaSetCheckTimeoutInSeconds": float64(0),
+		"ForceRestart":                           true,
+		"SortOrder":                              "ReverseNumeric",
+		"MonitoringPolicy": map[string]interface{}{
+			"FailureAction": "Rollback",
+			"HealthCheckStableDurationInMilliseconds": "PT0H2M0S",
+		},
+		"EnableDeltaHealthEvaluation": false,
+	}
+	if !reflect.DeepEqual(body, expected) {
+		t.Errorf("Got %+v, want %+v", body, expected)
+	}
+}
+
+func TestStartClusterUpgradeRejectsSortOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected request to %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	err := sfClient.StartClusterUpgrade(context.Background(), ClusterUpgradeDescription{SortOrder: "Random"})
+	if err == nil {
+		t.Fatal("Error should have been returned")
+	}
+}
+
+func TestUpdateClusterUpgrade(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/$/UpdateUpgrade" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Exception thrown %v", err)
+		}
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	forceRestart := false
+	timeout := int64(600)
+	err := sfClient.UpdateClusterUpgrade(context.Background(), ClusterUpgradeUpdateDescription{
+		UpdateDescription: &RollingUpgradeUpdateDescription{
+			RollingUpgradeMode:                   UpgradeModeUnmonitoredManual,
+			ForceRestart:                         &forceRestart,
+			ReplicaSetCheckTimeoutInMilliseconds: &timeout,
+			MonitoringPolicyDescription:          MonitoringPolicyDescription{UpgradeDomainTimeoutInMilliseconds: "PT1H0M0S"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"UpgradeKind": "Rolling",
+		"UpdateDescription": map[string]interface{}{
+			"RollingUpgradeMode":                   "UnmonitoredManual",
+			"ForceRestart":                         false,
+			"ReplicaSetCheckTimeoutInMilliseconds": float64(600),
+			"UpgradeDomainTimeoutInMilliseconds":   "PT1H0M0S",
+		},
+	}
+	if !reflect.DeepEqual(body, expected) {
+		t.Errorf("Got %+v, want %+v", body, expected)
+	}
+}
@@ -0,0 +1,169 @@
+package servicefabric
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Container network types, the external network name a compose network
+// or a ContainerNetworkPolicy connects containers to
+const (
+	// ContainerNetworkNAT maps container ports on the ports of the host
+	ContainerNetworkNAT = "nat"
+	// ContainerNetworkOpen gives every container its own address
+	// on the network of the cluster
+	ContainerNetworkOpen = "Open"
+	// ContainerNetworkIsolated connects containers to a network only the
+	// containers of the application share, see NetworkResourceDescription
+	ContainerNetworkIsolated = "Isolated"
+)
+
+// ComposeFile describes a Docker compose deployment. Its Content is JSON,
+// which compose accepts as YAML.
+type ComposeFile struct {
+	Version  string                    `json:"version"`
+	Services map[string]ComposeService `json:"services"`
+	Networks map[string]ComposeNetwork `json:"networks,omitempty"`
+}
+
+// ComposeService is a container service of a compose deployment
+type ComposeService struct {
+	Image string `json:"image"`
+	// Ports binds endpoints as "hostPort:containerPort" or "containerPort"
+	Ports       []string          `json:"ports,omitempty"`
+	Networks    []string          `json:"networks,omitempty"`
+	Environment map[string]string `json:"environment,omitempty"`
+	Deploy      *ComposeDeploy    `json:"deploy,omitempty"`
+}
+
+// ComposeDeploy configures the placement and resources of a compose service
+type ComposeDeploy struct {
+	// Mode is "replicated" or "global", one instance on every node
+	Mode      string                 `json:"mode,omitempty"`
+	Replicas  int                    `json:"replicas,omitempty"`
+	Resources *ComposeResourceLimits `json:"resources,omitempty"`
+}
+
+// ComposeResourceLimits governs the resources of every container of a service
+type ComposeResourceLimits struct {
+	Limits       *ComposeResources `json:"limits,omitempty"`
+	Reservations *ComposeResources `json:"reservations,omitempty"`
+}
+
+// ComposeResources is a number of CPU cores and an amount of memory
+type ComposeResources struct {
+	// CPUs is a number of cores, e.g. "0.5"
+	CPUs string `json:"cpus,omitempty"`
+	// Memory is an amount with a unit, e.g. "512M"
+	Memory string `json:"memory,omitempty"`
+}
+
+// ComposeNetwork is a network compose services connect to
+type ComposeNetwork struct {
+	External *ComposeExternalNetwork `json:"external,omitempty"`
+}
+
+// ComposeExternalNetwork names an existing network, such as ContainerNetworkOpen
+type ComposeExternalNetwork struct {
+	Name string `json:"name"`
+}
+
+// Validate checks that every service has an image
+// and only connects to networks the file declares
+func (f *ComposeFile) Validate() error {
+	if len(f.Services) == 0 {
+		return fmt.Errorf("compose file has no services")
+	}
+
+	names := make([]string, 0, len(f.Services))
+	for name := range f.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		service := f.Services[name]
+		if service.Image == "" {
+			return fmt.Errorf("compose service %s has no image", name)
+		}
+		for _, network := range service.Networks {
+			if _, ok := f.Networks[network]; !ok {
+				return fmt.Errorf("compose service %s connects to undeclared network %s", name, network)
+			}
+		}
+		if service.Deploy != nil && service.Deploy.Mode != "" && service.Deploy.Mode != "replicated" && service.Deploy.Mode != "global" {
+			return fmt.Errorf("compose service %s has unknown deploy mode %s", name, service.Deploy.Mode)
+		}
+	}
+	return nil
+}
+
+// Content returns the compose file content a compose deployment is created with
+func (f *ComposeFile) Content() (string, error) {
+	if err := f.Validate(); err != nil {
+		return "", err
+	}
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// RegistryCredential authenticates the cluster with the private
+// container registry the images of a compose deployment are pulled from
+type RegistryCredential struct {
+	RegistryUserName string `json:"RegistryUserName"`
+	RegistryPassword string `json:"RegistryPassword"`
+	// PasswordEncrypted is set when RegistryPassword was encrypted with
+	// the cluster certificate, see NewEncryptedRegistryCredential
+	PasswordEncrypted bool `json:"PasswordEncrypted"`
+}
+
+// NewEncryptedRegistryCredential returns a credential whose password is
+// encrypted with the cluster certificate cert, so that only the nodes of
+// the cluster can read it
+func NewEncryptedRegistryCredential(username, password string, cert *x509.Certificate) (*RegistryCredential, error) {
+	encrypted, err := EncryptValue(cert, password)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed encrypting registry password")
+	}
+	return &RegistryCredential{
+		RegistryUserName:  username,
+		RegistryPassword:  encrypted,
+		PasswordEncrypted: true,
+	}, nil
+}
+
+// CreateComposeDeploymentDescription describes a compose deployment to create
+type CreateComposeDeploymentDescription struct {
+	DeploymentName     string              `json:"DeploymentName"`
+	ComposeFileContent string              `json:"ComposeFileContent"`
+	RegistryCredential *RegistryCredential `json:"RegistryCredential,omitempty"`
+}
+
+// CreateComposeDeployment creates a compose deployment, see ComposeFile.Content
+func (c ServiceFabricClient) CreateComposeDeployment(ctx context.Context, description CreateComposeDeploymentDescription) (err error) {
+	ctx, call := c.startCall(ctx, "CreateComposeDeployment")
+	defer func() { call.finish(err) }()
+
+	if description.DeploymentName == "" || description.ComposeFileContent == "" {
+		return errors.New("deployment name and compose file content are required")
+	}
+
+	body, err := json.Marshal(description)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.sendHTTP(ctx, "PUT", opCreateComposeDeployment.on(description.DeploymentName), "ComposeDeployments/$/Create", body)
+	if err != nil {
+		return errors.Wrap(err, "failed creating compose deployment")
+	}
+	return nil
+}
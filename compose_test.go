@@ -0,0 +1,127 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestComposeFileContent(t *testing.T) {
+	file := ComposeFile{
+		Version: "3",
+		Services: map[string]ComposeService{
+			"web": {
+				Image:    "nginx:latest",
+				Ports:    []string{"80:80"},
+				Networks: []string{"frontend"},
+				Deploy: &ComposeDeploy{
+					Replicas: 2,
+					Resources: &ComposeResourceLimits{
+						Limits: &ComposeResources{CPUs: "0.5", Memory: "512M"},
+					},
+				},
+			},
+		},
+		Networks: map[string]ComposeNetwork{
+			"frontend": {External: &ComposeExternalNetwork{Name: ContainerNetworkOpen}},
+		},
+	}
+
+	content, err := file.Content()
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	var actual map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &actual); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	expected := map[string]interface{}{
+		"version": "3",
+		"services": map[string]interface{}{
+			"web": map[string]interface{}{
+				"image":    "nginx:latest",
+				"ports":    []interface{}{"80:80"},
+				"networks": []interface{}{"frontend"},
+				"deploy": map[string]interface{}{
+					"replicas": float64(2),
+					"resources": map[string]interface{}{
+						"limits": map[string]interface{}{"cpus": "0.5", "memory": "512M"},
+					},
+				},
+			},
+		},
+		"networks": map[string]interface{}{
+			"frontend": map[string]interface{}{"external": map[string]interface{}{"name": "Open"}},
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+}
+
+func TestComposeFileValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		file ComposeFile
+	}{
+		{"no services", ComposeFile{Version: "3"}},
+		{"no image", ComposeFile{Services: map[string]ComposeService{"web": {}}}},
+		{"undeclared network", ComposeFile{Services: map[string]ComposeService{"web": {Image: "nginx", Networks: []string{"backend"}}}}},
+		{"unknown mode", ComposeFile{Services: map[string]ComposeService{"web": {Image: "nginx", Deploy: &ComposeDeploy{Mode: "spread"}}}}},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.file.Content(); err == nil {
+				t.Error("Error should have been returned")
+			}
+		})
+	}
+}
+
+func TestCreateComposeDeployment(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" || r.URL.Path != "/ComposeDeployments/$/Create" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Exception thrown %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	err := sfClient.CreateComposeDeployment(context.Background(), CreateComposeDeploymentDescription{
+		DeploymentName:     "web",
+		ComposeFileContent: `{"version":"3"}`,
+		RegistryCredential: &RegistryCredential{RegistryUserName: "user", RegistryPassword: "password"},
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"DeploymentName":     "web",
+		"ComposeFileContent": `{"version":"3"}`,
+		"RegistryCredential": map[string]interface{}{
+			"RegistryUserName":  "user",
+			"RegistryPassword":  "password",
+			"PasswordEncrypted": false,
+		},
+	}
+	if !reflect.DeepEqual(body, expected) {
+		t.Errorf("Got %+v, want %+v", body, expected)
+	}
+
+	if err := sfClient.CreateComposeDeployment(context.Background(), CreateComposeDeploymentDescription{DeploymentName: "web"}); err == nil {
+		t.Error("Error should have been returned")
+	}
+}
@@ -0,0 +1,55 @@
+package servicefabric
+
+import "sync"
+
+// DefaultConcurrency is the number of requests aggregate queries
+// send at once unless the client is created WithConcurrency
+const DefaultConcurrency = 4
+
+func (c ServiceFabricClient) concurrency() int {
+	if c.maxConcurrency > 0 {
+		return c.maxConcurrency
+	}
+	return DefaultConcurrency
+}
+
+// forEach calls fn for every index below n, running at most c.concurrency()
+// calls at once. No further calls are started once one fails, and the
+// first error is returned after the running calls are done.
+func (c ServiceFabricClient) forEach(n int, fn func(i int) error) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+
+	sem := make(chan struct{}, c.concurrency())
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		if failed() {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := fn(i); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	return firstErr
+}
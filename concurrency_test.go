@@ -0,0 +1,68 @@
+package servicefabric
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestForEachBoundsConcurrency(t *testing.T) {
+	sfClient, _ := NewServiceFabricClient(nil, "https://cluster.example.com:19080", "", WithConcurrency(2))
+
+	var (
+		mu      sync.Mutex
+		running int
+		maxSeen int
+		visited = make([]bool, 10)
+	)
+	err := sfClient.forEach(len(visited), func(i int) error {
+		mu.Lock()
+		running++
+		if running > maxSeen {
+			maxSeen = running
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		visited[i] = true
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if maxSeen > 2 {
+		t.Errorf("Got %d concurrent calls, want at most 2", maxSeen)
+	}
+	for i, ok := range visited {
+		if !ok {
+			t.Errorf("Index %d was not visited", i)
+		}
+	}
+}
+
+func TestForEachReturnsFirstError(t *testing.T) {
+	sfClient, _ := NewServiceFabricClient(nil, "https://cluster.example.com:19080", "", WithConcurrency(1))
+
+	expected := errors.New("failed")
+	calls := 0
+	err := sfClient.forEach(5, func(i int) error {
+		calls++
+		if i == 1 {
+			return expected
+		}
+		return nil
+	})
+
+	if err != expected {
+		t.Errorf("Got %v, want %v", err, expected)
+	}
+	if calls != 2 {
+		t.Errorf("Got %d calls, want 2", calls)
+	}
+}
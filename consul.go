@@ -0,0 +1,260 @@
+package servicefabric
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Consul health check statuses the health states of instances map to
+const (
+	consulPassing  = "passing"
+	consulWarning  = "warning"
+	consulCritical = "critical"
+)
+
+// consulCatalog serves the services of a cluster
+// through the read only Consul catalog and health API
+type consulCatalog struct {
+	client    ServiceFabricClient
+	discovery Discovery
+}
+
+// NewConsulCatalog returns a handler serving the services of the cluster of
+// client and their instances, resolved with discovery, the way the Consul
+// agent HTTP API does, for tooling that already speaks Consul:
+//
+//	GET /v1/catalog/services
+//	GET /v1/catalog/service/{name}
+//	GET /v1/health/service/{name}?passing
+//
+// Services are named by their id, such as App~Service for
+// fabric:/App/Service. An instance is listed once for every endpoint it
+// publishes, tagged with the listener name, its health state mapping to
+// the status of a single check. Blocking
+// queries are not supported, the X-Consul-Index header only changing along
+// with the response.
+func NewConsulCatalog(client *ServiceFabricClient, discovery Discovery) http.Handler {
+	return consulCatalog{client: *client, discovery: discovery}
+}
+
+// consulNode is the node of a service instance
+type consulNode struct {
+	ID         string `json:"ID"`
+	Node       string `json:"Node"`
+	Address    string `json:"Address"`
+	Datacenter string `json:"Datacenter"`
+}
+
+// consulService is a service instance as registered with an agent
+type consulService struct {
+	ID      string            `json:"ID"`
+	Service string            `json:"Service"`
+	Tags    []string          `json:"Tags"`
+	Address string            `json:"Address"`
+	Port    int               `json:"Port"`
+	Meta    map[string]string `json:"Meta"`
+}
+
+// consulCatalogService is a service instance in the catalog
+type consulCatalogService struct {
+	ID             string            `json:"ID"`
+	Node           string            `json:"Node"`
+	Address        string            `json:"Address"`
+	Datacenter     string            `json:"Datacenter"`
+	ServiceID      string            `json:"ServiceID"`
+	ServiceName    string            `json:"ServiceName"`
+	ServiceTags    []string          `json:"ServiceTags"`
+	ServiceAddress string            `json:"ServiceAddress"`
+	ServicePort    int               `json:"ServicePort"`
+	ServiceMeta    map[string]string `json:"ServiceMeta"`
+}
+
+// consulCheck is the health check of a service instance
+type consulCheck struct {
+	Node        string `json:"Node"`
+	CheckID     string `json:"CheckID"`
+	Name        string `json:"Name"`
+	Status      string `json:"Status"`
+	ServiceID   string `json:"ServiceID"`
+	ServiceName string `json:"ServiceName"`
+}
+
+// consulServiceEntry is a service instance along with its node and checks
+type consulServiceEntry struct {
+	Node    consulNode    `json:"Node"`
+	Service consulService `json:"Service"`
+	Checks  []consulCheck `json:"Checks"`
+}
+
+func (c consulCatalog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/v1/catalog/services":
+		c.serveServices(w, r)
+	case strings.HasPrefix(r.URL.Path, "/v1/catalog/service/"):
+		entries, ok := c.entries(w, r, strings.TrimPrefix(r.URL.Path, "/v1/catalog/service/"))
+		if !ok {
+			return
+		}
+		services := []consulCatalogService{}
+		for _, entry := range entries {
+			services = append(services, consulCatalogService{
+				ID:             entry.Node.ID,
+				Node:           entry.Node.Node,
+				Address:        entry.Node.Address,
+				Datacenter:     entry.Node.Datacenter,
+				ServiceID:      entry.Service.ID,
+				ServiceName:    entry.Service.Service,
+				ServiceTags:    entry.Service.Tags,
+				ServiceAddress: entry.Service.Address,
+				ServicePort:    entry.Service.Port,
+				ServiceMeta:    entry.Service.Meta,
+			})
+		}
+		writeConsulJSON(w, services)
+	case strings.HasPrefix(r.URL.Path, "/v1/health/service/"):
+		entries, ok := c.entries(w, r, strings.TrimPrefix(r.URL.Path, "/v1/health/service/"))
+		if !ok {
+			return
+		}
+		if _, passing := r.URL.Query()["passing"]; passing {
+			healthy := []consulServiceEntry{}
+			for _, entry := range entries {
+				if entry.Checks[0].Status == consulPassing {
+					healthy = append(healthy, entry)
+				}
+			}
+			entries = healthy
+		}
+		writeConsulJSON(w, entries)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveServices lists every service, without tags as listeners are
+// only known once the instances of a service are resolved
+func (c consulCatalog) serveServices(w http.ResponseWriter, r *http.Request) {
+	apps, err := c.client.GetServicesForAllApplications(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	services := map[string][]string{}
+	for _, app := range apps {
+		for _, service := range app.Services {
+			services[serviceIDFromName(service.Name)] = []string{}
+		}
+	}
+	writeConsulJSON(w, services)
+}
+
+// entries resolves the instances of the service named name, filtered by
+// the tag query parameter, writing the error response when it fails
+func (c consulCatalog) entries(w http.ResponseWriter, r *http.Request, name string) ([]consulServiceEntry, bool) {
+	// Consul lists unknown services as having no instances
+	if !strings.Contains(strings.Trim(name, "~"), "~") {
+		return []consulServiceEntry{}, true
+	}
+	instances, err := c.discovery.Instances(fabricScheme + strings.Replace(name, "~", "/", -1))
+	if errors.Is(err, ErrParentNotFound) || ClassifyError(err) == ErrorClassNotFound {
+		return []consulServiceEntry{}, true
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return nil, false
+	}
+
+	tag := r.URL.Query().Get("tag")
+	entries := []consulServiceEntry{}
+	for _, instance := range instances {
+		listeners := make([]string, 0, len(instance.Endpoints))
+		for listener := range instance.Endpoints {
+			listeners = append(listeners, listener)
+		}
+		sort.Strings(listeners)
+
+		for _, listener := range listeners {
+			host, port, ok := splitEndpoint(instance.Endpoints[listener])
+			if !ok || tag != "" && tag != listener {
+				continue
+			}
+			id := instance.PartitionID + "/" + instance.ID + "/" + listener
+			meta := map[string]string{"PartitionId": instance.PartitionID, "Endpoint": instance.Endpoints[listener]}
+			if instance.Role != "" {
+				meta["ReplicaRole"] = instance.Role
+			}
+			entries = append(entries, consulServiceEntry{
+				Node:    consulNode{ID: instance.NodeName, Node: instance.NodeName, Address: host},
+				Service: consulService{ID: id, Service: name, Tags: []string{listener}, Address: host, Port: port, Meta: meta},
+				Checks: []consulCheck{{
+					Node:        instance.NodeName,
+					CheckID:     "service:" + id,
+					Name:        "Service Fabric health state",
+					Status:      consulStatus(instance.HealthState),
+					ServiceID:   id,
+					ServiceName: name,
+				}},
+			})
+		}
+	}
+	return entries, true
+}
+
+// splitEndpoint returns the host and port of an endpoint published
+// either as a URL or as host:port
+func splitEndpoint(endpoint string) (string, int, bool) {
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		endpoint = u.Host
+	}
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return "", 0, false
+	}
+	return host, n, true
+}
+
+// consulStatus maps a health state to the status of a Consul check
+func consulStatus(healthState string) string {
+	switch healthState {
+	case "Ok":
+		return consulPassing
+	case "Warning":
+		return consulWarning
+	}
+	return consulCritical
+}
+
+// writeConsulJSON writes v along with the headers Consul clients expect
+func writeConsulJSON(w http.ResponseWriter, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	index := fnv.New64a()
+	_, _ = index.Write(body)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(index.Sum64()|1, 10))
+	w.Header().Set("X-Consul-KnownLeader", "true")
+	w.Header().Set("X-Consul-LastContact", "0")
+	_, _ = w.Write(body)
+}
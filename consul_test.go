@@ -0,0 +1,125 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// staticDiscovery resolves TestApplication/TestService to fixed instances
+type staticDiscovery []Instance
+
+func (d staticDiscovery) Instances(serviceName string) ([]Instance, error) {
+	if serviceName != "fabric:/TestApplication/TestService" {
+		return nil, errors.Wrapf(ErrParentNotFound, "service %s", serviceName)
+	}
+	return d, nil
+}
+
+func (d staticDiscovery) Watch(ctx context.Context, serviceName string) <-chan DiscoveryEvent {
+	return nil
+}
+
+// consulCatalogServer serves the catalog of a cluster running TestService,
+// returning the catalog and the cluster servers
+func consulCatalogServer() (*httptest.Server, *httptest.Server) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Applications/", handleApplications)
+	mux.HandleFunc("/Applications/TestApplication/$/GetServices", handleServices)
+	mux.HandleFunc("/Applications/TestApplication2/$/GetServices", func(w http.ResponseWriter, r *http.Request) {
+		writeFixture(w, "services_empty.json")
+	})
+	cluster := httptest.NewServer(mux)
+
+	sfClient, _ := NewClient(http.DefaultClient, cluster.URL, "1.0", nil)
+	discovery := staticDiscovery{
+		{PartitionID: "p1", ID: "1", NodeName: "_Node_0", HealthState: "Ok",
+			Endpoints: map[string]string{"http": "http://10.0.0.4:8080/api", "grpc": "10.0.0.4:9090"}},
+		{PartitionID: "p1", ID: "2", NodeName: "_Node_1", HealthState: "Error",
+			Endpoints: map[string]string{"http": "http://10.0.0.5:8080/api"}},
+	}
+	return httptest.NewServer(NewConsulCatalog(sfClient, discovery)), cluster
+}
+
+func getConsulJSON(t *testing.T, url string, v interface{}) {
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("Got status %d, want %d", res.StatusCode, http.StatusOK)
+	}
+	if res.Header.Get("X-Consul-Index") == "" || res.Header.Get("X-Consul-LastContact") == "" {
+		t.Errorf("Got headers %v, want the Consul query headers", res.Header)
+	}
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+}
+
+func TestConsulCatalogServices(t *testing.T) {
+	server, cluster := consulCatalogServer()
+	defer server.Close()
+	defer cluster.Close()
+
+	var actual map[string][]string
+	getConsulJSON(t, server.URL+"/v1/catalog/services", &actual)
+
+	expected := map[string][]string{"TestApplication~TestService": {}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+}
+
+func TestConsulCatalogService(t *testing.T) {
+	server, cluster := consulCatalogServer()
+	defer server.Close()
+	defer cluster.Close()
+
+	var actual []consulCatalogService
+	getConsulJSON(t, server.URL+"/v1/catalog/service/TestApplication~TestService?tag=http", &actual)
+
+	if len(actual) != 2 {
+		t.Fatalf("Got %+v, want the http endpoints of 2 instances", actual)
+	}
+	if actual[0].Node != "_Node_0" || actual[0].ServiceAddress != "10.0.0.4" || actual[0].ServicePort != 8080 ||
+		actual[0].ServiceID != "p1/1/http" || !reflect.DeepEqual(actual[0].ServiceTags, []string{"http"}) {
+		t.Errorf("Got %+v, want the http endpoint of instance 1", actual[0])
+	}
+
+	getConsulJSON(t, server.URL+"/v1/catalog/service/TestApplication~Missing", &actual)
+	if len(actual) != 0 {
+		t.Errorf("Got %+v, want no instances of an unknown service", actual)
+	}
+}
+
+func TestConsulHealthService(t *testing.T) {
+	server, cluster := consulCatalogServer()
+	defer server.Close()
+	defer cluster.Close()
+
+	var actual []consulServiceEntry
+	getConsulJSON(t, server.URL+"/v1/health/service/TestApplication~TestService", &actual)
+	if len(actual) != 3 {
+		t.Fatalf("Got %d entries, want 3", len(actual))
+	}
+	if status := actual[2].Checks[0].Status; status != consulCritical {
+		t.Errorf("Got %s, want %s", status, consulCritical)
+	}
+
+	getConsulJSON(t, server.URL+"/v1/health/service/TestApplication~TestService?passing", &actual)
+	var ids []string
+	for _, entry := range actual {
+		ids = append(ids, entry.Service.ID)
+	}
+	if expected := []string{"p1/1/grpc", "p1/1/http"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Got %v, want %v", ids, expected)
+	}
+}
@@ -0,0 +1,27 @@
+package servicefabric
+
+import (
+	"context"
+	"net/http"
+)
+
+type headersKey struct{}
+
+// WithHeaders returns a copy of ctx carrying headers to send with every
+// request made with it, such as feature flags, tenant hints or tracing
+// baggage. Headers already attached to ctx are kept unless h sets them too.
+func WithHeaders(ctx context.Context, h http.Header) context.Context {
+	merged := headersFromContext(ctx).Clone()
+	if merged == nil {
+		merged = http.Header{}
+	}
+	for name, values := range h {
+		merged[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+	}
+	return context.WithValue(ctx, headersKey{}, merged)
+}
+
+func headersFromContext(ctx context.Context) http.Header {
+	h, _ := ctx.Value(headersKey{}).(http.Header)
+	return h
+}
@@ -0,0 +1,49 @@
+package servicefabric
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestWithHeaders(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	ctx := WithHeaders(context.Background(), http.Header{
+		"X-Tenant":  {"team1"},
+		"X-Feature": {"old"},
+	})
+	ctx = WithHeaders(ctx, http.Header{
+		"x-feature": {"new"},
+	})
+
+	_, _, err := sfClient.getHTTP(ctx, "Applications/")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if received.Get("X-Tenant") != "team1" {
+		t.Errorf("Got X-Tenant %q, want team1", received.Get("X-Tenant"))
+	}
+	if !reflect.DeepEqual(received["X-Feature"], []string{"new"}) {
+		t.Errorf("Got X-Feature %q, want [new]", received["X-Feature"])
+	}
+
+	_, _, err = sfClient.getHTTP(context.Background(), "Applications/")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if received.Get("X-Tenant") != "" {
+		t.Errorf("Headers leaked into a request without them: %v", received)
+	}
+}
@@ -0,0 +1,168 @@
+package servicefabric
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// TLSCredentials authenticate the client to a secure cluster with a client
+// certificate, and the cluster to the client
+type TLSCredentials struct {
+	// Certificate is presented to the cluster
+	Certificate tls.Certificate
+	// RootCAs verify the cluster certificate chain, the system pool when nil
+	RootCAs *x509.CertPool
+	// ServerThumbprints, when set, pin the cluster certificate by SHA-1
+	// thumbprint in place of chain validation, as clusters commonly use
+	// self-signed certificates
+	ServerThumbprints []string
+
+	// load reads Certificate again from the files it was loaded from
+	load func() (tls.Certificate, error)
+	mu   sync.RWMutex
+}
+
+// LoadPEMCredentials reads a PEM client certificate and private key, and the
+// PEM bundle of certificate authorities the cluster certificate chains to,
+// if caFile is not empty
+func LoadPEMCredentials(certFile, keyFile, caFile string) (*TLSCredentials, error) {
+	load := func() (tls.Certificate, error) {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return cert, errors.Wrap(err, "failed loading client certificate")
+		}
+		return cert, nil
+	}
+	return newTLSCredentials(load, caFile)
+}
+
+// PFXDecoder decodes PKCS #12 data protected by password into its private
+// key, certificate and chain, e.g. DecodeChain of software.sslmate.com/src/go-pkcs12
+type PFXDecoder func(pfxData []byte, password string) (privateKey interface{}, certificate *x509.Certificate, caCerts []*x509.Certificate, err error)
+
+// LoadPFXCredentials reads a PKCS #12 client certificate and private key
+// protected by password, and the PEM bundle of certificate authorities the
+// cluster certificate chains to, if caFile is not empty. The client bundles
+// no PKCS #12 implementation, so loading PFX files requires the caller to
+// supply decode.
+func LoadPFXCredentials(pfxFile, password, caFile string, decode PFXDecoder) (*TLSCredentials, error) {
+	if decode == nil {
+		return nil, errors.New("a PKCS #12 decoder is required")
+	}
+	return newTLSCredentials(func() (tls.Certificate, error) {
+		return loadPFXCertificate(pfxFile, password, decode)
+	}, caFile)
+}
+
+func loadPFXCertificate(pfxFile, password string, decode PFXDecoder) (tls.Certificate, error) {
+	data, err := ioutil.ReadFile(pfxFile)
+	if err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "failed loading client certificate")
+	}
+
+	key, leaf, chain, err := decode(data, password)
+	if err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "failed decoding client certificate")
+	}
+	if key == nil || leaf == nil {
+		return tls.Certificate{}, errors.New("failed decoding client certificate: no private key or certificate found")
+	}
+
+	cert := tls.Certificate{Certificate: [][]byte{leaf.Raw}, PrivateKey: key, Leaf: leaf}
+	for _, ca := range chain {
+		cert.Certificate = append(cert.Certificate, ca.Raw)
+	}
+	return cert, nil
+}
+
+func newTLSCredentials(load func() (tls.Certificate, error), caFile string) (*TLSCredentials, error) {
+	cert, err := load()
+	if err != nil {
+		return nil, err
+	}
+	creds := &TLSCredentials{Certificate: cert, load: load}
+	if caFile == "" {
+		return creds, nil
+	}
+
+	bundle, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed loading certificate authorities")
+	}
+	creds.RootCAs = x509.NewCertPool()
+	if !creds.RootCAs.AppendCertsFromPEM(bundle) {
+		return nil, fmt.Errorf("no certificate authority found in %s", caFile)
+	}
+	return creds, nil
+}
+
+// WithServerThumbprints pins the cluster certificate to thumbprints
+func (c *TLSCredentials) WithServerThumbprints(thumbprints ...string) *TLSCredentials {
+	c.ServerThumbprints = append(c.ServerThumbprints, thumbprints...)
+	return c
+}
+
+// TLSConfig returns the TLS configuration presenting the client
+// certificate and verifying the cluster certificate
+func (c *TLSCredentials) TLSConfig() *tls.Config {
+	config := &tls.Config{
+		Certificates: []tls.Certificate{c.certificate()},
+		RootCAs:      c.RootCAs,
+	}
+	if c.load != nil {
+		// presents the certificate Refresh reloaded on new connections
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert := c.certificate()
+			return &cert, nil
+		}
+	}
+	if len(c.ServerThumbprints) > 0 {
+		// Chain validation is replaced by thumbprint pinning below
+		config.InsecureSkipVerify = true
+		config.VerifyPeerCertificate = verifyThumbprint("cluster", c.ServerThumbprints)
+	}
+	return config
+}
+
+// Refresh reloads Certificate from the files the credentials were
+// loaded from, picking up a rotated client certificate
+func (c *TLSCredentials) Refresh(ctx context.Context) error {
+	if c.load == nil {
+		return errors.New("credentials were not loaded from files")
+	}
+	cert, err := c.load()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Certificate = cert
+	return nil
+}
+
+func (c *TLSCredentials) certificate() tls.Certificate {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Certificate
+}
+
+// NewClientWithCredentials creates a ServiceFabricClient authenticating
+// to a secure cluster with creds, see NewClient. Credentials loaded from
+// files are reloaded when the cluster rejects them, see WithCredentialRefresher.
+func NewClientWithCredentials(endpoint, apiVersion string, creds *TLSCredentials, opts ...ClientOption) (*ServiceFabricClient, error) {
+	if creds == nil {
+		return nil, errors.New("credentials missing for secure cluster")
+	}
+	if creds.load != nil {
+		opts = append([]ClientOption{WithCredentialRefresher(creds)}, opts...)
+	}
+	return NewClient(&http.Client{}, endpoint, apiVersion, creds.TLSConfig(), opts...)
+}
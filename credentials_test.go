@@ -0,0 +1,234 @@
+package servicefabric
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCertificate writes a self-signed certificate and its key
+// as PEM files to dir, returning their paths
+func writeTestCertificate(t *testing.T, dir, name string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	return certFile, keyFile
+}
+
+func TestLoadPEMCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := writeTestCertificate(t, dir, "client")
+	caFile, _ := writeTestCertificate(t, dir, "ca")
+
+	creds, err := LoadPEMCredentials(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if creds.RootCAs == nil {
+		t.Error("RootCAs should have been loaded")
+	}
+
+	config := creds.TLSConfig()
+	if len(config.Certificates) != 1 || config.InsecureSkipVerify {
+		t.Errorf("Got %+v, want the client certificate and chain validation", config)
+	}
+
+	_, err = LoadPEMCredentials(certFile, keyFile, keyFile)
+	if err == nil {
+		t.Error("Error should have been returned")
+	}
+}
+
+func TestLoadPFXCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := writeTestCertificate(t, dir, "client")
+	caFile, _ := writeTestCertificate(t, dir, "ca")
+
+	// the decoder stands in for a PKCS #12 library, reading the PEM pair
+	decode := func(pfxData []byte, password string) (interface{}, *x509.Certificate, []*x509.Certificate, error) {
+		if password != "secret" {
+			return nil, nil, nil, errors.New("pkcs12: decryption password incorrect")
+		}
+		pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		leaf, err := x509.ParseCertificate(pair.Certificate[0])
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		ca, err := x509.ParseCertificate(pair.Certificate[0])
+		return pair.PrivateKey, leaf, []*x509.Certificate{ca}, err
+	}
+
+	creds, err := LoadPFXCredentials(certFile, "secret", caFile, decode)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if len(creds.Certificate.Certificate) != 2 || creds.Certificate.Leaf == nil || creds.Certificate.PrivateKey == nil {
+		t.Errorf("Got %+v, want the client certificate, its chain and key", creds.Certificate)
+	}
+
+	_, err = LoadPFXCredentials(certFile, "wrong", caFile, decode)
+	if err == nil {
+		t.Error("Error should have been returned")
+	}
+	_, err = LoadPFXCredentials(certFile, "secret", caFile, nil)
+	if err == nil {
+		t.Error("Error should have been returned")
+	}
+}
+
+func TestNewClientWithCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var clientCerts int
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientCerts = len(r.TLS.PeerCertificates)
+		handleApplications(w, r)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	sum := sha1.Sum(server.Certificate().Raw)
+	thumbprint := hex.EncodeToString(sum[:])
+
+	certFile, keyFile := writeTestCertificate(t, dir, "client")
+	creds, err := LoadPEMCredentials(certFile, keyFile, "")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	sfClient, err := NewClientWithCredentials(server.URL, "1.0", creds.WithServerThumbprints(thumbprint))
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	_, err = sfClient.GetApplications(context.Background())
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if clientCerts != 1 {
+		t.Errorf("Got %d client certificates, want 1", clientCerts)
+	}
+
+	creds.ServerThumbprints = []string{"0000000000000000000000000000000000000000"}
+	sfClient, _ = NewClientWithCredentials(server.URL, "1.0", creds)
+	_, err = sfClient.GetApplications(context.Background())
+	if err == nil {
+		t.Error("Error should have been returned")
+	}
+}
+
+func TestRotatedCertificateReloaded(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	rotated := filepath.Join(dir, "rotated")
+	if err := os.Mkdir(rotated, 0700); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	var subjects []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject := r.TLS.PeerCertificates[0].Subject.CommonName
+		subjects = append(subjects, subject)
+		if subject != "client" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		handleApplications(w, r)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	sum := sha1.Sum(server.Certificate().Raw)
+	thumbprint := hex.EncodeToString(sum[:])
+
+	// the files hold an expired certificate until they are rotated
+	certFile, keyFile := writeTestCertificate(t, dir, "expired")
+	creds, err := LoadPEMCredentials(certFile, keyFile, "")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	newCertFile, newKeyFile := writeTestCertificate(t, rotated, "client")
+	if err := os.Rename(newCertFile, certFile); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if err := os.Rename(newKeyFile, keyFile); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	sfClient, err := NewClientWithCredentials(server.URL, "1.0", creds.WithServerThumbprints(thumbprint))
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if _, err := sfClient.GetApplications(context.Background()); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if len(subjects) < 2 || subjects[0] != "expired" || subjects[1] != "client" {
+		t.Errorf("Got %+v, want the rotated certificate presented after the first rejection", subjects)
+	}
+}
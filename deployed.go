@@ -0,0 +1,137 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// DeployedServicePackageInfo describes a service package deployed on a node.
+// ServicePackageActivationID is empty for packages activated with the
+// SharedProcess activation mode, and identifies the activation otherwise,
+// so it must be passed along when restarting one of their code packages.
+type DeployedServicePackageInfo struct {
+	Name                       string `json:"Name"`
+	Version                    string `json:"Version"`
+	Status                     string `json:"Status"`
+	ServicePackageActivationID string `json:"ServicePackageActivationId"`
+}
+
+// DeployedCodePackageInfo describes a code package deployed on a node
+type DeployedCodePackageInfo struct {
+	Name                       string                 `json:"Name"`
+	Version                    string                 `json:"Version"`
+	ServiceManifestName        string                 `json:"ServiceManifestName"`
+	ServicePackageActivationID string                 `json:"ServicePackageActivationId"`
+	HostType                   string                 `json:"HostType"`
+	HostIsolationMode          string                 `json:"HostIsolationMode"`
+	Status                     string                 `json:"Status"`
+	RunFrequencyInterval       string                 `json:"RunFrequencyInterval"`
+	SetupEntryPoint            *CodePackageEntryPoint `json:"SetupEntryPoint,omitempty"`
+	MainEntryPoint             *CodePackageEntryPoint `json:"MainEntryPoint,omitempty"`
+}
+
+// CodePackageEntryPoint reports the process of a code package entry point.
+// NextActivationTime is set while the process waits to be activated again,
+// e.g. after it exited and is being backed off.
+type CodePackageEntryPoint struct {
+	EntryPointLocation string                           `json:"EntryPointLocation"`
+	ProcessID          string                           `json:"ProcessId"`
+	RunAsUserName      string                           `json:"RunAsUserName"`
+	Status             string                           `json:"Status"`
+	NextActivationTime string                           `json:"NextActivationTime"`
+	InstanceID         string                           `json:"InstanceId"`
+	Statistics         *CodePackageEntryPointStatistics `json:"CodePackageEntryPointStatistics,omitempty"`
+}
+
+// CodePackageEntryPointStatistics counts the activations and
+// exits of a code package entry point process
+type CodePackageEntryPointStatistics struct {
+	LastExitCode                     string `json:"LastExitCode"`
+	LastActivationTime               string `json:"LastActivationTime"`
+	LastExitTime                     string `json:"LastExitTime"`
+	LastSuccessfulActivationTime     string `json:"LastSuccessfulActivationTime"`
+	LastSuccessfulExitTime           string `json:"LastSuccessfulExitTime"`
+	ActivationCount                  int64  `json:"ActivationCount,string"`
+	ActivationFailureCount           int64  `json:"ActivationFailureCount,string"`
+	ContinuousActivationFailureCount int64  `json:"ContinuousActivationFailureCount,string"`
+	ExitCount                        int64  `json:"ExitCount,string"`
+	ExitFailureCount                 int64  `json:"ExitFailureCount,string"`
+	ContinuousExitFailureCount       int64  `json:"ContinuousExitFailureCount,string"`
+}
+
+// GetDeployedServicePackages returns the service packages of an application deployed on a node
+func (c ServiceFabricClient) GetDeployedServicePackages(ctx context.Context, nodeName, appID string) (packages []DeployedServicePackageInfo, err error) {
+	ctx, call := c.startCall(ctx, "GetDeployedServicePackages")
+	defer func() { call.finish(err) }()
+
+	res, _, err := c.getHTTP(ctx, "Nodes/"+nodeName+"/$/GetApplications/"+appID+"/$/GetServicePackages")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting deployed service packages")
+	}
+
+	err = json.Unmarshal(res, &packages)
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return packages, nil
+}
+
+// GetDeployedCodePackages returns the code packages of an application deployed
+// on a node, optionally narrowed to a service manifest or a code package name
+func (c ServiceFabricClient) GetDeployedCodePackages(ctx context.Context, nodeName, appID, serviceManifestName, codePackageName string) (packages []DeployedCodePackageInfo, err error) {
+	ctx, call := c.startCall(ctx, "GetDeployedCodePackages")
+	defer func() { call.finish(err) }()
+
+	return c.getDeployedCodePackages(ctx, nodeName, appID, serviceManifestName, codePackageName)
+}
+
+func (c ServiceFabricClient) getDeployedCodePackages(ctx context.Context, nodeName, appID, serviceManifestName, codePackageName string) ([]DeployedCodePackageInfo, error) {
+	res, _, err := c.getHTTP(ctx, "Nodes/"+nodeName+"/$/GetApplications/"+appID+"/$/GetCodePackages",
+		withOptionalParam("ServiceManifestName", serviceManifestName), withOptionalParam("CodePackageName", codePackageName))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting deployed code packages")
+	}
+
+	var packages []DeployedCodePackageInfo
+	err = json.Unmarshal(res, &packages)
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return packages, nil
+}
+
+// getDeployedApplicationIDs returns the ids of the applications deployed on a node
+func (c ServiceFabricClient) getDeployedApplicationIDs(ctx context.Context, nodeName string) ([]string, error) {
+	var ids []string
+	var continueToken string
+	for {
+		res, _, err := c.getHTTP(ctx, "Nodes/"+nodeName+"/$/GetApplications", withContinue(continueToken))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed getting deployed applications")
+		}
+
+		var deployedPage struct {
+			ContinuationToken *string `json:"ContinuationToken"`
+			Items             []struct {
+				ID string `json:"Id"`
+			} `json:"Items"`
+		}
+		err = json.Unmarshal(res, &deployedPage)
+		if err != nil {
+			return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+		}
+
+		for _, app := range deployedPage.Items {
+			ids = append(ids, app.ID)
+		}
+
+		continueToken = getString(deployedPage.ContinuationToken)
+		if continueToken == "" {
+			break
+		}
+	}
+	return ids, nil
+}
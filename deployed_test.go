@@ -0,0 +1,80 @@
+package servicefabric
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGetDeployedServicePackages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleDeployed))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	expected := []DeployedServicePackageInfo{
+		{Name: "TestServicePkg", Version: "1.0.0", Status: "Active"},
+		{Name: "TestWorkerPkg", Version: "1.0.0", Status: "Active", ServicePackageActivationID: "1b2c3d4e-0000-4000-8000-000000000001"},
+	}
+
+	actual, err := sfClient.GetDeployedServicePackages(context.Background(), "_Node_0", "TestApplication")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+}
+
+func TestGetDeployedServicePackagesReturnsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleDeployed))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	if _, err := sfClient.GetDeployedServicePackages(context.Background(), "_Node_1", "TestApplication"); err == nil {
+		t.Error("Error should have been returned")
+	}
+}
+
+func TestGetDeployedCodePackages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleDeployed))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetDeployedCodePackages(context.Background(), "_Node_0", "TestApplication", "TestServicePkg", "")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if len(actual) != 1 || actual[0].SetupEntryPoint == nil || actual[0].MainEntryPoint == nil {
+		t.Fatalf("Got %+v, want one code package with setup and main entry points", actual)
+	}
+
+	if actual[0].SetupEntryPoint.RunAsUserName != "SetupAdminUser" {
+		t.Errorf("Got %q, want SetupAdminUser", actual[0].SetupEntryPoint.RunAsUserName)
+	}
+
+	main := actual[0].MainEntryPoint
+	expected := &CodePackageEntryPointStatistics{
+		LastExitCode:                 "3221225477",
+		LastActivationTime:           "2020-01-01T10:03:00.000Z",
+		LastExitTime:                 "2020-01-01T10:03:05.000Z",
+		LastSuccessfulActivationTime: "2020-01-01T10:03:00.000Z",
+		LastSuccessfulExitTime:       "0001-01-01T00:00:00.000Z",
+		ActivationCount:              7,
+		ExitCount:                    7,
+		ExitFailureCount:             7,
+		ContinuousExitFailureCount:   7,
+	}
+	if main.Status != "Pending" || main.NextActivationTime != "2020-01-01T10:05:00.000Z" {
+		t.Errorf("Got %s %s, want Pending 2020-01-01T10:05:00.000Z", main.Status, main.NextActivationTime)
+	}
+	if !reflect.DeepEqual(main.Statistics, expected) {
+		t.Errorf("Got %+v, want %+v", main.Statistics, expected)
+	}
+}
@@ -0,0 +1,223 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultDiscoveryPollInterval is how often Watch resolves instances by default
+const DefaultDiscoveryPollInterval = 10 * time.Second
+
+// Instance is a ready replica or instance of a partition of a service
+type Instance struct {
+	ServiceName string
+	PartitionID string
+	// ID is the replica id of stateful services,
+	// and the instance id of stateless ones
+	ID       string
+	NodeName string
+	// Role is the replica role of stateful services, such as "Primary",
+	// and empty for stateless ones
+	Role        string
+	HealthState string
+	// Address is the address published by the replica, usually a JSON
+	// object whose endpoints, by listener name, are in Endpoints
+	Address   string
+	Endpoints map[string]string
+}
+
+// DiscoveryEvent carries the instances of a watched service,
+// or the error resolving them
+type DiscoveryEvent struct {
+	Instances []Instance
+	Err       error
+}
+
+// Discovery resolves services to the instances serving them, the way
+// service discovery frameworks such as go-kit or go-micro use a registry
+type Discovery interface {
+	// Instances returns the ready instances of serviceName
+	Instances(serviceName string) ([]Instance, error)
+	// Watch sends the instances of serviceName once resolved and whenever
+	// they change, and the errors resolving them, until ctx is done
+	// when the channel is closed
+	Watch(ctx context.Context, serviceName string) <-chan DiscoveryEvent
+}
+
+// DiscoveryOptions tunes NewDiscovery
+type DiscoveryOptions struct {
+	// PollInterval is how often Watch resolves the instances, defaults to 10s
+	PollInterval time.Duration
+	// Timeout bounds every resolution, unbounded when zero
+	Timeout time.Duration
+}
+
+type discovery struct {
+	client ServiceFabricClient
+	opts   DiscoveryOptions
+}
+
+// NewDiscovery returns a Discovery resolving instances with client
+func NewDiscovery(client *ServiceFabricClient, opts DiscoveryOptions) Discovery {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultDiscoveryPollInterval
+	}
+	return discovery{client: *client, opts: opts}
+}
+
+func (d discovery) Instances(serviceName string) ([]Instance, error) {
+	return d.resolve(context.Background(), serviceName)
+}
+
+func (d discovery) Watch(ctx context.Context, serviceName string) <-chan DiscoveryEvent {
+	events := make(chan DiscoveryEvent)
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(d.opts.PollInterval)
+		defer ticker.Stop()
+
+		var last []Instance
+		for first := true; ; first = false {
+			instances, err := d.resolve(ctx, serviceName)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil || first || !reflect.DeepEqual(instances, last) {
+				select {
+				case events <- DiscoveryEvent{Instances: instances, Err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err == nil {
+				last = instances
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return events
+}
+
+func (d discovery) resolve(ctx context.Context, serviceName string) ([]Instance, error) {
+	if d.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.opts.Timeout)
+		defer cancel()
+	}
+	return d.client.GetServiceInstances(ctx, serviceName)
+}
+
+// GetServiceInstances returns the ready replicas or instances of every
+// partition of the service serviceName, such as fabric:/App/Service,
+// ordered by partition and id
+func (c ServiceFabricClient) GetServiceInstances(ctx context.Context, serviceName string) (instances []Instance, err error) {
+	ctx, call := c.startCall(ctx, "GetServiceInstances")
+	defer func() { call.finish(err) }()
+
+	return c.getServiceInstances(ctx, serviceName)
+}
+
+func (c ServiceFabricClient) getServiceInstances(ctx context.Context, serviceName string) ([]Instance, error) {
+	segments := strings.SplitN(strings.TrimPrefix(serviceName, fabricScheme), "/", 2)
+	if len(segments) != 2 || segments[0] == "" || segments[1] == "" {
+		return nil, fmt.Errorf("service name %q must be of the form %sApplication/Service", serviceName, fabricScheme)
+	}
+	appName := segments[0]
+	serviceName = fabricScheme + segments[0] + "/" + segments[1]
+	serviceID := serviceIDFromName(serviceName)
+	partitions, err := c.getPartitions(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := []Instance{}
+	for _, partition := range partitions.Items {
+		partitionID := partition.PartitionInformation.ID
+		err := c.getPartitionReplicas(ctx, appName, serviceID, partitionID, func(res []byte) (*string, error) {
+			items, token, err := decodeReplicaPage(partition.ServiceKind, res)
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range items {
+				id, replica := item.GetReplicaData()
+				if replica == nil || replica.ReplicaStatus != "Ready" {
+					continue
+				}
+				instances = append(instances, Instance{
+					ServiceName: serviceName,
+					PartitionID: partitionID,
+					ID:          id,
+					NodeName:    replica.NodeName,
+					Role:        replica.ReplicaRole,
+					HealthState: replica.HealthState,
+					Address:     replica.Address,
+					Endpoints:   publishedEndpoints(replica.Address),
+				})
+			}
+			return token, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(instances, func(i, j int) bool {
+		if instances[i].PartitionID != instances[j].PartitionID {
+			return instances[i].PartitionID < instances[j].PartitionID
+		}
+		return instances[i].ID < instances[j].ID
+	})
+	return instances, nil
+}
+
+// replicaData is implemented by ReplicaItem and InstanceItem
+type replicaData interface {
+	GetReplicaData() (string, *ReplicaItemBase)
+}
+
+// decodeReplicaPage decodes a page of the replicas of a stateful service
+// partition, or of the instances of a stateless one
+func decodeReplicaPage(serviceKind string, res []byte) ([]replicaData, *string, error) {
+	var items []replicaData
+	if serviceKind == "Stateless" {
+		var page InstanceItemsPage
+		if err := json.Unmarshal(res, &page); err != nil {
+			return nil, nil, err
+		}
+		for i := range page.Items {
+			items = append(items, &page.Items[i])
+		}
+		return items, page.ContinuationToken, nil
+	}
+
+	var page ReplicaItemsPage
+	if err := json.Unmarshal(res, &page); err != nil {
+		return nil, nil, err
+	}
+	for i := range page.Items {
+		items = append(items, &page.Items[i])
+	}
+	return items, page.ContinuationToken, nil
+}
+
+// publishedEndpoints returns the endpoints, by listener name, of an address
+// published as {"Endpoints":{...}}, nil for other addresses
+func publishedEndpoints(address string) map[string]string {
+	var published struct {
+		Endpoints map[string]string `json:"Endpoints"`
+	}
+	if err := json.Unmarshal([]byte(address), &published); err != nil {
+		return nil
+	}
+	return published.Endpoints
+}
@@ -0,0 +1,93 @@
+package servicefabric
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetServiceInstances(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Services/TestApplication~TestService/$/GetPartitions", func(w http.ResponseWriter, r *http.Request) {
+		writeFixture(w, "partitions.json")
+	})
+	mux.HandleFunc("/Partitions/bce46a8c-b62d-4996-89dc-7ffc00a96902/$/GetReplicas", handleReplicas)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := NewDiscovery(sfClient, DiscoveryOptions{}).Instances("fabric:/TestApplication/TestService")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	address := "localhost:30001+bce46a8c-b62d-4996-89dc-7ffc00a96902-131496928082309293"
+	expected := []Instance{{
+		ServiceName: "fabric:/TestApplication/TestService",
+		PartitionID: "bce46a8c-b62d-4996-89dc-7ffc00a96902",
+		ID:          "131496928082309293",
+		NodeName:    "_Node_0",
+		Role:        "Primary",
+		HealthState: "Ok",
+		Address:     `{"Endpoints":{"":"` + address + `"}}`,
+		Endpoints:   map[string]string{"": address},
+	}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+
+	for _, name := range []string{"TestApplication/MissingService", "", "fabric:", "fabric:/TestApplication", "fabric:/TestApplication/"} {
+		_, err = sfClient.GetServiceInstances(context.Background(), name)
+		if err == nil {
+			t.Errorf("Error should have been returned for %q", name)
+		}
+	}
+}
+
+func TestDiscoveryWatch(t *testing.T) {
+	var moved int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Services/TestApplication~TestService/$/GetPartitions", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Items":[{"ServiceKind":"Stateless","PartitionInformation":{"ServicePartitionKind":"Singleton","Id":"824091ba-fa32-4e9c-9e9c-71738e018312"}}]}`))
+	})
+	mux.HandleFunc("/Partitions/824091ba-fa32-4e9c-9e9c-71738e018312/$/GetReplicas", func(w http.ResponseWriter, r *http.Request) {
+		node := "_Node_0"
+		if atomic.LoadInt32(&moved) == 1 {
+			node = "_Node_1"
+		}
+		w.Write([]byte(`{"Items":[{"ServiceKind":"Stateless","InstanceId":"1","ReplicaStatus":"Ready","NodeName":"` + node + `","Address":"http://` + node + `"},` +
+			`{"ServiceKind":"Stateless","InstanceId":"2","ReplicaStatus":"InBuild","NodeName":"_Node_2"}]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := NewDiscovery(sfClient, DiscoveryOptions{PollInterval: time.Millisecond}).Watch(ctx, "fabric:/TestApplication/TestService")
+
+	for _, node := range []string{"_Node_0", "_Node_1"} {
+		select {
+		case event := <-events:
+			if event.Err != nil {
+				t.Fatalf("Exception thrown %v", event.Err)
+			}
+			if len(event.Instances) != 1 || event.Instances[0].NodeName != node || event.Instances[0].Endpoints != nil {
+				t.Errorf("Got %+v, want the ready instance on %s", event.Instances, node)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Got no event, want the instance on %s", node)
+		}
+		atomic.StoreInt32(&moved, 1)
+	}
+
+	cancel()
+	for range events {
+	}
+}
@@ -0,0 +1,69 @@
+package servicefabric
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// parseEndpoint parses and validates a cluster management endpoint such as
+// https://cluster.example.com:19080 or https://[fd00::1]:19080, trailing
+// slashes are stripped so request paths can be appended as is
+func parseEndpoint(endpoint string) (*url.URL, error) {
+	if !strings.Contains(endpoint, "://") {
+		return nil, fmt.Errorf("invalid endpoint %q: scheme missing, use http:// or https://", endpoint)
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %v", endpoint, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid endpoint %q: scheme must be http or https", endpoint)
+	}
+
+	host := u.Hostname()
+	if host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q: host missing", endpoint)
+	}
+	if strings.Contains(host, ":") && !strings.HasPrefix(u.Host, "[") {
+		return nil, fmt.Errorf("invalid endpoint %q: IPv6 addresses must be enclosed in brackets", endpoint)
+	}
+
+	if port := u.Port(); port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("invalid endpoint %q: port %s out of range", endpoint, port)
+		}
+	}
+
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("invalid endpoint %q: query and fragment are not allowed", endpoint)
+	}
+
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	return u, nil
+}
+
+// checkConnectivity issues a lightweight cluster health query, with every
+// child health state filtered out, to fail fast on unreachable endpoints
+func (c ServiceFabricClient) checkConnectivity(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	_, _, err := c.getHTTP(ctx, "$/GetClusterHealth",
+		withParam("NodesHealthStateFilter", "1"),
+		withParam("ApplicationsHealthStateFilter", "1"),
+		withParam("EventsHealthStateFilter", "1"))
+	if err != nil {
+		return errors.Wrapf(err, "endpoint %s is not reachable", c.endpoint)
+	}
+	return nil
+}
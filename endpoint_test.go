@@ -0,0 +1,96 @@
+package servicefabric
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		host     string
+		port     string
+		valid    bool
+	}{
+		{endpoint: "https://cluster.example.com:19080", host: "cluster.example.com", port: "19080", valid: true},
+		{endpoint: "http://10.0.0.4:19080", host: "10.0.0.4", port: "19080", valid: true},
+		{endpoint: "https://[fd00::1]:19080", host: "fd00::1", port: "19080", valid: true},
+		{endpoint: "https://[fd00::1]", host: "fd00::1", valid: true},
+		{endpoint: "https://fd00::1:19080"},
+		{endpoint: "cluster.example.com:19080"},
+		{endpoint: "ftp://cluster.example.com"},
+		{endpoint: "https://"},
+		{endpoint: "https://cluster.example.com:99999"},
+		{endpoint: "https://[fd00::1:19080"},
+		{endpoint: "https://cluster.example.com:19080/?a=b"},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.endpoint, func(t *testing.T) {
+			u, err := parseEndpoint(test.endpoint)
+			if !test.valid {
+				if err == nil {
+					t.Error("Error should have been returned")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exception thrown %v", err)
+			}
+			if u.Hostname() != test.host || u.Port() != test.port {
+				t.Errorf("Got %s %s, want %s %s", u.Hostname(), u.Port(), test.host, test.port)
+			}
+		})
+	}
+}
+
+func TestGetURLEscapesPathAndParams(t *testing.T) {
+	sfClient, err := NewServiceFabricClient(nil, "https://[fd00::1]:19080", "6.0")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := "/Names/My%20App/$/GetProperties?api-version=6.0&continue=a%2Bb%2Fc%26d"
+	actual := sfClient.getURL("Names/My App/$/GetProperties", withContinue("a+b/c&d"))
+	if actual != expected {
+		t.Errorf("Got %q, want %q", actual, expected)
+	}
+}
+
+func TestEndpointStripsTrailingSlashes(t *testing.T) {
+	sfClient, err := NewServiceFabricClient(nil, "https://cluster.example.com:19080//", "")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := "https://cluster.example.com:19080"
+	if actual := sfClient.Endpoint(); actual != expected {
+		t.Errorf("Got %q, want %q", actual, expected)
+	}
+}
+
+func TestConnectivityCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/$/GetClusterHealth" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"AggregatedHealthState":"Ok"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewServiceFabricClient(http.DefaultClient, server.URL, "6.0", WithConnectivityCheck(time.Second))
+	if err != nil {
+		t.Errorf("Should not have thrown: %v", err)
+	}
+
+	server.Close()
+	_, err = NewServiceFabricClient(http.DefaultClient, server.URL, "6.0", WithConnectivityCheck(time.Second))
+	if err == nil {
+		t.Error("Error should have been returned")
+	}
+}
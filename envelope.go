@@ -0,0 +1,235 @@
+package servicefabric
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/pkg/errors"
+)
+
+// Object identifiers of the CMS enveloped data encrypted text is sealed in
+var (
+	oidData            = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEnvelopedData   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+	oidRSAEncryption   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidAES256CBC       = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	asn1NullParameters = asn1.RawValue{Tag: asn1.TagNull}
+)
+
+// EncryptValue encrypts value with the cluster certificate cert the way
+// Invoke-ServiceFabricEncryptText does, for secrets such as Settings.xml
+// parameters marked IsEncrypted. Only nodes holding the certificate key
+// can decrypt the base64 result. cert must have an RSA key.
+func EncryptValue(cert *x509.Certificate, value string) (string, error) {
+	sealed, err := encryptText(cert, value)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptValue decrypts a value encrypted for the certificate of cert,
+// such as the TLSCredentials Certificate, with its private key
+func DecryptValue(cert tls.Certificate, encrypted string) (string, error) {
+	if len(cert.Certificate) == 0 {
+		return "", errors.New("certificate missing")
+	}
+	leaf := cert.Leaf
+	if leaf == nil {
+		var err error
+		leaf, err = x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return "", errors.Wrap(err, "invalid certificate")
+		}
+	}
+	key, ok := cert.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("certificate key %T is not supported, an RSA key is required", cert.PrivateKey)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encrypted))
+	if err != nil {
+		return "", errors.Wrap(err, "encrypted value is not base64")
+	}
+	return decryptText(leaf, key, sealed)
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     envelopedData `asn1:"explicit,tag:0"`
+}
+
+type envelopedData struct {
+	Version              int
+	RecipientInfos       []keyTransRecipientInfo `asn1:"set"`
+	EncryptedContentInfo encryptedContentInfo
+}
+
+type keyTransRecipientInfo struct {
+	Version                int
+	IssuerAndSerialNumber  issuerAndSerialNumber
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           []byte `asn1:"tag:0,optional"`
+}
+
+// encryptText seals text, encoded as UTF-16 the way Windows encodes strings,
+// in CMS enveloped data only the private key of cert opens, as
+// Invoke-ServiceFabricEncryptText does
+func encryptText(cert *x509.Certificate, text string) ([]byte, error) {
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("certificate key %T is not supported, an RSA key is required", cert.PublicKey)
+	}
+
+	key := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	content := pkcs7Pad(encodeUTF16(text), aes.BlockSize)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(content, content)
+
+	encryptedKey, err := rsa.EncryptPKCS1v15(rand.Reader, pub, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed encrypting content key")
+	}
+	ivParameters, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(contentInfo{
+		ContentType: oidEnvelopedData,
+		Content: envelopedData{
+			RecipientInfos: []keyTransRecipientInfo{{
+				IssuerAndSerialNumber: issuerAndSerialNumber{
+					Issuer:       asn1.RawValue{FullBytes: cert.RawIssuer},
+					SerialNumber: cert.SerialNumber,
+				},
+				KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1NullParameters},
+				EncryptedKey:           encryptedKey,
+			}},
+			EncryptedContentInfo: encryptedContentInfo{
+				ContentType:                oidData,
+				ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParameters}},
+				EncryptedContent:           content,
+			},
+		},
+	})
+}
+
+// decryptText opens enveloped data sealed by encryptText for cert with key
+func decryptText(cert *x509.Certificate, key *rsa.PrivateKey, sealed []byte) (string, error) {
+	var info contentInfo
+	if _, err := asn1.Unmarshal(sealed, &info); err != nil {
+		return "", errors.Wrap(err, "invalid enveloped data")
+	}
+	if !info.ContentType.Equal(oidEnvelopedData) {
+		return "", fmt.Errorf("content type %s is not enveloped data", info.ContentType)
+	}
+
+	var encryptedKey []byte
+	for _, recipient := range info.Content.RecipientInfos {
+		if bytes.Equal(recipient.IssuerAndSerialNumber.Issuer.FullBytes, cert.RawIssuer) &&
+			recipient.IssuerAndSerialNumber.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			encryptedKey = recipient.EncryptedKey
+		}
+	}
+	if encryptedKey == nil {
+		return "", errors.New("text was not encrypted for the certificate")
+	}
+
+	algorithm := info.Content.EncryptedContentInfo.ContentEncryptionAlgorithm
+	if !algorithm.Algorithm.Equal(oidAES256CBC) {
+		return "", fmt.Errorf("content encryption algorithm %s is not supported", algorithm.Algorithm)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(algorithm.Parameters.FullBytes, &iv); err != nil || len(iv) != aes.BlockSize {
+		return "", errors.New("invalid content encryption iv")
+	}
+
+	contentKey, err := rsa.DecryptPKCS1v15(rand.Reader, key, encryptedKey)
+	if err != nil {
+		return "", errors.Wrap(err, "failed decrypting content key")
+	}
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return "", err
+	}
+
+	content := append([]byte{}, info.Content.EncryptedContentInfo.EncryptedContent...)
+	if len(content) == 0 || len(content)%aes.BlockSize != 0 {
+		return "", errors.New("invalid encrypted content length")
+	}
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(content, content)
+	content, err = pkcs7Unpad(content, aes.BlockSize)
+	if err != nil {
+		return "", err
+	}
+	return decodeUTF16(content)
+}
+
+func pkcs7Pad(b []byte, blockSize int) []byte {
+	n := blockSize - len(b)%blockSize
+	return append(b, bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+func pkcs7Unpad(b []byte, blockSize int) ([]byte, error) {
+	n := int(b[len(b)-1])
+	if n == 0 || n > blockSize || n > len(b) || !bytes.Equal(b[len(b)-n:], bytes.Repeat([]byte{byte(n)}, n)) {
+		return nil, errors.New("invalid content padding")
+	}
+	return b[:len(b)-n], nil
+}
+
+// encodeUTF16 encodes s as little endian UTF-16
+func encodeUTF16(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, 0, 2*len(units))
+	for _, u := range units {
+		b = append(b, byte(u), byte(u>>8))
+	}
+	return b
+}
+
+func decodeUTF16(b []byte) (string, error) {
+	if len(b)%2 != 0 {
+		return "", errors.New("invalid UTF-16 text length")
+	}
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = uint16(b[2*i]) | uint16(b[2*i+1])<<8
+	}
+	return string(utf16.Decode(units)), nil
+}
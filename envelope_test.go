@@ -0,0 +1,97 @@
+package servicefabric
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// newTestRSACertificate returns a self-signed certificate with an RSA key,
+// as clusters are secured with
+func newTestRSACertificate(t *testing.T, serial int64) (*x509.Certificate, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "cluster"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDataEncipherment,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	return cert, key
+}
+
+func TestNewEncryptedRegistryCredential(t *testing.T) {
+	cert, key := newTestRSACertificate(t, 1)
+
+	credential, err := NewEncryptedRegistryCredential("user", "p@ssw0rd€", cert)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if credential.RegistryUserName != "user" || !credential.PasswordEncrypted {
+		t.Errorf("Got %+v, want an encrypted password for user", credential)
+	}
+
+	password, err := DecryptValue(tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key}, credential.RegistryPassword)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if password != "p@ssw0rd€" {
+		t.Errorf("Got %+v, want %+v", password, "p@ssw0rd€")
+	}
+}
+
+func TestEncryptValue(t *testing.T) {
+	cert, key := newTestRSACertificate(t, 1)
+
+	encrypted, err := EncryptValue(cert, "connection string")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	// text is encrypted as UTF-16, as Windows decrypts it
+	var info contentInfo
+	if _, err := asn1.Unmarshal(sealed, &info); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if len(info.Content.EncryptedContentInfo.EncryptedContent) != 48 {
+		t.Errorf("Got %d encrypted bytes, want 48", len(info.Content.EncryptedContentInfo.EncryptedContent))
+	}
+
+	decrypted, err := DecryptValue(tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key, Leaf: cert}, encrypted)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if decrypted != "connection string" {
+		t.Errorf("Got %+v, want %+v", decrypted, "connection string")
+	}
+
+	other, otherKey := newTestRSACertificate(t, 2)
+	if _, err := DecryptValue(tls.Certificate{Certificate: [][]byte{other.Raw}, PrivateKey: otherKey}, encrypted); err == nil {
+		t.Error("Error should have been returned")
+	}
+	if _, err := DecryptValue(tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key}, "not base64"); err == nil {
+		t.Error("Error should have been returned")
+	}
+}
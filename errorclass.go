@@ -0,0 +1,116 @@
+package servicefabric
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrorClass groups failures by how callers should react to them,
+// for retries and alert routing
+type ErrorClass string
+
+// Error classes, see ClassifyError
+const (
+	// ErrorClassTransient failures, such as unreachable gateways
+	// and pending reconfigurations, may succeed when retried
+	ErrorClassTransient ErrorClass = "Transient"
+	// ErrorClassThrottled failures succeed when retried after backing off
+	ErrorClassThrottled ErrorClass = "Throttled"
+	// ErrorClassNotFound failures name an entity that does not exist
+	ErrorClassNotFound ErrorClass = "NotFound"
+	// ErrorClassConflict failures clash with the state of the entity, such
+	// as creating an existing application or upgrading one being upgraded
+	ErrorClassConflict ErrorClass = "Conflict"
+	// ErrorClassAuthFailure failures were refused for lack of credentials or rights
+	ErrorClassAuthFailure ErrorClass = "AuthFailure"
+	// ErrorClassTerminal failures fail again when retried as is
+	ErrorClassTerminal ErrorClass = "Terminal"
+)
+
+// transientFabricErrors are the FabricError codes of ErrorClassTransient failures
+var transientFabricErrors = map[string]bool{
+	FabricErrorGatewayNotReachable:    true,
+	FabricErrorTimeout:                true,
+	FabricErrorNotReady:               true,
+	FabricErrorReconfigurationPending: true,
+}
+
+// conflictFabricErrors are the FabricError codes of ErrorClassConflict failures
+var conflictFabricErrors = map[string]bool{
+	FabricErrorApplicationAlreadyExists:     true,
+	FabricErrorServiceAlreadyExists:         true,
+	FabricErrorApplicationUpgradeInProgress: true,
+}
+
+// Class returns the class of the failure, from its FabricError
+// code when the cluster reported one and its status code otherwise
+func (e *StatusError) Class() ErrorClass {
+	var code string
+	var fabricErr *FabricError
+	if errors.As(e.Err, &fabricErr) {
+		code = fabricErr.Code
+	}
+	return classify(e.StatusCode, code)
+}
+
+// ClassifyError returns the class of err, an error returned by the client.
+// Requests that got no answer are transient, unless ctx was cancelled or
+// expired, and errors raised client side are terminal. The class of a nil
+// error is empty.
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ""
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Class()
+	}
+	if transportError(err) {
+		return ErrorClassTransient
+	}
+	return ErrorClassTerminal
+}
+
+// transportError reports whether err failed a request in transit, such as
+// a refused connection or a connection dropped mid response, rather than
+// client side or because ctx was cancelled or expired
+func transportError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// classify returns the class of a response with status and FabricError code
+func classify(status int, code string) ErrorClass {
+	switch {
+	case code == FabricErrorServiceTooBusy:
+		return ErrorClassThrottled
+	case transientFabricErrors[code]:
+		return ErrorClassTransient
+	case conflictFabricErrors[code]:
+		return ErrorClassConflict
+	case strings.HasSuffix(code, "_NOT_FOUND") || strings.HasSuffix(code, "_DOES_NOT_EXIST"):
+		return ErrorClassNotFound
+	}
+
+	switch status {
+	case http.StatusTooManyRequests:
+		return ErrorClassThrottled
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout, http.StatusRequestTimeout:
+		return ErrorClassTransient
+	case http.StatusNotFound, http.StatusGone:
+		return ErrorClassNotFound
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return ErrorClassConflict
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrorClassAuthFailure
+	}
+	return ErrorClassTerminal
+}
@@ -0,0 +1,96 @@
+package servicefabric
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		expected ErrorClass
+	}{
+		{"throttled", http.StatusTooManyRequests, "", ErrorClassThrottled},
+		{"too busy", http.StatusServiceUnavailable, `{"Error":{"Code":"FABRIC_E_SERVICE_TOO_BUSY"}}`, ErrorClassThrottled},
+		{"unavailable", http.StatusServiceUnavailable, "", ErrorClassTransient},
+		{"reconfiguring", http.StatusInternalServerError, `{"Error":{"Code":"FABRIC_E_RECONFIGURATION_PENDING"}}`, ErrorClassTransient},
+		{"not found", http.StatusNotFound, "", ErrorClassNotFound},
+		{"service missing", http.StatusBadRequest, `{"Error":{"Code":"FABRIC_E_SERVICE_DOES_NOT_EXIST"}}`, ErrorClassNotFound},
+		{"exists", http.StatusBadRequest, `{"Error":{"Code":"FABRIC_E_APPLICATION_ALREADY_EXISTS"}}`, ErrorClassConflict},
+		{"conflict", http.StatusConflict, "", ErrorClassConflict},
+		{"unauthorized", http.StatusUnauthorized, "", ErrorClassAuthFailure},
+		{"forbidden", http.StatusForbidden, "", ErrorClassAuthFailure},
+		{"bad request", http.StatusBadRequest, `{"Error":{"Code":"FABRIC_E_INVALID_ARGUMENT"}}`, ErrorClassTerminal},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.status)
+				w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+			err := sfClient.DeleteService(context.Background(), "TestApplication~TestService")
+			if actual := ClassifyError(err); actual != test.expected {
+				t.Errorf("Got %+v, want %+v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestClassifyErrorWithoutResponse(t *testing.T) {
+	sfClient, _ := NewClient(http.DefaultClient, "http://127.0.0.1:1", "1.0", nil)
+
+	_, err := sfClient.GetNodes(context.Background())
+	if actual := ClassifyError(err); actual != ErrorClassTransient {
+		t.Errorf("Got %+v, want %+v", actual, ErrorClassTransient)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = sfClient.GetNodes(ctx)
+	if actual := ClassifyError(err); actual != ErrorClassTerminal {
+		t.Errorf("Got %+v, want %+v", actual, ErrorClassTerminal)
+	}
+
+	if actual := ClassifyError(errors.New("invalid service kind")); actual != ErrorClassTerminal {
+		t.Errorf("Got %+v, want %+v", actual, ErrorClassTerminal)
+	}
+	if actual := ClassifyError(nil); actual != "" {
+		t.Errorf("Got %+v, want no class", actual)
+	}
+}
+
+func TestRetryPolicyRetryableClasses(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusRequestTimeout)
+			return
+		}
+		w.Write([]byte(`{"Items":[]}`))
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil, WithRetryPolicy(RetryPolicy{
+		MaxAttempts:      2,
+		RetryableClasses: []ErrorClass{ErrorClassTransient},
+	}))
+
+	if _, err := sfClient.GetNodes(context.Background()); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Got %d attempts, want 2", attempts)
+	}
+}
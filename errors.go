@@ -0,0 +1,100 @@
+package servicefabric
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// Error codes of FabricError
+const (
+	FabricErrorApplicationNotFound          = "FABRIC_E_APPLICATION_NOT_FOUND"
+	FabricErrorApplicationTypeNotFound      = "FABRIC_E_APPLICATION_TYPE_NOT_FOUND"
+	FabricErrorServiceDoesNotExist          = "FABRIC_E_SERVICE_DOES_NOT_EXIST"
+	FabricErrorPartitionNotFound            = "FABRIC_E_PARTITION_NOT_FOUND"
+	FabricErrorReplicaDoesNotExist          = "FABRIC_E_REPLICA_DOES_NOT_EXIST"
+	FabricErrorNodeNotFound                 = "FABRIC_E_NODE_NOT_FOUND"
+	FabricErrorNameDoesNotExist             = "FABRIC_E_NAME_DOES_NOT_EXIST"
+	FabricErrorPropertyDoesNotExist         = "FABRIC_E_PROPERTY_DOES_NOT_EXIST"
+	FabricErrorNameAlreadyExists            = "FABRIC_E_NAME_ALREADY_EXISTS"
+	FabricErrorApplicationAlreadyExists     = "FABRIC_E_APPLICATION_ALREADY_EXISTS"
+	FabricErrorServiceAlreadyExists         = "FABRIC_E_SERVICE_ALREADY_EXISTS"
+	FabricErrorApplicationUpgradeInProgress = "FABRIC_E_APPLICATION_UPGRADE_IN_PROGRESS"
+	FabricErrorTimeout                      = "FABRIC_E_TIMEOUT"
+	FabricErrorGatewayNotReachable          = "FABRIC_E_GATEWAY_NOT_REACHABLE"
+	FabricErrorServiceTooBusy               = "FABRIC_E_SERVICE_TOO_BUSY"
+	FabricErrorNotReady                     = "FABRIC_E_NOT_READY"
+	FabricErrorReconfigurationPending       = "FABRIC_E_RECONFIGURATION_PENDING"
+)
+
+// FabricError is the error the cluster describes an unsuccessful
+// request with, found with errors.As on the errors of the client
+type FabricError struct {
+	// Code is one of the FABRIC_E_ codes, such as FabricErrorServiceDoesNotExist
+	Code    string `json:"Code"`
+	Message string `json:"Message"`
+}
+
+func (e *FabricError) Error() string {
+	if e.Message == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Message
+}
+
+// IsFabricError reports whether err is a FabricError with code
+func IsFabricError(err error, code string) bool {
+	var fabricErr *FabricError
+	return errors.As(err, &fabricErr) && fabricErr.Code == code
+}
+
+// parseFabricError returns the FabricError body describes, or nil
+func parseFabricError(body []byte) *FabricError {
+	var res struct {
+		Error *FabricError `json:"Error"`
+	}
+	if json.Unmarshal(body, &res) != nil || res.Error == nil || res.Error.Code == "" {
+		return nil
+	}
+	return res.Error
+}
+
+// StatusError is returned when the cluster answers a request with an
+// unsuccessful status code. A 404 status matches ErrResourceNotFound
+// with errors.Is.
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	// Err is the FabricError the response body describes,
+	// or else the error reported by the HTTP client
+	Err error
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("failed connecting to Service Fabric server, status code %d: %s", e.StatusCode, e.Err)
+}
+
+// Unwrap returns Err
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the status code is the one target stands for
+func (e *StatusError) Is(target error) bool {
+	return target == ErrResourceNotFound && e.StatusCode == http.StatusNotFound
+}
+
+// requestError wraps err in a StatusError when a response was received,
+// replacing it with the FabricError body describes if any
+func requestError(method, basePath string, status int, body []byte, err error) error {
+	if status <= 0 {
+		return errors.Wrap(err, "failed to connect to Service Fabric server")
+	}
+	if fabricErr := parseFabricError(body); fabricErr != nil {
+		err = fabricErr
+	}
+	return &StatusError{Method: method, Path: basePath, StatusCode: status, Err: err}
+}
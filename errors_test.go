@@ -0,0 +1,116 @@
+package servicefabric
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestDeleteServiceReturnsStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	err := sfClient.DeleteService(context.Background(), "TestApplication~TestService")
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("Got %v, want a *StatusError", err)
+	}
+	if statusErr.StatusCode != http.StatusInternalServerError || statusErr.Method != "POST" ||
+		statusErr.Path != "Services/TestApplication~TestService/$/Delete" {
+		t.Errorf("Got %+v, want POST Services/TestApplication~TestService/$/Delete failing with 500", statusErr)
+	}
+	if errors.Is(err, ErrResourceNotFound) {
+		t.Error("Error should not match ErrResourceNotFound")
+	}
+}
+
+func TestDeleteServiceNotFoundMatchesErrResourceNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(http.NotFound))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	err := sfClient.DeleteService(context.Background(), "TestApplication~TestService")
+	if !errors.Is(err, ErrResourceNotFound) {
+		t.Errorf("Got %v, want %v", err, ErrResourceNotFound)
+	}
+}
+
+func TestCheckClusterHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/$/GetClusterHealth" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	err := sfClient.CheckClusterHealth(context.Background())
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Got %v, want a *StatusError with status 503", err)
+	}
+
+	healthy, err := sfClient.GetClusterHealth(context.Background())
+	if healthy || !errors.As(err, &statusErr) {
+		t.Errorf("Got %v %v, want false and a *StatusError", healthy, err)
+	}
+}
+
+func TestGetServiceDescriptionReturnsFabricError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"Error":{"Code":"FABRIC_E_SERVICE_DOES_NOT_EXIST","Message":"Service does not exist."}}`))
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	_, err := sfClient.GetServiceDescription(context.Background(), "TestApplication~TestService")
+
+	var fabricErr *FabricError
+	if !errors.As(err, &fabricErr) {
+		t.Fatalf("Got %v, want a *FabricError", err)
+	}
+	expected := FabricError{Code: FabricErrorServiceDoesNotExist, Message: "Service does not exist."}
+	if *fabricErr != expected {
+		t.Errorf("Got %+v, want %+v", *fabricErr, expected)
+	}
+	if !IsFabricError(err, FabricErrorServiceDoesNotExist) || IsFabricError(err, FabricErrorApplicationNotFound) {
+		t.Errorf("Got %v, want only %s to match", err, FabricErrorServiceDoesNotExist)
+	}
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Got %v, want a *StatusError with status 400", err)
+	}
+}
+
+func TestStatusErrorWithoutFabricError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("<html>Bad Gateway</html>"))
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	err := sfClient.DeleteService(context.Background(), "TestApplication~TestService")
+
+	var fabricErr *FabricError
+	if errors.As(err, &fabricErr) {
+		t.Errorf("Got %v, want no *FabricError", fabricErr)
+	}
+}
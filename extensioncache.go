@@ -0,0 +1,105 @@
+package servicefabric
+
+import (
+	"context"
+	"sync"
+)
+
+// maxCachedServiceTypes is how many application type versions the service
+// type cache holds, the versions prefetched first being evicted first
+const maxCachedServiceTypes = 256
+
+type cacheKey struct{}
+
+// WithCache returns a copy of ctx whose requests use the client caches, such
+// as the service types PrefetchExtensions cached, unless enabled is false.
+// Bypassing the cache reads from the cluster versions that other clients
+// may have unprovisioned since they were cached.
+func WithCache(ctx context.Context, enabled bool) context.Context {
+	return context.WithValue(ctx, cacheKey{}, enabled)
+}
+
+func cacheEnabled(ctx context.Context) bool {
+	enabled, ok := ctx.Value(cacheKey{}).(bool)
+	return !ok || enabled
+}
+
+// serviceTypeCache holds the service types of prefetched application type
+// versions. The service types of a provisioned version never change, so
+// entries are dropped when the version is unprovisioned or found missing,
+// or evicted once the cache is full.
+type serviceTypeCache struct {
+	mu    sync.RWMutex
+	types map[string][]ServiceType
+	// order lists the keys of types, oldest first
+	order []string
+}
+
+func newServiceTypeCache() *serviceTypeCache {
+	return &serviceTypeCache{types: map[string][]ServiceType{}}
+}
+
+func (s *serviceTypeCache) get(appType, applicationVersion string) ([]ServiceType, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	serviceTypes, ok := s.types[appType+"@"+applicationVersion]
+	return serviceTypes, ok
+}
+
+func (s *serviceTypeCache) put(appType, applicationVersion string, serviceTypes []ServiceType) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := appType + "@" + applicationVersion
+	if _, ok := s.types[key]; !ok {
+		if len(s.order) >= maxCachedServiceTypes {
+			delete(s.types, s.order[0])
+			s.order = s.order[1:]
+		}
+		s.order = append(s.order, key)
+	}
+	s.types[key] = serviceTypes
+}
+
+func (s *serviceTypeCache) drop(appType, applicationVersion string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := appType + "@" + applicationVersion
+	if _, ok := s.types[key]; !ok {
+		return
+	}
+	delete(s.types, key)
+	for i, k := range s.order {
+		if k == key {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// PrefetchExtensions fetches the service types of an application type
+// version once and caches them, so that the extension lookups of every
+// service of that version, such as GetServiceExtension and
+// GetServiceExtensions, are answered from the cache afterwards. Calling it
+// again refreshes the cached service types. The cache is shared by the
+// copies of the client, holds up to 256 versions and drops the versions
+// unprovisioned through it; WithCache(ctx, false) bypasses it.
+func (c ServiceFabricClient) PrefetchExtensions(ctx context.Context, appType, applicationVersion string) (err error) {
+	ctx, call := c.startCall(ctx, "PrefetchExtensions")
+	defer func() { call.finish(err) }()
+
+	serviceTypes, err := c.fetchServiceTypes(ctx, appType, applicationVersion)
+	if err != nil {
+		return err
+	}
+	c.serviceTypes.put(appType, applicationVersion, serviceTypes)
+	return nil
+}
@@ -0,0 +1,115 @@
+package servicefabric
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPrefetchExtensions(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ApplicationTypes/TestApplication/$/Unprovision" {
+			return
+		}
+		atomic.AddInt32(&requests, 1)
+		handleExtensionA(w, r)
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	err := sfClient.PrefetchExtensions(context.Background(), "TestApplication", "1.0.0")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		var extension ServiceExtensionLabels
+		err = sfClient.GetServiceExtension(context.Background(), "TestApplication", "1.0.0", "Test", "Test", &extension)
+		if err != nil {
+			t.Fatalf("Exception thrown %v", err)
+		}
+		if len(extension.Label) != 1 || extension.Label[0].Value != "value1" {
+			t.Errorf("Got %+v, want the label key1", extension)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Got %d requests, want 1", requests)
+	}
+
+	err = sfClient.UnprovisionApplicationType(context.Background(), "TestApplication", "1.0.0", false)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	_, err = sfClient.GetServiceExtensions(context.Background(), "TestApplication", "1.0.0", "Test")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Got %d requests, want 2", requests)
+	}
+}
+
+func TestWithCacheBypassesPrefetchedServiceTypes(t *testing.T) {
+	var requests int32
+	unprovisioned := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.LoadInt32(&unprovisioned) == 1 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		handleExtensionA(w, r)
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	err := sfClient.PrefetchExtensions(context.Background(), "TestApplication", "1.0.0")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	// another client unprovisions the version
+	atomic.StoreInt32(&unprovisioned, 1)
+	_, err = sfClient.GetServiceExtensions(context.Background(), "TestApplication", "1.0.0", "Test")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Got %d requests, want 1", requests)
+	}
+
+	_, err = sfClient.GetServiceExtensions(WithCache(context.Background(), false), "TestApplication", "1.0.0", "Test")
+	if err == nil {
+		t.Error("Error should have been returned")
+	}
+	_, err = sfClient.GetServiceExtensions(context.Background(), "TestApplication", "1.0.0", "Test")
+	if err == nil {
+		t.Error("Error should have been returned")
+	}
+	if requests != 3 {
+		t.Errorf("Got %d requests, want 3", requests)
+	}
+}
+
+func TestServiceTypeCacheEvictsOldestVersions(t *testing.T) {
+	cache := newServiceTypeCache()
+	for i := 0; i <= maxCachedServiceTypes; i++ {
+		cache.put("TestApplication", strconv.Itoa(i), nil)
+	}
+
+	if _, ok := cache.get("TestApplication", "0"); ok {
+		t.Error("Got version 0 cached, want it evicted")
+	}
+	if _, ok := cache.get("TestApplication", strconv.Itoa(maxCachedServiceTypes)); !ok {
+		t.Error("Got the last version evicted, want it cached")
+	}
+	if len(cache.types) != maxCachedServiceTypes || len(cache.order) != maxCachedServiceTypes {
+		t.Errorf("Got %d versions cached, want %d", len(cache.types), maxCachedServiceTypes)
+	}
+}
@@ -0,0 +1,206 @@
+package servicefabric
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultPackageURLExpiry is how long the download URL of an sfpkg
+// provisioned from an external store stays valid unless set otherwise
+const DefaultPackageURLExpiry = time.Hour
+
+// PackageStore stores application packages outside the cluster, for the
+// cluster to download them from when provisioning
+type PackageStore interface {
+	// Upload stores the size bytes of r as name
+	Upload(ctx context.Context, name string, r io.Reader, size int64) error
+	// DownloadURL returns a URL name can be downloaded from until expiry elapsed
+	DownloadURL(ctx context.Context, name string, expiry time.Duration) (string, error)
+}
+
+// ExternalProvisionOptions configures ProvisionFromPackageStore
+type ExternalProvisionOptions struct {
+	// Name is the name the sfpkg is stored as,
+	// "<TypeName>.<TypeVersion>.sfpkg" when empty
+	Name string
+	// URLExpiry is how long the cluster may take to start downloading the
+	// sfpkg, DefaultPackageURLExpiry when zero
+	URLExpiry time.Duration
+	// Async returns once the cluster accepted the request, the type
+	// version reporting the Provisioning status until it is ready
+	Async bool
+}
+
+// ProvisionFromPackageStore uploads the sfpkg in r to store and provisions
+// version typeVersion of application type typeName from it, the cluster
+// downloading the sfpkg through a URL valid for opts.URLExpiry. opts may be nil.
+func (c ServiceFabricClient) ProvisionFromPackageStore(ctx context.Context, store PackageStore, r io.Reader, size int64, typeName, typeVersion string, opts *ExternalProvisionOptions) (err error) {
+	ctx, call := c.startCall(ctx, "ProvisionFromPackageStore")
+	defer func() { call.finish(err) }()
+
+	if typeName == "" || typeVersion == "" {
+		return errors.New("application type name and version are required")
+	}
+	if opts == nil {
+		opts = &ExternalProvisionOptions{}
+	}
+	name := opts.Name
+	if name == "" {
+		name = typeName + "." + typeVersion + ".sfpkg"
+	}
+	expiry := opts.URLExpiry
+	if expiry <= 0 {
+		expiry = DefaultPackageURLExpiry
+	}
+
+	if err := store.Upload(ctx, name, r, size); err != nil {
+		return errors.Wrapf(err, "failed uploading %s", name)
+	}
+	downloadURL, err := store.DownloadURL(ctx, name, expiry)
+	if err != nil {
+		return errors.Wrapf(err, "failed signing %s", name)
+	}
+
+	return c.provisionApplicationType(ctx, ProvisionDescription{
+		DownloadURI:            downloadURL,
+		ApplicationTypeName:    typeName,
+		ApplicationTypeVersion: typeVersion,
+		Async:                  opts.Async,
+	})
+}
+
+// azureStorageVersion is the Blob service version of the requests
+// AzureBlobStore sends, and of the SAS tokens it signs
+const azureStorageVersion = "2015-04-05"
+
+// AzureBlobStore is a PackageStore keeping packages as block blobs of an
+// Azure storage container, authenticated with a storage account key.
+// Download URLs are read-only service SAS URLs of the blob.
+type AzureBlobStore struct {
+	Account   string
+	Container string
+	// Endpoint is the blob service endpoint,
+	// https://<Account>.blob.core.windows.net when empty
+	Endpoint string
+	// Client sends the requests, http.DefaultClient when nil
+	Client Doer
+
+	key []byte
+}
+
+// NewAzureBlobStore returns a store keeping packages in container of the
+// storage account, whose base64 encoded access key is accountKey
+func NewAzureBlobStore(account, accountKey, container string) (*AzureBlobStore, error) {
+	key, err := base64.StdEncoding.DecodeString(accountKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid storage account key")
+	}
+	return &AzureBlobStore{Account: account, Container: container, key: key}, nil
+}
+
+func (s *AzureBlobStore) blobURL(name string) string {
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = "https://" + s.Account + ".blob.core.windows.net"
+	}
+	return strings.TrimRight(endpoint, "/") + "/" + s.Container + "/" + (&url.URL{Path: name}).EscapedPath()
+}
+
+// Upload stores r as the block blob name, in a single request
+func (s *AzureBlobStore) Upload(ctx context.Context, name string, r io.Reader, size int64) error {
+	req, err := http.NewRequest("PUT", s.blobURL(name), ioutil.NopCloser(r))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureStorageVersion)
+	req.Header.Set("Authorization", "SharedKey "+s.Account+":"+s.sign(s.sharedKeyString(req, name)))
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("blob storage answered %s: %s", res.Status, body)
+	}
+	return nil
+}
+
+// DownloadURL returns the URL of the blob name with a SAS granting
+// read access until expiry elapsed
+func (s *AzureBlobStore) DownloadURL(ctx context.Context, name string, expiry time.Duration) (string, error) {
+	// starting earlier tolerates clocks running ahead of the storage service
+	start := time.Now().UTC().Add(-5 * time.Minute).Format(time.RFC3339)
+	end := time.Now().UTC().Add(expiry).Format(time.RFC3339)
+	stringToSign := strings.Join([]string{
+		"r", start, end,
+		"/blob/" + s.Account + "/" + s.Container + "/" + name,
+		"", "", "https", azureStorageVersion,
+		"", "", "", "", "",
+	}, "\n")
+
+	query := url.Values{
+		"sv":  {azureStorageVersion},
+		"sr":  {"b"},
+		"sp":  {"r"},
+		"st":  {start},
+		"se":  {end},
+		"spr": {"https"},
+		"sig": {s.sign(stringToSign)},
+	}
+	return s.blobURL(name) + "?" + query.Encode(), nil
+}
+
+// sharedKeyString returns the string to sign of a Put Blob request
+func (s *AzureBlobStore) sharedKeyString(req *http.Request, name string) string {
+	length := ""
+	if req.ContentLength > 0 {
+		length = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	var headers []string
+	for header := range req.Header {
+		if lower := strings.ToLower(header); strings.HasPrefix(lower, "x-ms-") {
+			headers = append(headers, lower+":"+strings.TrimSpace(req.Header.Get(header)))
+		}
+	}
+	sort.Strings(headers)
+
+	return strings.Join([]string{
+		req.Method,
+		"", "", length, "",
+		req.Header.Get("Content-Type"),
+		"", "", "", "", "", "",
+		strings.Join(headers, "\n"),
+		"/" + s.Account + "/" + s.Container + "/" + (&url.URL{Path: name}).EscapedPath(),
+	}, "\n")
+}
+
+func (s *AzureBlobStore) sign(stringToSign string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,83 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProvisionFromPackageStore(t *testing.T) {
+	var blob []byte
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" || r.URL.Path != "/packages/TestApplicationType.1.0.0.sfpkg" || r.Header.Get("x-ms-blob-type") != "BlockBlob" {
+			http.NotFound(w, r)
+			return
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "SharedKey account:") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		blob, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer storage.Close()
+
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/ApplicationTypes/$/Provision" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Exception thrown %v", err)
+		}
+	}))
+	defer server.Close()
+
+	store, err := NewAzureBlobStore("account", base64.StdEncoding.EncodeToString([]byte("key")), "packages")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	store.Endpoint = storage.URL
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	err = sfClient.ProvisionFromPackageStore(context.Background(), store, strings.NewReader("sfpkg"), 5, "TestApplicationType", "1.0.0", &ExternalProvisionOptions{
+		URLExpiry: 2 * time.Hour,
+		Async:     true,
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if string(blob) != "sfpkg" {
+		t.Errorf("Got %q, want %q", blob, "sfpkg")
+	}
+	if body["Kind"] != "ExternalStore" || body["Async"] != true ||
+		body["ApplicationTypeName"] != "TestApplicationType" || body["ApplicationTypeVersion"] != "1.0.0" {
+		t.Errorf("Got %+v, want an asynchronous external store provision of TestApplicationType 1.0.0", body)
+	}
+
+	downloadURL, err := url.Parse(body["ApplicationPackageDownloadUri"].(string))
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	query := downloadURL.Query()
+	if downloadURL.Path != "/packages/TestApplicationType.1.0.0.sfpkg" || query.Get("sp") != "r" || query.Get("sr") != "b" || query.Get("sig") == "" {
+		t.Errorf("Got %s, want a read-only blob SAS URL", downloadURL)
+	}
+	expiry, err := time.Parse(time.RFC3339, query.Get("se"))
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if remaining := time.Until(expiry); remaining < time.Hour || remaining > 2*time.Hour {
+		t.Errorf("Got the SAS expiring in %s, want about 2h", remaining)
+	}
+}
@@ -0,0 +1,127 @@
+package servicefabric
+
+import (
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultEndpointCooldown is how long an unreachable endpoint
+// is tried last, after the endpoints known to be reachable
+const DefaultEndpointCooldown = 30 * time.Second
+
+// EndpointHealth reports the reachability of a management endpoint
+type EndpointHealth struct {
+	Endpoint string
+	// Healthy is false while the endpoint cools down after failing
+	Healthy bool
+	// Failures counts the consecutive requests the endpoint failed
+	Failures int
+	// LastError is the error of the last failed request
+	LastError error
+}
+
+// endpointPool spreads requests over several gateways of a cluster in
+// round robin order, moving unreachable gateways to the back until
+// they cooled down
+type endpointPool struct {
+	endpoints []*url.URL
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	next      int
+	failures  []int
+	lastError []error
+	downUntil []time.Time
+}
+
+func newEndpointPool(endpoints []*url.URL, cooldown time.Duration) *endpointPool {
+	if cooldown <= 0 {
+		cooldown = DefaultEndpointCooldown
+	}
+	return &endpointPool{
+		endpoints: endpoints,
+		cooldown:  cooldown,
+		failures:  make([]int, len(endpoints)),
+		lastError: make([]error, len(endpoints)),
+		downUntil: make([]time.Time, len(endpoints)),
+	}
+}
+
+// order returns the indexes of the endpoints to try a request on, the
+// healthy ones in round robin order followed by the cooling down ones
+func (p *endpointPool) order() []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	healthy := make([]int, 0, len(p.endpoints))
+	var down []int
+	for i := range p.endpoints {
+		n := (p.next + i) % len(p.endpoints)
+		if now.Before(p.downUntil[n]) {
+			down = append(down, n)
+		} else {
+			healthy = append(healthy, n)
+		}
+	}
+	p.next = (p.next + 1) % len(p.endpoints)
+	return append(healthy, down...)
+}
+
+// failed marks endpoint i as unreachable for the cooldown
+func (p *endpointPool) failed(i int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failures[i]++
+	p.lastError[i] = err
+	p.downUntil[i] = time.Now().Add(p.cooldown)
+}
+
+// succeeded marks endpoint i as reachable
+func (p *endpointPool) succeeded(i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failures[i] = 0
+	p.downUntil[i] = time.Time{}
+}
+
+func (p *endpointPool) health() []EndpointHealth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	health := make([]EndpointHealth, len(p.endpoints))
+	for i, endpoint := range p.endpoints {
+		health[i] = EndpointHealth{
+			Endpoint:  endpoint.String(),
+			Healthy:   !now.Before(p.downUntil[i]),
+			Failures:  p.failures[i],
+			LastError: p.lastError[i],
+		}
+	}
+	return health
+}
+
+// EndpointHealth reports the reachability of every management endpoint,
+// see WithFailoverEndpoints
+func (c ServiceFabricClient) EndpointHealth() []EndpointHealth {
+	if c.endpoints == nil {
+		return []EndpointHealth{{Endpoint: c.endpoint.String(), Healthy: true}}
+	}
+	return c.endpoints.health()
+}
+
+// canFailOver reports whether a request failing with err may be sent to
+// another endpoint. Mutations only fail over when no connection could be
+// made, as the cluster may have applied them before the failure.
+func canFailOver(method string, err error) bool {
+	if method == "GET" {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
@@ -0,0 +1,139 @@
+package servicefabric
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFailoverEndpoints(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(handleServiceDescription))
+	down.Close()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method == "POST" {
+			return
+		}
+		handleServiceDescription(w, r)
+	}))
+	defer server.Close()
+
+	sfClient, err := NewClient(http.DefaultClient, down.URL, "1.0", nil, WithFailoverEndpoints(server.URL))
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := sfClient.GetServiceDescription(context.Background(), "TestApplication~TestService"); err != nil {
+			t.Fatalf("Exception thrown %v", err)
+		}
+	}
+	// mutations fail over when no connection could be made
+	if err := sfClient.DeleteService(context.Background(), "TestApplication~TestService"); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("Got %d requests, want 3", requests)
+	}
+
+	health := sfClient.EndpointHealth()
+	if len(health) != 2 || health[0].Healthy || health[0].Failures != 1 || health[0].LastError == nil || !health[1].Healthy {
+		t.Errorf("Got %+v, want the first endpoint unhealthy after failing once", health)
+	}
+}
+
+func TestFailoverEndpointsRoundRobin(t *testing.T) {
+	var served []string
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			served = append(served, name)
+			handleServiceDescription(w, r)
+		}
+	}
+	first := httptest.NewServer(handler("first"))
+	defer first.Close()
+	second := httptest.NewServer(handler("second"))
+	defer second.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, first.URL, "1.0", nil, WithFailoverEndpoints(second.URL))
+
+	for i := 0; i < 4; i++ {
+		if _, err := sfClient.GetServiceDescription(context.Background(), "TestApplication~TestService"); err != nil {
+			t.Fatalf("Exception thrown %v", err)
+		}
+	}
+
+	expected := []string{"first", "second", "first", "second"}
+	for i := range expected {
+		if len(served) != len(expected) || served[i] != expected[i] {
+			t.Fatalf("Got %+v, want %+v", served, expected)
+		}
+	}
+}
+
+func TestSessionPinsEndpoint(t *testing.T) {
+	var served, ids []string
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			served = append(served, name)
+			ids = append(ids, r.Header.Get("X-Session"))
+			handleServiceDescription(w, r)
+		}
+	}
+	first := httptest.NewServer(handler("first"))
+	defer first.Close()
+	second := httptest.NewServer(handler("second"))
+	defer second.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, first.URL, "1.0", nil, WithFailoverEndpoints(second.URL), WithSessionHeader("X-Session"))
+
+	// move the round robin past the first endpoint
+	if _, err := sfClient.GetServiceDescription(context.Background(), "TestApplication~TestService"); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	ctx := WithSession(context.Background())
+	for i := 0; i < 3; i++ {
+		if _, err := sfClient.GetServiceDescription(ctx, "TestApplication~TestService"); err != nil {
+			t.Fatalf("Exception thrown %v", err)
+		}
+	}
+
+	expected := []string{"first", "second", "second", "second"}
+	for i := range expected {
+		if len(served) != len(expected) || served[i] != expected[i] {
+			t.Fatalf("Got %+v, want %+v", served, expected)
+		}
+	}
+	if ids[0] != "" || ids[1] == "" || ids[1] != SessionID(ctx) || ids[3] != ids[1] {
+		t.Errorf("Got session ids %+v, want the session id on the session requests", ids)
+	}
+}
+
+func TestFailoverEndpointsAllDown(t *testing.T) {
+	first := httptest.NewServer(http.HandlerFunc(handleServiceDescription))
+	first.Close()
+	second := httptest.NewServer(http.HandlerFunc(handleServiceDescription))
+	second.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, first.URL, "1.0", nil, WithFailoverEndpoints(second.URL))
+
+	if _, err := sfClient.GetServiceDescription(context.Background(), "TestApplication~TestService"); err == nil {
+		t.Error("Error should have been returned")
+	}
+	for _, health := range sfClient.EndpointHealth() {
+		if health.Healthy {
+			t.Errorf("Got %+v, want unhealthy", health)
+		}
+	}
+}
+
+func TestFailoverEndpointsInvalid(t *testing.T) {
+	_, err := NewClient(http.DefaultClient, "https://cluster.example.com:19080", "1.0", nil,
+		WithFailoverEndpoints("cluster2.example.com:19080"))
+	if err == nil {
+		t.Error("Error should have been returned")
+	}
+}
@@ -0,0 +1,62 @@
+package servicefabric
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Feature names a cluster capability that depends on the REST API
+// version the client speaks and on the Service Fabric runtime version
+type Feature string
+
+// Features known to Supports
+const (
+	FeatureEventsStore        Feature = "EventsStore"
+	FeatureBackupRestore      Feature = "BackupRestore"
+	FeatureComposeDeployments Feature = "ComposeDeployments"
+)
+
+// featureVersion is the minimum API and runtime version of a feature
+type featureVersion struct {
+	apiVersion     string
+	clusterVersion string
+}
+
+var featureVersions = map[Feature]featureVersion{
+	FeatureEventsStore:        {apiVersion: "6.2", clusterVersion: "6.2"},
+	FeatureBackupRestore:      {apiVersion: "6.4", clusterVersion: "6.4"},
+	FeatureComposeDeployments: {apiVersion: "6.0", clusterVersion: "6.1"},
+}
+
+// Supports reports whether feature can be used with this client and cluster.
+// The client api-version is checked first, the cluster code version is then
+// read from the cluster upgrade progress, which reports the target version
+// while a cluster upgrade is in flight.
+func (c ServiceFabricClient) Supports(ctx context.Context, feature Feature) (bool, error) {
+	required, ok := featureVersions[feature]
+	if !ok {
+		return false, fmt.Errorf("unknown feature %q", feature)
+	}
+
+	if compareVersions(trimPreview(c.apiVersion), required.apiVersion) < 0 {
+		return false, nil
+	}
+
+	progress, err := c.GetClusterUpgradeProgress(ctx)
+	if err != nil {
+		return false, err
+	}
+	if progress.CodeVersion == "" {
+		return false, fmt.Errorf("cluster did not report its code version")
+	}
+	return compareVersions(progress.CodeVersion, required.clusterVersion) >= 0, nil
+}
+
+// trimPreview strips the suffix of preview API versions such as 6.0-preview
+func trimPreview(apiVersion string) string {
+	if i := strings.Index(apiVersion, "-"); i >= 0 {
+		return apiVersion[:i]
+	}
+	return apiVersion
+}
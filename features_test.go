@@ -0,0 +1,53 @@
+package servicefabric
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSupports(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/$/GetUpgradeProgress" {
+			http.NotFound(w, r)
+			return
+		}
+		writeFixture(w, "cluster_upgrade_progress.json")
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		apiVersion string
+		feature    Feature
+		expected   bool
+	}{
+		{apiVersion: "6.4", feature: FeatureBackupRestore, expected: true},
+		{apiVersion: "6.2", feature: FeatureBackupRestore, expected: false},
+		{apiVersion: "6.2", feature: FeatureEventsStore, expected: true},
+		{apiVersion: "6.0-preview", feature: FeatureComposeDeployments, expected: true},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.apiVersion+"/"+string(test.feature), func(t *testing.T) {
+			sfClient, _ := NewClient(http.DefaultClient, server.URL, test.apiVersion, nil)
+
+			actual, err := sfClient.Supports(context.Background(), test.feature)
+			if err != nil {
+				t.Fatalf("Exception thrown %v", err)
+			}
+			if actual != test.expected {
+				t.Errorf("Got %v, want %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestSupportsUnknownFeature(t *testing.T) {
+	sfClient, _ := NewServiceFabricClient(nil, "https://cluster.example.com:19080", "")
+
+	if _, err := sfClient.Supports(context.Background(), Feature("Unknown")); err == nil {
+		t.Error("Error should have been returned")
+	}
+}
@@ -78,7 +78,7 @@ func handleServices(w http.ResponseWriter, r *http.Request) {
 }
 
 func handlePartitions(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path != "/Applications/TestApplication/$/GetServices/TestApplication/TestService/$/GetPartitions/" {
+	if r.URL.Path != "/Services/TestApplication/TestService/$/GetPartitions" {
 		http.NotFound(w, r)
 		return
 	}
@@ -105,7 +105,7 @@ func handlePartitions(w http.ResponseWriter, r *http.Request) {
 }
 
 func handleReplicas(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path != "/Applications/TestApplication/$/GetServices/TestApplication/TestService/$/GetPartitions/bce46a8c-b62d-4996-89dc-7ffc00a96902/$/GetReplicas" {
+	if r.URL.Path != "/Partitions/bce46a8c-b62d-4996-89dc-7ffc00a96902/$/GetReplicas" {
 		http.NotFound(w, r)
 		return
 	}
@@ -132,7 +132,7 @@ func handleReplicas(w http.ResponseWriter, r *http.Request) {
 }
 
 func handleInstances(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path != "/Applications/TestApplication/$/GetServices/TestApplication/TestService/$/GetPartitions/824091ba-fa32-4e9c-9e9c-71738e018312/$/GetReplicas" {
+	if r.URL.Path != "/Partitions/824091ba-fa32-4e9c-9e9c-71738e018312/$/GetReplicas" {
 		http.NotFound(w, r)
 		return
 	}
@@ -211,3 +211,217 @@ func handleExtensionB(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 	}
 }
+
+func handleArmServices(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/Applications/ArmApplication/$/GetServices" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.URL.RawQuery == "api-version=1.0" {
+		body, err := ioutil.ReadFile("fixtures/services_arm.json")
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, err = w.Write([]byte(err.Error()))
+			if err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(body)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		http.NotFound(w, r)
+	}
+}
+
+// writeFixture responds with the named file from the fixtures directory
+func writeFixture(w http.ResponseWriter, name string) {
+	body, err := ioutil.ReadFile("fixtures/" + name)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, err = w.Write([]byte(err.Error()))
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(body)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func handleApplicationTypes(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/ApplicationTypes/" {
+		handleApplications(w, r)
+		return
+	}
+
+	if r.URL.RawQuery == "api-version=1.0" {
+		writeFixture(w, "applicationtypes.json")
+	} else {
+		http.NotFound(w, r)
+	}
+}
+
+func handleApplicationsByType(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/Applications/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	// the second page holds an application of another type, as returned
+	// by API versions which do not support the type name filter
+	switch r.URL.RawQuery {
+	case "api-version=1.0&ApplicationTypeName=TestApplicationType":
+		writeFixture(w, "applications.json")
+	case "api-version=1.0&ApplicationTypeName=TestApplicationType&continue=00001234":
+		writeFixture(w, "applications_continue.json")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func handleClusterUpgradeProgress(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/$/GetUpgradeProgress" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.URL.RawQuery == "api-version=1.0" {
+		writeFixture(w, "cluster_upgrade_progress.json")
+	} else {
+		http.NotFound(w, r)
+	}
+}
+
+func handleProperties(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/Names/TestApplication/TestService/$/GetProperties":
+		if r.URL.RawQuery == "api-version=1.0&IncludeValues=true" {
+			writeFixture(w, "properties.json")
+		} else if r.URL.RawQuery == "api-version=1.0&IncludeValues=false" {
+			writeFixture(w, "properties_novalues.json")
+		} else {
+			http.NotFound(w, r)
+		}
+	case "/Names/TestApplication/TestService/$/GetProperty":
+		if r.URL.RawQuery == "api-version=1.0&PropertyName=blob" {
+			writeFixture(w, "property_binary.json")
+		} else {
+			http.NotFound(w, r)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func handleServiceGroups(w http.ResponseWriter, r *http.Request) {
+	if r.URL.RawQuery != "api-version=1.0" {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/Applications/TestApplication/$/GetServiceGroups/TestApplication~TestGroup":
+		writeFixture(w, "service_group_members.json")
+	case "/Services/TestApplication~TestGroup/$/GetServiceGroupDescription":
+		writeFixture(w, "service_group_description.json")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func handleDeployed(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/Nodes/_Node_0/$/GetApplications/TestApplication/$/GetServicePackages":
+		if r.URL.RawQuery == "api-version=1.0" {
+			writeFixture(w, "deployed_service_packages.json")
+		} else {
+			http.NotFound(w, r)
+		}
+	case "/Nodes/_Node_0/$/GetApplications":
+		writeFixture(w, "deployed_applications.json")
+	case "/Nodes/_Node_0/$/GetLoadInformation":
+		writeFixture(w, "node_load.json")
+	case "/Nodes/_Node_0/$/GetApplications/TestApplication/$/GetCodePackages":
+		if r.URL.RawQuery == "api-version=1.0&ServiceManifestName=TestServicePkg" || r.URL.RawQuery == "api-version=1.0" {
+			writeFixture(w, "deployed_code_packages.json")
+		} else {
+			http.NotFound(w, r)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func handleNodes(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/Nodes/" {
+		handleDeployed(w, r)
+		return
+	}
+
+	if r.URL.RawQuery == "api-version=1.0" {
+		writeFixture(w, "nodes.json")
+	} else {
+		http.NotFound(w, r)
+	}
+}
+
+func handleServiceTypes(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/ApplicationTypes/TestApplicationType/$/GetServiceTypes" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.URL.RawQuery == "api-version=1.0&ApplicationTypeVersion=1.0.0" {
+		writeFixture(w, "service_types.json")
+	} else {
+		http.NotFound(w, r)
+	}
+}
+
+func handleManifests(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/ApplicationTypes/TestApplicationType/$/GetApplicationManifest":
+		switch r.URL.RawQuery {
+		case "api-version=1.0&ApplicationTypeVersion=1.0.0":
+			writeFixture(w, "application_manifest_1.json")
+		case "api-version=1.0&ApplicationTypeVersion=2.0.0":
+			writeFixture(w, "application_manifest_2.json")
+		default:
+			http.NotFound(w, r)
+		}
+	case "/ApplicationTypes/TestApplicationType/$/GetServiceManifest":
+		switch r.URL.RawQuery {
+		case "api-version=1.0&ApplicationTypeVersion=1.0.0&ServiceManifestName=TestServicePkg":
+			writeFixture(w, "service_manifest_1.json")
+		case "api-version=1.0&ApplicationTypeVersion=2.0.0&ServiceManifestName=TestServicePkg":
+			writeFixture(w, "service_manifest_2.json")
+		default:
+			http.NotFound(w, r)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func handleServiceDescription(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/Services/TestApplication~TestService/$/GetDescription" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.URL.RawQuery == "api-version=1.0" {
+		writeFixture(w, "service_description.json")
+	} else {
+		http.NotFound(w, r)
+	}
+}
@@ -0,0 +1,479 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Health states reported for the entities of the cluster
+const (
+	HealthStateInvalid = "Invalid"
+	HealthStateOk      = "Ok"
+	HealthStateWarning = "Warning"
+	HealthStateError   = "Error"
+	HealthStateUnknown = "Unknown"
+)
+
+// healthStateFilters maps health states to the bits
+// of the health state filters of health queries
+var healthStateFilters = map[string]int{
+	HealthStateOk:      2,
+	HealthStateWarning: 4,
+	HealthStateError:   8,
+}
+
+// HealthStateFilter returns the filter selecting the children and
+// events of a health query in the given states, or every child
+// and event when no state is given
+func HealthStateFilter(states ...string) string {
+	return strconv.Itoa(HealthStateFilterMask(states...))
+}
+
+// HealthStateFilterMask returns the bitmask HealthStateFilter formats,
+// as taken by the filters of health chunk queries
+func HealthStateFilterMask(states ...string) int {
+	if len(states) == 0 {
+		// All
+		return 65535
+	}
+	filter := 0
+	for _, state := range states {
+		filter |= healthStateFilters[state]
+	}
+	if filter == 0 {
+		// None
+		return 1
+	}
+	return filter
+}
+
+// HealthEvent is a health report of an entity, as stored by the health store
+type HealthEvent struct {
+	SourceID                 string `json:"SourceId"`
+	Property                 string `json:"Property"`
+	HealthState              string `json:"HealthState"`
+	Description              string `json:"Description"`
+	TimeToLiveInMilliSeconds string `json:"TimeToLiveInMilliSeconds"`
+	SequenceNumber           string `json:"SequenceNumber"`
+	RemoveWhenExpired        bool   `json:"RemoveWhenExpired"`
+	SourceUtcTimestamp       string `json:"SourceUtcTimestamp"`
+	LastModifiedUtcTimestamp string `json:"LastModifiedUtcTimestamp"`
+	IsExpired                bool   `json:"IsExpired"`
+}
+
+// HealthQueryOptions filter the health events and
+// the children health states of a health query
+type HealthQueryOptions struct {
+	// EventStates selects the health events returned, every event when empty
+	EventStates []string
+	// ChildStates selects the children whose health state
+	// is returned, every child when empty
+	ChildStates []string
+}
+
+// eventsFilter returns the filter of the health events o selects
+func (o *HealthQueryOptions) eventsFilter() string {
+	if o == nil {
+		return HealthStateFilter()
+	}
+	return HealthStateFilter(o.EventStates...)
+}
+
+// childrenFilter returns the filter of the children health states o selects
+func (o *HealthQueryOptions) childrenFilter() string {
+	if o == nil {
+		return HealthStateFilter()
+	}
+	return HealthStateFilter(o.ChildStates...)
+}
+
+// getHealth queries the health at basePath, evaluated with the policies
+// sent as the body of a POST request unless policies, a pointer, is nil
+func (c ServiceFabricClient) getHealth(ctx context.Context, basePath string, policies interface{}, paramsFuncs ...queryParamsFunc) ([]byte, int, error) {
+	if policies == nil || reflect.ValueOf(policies).IsNil() {
+		return c.getHTTP(ctx, basePath, paramsFuncs...)
+	}
+
+	body, err := json.Marshal(policies)
+	if err != nil {
+		return nil, 0, err
+	}
+	return c.queryHTTP(ctx, basePath, body, paramsFuncs...)
+}
+
+// TimeToLive decodes TimeToLiveInMilliSeconds, an ISO 8601 duration or a
+// number of milliseconds, capped to the maximum of time.Duration for
+// reports that never expire
+func (e HealthEvent) TimeToLive() (time.Duration, error) {
+	return ParseUpgradeDuration(e.TimeToLiveInMilliSeconds)
+}
+
+// NodeHealthState is the aggregated health state of a node
+type NodeHealthState struct {
+	Name                  string `json:"Name"`
+	ID                    NodeID `json:"Id"`
+	AggregatedHealthState string `json:"AggregatedHealthState"`
+}
+
+// ApplicationHealthState is the aggregated health state of an application
+type ApplicationHealthState struct {
+	Name                  string `json:"Name"`
+	AggregatedHealthState string `json:"AggregatedHealthState"`
+}
+
+// ServiceHealthState is the aggregated health state of a service
+type ServiceHealthState struct {
+	ServiceName           string `json:"ServiceName"`
+	AggregatedHealthState string `json:"AggregatedHealthState"`
+}
+
+// DeployedApplicationHealthState is the aggregated
+// health state of an application deployed on a node
+type DeployedApplicationHealthState struct {
+	ApplicationName       string `json:"ApplicationName"`
+	NodeName              string `json:"NodeName"`
+	AggregatedHealthState string `json:"AggregatedHealthState"`
+}
+
+// ClusterHealth is the health of the cluster: its aggregated health
+// state, the health states of its nodes and applications and its health
+// events, with the evaluations explaining why it is unhealthy
+type ClusterHealth struct {
+	AggregatedHealthState   string                    `json:"AggregatedHealthState"`
+	HealthEvents            []HealthEvent             `json:"HealthEvents"`
+	UnhealthyEvaluations    []HealthEvaluationWrapper `json:"UnhealthyEvaluations"`
+	NodeHealthStates        []NodeHealthState         `json:"NodeHealthStates"`
+	ApplicationHealthStates []ApplicationHealthState  `json:"ApplicationHealthStates"`
+}
+
+// GetClusterHealthDetailed returns the health of the cluster, with
+// the health states of every node and application and every event.
+// The health is evaluated with policies, or with the policies of the
+// cluster manifest and of the application manifests when nil.
+func (c ServiceFabricClient) GetClusterHealthDetailed(ctx context.Context, policies *ClusterHealthPolicies) (health *ClusterHealth, err error) {
+	ctx, call := c.startCall(ctx, "GetClusterHealthDetailed")
+	defer func() { call.finish(err) }()
+
+	res, _, err := c.getHealth(ctx, "$/GetClusterHealth", policies,
+		withParam("NodesHealthStateFilter", HealthStateFilter()),
+		withParam("ApplicationsHealthStateFilter", HealthStateFilter()),
+		withParam("EventsHealthStateFilter", HealthStateFilter()))
+	if err != nil {
+		return nil, err
+	}
+
+	health = &ClusterHealth{}
+	err = json.Unmarshal(res, health)
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return health, nil
+}
+
+// ApplicationHealth is the health of an application: its aggregated health
+// state, the health states of its services and of its deployments on nodes
+// and its health events, with the evaluations explaining why it is unhealthy
+type ApplicationHealth struct {
+	Name                            string                           `json:"Name"`
+	AggregatedHealthState           string                           `json:"AggregatedHealthState"`
+	HealthEvents                    []HealthEvent                    `json:"HealthEvents"`
+	UnhealthyEvaluations            []HealthEvaluationWrapper        `json:"UnhealthyEvaluations"`
+	ServiceHealthStates             []ServiceHealthState             `json:"ServiceHealthStates"`
+	DeployedApplicationHealthStates []DeployedApplicationHealthState `json:"DeployedApplicationHealthStates"`
+}
+
+// GetApplicationHealth returns the health of the application appID,
+// with the events and the services and deployed applications opts
+// selects, every one when opts is nil. The health is evaluated with
+// policy, or with the policy of the application manifest when nil.
+func (c ServiceFabricClient) GetApplicationHealth(ctx context.Context, appID string, opts *HealthQueryOptions, policy *ApplicationHealthPolicy) (health *ApplicationHealth, err error) {
+	ctx, call := c.startCall(ctx, "GetApplicationHealth")
+	defer func() { call.finish(err) }()
+
+	res, _, err := c.getHealth(ctx, "Applications/"+appID+"/$/GetHealth", policy,
+		withParam("EventsHealthStateFilter", opts.eventsFilter()),
+		withParam("ServicesHealthStateFilter", opts.childrenFilter()),
+		withParam("DeployedApplicationsHealthStateFilter", opts.childrenFilter()))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting health of application %s", appID)
+	}
+
+	health = &ApplicationHealth{}
+	err = json.Unmarshal(res, health)
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return health, nil
+}
+
+// PartitionHealthState is the aggregated health state of a partition
+type PartitionHealthState struct {
+	PartitionID           string `json:"PartitionId"`
+	AggregatedHealthState string `json:"AggregatedHealthState"`
+}
+
+// ReplicaHealthState is the aggregated health state of a replica of a
+// stateful service, ReplicaID set, or of an instance of a stateless
+// service, InstanceID set
+type ReplicaHealthState struct {
+	ServiceKind           string `json:"ServiceKind"`
+	PartitionID           string `json:"PartitionId"`
+	ReplicaID             string `json:"ReplicaId,omitempty"`
+	InstanceID            string `json:"InstanceId,omitempty"`
+	AggregatedHealthState string `json:"AggregatedHealthState"`
+}
+
+// ServiceHealth is the health of a service: its aggregated health state,
+// the health states of its partitions and its health events, with the
+// evaluations explaining why it is unhealthy
+type ServiceHealth struct {
+	Name                  string                    `json:"Name"`
+	AggregatedHealthState string                    `json:"AggregatedHealthState"`
+	HealthEvents          []HealthEvent             `json:"HealthEvents"`
+	UnhealthyEvaluations  []HealthEvaluationWrapper `json:"UnhealthyEvaluations"`
+	PartitionHealthStates []PartitionHealthState    `json:"PartitionHealthStates"`
+}
+
+// PartitionHealth is the health of a partition: its aggregated health
+// state, the health states of its replicas or instances and its health
+// events, with the evaluations explaining why it is unhealthy
+type PartitionHealth struct {
+	PartitionID           string                    `json:"PartitionId"`
+	AggregatedHealthState string                    `json:"AggregatedHealthState"`
+	HealthEvents          []HealthEvent             `json:"HealthEvents"`
+	UnhealthyEvaluations  []HealthEvaluationWrapper `json:"UnhealthyEvaluations"`
+	ReplicaHealthStates   []ReplicaHealthState      `json:"ReplicaHealthStates"`
+}
+
+// GetServiceHealth returns the health of the service serviceID, with
+// the events and the partitions opts selects, every one when opts is nil
+func (c ServiceFabricClient) GetServiceHealth(ctx context.Context, serviceID string, opts *HealthQueryOptions) (health *ServiceHealth, err error) {
+	ctx, call := c.startCall(ctx, "GetServiceHealth")
+	defer func() { call.finish(err) }()
+
+	res, _, err := c.getHTTP(ctx, "Services/"+serviceID+"/$/GetHealth",
+		withParam("EventsHealthStateFilter", opts.eventsFilter()),
+		withParam("PartitionsHealthStateFilter", opts.childrenFilter()))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting health of service %s", serviceID)
+	}
+
+	health = &ServiceHealth{}
+	err = json.Unmarshal(res, health)
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return health, nil
+}
+
+// GetPartitionHealth returns the health of the partition partitionID, with
+// the events and the replicas opts selects, every one when opts is nil
+func (c ServiceFabricClient) GetPartitionHealth(ctx context.Context, partitionID string, opts *HealthQueryOptions) (health *PartitionHealth, err error) {
+	ctx, call := c.startCall(ctx, "GetPartitionHealth")
+	defer func() { call.finish(err) }()
+
+	res, _, err := c.getHTTP(ctx, "Partitions/"+partitionID+"/$/GetHealth",
+		withParam("EventsHealthStateFilter", opts.eventsFilter()),
+		withParam("ReplicasHealthStateFilter", opts.childrenFilter()))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting health of partition %s", partitionID)
+	}
+
+	health = &PartitionHealth{}
+	err = json.Unmarshal(res, health)
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return health, nil
+}
+
+// ReplicaHealth is the health of a replica of a stateful service, ReplicaID
+// set, or of an instance of a stateless service, InstanceID set
+type ReplicaHealth struct {
+	ServiceKind           string                    `json:"ServiceKind"`
+	PartitionID           string                    `json:"PartitionId"`
+	ReplicaID             string                    `json:"ReplicaId,omitempty"`
+	InstanceID            string                    `json:"InstanceId,omitempty"`
+	AggregatedHealthState string                    `json:"AggregatedHealthState"`
+	HealthEvents          []HealthEvent             `json:"HealthEvents"`
+	UnhealthyEvaluations  []HealthEvaluationWrapper `json:"UnhealthyEvaluations"`
+}
+
+// NodeHealth is the health of a node
+type NodeHealth struct {
+	Name                  string                    `json:"Name"`
+	AggregatedHealthState string                    `json:"AggregatedHealthState"`
+	HealthEvents          []HealthEvent             `json:"HealthEvents"`
+	UnhealthyEvaluations  []HealthEvaluationWrapper `json:"UnhealthyEvaluations"`
+}
+
+// GetReplicaHealth returns the health of the replica or instance replicaID
+// of the partition partitionID, with the events opts selects, every one
+// when opts is nil. Replicas have no children, ChildStates is unused.
+func (c ServiceFabricClient) GetReplicaHealth(ctx context.Context, partitionID, replicaID string, opts *HealthQueryOptions) (health *ReplicaHealth, err error) {
+	ctx, call := c.startCall(ctx, "GetReplicaHealth")
+	defer func() { call.finish(err) }()
+
+	res, _, err := c.getHTTP(ctx, "Partitions/"+partitionID+"/$/GetReplicas/"+replicaID+"/$/GetHealth",
+		withParam("EventsHealthStateFilter", opts.eventsFilter()))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting health of replica %s of partition %s", replicaID, partitionID)
+	}
+
+	health = &ReplicaHealth{}
+	err = json.Unmarshal(res, health)
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return health, nil
+}
+
+// GetNodeHealth returns the health of the node nodeName, with the events
+// opts selects, every one when opts is nil. Nodes have no children in the
+// health hierarchy, ChildStates is unused.
+func (c ServiceFabricClient) GetNodeHealth(ctx context.Context, nodeName string, opts *HealthQueryOptions) (health *NodeHealth, err error) {
+	ctx, call := c.startCall(ctx, "GetNodeHealth")
+	defer func() { call.finish(err) }()
+
+	res, _, err := c.getHTTP(ctx, "Nodes/"+nodeName+"/$/GetHealth",
+		withParam("EventsHealthStateFilter", opts.eventsFilter()))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting health of node %s", nodeName)
+	}
+
+	health = &NodeHealth{}
+	err = json.Unmarshal(res, health)
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return health, nil
+}
+
+// HealthInformation is a health report sent to the health store
+type HealthInformation struct {
+	// SourceID identifies the watchdog reporting, e.g. "Watchdog.Disk"
+	SourceID string
+	// Property identifies the report among those of SourceID on the entity
+	Property    string
+	HealthState string
+	Description string
+	// TimeToLive is how long the report stays valid, forever when zero
+	TimeToLive time.Duration
+	// RemoveWhenExpired removes the report once it expired, rather
+	// than keeping it as an Error report
+	RemoveWhenExpired bool
+	// SequenceNumber orders the reports of SourceID and Property,
+	// the health store assigns one when empty
+	SequenceNumber string
+	// Immediate sends the report to the health store at once rather
+	// than batched with the other reports of the gateway
+	Immediate bool
+}
+
+// validate checks the fields the health store requires
+func (h HealthInformation) validate() error {
+	if h.SourceID == "" || h.Property == "" {
+		return errors.New("health report needs a source id and a property")
+	}
+	switch h.HealthState {
+	case HealthStateOk, HealthStateWarning, HealthStateError:
+	default:
+		return fmt.Errorf("health report state %q must be Ok, Warning or Error", h.HealthState)
+	}
+	if h.TimeToLive < 0 {
+		return fmt.Errorf("health report time to live must not be negative, got %s", h.TimeToLive)
+	}
+	return nil
+}
+
+// ReportClusterHealth sends a health report on the cluster
+func (c ServiceFabricClient) ReportClusterHealth(ctx context.Context, info HealthInformation) (err error) {
+	ctx, call := c.startCall(ctx, "ReportClusterHealth")
+	defer func() { call.finish(err) }()
+
+	return c.reportHealth(ctx, opReportClusterHealth, "$/ReportClusterHealth", info)
+}
+
+// ReportApplicationHealth sends a health report on the application appID
+func (c ServiceFabricClient) ReportApplicationHealth(ctx context.Context, appID string, info HealthInformation) (err error) {
+	ctx, call := c.startCall(ctx, "ReportApplicationHealth")
+	defer func() { call.finish(err) }()
+
+	return c.reportHealth(ctx, opReportApplicationHealth.on(appID), "Applications/"+appID+"/$/ReportHealth", info)
+}
+
+// ReportServiceHealth sends a health report on the service serviceID
+func (c ServiceFabricClient) ReportServiceHealth(ctx context.Context, serviceID string, info HealthInformation) (err error) {
+	ctx, call := c.startCall(ctx, "ReportServiceHealth")
+	defer func() { call.finish(err) }()
+
+	return c.reportHealth(ctx, opReportServiceHealth.on(serviceID), "Services/"+serviceID+"/$/ReportHealth", info)
+}
+
+// ReportNodeHealth sends a health report on the node nodeName
+func (c ServiceFabricClient) ReportNodeHealth(ctx context.Context, nodeName string, info HealthInformation) (err error) {
+	ctx, call := c.startCall(ctx, "ReportNodeHealth")
+	defer func() { call.finish(err) }()
+
+	return c.reportHealth(ctx, opReportNodeHealth.on(nodeName), "Nodes/"+nodeName+"/$/ReportHealth", info)
+}
+
+// ReportReplicaHealth sends a health report on the replica or instance
+// replicaID of the partition partitionID of a service of serviceKind,
+// ServiceKindStateful or ServiceKindStateless
+func (c ServiceFabricClient) ReportReplicaHealth(ctx context.Context, partitionID, replicaID, serviceKind string, info HealthInformation) (err error) {
+	ctx, call := c.startCall(ctx, "ReportReplicaHealth")
+	defer func() { call.finish(err) }()
+
+	if serviceKind != ServiceKindStateful && serviceKind != ServiceKindStateless {
+		return fmt.Errorf("service kind %q must be Stateful or Stateless", serviceKind)
+	}
+	return c.reportHealth(ctx, opReportReplicaHealth.on(partitionID+"/"+replicaID),
+		"Partitions/"+partitionID+"/$/GetReplicas/"+replicaID+"/$/ReportHealth", info, withParam("ServiceKind", serviceKind))
+}
+
+func (c ServiceFabricClient) reportHealth(ctx context.Context, op Operation, path string, info HealthInformation, paramsFuncs ...queryParamsFunc) error {
+	if err := info.validate(); err != nil {
+		return err
+	}
+
+	var ttl string
+	if info.TimeToLive > 0 {
+		ttl = FormatUpgradeDuration(info.TimeToLive)
+	}
+	body, err := json.Marshal(struct {
+		SourceID                 string `json:"SourceId"`
+		Property                 string `json:"Property"`
+		HealthState              string `json:"HealthState"`
+		Description              string `json:"Description,omitempty"`
+		TimeToLiveInMilliSeconds string `json:"TimeToLiveInMilliSeconds,omitempty"`
+		SequenceNumber           string `json:"SequenceNumber,omitempty"`
+		RemoveWhenExpired        bool   `json:"RemoveWhenExpired"`
+	}{
+		SourceID:                 info.SourceID,
+		Property:                 info.Property,
+		HealthState:              info.HealthState,
+		Description:              info.Description,
+		TimeToLiveInMilliSeconds: ttl,
+		SequenceNumber:           info.SequenceNumber,
+		RemoveWhenExpired:        info.RemoveWhenExpired,
+	})
+	if err != nil {
+		return err
+	}
+
+	if info.Immediate {
+		paramsFuncs = append(paramsFuncs, withParam("Immediate", "true"))
+	}
+	_, _, err = c.postHTTP(ctx, op, path, body, paramsFuncs...)
+	if err != nil {
+		return errors.Wrap(err, "failed reporting health")
+	}
+	return nil
+}
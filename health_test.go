@@ -0,0 +1,575 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetClusterHealthDetailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/$/GetClusterHealth" || query.Get("NodesHealthStateFilter") != "65535" ||
+			query.Get("ApplicationsHealthStateFilter") != "65535" || query.Get("EventsHealthStateFilter") != "65535" {
+			http.NotFound(w, r)
+			return
+		}
+		writeFixture(w, "cluster_health.json")
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetClusterHealthDetailed(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := &ClusterHealth{
+		AggregatedHealthState: HealthStateWarning,
+		HealthEvents: []HealthEvent{
+			{
+				SourceID:                 "System.FM",
+				Property:                 "State",
+				HealthState:              HealthStateOk,
+				Description:              "Fabric is up.",
+				TimeToLiveInMilliSeconds: "922337203685477",
+				SequenceNumber:           "13",
+				SourceUtcTimestamp:       "2020-01-01T10:00:00.000Z",
+				LastModifiedUtcTimestamp: "2020-01-01T10:00:00.000Z",
+			},
+		},
+		UnhealthyEvaluations: []HealthEvaluationWrapper{
+			{
+				HealthEvaluation: HealthEvaluation{
+					Kind:                  "Nodes",
+					AggregatedHealthState: HealthStateWarning,
+					Description:           "1% (1/3) nodes are unhealthy.",
+					TotalCount:            3,
+					UnhealthyEvaluations: []HealthEvaluationWrapper{
+						{
+							HealthEvaluation: HealthEvaluation{
+								Kind:                  "Node",
+								AggregatedHealthState: HealthStateWarning,
+								Description:           "Node '_NodeType0_1' is unhealthy.",
+								NodeName:              "_NodeType0_1",
+							},
+						},
+					},
+				},
+			},
+		},
+		NodeHealthStates: []NodeHealthState{
+			{Name: "_NodeType0_0", ID: NodeID{ID: "1"}, AggregatedHealthState: HealthStateOk},
+			{Name: "_NodeType0_1", ID: NodeID{ID: "2"}, AggregatedHealthState: HealthStateWarning},
+		},
+		ApplicationHealthStates: []ApplicationHealthState{
+			{Name: "fabric:/TestApplication", AggregatedHealthState: HealthStateOk},
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+}
+
+func TestHealthStateFilter(t *testing.T) {
+	tests := []struct {
+		states   []string
+		expected string
+	}{
+		{nil, "65535"},
+		{[]string{HealthStateError}, "8"},
+		{[]string{HealthStateWarning, HealthStateError}, "12"},
+		{[]string{HealthStateUnknown}, "1"},
+	}
+	for _, test := range tests {
+		if actual := HealthStateFilter(test.states...); actual != test.expected {
+			t.Errorf("Got %+v, want %+v", actual, test.expected)
+		}
+	}
+}
+
+func TestGetHealthWithPolicies(t *testing.T) {
+	bodies := map[string]map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Exception thrown %v", err)
+		}
+		bodies[r.URL.Path] = body
+		w.Write([]byte(`{"AggregatedHealthState":"Warning"}`))
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	_, err := sfClient.GetClusterHealthDetailed(context.Background(), &ClusterHealthPolicies{
+		ClusterHealthPolicy: &ClusterHealthPolicy{ConsiderWarningAsError: true},
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	_, err = sfClient.GetApplicationHealth(context.Background(), "TestApplication", nil, &ApplicationHealthPolicy{MaxPercentUnhealthyDeployedApplications: 20})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := map[string]map[string]interface{}{
+		"/$/GetClusterHealth": {
+			"ClusterHealthPolicy": map[string]interface{}{
+				"ConsiderWarningAsError":          true,
+				"MaxPercentUnhealthyNodes":        float64(0),
+				"MaxPercentUnhealthyApplications": float64(0),
+			},
+		},
+		"/Applications/TestApplication/$/GetHealth": {
+			"ConsiderWarningAsError":                  false,
+			"MaxPercentUnhealthyDeployedApplications": float64(20),
+		},
+	}
+	if !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Got %+v, want %+v", bodies, expected)
+	}
+}
+
+func TestGetClusterHealthChunk(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/$/GetClusterHealthChunk" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == "GET" {
+			w.Write([]byte(`{"HealthState":"Ok"}`))
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Exception thrown %v", err)
+		}
+		writeFixture(w, "cluster_health_chunk.json")
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetClusterHealthChunk(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if actual.HealthState != HealthStateOk {
+		t.Errorf("Got %+v, want %+v", actual.HealthState, HealthStateOk)
+	}
+
+	actual, err = sfClient.GetClusterHealthChunk(context.Background(), &ClusterHealthChunkQueryDescription{
+		NodeFilters: []NodeHealthStateFilter{
+			{HealthStateFilter: HealthStateFilterMask(HealthStateError)},
+		},
+		ApplicationFilters: []ApplicationHealthStateFilter{
+			{
+				ApplicationNameFilter: "fabric:/TestApplication",
+				ServiceFilters: []ServiceHealthStateFilter{
+					{
+						HealthStateFilter: HealthStateFilterMask(HealthStateError),
+						PartitionFilters:  []PartitionHealthStateFilter{{HealthStateFilter: HealthStateFilterMask(HealthStateError)}},
+					},
+				},
+			},
+		},
+	}, &ClusterHealthPolicies{
+		ClusterHealthPolicy: &ClusterHealthPolicy{MaxPercentUnhealthyNodes: 10},
+		ApplicationHealthPolicyMap: []ApplicationHealthPolicyMapItem{
+			{Key: "fabric:/TestApplication", Value: ApplicationHealthPolicy{ConsiderWarningAsError: true}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expectedBody := map[string]interface{}{
+		"NodeFilters": []interface{}{
+			map[string]interface{}{"HealthStateFilter": float64(8)},
+		},
+		"ApplicationFilters": []interface{}{
+			map[string]interface{}{
+				"ApplicationNameFilter": "fabric:/TestApplication",
+				"ServiceFilters": []interface{}{
+					map[string]interface{}{
+						"HealthStateFilter": float64(8),
+						"PartitionFilters": []interface{}{
+							map[string]interface{}{"HealthStateFilter": float64(8)},
+						},
+					},
+				},
+			},
+		},
+		"ClusterHealthPolicy": map[string]interface{}{
+			"ConsiderWarningAsError":          false,
+			"MaxPercentUnhealthyNodes":        float64(10),
+			"MaxPercentUnhealthyApplications": float64(0),
+		},
+		"ApplicationHealthPolicies": map[string]interface{}{
+			"ApplicationHealthPolicyMap": []interface{}{
+				map[string]interface{}{
+					"Key": "fabric:/TestApplication",
+					"Value": map[string]interface{}{
+						"ConsiderWarningAsError":                  true,
+						"MaxPercentUnhealthyDeployedApplications": float64(0),
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(body, expectedBody) {
+		t.Errorf("Got %+v, want %+v", body, expectedBody)
+	}
+
+	if actual.HealthState != HealthStateError || len(actual.NodeHealthStateChunks.Items) != 1 {
+		t.Fatalf("Got %+v, want the unhealthy node", actual)
+	}
+	services := actual.ApplicationHealthStateChunks.Items[0].ServiceHealthStateChunks.Items
+	expected := []PartitionHealthStateChunk{
+		{
+			PartitionID:              "b3a2d4e1-0c2f-4f5e-8a6b-7c9d0e1f2a3b",
+			HealthState:              HealthStateError,
+			ReplicaHealthStateChunks: ReplicaHealthStateChunks{Items: []ReplicaHealthStateChunk{}},
+		},
+	}
+	if len(services) != 1 || !reflect.DeepEqual(services[0].PartitionHealthStateChunks.Items, expected) {
+		t.Errorf("Got %+v, want %+v", services, expected)
+	}
+}
+
+func TestGetApplicationHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/Applications/TestApplication/$/GetHealth" || query.Get("EventsHealthStateFilter") != "12" ||
+			query.Get("ServicesHealthStateFilter") != "65535" || query.Get("DeployedApplicationsHealthStateFilter") != "65535" {
+			http.NotFound(w, r)
+			return
+		}
+		writeFixture(w, "application_health.json")
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetApplicationHealth(context.Background(), "TestApplication", &HealthQueryOptions{
+		EventStates: []string{HealthStateWarning, HealthStateError},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := &ApplicationHealth{
+		Name:                  "fabric:/TestApplication",
+		AggregatedHealthState: HealthStateError,
+		HealthEvents:          []HealthEvent{},
+		UnhealthyEvaluations: []HealthEvaluationWrapper{
+			{
+				HealthEvaluation: HealthEvaluation{
+					Kind:                  "Services",
+					AggregatedHealthState: HealthStateError,
+					Description:           "100% (1/1) services of service type 'TestServiceType' are unhealthy.",
+					TotalCount:            1,
+				},
+			},
+		},
+		ServiceHealthStates: []ServiceHealthState{
+			{ServiceName: "fabric:/TestApplication/TestService", AggregatedHealthState: HealthStateError},
+		},
+		DeployedApplicationHealthStates: []DeployedApplicationHealthState{
+			{ApplicationName: "fabric:/TestApplication", NodeName: "_NodeType0_0", AggregatedHealthState: HealthStateOk},
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+
+	_, err = sfClient.GetApplicationHealth(context.Background(), "MissingApplication", nil, nil)
+	if err == nil {
+		t.Error("Error should have been returned")
+	}
+}
+
+func TestGetServiceHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/Services/TestApplication~TestService/$/GetHealth" ||
+			query.Get("EventsHealthStateFilter") != "65535" || query.Get("PartitionsHealthStateFilter") != "4" {
+			http.NotFound(w, r)
+			return
+		}
+		writeFixture(w, "service_health.json")
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetServiceHealth(context.Background(), "TestApplication~TestService", &HealthQueryOptions{
+		ChildStates: []string{HealthStateWarning},
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := &ServiceHealth{
+		Name:                  "fabric:/TestApplication/TestService",
+		AggregatedHealthState: HealthStateWarning,
+		HealthEvents:          []HealthEvent{},
+		UnhealthyEvaluations:  []HealthEvaluationWrapper{},
+		PartitionHealthStates: []PartitionHealthState{
+			{PartitionID: "b3a2d4e1-0c2f-4f5e-8a6b-7c9d0e1f2a3b", AggregatedHealthState: HealthStateWarning},
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+}
+
+func TestGetPartitionHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/Partitions/b3a2d4e1-0c2f-4f5e-8a6b-7c9d0e1f2a3b/$/GetHealth" ||
+			query.Get("EventsHealthStateFilter") != "65535" || query.Get("ReplicasHealthStateFilter") != "65535" {
+			http.NotFound(w, r)
+			return
+		}
+		writeFixture(w, "partition_health.json")
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetPartitionHealth(context.Background(), "b3a2d4e1-0c2f-4f5e-8a6b-7c9d0e1f2a3b", nil)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := &PartitionHealth{
+		PartitionID:           "b3a2d4e1-0c2f-4f5e-8a6b-7c9d0e1f2a3b",
+		AggregatedHealthState: HealthStateWarning,
+		HealthEvents: []HealthEvent{
+			{
+				SourceID:                 "System.FM",
+				Property:                 "State",
+				HealthState:              HealthStateWarning,
+				Description:              "Partition is below target replica or instance count.",
+				TimeToLiveInMilliSeconds: "922337203685477",
+				SequenceNumber:           "42",
+				SourceUtcTimestamp:       "2020-01-01T10:00:00.000Z",
+				LastModifiedUtcTimestamp: "2020-01-01T10:00:00.000Z",
+			},
+		},
+		UnhealthyEvaluations: []HealthEvaluationWrapper{},
+		ReplicaHealthStates: []ReplicaHealthState{
+			{
+				ServiceKind:           ServiceKindStateful,
+				PartitionID:           "b3a2d4e1-0c2f-4f5e-8a6b-7c9d0e1f2a3b",
+				ReplicaID:             "132149019876543210",
+				AggregatedHealthState: HealthStateOk,
+			},
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+}
+
+func TestGetNodeHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/Nodes/_NodeType0_1/$/GetHealth" || r.URL.Query().Get("EventsHealthStateFilter") != "4" {
+			http.NotFound(w, r)
+			return
+		}
+		writeFixture(w, "node_health.json")
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetNodeHealth(context.Background(), "_NodeType0_1", &HealthQueryOptions{
+		EventStates: []string{HealthStateWarning},
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := &NodeHealth{
+		Name:                  "_NodeType0_1",
+		AggregatedHealthState: HealthStateWarning,
+		HealthEvents: []HealthEvent{
+			{
+				SourceID:                 "DiskMonitor",
+				Property:                 "DiskSpace",
+				HealthState:              HealthStateWarning,
+				Description:              "Disk D: is 91% full.",
+				TimeToLiveInMilliSeconds: "PT0H10M0S",
+				SequenceNumber:           "7",
+				RemoveWhenExpired:        true,
+				SourceUtcTimestamp:       "2020-01-01T10:00:00.000Z",
+				LastModifiedUtcTimestamp: "2020-01-01T10:00:00.000Z",
+			},
+		},
+		UnhealthyEvaluations: []HealthEvaluationWrapper{
+			{
+				HealthEvaluation: HealthEvaluation{
+					Kind:                  "Event",
+					AggregatedHealthState: HealthStateWarning,
+					Description:           "Warning event: SourceId='DiskMonitor', Property='DiskSpace'.",
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+
+	ttl, err := actual.HealthEvents[0].TimeToLive()
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if ttl != 10*time.Minute {
+		t.Errorf("Got %+v, want %+v", ttl, 10*time.Minute)
+	}
+}
+
+func TestGetReplicaHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/Partitions/b3a2d4e1-0c2f-4f5e-8a6b-7c9d0e1f2a3b/$/GetReplicas/132149019876543211/$/GetHealth" ||
+			r.URL.Query().Get("EventsHealthStateFilter") != "65535" {
+			http.NotFound(w, r)
+			return
+		}
+		writeFixture(w, "replica_health.json")
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetReplicaHealth(context.Background(), "b3a2d4e1-0c2f-4f5e-8a6b-7c9d0e1f2a3b", "132149019876543211", nil)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if actual.ServiceKind != ServiceKindStateless || actual.InstanceID != "132149019876543211" ||
+		actual.AggregatedHealthState != HealthStateOk || len(actual.HealthEvents) != 1 {
+		t.Fatalf("Got %+v, want the healthy instance", actual)
+	}
+
+	event := actual.HealthEvents[0]
+	if event.SourceID != "System.RA" || event.Property != "State" || event.SequenceNumber != "132149019876543211" {
+		t.Errorf("Got %+v, want the System.RA state event", event)
+	}
+	ttl, err := event.TimeToLive()
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if ttl != math.MaxInt64 {
+		t.Errorf("Got %+v, want the report to never expire", ttl)
+	}
+}
+
+func TestReportHealth(t *testing.T) {
+	type request struct {
+		path  string
+		query string
+		body  map[string]interface{}
+	}
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.NotFound(w, r)
+			return
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Exception thrown %v", err)
+		}
+		requests = append(requests, request{path: r.URL.Path, query: r.URL.RawQuery, body: body})
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	info := HealthInformation{
+		SourceID:          "Watchdog.Disk",
+		Property:          "DiskSpace",
+		HealthState:       HealthStateWarning,
+		Description:       "Disk D: is 91% full.",
+		TimeToLive:        10 * time.Minute,
+		RemoveWhenExpired: true,
+	}
+	ctx := context.Background()
+	if err := sfClient.ReportClusterHealth(ctx, info); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if err := sfClient.ReportApplicationHealth(ctx, "TestApplication", info); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if err := sfClient.ReportServiceHealth(ctx, "TestApplication~TestService", info); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if err := sfClient.ReportNodeHealth(ctx, "_NodeType0_0", info); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	info.Immediate = true
+	if err := sfClient.ReportReplicaHealth(ctx, "b3a2d4e1-0c2f-4f5e-8a6b-7c9d0e1f2a3b", "132149019876543211", ServiceKindStateless, info); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expectedBody := map[string]interface{}{
+		"SourceId":                 "Watchdog.Disk",
+		"Property":                 "DiskSpace",
+		"HealthState":              "Warning",
+		"Description":              "Disk D: is 91% full.",
+		"TimeToLiveInMilliSeconds": "PT0H10M0S",
+		"RemoveWhenExpired":        true,
+	}
+	expected := []request{
+		{path: "/$/ReportClusterHealth", query: "api-version=1.0", body: expectedBody},
+		{path: "/Applications/TestApplication/$/ReportHealth", query: "api-version=1.0", body: expectedBody},
+		{path: "/Services/TestApplication~TestService/$/ReportHealth", query: "api-version=1.0", body: expectedBody},
+		{path: "/Nodes/_NodeType0_0/$/ReportHealth", query: "api-version=1.0", body: expectedBody},
+		{
+			path:  "/Partitions/b3a2d4e1-0c2f-4f5e-8a6b-7c9d0e1f2a3b/$/GetReplicas/132149019876543211/$/ReportHealth",
+			query: "api-version=1.0&ServiceKind=Stateless&Immediate=true",
+			body:  expectedBody,
+		},
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Got %+v, want %+v", requests, expected)
+	}
+}
+
+func TestReportHealthValidation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected request to %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	tests := []HealthInformation{
+		{Property: "DiskSpace", HealthState: HealthStateOk},
+		{SourceID: "Watchdog.Disk", Property: "DiskSpace", HealthState: HealthStateUnknown},
+		{SourceID: "Watchdog.Disk", Property: "DiskSpace", HealthState: HealthStateOk, TimeToLive: -time.Second},
+	}
+	for _, test := range tests {
+		if err := sfClient.ReportClusterHealth(context.Background(), test); err == nil {
+			t.Errorf("Error should have been returned for %+v", test)
+		}
+	}
+
+	info := HealthInformation{SourceID: "Watchdog.Disk", Property: "DiskSpace", HealthState: HealthStateOk}
+	if err := sfClient.ReportReplicaHealth(context.Background(), "b3a2d4e1", "1", "Stateful ", info); err == nil {
+		t.Error("Error should have been returned")
+	}
+}
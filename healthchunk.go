@@ -0,0 +1,216 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ClusterHealthChunkQueryDescription selects the entities a cluster health
+// chunk query returns. Entities are returned only when a filter matches
+// them, filters on children apply to the entities their parent selects.
+// HealthStateFilter fields take a HealthStateFilterMask, 0 selecting
+// every entity matched by name.
+type ClusterHealthChunkQueryDescription struct {
+	NodeFilters        []NodeHealthStateFilter        `json:"NodeFilters,omitempty"`
+	ApplicationFilters []ApplicationHealthStateFilter `json:"ApplicationFilters,omitempty"`
+}
+
+// ApplicationHealthPolicies overrides the health policies of applications
+type ApplicationHealthPolicies struct {
+	ApplicationHealthPolicyMap []ApplicationHealthPolicyMapItem `json:"ApplicationHealthPolicyMap,omitempty"`
+}
+
+// NodeHealthStateFilter selects nodes by name and health state
+type NodeHealthStateFilter struct {
+	NodeNameFilter    string `json:"NodeNameFilter,omitempty"`
+	HealthStateFilter int    `json:"HealthStateFilter,omitempty"`
+}
+
+// ApplicationHealthStateFilter selects applications, by name or
+// type, and health state, and the children returned with them
+type ApplicationHealthStateFilter struct {
+	ApplicationNameFilter      string                                 `json:"ApplicationNameFilter,omitempty"`
+	ApplicationTypeNameFilter  string                                 `json:"ApplicationTypeNameFilter,omitempty"`
+	HealthStateFilter          int                                    `json:"HealthStateFilter,omitempty"`
+	ServiceFilters             []ServiceHealthStateFilter             `json:"ServiceFilters,omitempty"`
+	DeployedApplicationFilters []DeployedApplicationHealthStateFilter `json:"DeployedApplicationFilters,omitempty"`
+}
+
+// ServiceHealthStateFilter selects services by name and health state
+type ServiceHealthStateFilter struct {
+	ServiceNameFilter string                       `json:"ServiceNameFilter,omitempty"`
+	HealthStateFilter int                          `json:"HealthStateFilter,omitempty"`
+	PartitionFilters  []PartitionHealthStateFilter `json:"PartitionFilters,omitempty"`
+}
+
+// PartitionHealthStateFilter selects partitions by ID and health state
+type PartitionHealthStateFilter struct {
+	PartitionIDFilter string                     `json:"PartitionIdFilter,omitempty"`
+	HealthStateFilter int                        `json:"HealthStateFilter,omitempty"`
+	ReplicaFilters    []ReplicaHealthStateFilter `json:"ReplicaFilters,omitempty"`
+}
+
+// ReplicaHealthStateFilter selects replicas or instances by ID and health state
+type ReplicaHealthStateFilter struct {
+	ReplicaOrInstanceIDFilter string `json:"ReplicaOrInstanceIdFilter,omitempty"`
+	HealthStateFilter         int    `json:"HealthStateFilter,omitempty"`
+}
+
+// DeployedApplicationHealthStateFilter selects the applications
+// deployed on nodes by node name and health state
+type DeployedApplicationHealthStateFilter struct {
+	NodeNameFilter                string                                    `json:"NodeNameFilter,omitempty"`
+	HealthStateFilter             int                                       `json:"HealthStateFilter,omitempty"`
+	DeployedServicePackageFilters []DeployedServicePackageHealthStateFilter `json:"DeployedServicePackageFilters,omitempty"`
+}
+
+// DeployedServicePackageHealthStateFilter selects deployed
+// service packages by manifest name and health state
+type DeployedServicePackageHealthStateFilter struct {
+	ServiceManifestNameFilter        string `json:"ServiceManifestNameFilter,omitempty"`
+	ServicePackageActivationIDFilter string `json:"ServicePackageActivationIdFilter,omitempty"`
+	HealthStateFilter                int    `json:"HealthStateFilter,omitempty"`
+}
+
+// ClusterHealthChunk is the health state of the cluster,
+// with the health states of the entities a query selected
+type ClusterHealthChunk struct {
+	HealthState                  string                       `json:"HealthState"`
+	NodeHealthStateChunks        NodeHealthStateChunks        `json:"NodeHealthStateChunks"`
+	ApplicationHealthStateChunks ApplicationHealthStateChunks `json:"ApplicationHealthStateChunks"`
+}
+
+// NodeHealthStateChunks lists the selected nodes
+type NodeHealthStateChunks struct {
+	TotalCount int64                  `json:"TotalCount"`
+	Items      []NodeHealthStateChunk `json:"Items"`
+}
+
+// NodeHealthStateChunk is the health state of a node
+type NodeHealthStateChunk struct {
+	NodeName    string `json:"NodeName"`
+	HealthState string `json:"HealthState"`
+}
+
+// ApplicationHealthStateChunks lists the selected applications
+type ApplicationHealthStateChunks struct {
+	TotalCount int64                         `json:"TotalCount"`
+	Items      []ApplicationHealthStateChunk `json:"Items"`
+}
+
+// ApplicationHealthStateChunk is the health state of an application,
+// with the health states of its selected children
+type ApplicationHealthStateChunk struct {
+	ApplicationName                      string                               `json:"ApplicationName"`
+	ApplicationTypeName                  string                               `json:"ApplicationTypeName"`
+	HealthState                          string                               `json:"HealthState"`
+	ServiceHealthStateChunks             ServiceHealthStateChunks             `json:"ServiceHealthStateChunks"`
+	DeployedApplicationHealthStateChunks DeployedApplicationHealthStateChunks `json:"DeployedApplicationHealthStateChunks"`
+}
+
+// ServiceHealthStateChunks lists the selected services
+type ServiceHealthStateChunks struct {
+	TotalCount int64                     `json:"TotalCount"`
+	Items      []ServiceHealthStateChunk `json:"Items"`
+}
+
+// ServiceHealthStateChunk is the health state of a service
+type ServiceHealthStateChunk struct {
+	ServiceName                string                     `json:"ServiceName"`
+	HealthState                string                     `json:"HealthState"`
+	PartitionHealthStateChunks PartitionHealthStateChunks `json:"PartitionHealthStateChunks"`
+}
+
+// PartitionHealthStateChunks lists the selected partitions
+type PartitionHealthStateChunks struct {
+	TotalCount int64                       `json:"TotalCount"`
+	Items      []PartitionHealthStateChunk `json:"Items"`
+}
+
+// PartitionHealthStateChunk is the health state of a partition
+type PartitionHealthStateChunk struct {
+	PartitionID              string                   `json:"PartitionId"`
+	HealthState              string                   `json:"HealthState"`
+	ReplicaHealthStateChunks ReplicaHealthStateChunks `json:"ReplicaHealthStateChunks"`
+}
+
+// ReplicaHealthStateChunks lists the selected replicas or instances
+type ReplicaHealthStateChunks struct {
+	TotalCount int64                     `json:"TotalCount"`
+	Items      []ReplicaHealthStateChunk `json:"Items"`
+}
+
+// ReplicaHealthStateChunk is the health state of a replica or instance
+type ReplicaHealthStateChunk struct {
+	ReplicaOrInstanceID string `json:"ReplicaOrInstanceId"`
+	HealthState         string `json:"HealthState"`
+}
+
+// DeployedApplicationHealthStateChunks lists the selected deployed applications
+type DeployedApplicationHealthStateChunks struct {
+	TotalCount int64                                 `json:"TotalCount"`
+	Items      []DeployedApplicationHealthStateChunk `json:"Items"`
+}
+
+// DeployedApplicationHealthStateChunk is the health state
+// of an application deployed on a node
+type DeployedApplicationHealthStateChunk struct {
+	NodeName                                string                                  `json:"NodeName"`
+	HealthState                             string                                  `json:"HealthState"`
+	DeployedServicePackageHealthStateChunks DeployedServicePackageHealthStateChunks `json:"DeployedServicePackageHealthStateChunks"`
+}
+
+// DeployedServicePackageHealthStateChunks lists the selected deployed service packages
+type DeployedServicePackageHealthStateChunks struct {
+	TotalCount int64                                    `json:"TotalCount"`
+	Items      []DeployedServicePackageHealthStateChunk `json:"Items"`
+}
+
+// DeployedServicePackageHealthStateChunk is the health
+// state of a service package deployed on a node
+type DeployedServicePackageHealthStateChunk struct {
+	ServiceManifestName        string `json:"ServiceManifestName"`
+	ServicePackageActivationID string `json:"ServicePackageActivationId"`
+	HealthState                string `json:"HealthState"`
+}
+
+// clusterHealthChunkQuery is the request body of a cluster health chunk
+// query, the filters along with the policies evaluating the health
+type clusterHealthChunkQuery struct {
+	*ClusterHealthChunkQueryDescription
+	ClusterHealthPolicy       *ClusterHealthPolicy       `json:"ClusterHealthPolicy,omitempty"`
+	ApplicationHealthPolicies *ApplicationHealthPolicies `json:"ApplicationHealthPolicies,omitempty"`
+}
+
+// GetClusterHealthChunk returns the health state of the cluster with the
+// health states of the entities query selects. With a nil query only the
+// cluster health state is returned, as no entity is selected. The health
+// is evaluated with policies, or with the policies of the cluster
+// manifest and of the application manifests when nil.
+func (c ServiceFabricClient) GetClusterHealthChunk(ctx context.Context, query *ClusterHealthChunkQueryDescription, policies *ClusterHealthPolicies) (chunk *ClusterHealthChunk, err error) {
+	ctx, call := c.startCall(ctx, "GetClusterHealthChunk")
+	defer func() { call.finish(err) }()
+
+	var body *clusterHealthChunkQuery
+	if query != nil || policies != nil {
+		body = &clusterHealthChunkQuery{ClusterHealthChunkQueryDescription: query}
+		if policies != nil {
+			body.ClusterHealthPolicy = policies.ClusterHealthPolicy
+			if len(policies.ApplicationHealthPolicyMap) > 0 {
+				body.ApplicationHealthPolicies = &ApplicationHealthPolicies{ApplicationHealthPolicyMap: policies.ApplicationHealthPolicyMap}
+			}
+		}
+	}
+	res, _, err := c.getHealth(ctx, "$/GetClusterHealthChunk", body)
+	if err != nil {
+		return nil, err
+	}
+
+	chunk = &ClusterHealthChunk{}
+	err = json.Unmarshal(res, chunk)
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return chunk, nil
+}
@@ -0,0 +1,39 @@
+package servicefabric
+
+// Health evaluation kinds reported for delta health checks during upgrades
+const (
+	HealthEvaluationKindDeltaNodesCheck              = "DeltaNodesCheck"
+	HealthEvaluationKindUpgradeDomainDeltaNodesCheck = "UpgradeDomainDeltaNodesCheck"
+)
+
+// HealthEvaluationWrapper wraps a HealthEvaluation
+// in the lists the Service Fabric API returns
+type HealthEvaluationWrapper struct {
+	HealthEvaluation HealthEvaluation `json:"HealthEvaluation"`
+}
+
+// HealthEvaluation explains why an entity was evaluated unhealthy.
+// Kind tells which of the optional fields are reported, and
+// UnhealthyEvaluations holds the evaluations of its children.
+type HealthEvaluation struct {
+	Kind                  string `json:"Kind"`
+	AggregatedHealthState string `json:"AggregatedHealthState"`
+	Description           string `json:"Description"`
+
+	// Set by entity specific evaluations
+	NodeName          string `json:"NodeName,omitempty"`
+	ApplicationName   string `json:"ApplicationName,omitempty"`
+	ServiceName       string `json:"ServiceName,omitempty"`
+	UpgradeDomainName string `json:"UpgradeDomainName,omitempty"`
+
+	// Set by aggregated and delta evaluations
+	TotalCount                                 int64 `json:"TotalCount,omitempty"`
+	BaselineErrorCount                         int64 `json:"BaselineErrorCount,omitempty"`
+	BaselineTotalCount                         int64 `json:"BaselineTotalCount,omitempty"`
+	MaxPercentUnhealthyNodes                   int   `json:"MaxPercentUnhealthyNodes,omitempty"`
+	MaxPercentUnhealthyApplications            int   `json:"MaxPercentUnhealthyApplications,omitempty"`
+	MaxPercentDeltaUnhealthyNodes              int   `json:"MaxPercentDeltaUnhealthyNodes,omitempty"`
+	MaxPercentUpgradeDomainDeltaUnhealthyNodes int   `json:"MaxPercentUpgradeDomainDeltaUnhealthyNodes,omitempty"`
+
+	UnhealthyEvaluations []HealthEvaluationWrapper `json:"UnhealthyEvaluations,omitempty"`
+}
@@ -0,0 +1,95 @@
+package servicefabric
+
+import "context"
+
+// ClusterHealthPolicy defines how the health of the cluster
+// and its nodes is evaluated
+type ClusterHealthPolicy struct {
+	ConsiderWarningAsError          bool                                 `json:"ConsiderWarningAsError"`
+	MaxPercentUnhealthyNodes        int                                  `json:"MaxPercentUnhealthyNodes"`
+	MaxPercentUnhealthyApplications int                                  `json:"MaxPercentUnhealthyApplications"`
+	ApplicationTypeHealthPolicyMap  []ApplicationTypeHealthPolicyMapItem `json:"ApplicationTypeHealthPolicyMap,omitempty"`
+	NodeTypeHealthPolicyMap         []NodeTypeHealthPolicyMapItem        `json:"NodeTypeHealthPolicyMap,omitempty"`
+}
+
+// ApplicationTypeHealthPolicyMapItem overrides the maximum percentage
+// of unhealthy applications for one application type
+type ApplicationTypeHealthPolicyMapItem struct {
+	Key   string `json:"Key"`
+	Value int    `json:"Value"`
+}
+
+// NodeTypeHealthPolicyMapItem overrides the maximum percentage
+// of unhealthy nodes for one node type
+type NodeTypeHealthPolicyMapItem struct {
+	Key   string `json:"Key"`
+	Value int    `json:"Value"`
+}
+
+// ClusterUpgradeHealthPolicy defines the delta health checks
+// applied while the cluster is being upgraded
+type ClusterUpgradeHealthPolicy struct {
+	MaxPercentDeltaUnhealthyNodes              int `json:"MaxPercentDeltaUnhealthyNodes"`
+	MaxPercentUpgradeDomainDeltaUnhealthyNodes int `json:"MaxPercentUpgradeDomainDeltaUnhealthyNodes"`
+}
+
+// ApplicationHealthPolicy defines how the health of an application
+// and its children is evaluated
+type ApplicationHealthPolicy struct {
+	ConsiderWarningAsError                  bool                             `json:"ConsiderWarningAsError"`
+	MaxPercentUnhealthyDeployedApplications int                              `json:"MaxPercentUnhealthyDeployedApplications"`
+	DefaultServiceTypeHealthPolicy          *ServiceTypeHealthPolicy         `json:"DefaultServiceTypeHealthPolicy,omitempty"`
+	ServiceTypeHealthPolicyMap              []ServiceTypeHealthPolicyMapItem `json:"ServiceTypeHealthPolicyMap,omitempty"`
+}
+
+// ServiceTypeHealthPolicy defines how the services of
+// a service type, and their children, are evaluated
+type ServiceTypeHealthPolicy struct {
+	MaxPercentUnhealthyPartitionsPerService int `json:"MaxPercentUnhealthyPartitionsPerService"`
+	MaxPercentUnhealthyReplicasPerPartition int `json:"MaxPercentUnhealthyReplicasPerPartition"`
+	MaxPercentUnhealthyServices             int `json:"MaxPercentUnhealthyServices"`
+}
+
+// ServiceTypeHealthPolicyMapItem overrides the
+// health policy of one service type
+type ServiceTypeHealthPolicyMapItem struct {
+	Key   string                  `json:"Key"`
+	Value ServiceTypeHealthPolicy `json:"Value"`
+}
+
+// ApplicationHealthPolicyMapItem overrides the
+// health policy of one application, keyed by name
+type ApplicationHealthPolicyMapItem struct {
+	Key   string                  `json:"Key"`
+	Value ApplicationHealthPolicy `json:"Value"`
+}
+
+// ClusterHealthPolicies groups the policies the cluster health is
+// evaluated with in health queries
+type ClusterHealthPolicies struct {
+	ApplicationHealthPolicyMap []ApplicationHealthPolicyMapItem `json:"ApplicationHealthPolicyMap,omitempty"`
+	ClusterHealthPolicy        *ClusterHealthPolicy             `json:"ClusterHealthPolicy,omitempty"`
+}
+
+// ClusterUpgradeHealthPolicies groups the health policies the current or
+// last cluster upgrade is evaluated with. Upgrades nest the application
+// health policy map in an ApplicationHealthPolicies object.
+type ClusterUpgradeHealthPolicies struct {
+	ApplicationHealthPolicyMap *ApplicationHealthPolicies  `json:"ApplicationHealthPolicyMap,omitempty"`
+	ClusterHealthPolicy        *ClusterHealthPolicy        `json:"ClusterHealthPolicy,omitempty"`
+	ClusterUpgradeHealthPolicy *ClusterUpgradeHealthPolicy `json:"ClusterUpgradeHealthPolicy,omitempty"`
+}
+
+// GetClusterUpgradeHealthPolicy returns the health policies of the current
+// or last cluster upgrade. Fields are nil when the cluster reports none.
+func (c ServiceFabricClient) GetClusterUpgradeHealthPolicy(ctx context.Context) (*ClusterUpgradeHealthPolicies, error) {
+	progress, err := c.GetClusterUpgradeProgress(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if progress.UpgradeDescription == nil {
+		return &ClusterUpgradeHealthPolicies{}, nil
+	}
+	return &progress.UpgradeDescription.ClusterUpgradeHealthPolicies, nil
+}
@@ -0,0 +1,59 @@
+package servicefabric
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGetClusterUpgradeHealthPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleClusterUpgradeProgress))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	expected := &ClusterUpgradeHealthPolicies{
+		ApplicationHealthPolicyMap: &ApplicationHealthPolicies{
+			ApplicationHealthPolicyMap: []ApplicationHealthPolicyMapItem{
+				{
+					Key: "fabric:/TestApplication",
+					Value: ApplicationHealthPolicy{
+						ConsiderWarningAsError:         true,
+						DefaultServiceTypeHealthPolicy: &ServiceTypeHealthPolicy{},
+						ServiceTypeHealthPolicyMap: []ServiceTypeHealthPolicyMapItem{
+							{
+								Key: "TestServiceType",
+								Value: ServiceTypeHealthPolicy{
+									MaxPercentUnhealthyPartitionsPerService: 10,
+									MaxPercentUnhealthyReplicasPerPartition: 20,
+									MaxPercentUnhealthyServices:             30,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		ClusterHealthPolicy: &ClusterHealthPolicy{
+			MaxPercentUnhealthyNodes: 10,
+			ApplicationTypeHealthPolicyMap: []ApplicationTypeHealthPolicyMapItem{
+				{Key: "TestApplicationType", Value: 20},
+			},
+		},
+		ClusterUpgradeHealthPolicy: &ClusterUpgradeHealthPolicy{
+			MaxPercentDeltaUnhealthyNodes:              10,
+			MaxPercentUpgradeDomainDeltaUnhealthyNodes: 15,
+		},
+	}
+
+	actual, err := sfClient.GetClusterUpgradeHealthPolicy(context.Background())
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+}
@@ -0,0 +1,62 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// UnmarshalHook decodes data into v, replacing the decoding the client
+// would otherwise apply, such as XML for service type extensions
+type UnmarshalHook func(data []byte, v interface{}) error
+
+// unmarshalHook returns the hook registered for the type of v, if any
+func (c ServiceFabricClient) unmarshalHook(v interface{}) UnmarshalHook {
+	if v == nil {
+		return nil
+	}
+	return c.typeHooks[reflect.TypeOf(v)]
+}
+
+// unmarshal decodes data into v with the hook registered for
+// the type of v, if any, and with fallback otherwise
+func (c ServiceFabricClient) unmarshal(data []byte, v interface{}, fallback func([]byte, interface{}) error) error {
+	if hook := c.unmarshalHook(v); hook != nil {
+		return hook(data, v)
+	}
+	return fallback(data, v)
+}
+
+// GetPropertyObject decodes the property propertyName of a Service Fabric
+// name into v. The value is decoded with the hook registered for its
+// CustomTypeId with WithCustomTypeHook, else with the hook registered for
+// the type of v with WithUnmarshalHook, else as JSON. Binary values are
+// passed to hooks as bytes, the values of other kinds as their text.
+func (c ServiceFabricClient) GetPropertyObject(ctx context.Context, name, propertyName string, v interface{}) (err error) {
+	ctx, call := c.startCall(ctx, "GetPropertyObject")
+	defer func() { call.finish(err) }()
+
+	property, err := c.getProperty(ctx, name, propertyName)
+	if err != nil {
+		return err
+	}
+
+	data := []byte(property.Value.Data)
+	if property.Value.Kind == PropertyKindBinary {
+		data, err = decodeBinary(propertyName, property.Value.Data)
+		if err != nil {
+			return err
+		}
+	}
+
+	if hook, ok := c.customTypeHooks[property.Metadata.CustomTypeID]; ok {
+		err = hook(data, v)
+	} else {
+		err = c.unmarshal(data, v, json.Unmarshal)
+	}
+	if err != nil {
+		return fmt.Errorf("could not deserialise property %s: %+v", propertyName, err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// labelSet decodes the labels extension into a map rather than a list
+type labelSet map[string]string
+
+func TestGetServiceExtensionWithUnmarshalHook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleExtensionA))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil,
+		WithUnmarshalHook((*labelSet)(nil), func(data []byte, v interface{}) error {
+			var labels ServiceExtensionLabels
+			if err := xml.Unmarshal(data, &labels); err != nil {
+				return err
+			}
+			set := labelSet{}
+			for _, label := range labels.Label {
+				set[label.Key] = label.Value
+			}
+			*v.(*labelSet) = set
+			return nil
+		}))
+
+	var actual labelSet
+	err := sfClient.GetServiceExtension(context.Background(), "TestApplication", "1.0.0", "Test", "Test", &actual)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := labelSet{"key1": "value1"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+
+	// types without a hook still decode as XML
+	var labels ServiceExtensionLabels
+	err = sfClient.GetServiceExtension(context.Background(), "TestApplication", "1.0.0", "Test", "Test", &labels)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if len(labels.Label) != 1 {
+		t.Errorf("Got %+v, want one label", labels)
+	}
+}
+
+func TestGetPropertyObject(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("PropertyName") {
+		case "Endpoint":
+			w.Write([]byte(`{"Name":"Endpoint","Value":{"Kind":"Binary","Data":[104,111,115,116,58,56,48]},"Metadata":{"TypeId":"Binary","CustomTypeId":"hostport"}}`))
+		case "Settings":
+			w.Write([]byte(`{"Name":"Settings","Value":{"Kind":"String","Data":"{\"Replicas\":3}"},"Metadata":{"TypeId":"String"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	type hostPort struct {
+		Host string
+		Port string
+	}
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil,
+		WithCustomTypeHook("hostport", func(data []byte, v interface{}) error {
+			parts := strings.SplitN(string(data), ":", 2)
+			*v.(*hostPort) = hostPort{Host: parts[0], Port: parts[1]}
+			return nil
+		}))
+
+	var endpoint hostPort
+	err := sfClient.GetPropertyObject(context.Background(), "TestApplication", "Endpoint", &endpoint)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if endpoint != (hostPort{Host: "host", Port: "80"}) {
+		t.Errorf("Got %+v, want %+v", endpoint, hostPort{Host: "host", Port: "80"})
+	}
+
+	var settings struct{ Replicas int }
+	err = sfClient.GetPropertyObject(context.Background(), "TestApplication", "Settings", &settings)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if settings.Replicas != 3 {
+		t.Errorf("Got %+v, want %+v", settings.Replicas, 3)
+	}
+
+	if err := sfClient.GetPropertyObject(context.Background(), "TestApplication", "Missing", &settings); err == nil {
+		t.Error("Error should have been returned")
+	}
+}
@@ -0,0 +1,118 @@
+package servicefabric
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ManagedApplicationIdentity assigns an Azure managed identity
+// to a managed identity an application manifest declares
+type ManagedApplicationIdentity struct {
+	// Name is the name of the identity in the application manifest
+	Name string `json:"Name"`
+	// PrincipalID is the principal id of the Azure managed identity
+	PrincipalID string `json:"PrincipalId,omitempty"`
+}
+
+// ManagedApplicationIdentityDescription lists the managed identities
+// of an application, which its service code obtains tokens for
+type ManagedApplicationIdentityDescription struct {
+	// TokenServiceEndpoint is the endpoint of the managed identity token
+	// service, left empty to use the cluster default
+	TokenServiceEndpoint string                       `json:"TokenServiceEndpoint,omitempty"`
+	ManagedIdentities    []ManagedApplicationIdentity `json:"ManagedIdentities"`
+}
+
+// ApplicationDescription describes an application to create
+type ApplicationDescription struct {
+	Name          string         `json:"Name"`
+	TypeName      string         `json:"TypeName"`
+	TypeVersion   string         `json:"TypeVersion"`
+	ParameterList []AppParameter `json:"ParameterList,omitempty"`
+	// ManagedApplicationIdentity assigns the managed identities
+	// the application manifest declares
+	ManagedApplicationIdentity *ManagedApplicationIdentityDescription `json:"ManagedApplicationIdentity,omitempty"`
+}
+
+// validate checks the names of the application and its type, and that
+// no parameter is listed twice
+func (d ApplicationDescription) validate() error {
+	if !strings.HasPrefix(d.Name, fabricScheme) {
+		return fmt.Errorf("application name %q must start with %s", d.Name, fabricScheme)
+	}
+	if d.TypeName == "" || d.TypeVersion == "" {
+		return fmt.Errorf("application type name and version are required")
+	}
+	seen := map[string]bool{}
+	for _, parameter := range d.ParameterList {
+		if parameter.Key == "" {
+			return fmt.Errorf("application parameter name is required")
+		}
+		if seen[parameter.Key] {
+			return fmt.Errorf("application parameter %s is listed twice", parameter.Key)
+		}
+		seen[parameter.Key] = true
+	}
+	return nil
+}
+
+// Validate checks that every identity the service identities of manifest are
+// bound to is assigned, and that only identities the manifest declares are
+func (d *ManagedApplicationIdentityDescription) Validate(manifest *ApplicationManifest) error {
+	declared := map[string]bool{}
+	for _, identity := range manifest.ManagedIdentities {
+		declared[identity.Name] = true
+	}
+
+	assigned := map[string]bool{}
+	if d != nil {
+		for _, identity := range d.ManagedIdentities {
+			if identity.Name == "" {
+				return fmt.Errorf("managed identity name is required")
+			}
+			if !declared[identity.Name] {
+				return fmt.Errorf("managed identity %s is not declared by application type %s", identity.Name, manifest.ApplicationTypeName)
+			}
+			assigned[identity.Name] = true
+		}
+	}
+
+	var missing []string
+	for _, name := range boundIdentities(manifest) {
+		if !assigned[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("managed identities %v are bound to service identities but not assigned", missing)
+	}
+	return nil
+}
+
+// ServiceIdentities maps each service manifest of manifest to its
+// service identities and the application identities they are bound to
+func (m *ApplicationManifest) ServiceIdentities() map[string]map[string]string {
+	identities := map[string]map[string]string{}
+	for _, imp := range m.ServiceManifestImports {
+		for _, binding := range imp.IdentityBindings {
+			name := imp.ServiceManifestRef.ServiceManifestName
+			if identities[name] == nil {
+				identities[name] = map[string]string{}
+			}
+			identities[name][binding.ServiceIdentityRef] = binding.ApplicationIdentityRef
+		}
+	}
+	return identities
+}
+
+// boundIdentities returns the sorted names of the application
+// identities service identities of manifest are bound to
+func boundIdentities(manifest *ApplicationManifest) []string {
+	var names []string
+	for _, imp := range manifest.ServiceManifestImports {
+		for _, binding := range imp.IdentityBindings {
+			names = append(names, binding.ApplicationIdentityRef)
+		}
+	}
+	return sortedDistinct(names)
+}
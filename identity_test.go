@@ -0,0 +1,78 @@
+package servicefabric
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+const identityManifest = `<?xml version="1.0" encoding="utf-8"?>
+<ApplicationManifest xmlns="http://schemas.microsoft.com/2011/01/fabric" ApplicationTypeName="TestApplicationType" ApplicationTypeVersion="1.0.0">
+  <ServiceManifestImport>
+    <ServiceManifestRef ServiceManifestName="TestServicePkg" ServiceManifestVersion="1.0.0" />
+    <Policies>
+      <IdentityBindingPolicy ServiceIdentityRef="WebAdmin" ApplicationIdentityRef="AdminUser" />
+    </Policies>
+  </ServiceManifestImport>
+  <Principals>
+    <ManagedIdentities>
+      <ManagedIdentity Name="AdminUser" />
+      <ManagedIdentity Name="Reader" />
+    </ManagedIdentities>
+  </Principals>
+</ApplicationManifest>`
+
+func TestManagedApplicationIdentityValidate(t *testing.T) {
+	var manifest ApplicationManifest
+	if err := xml.Unmarshal([]byte(identityManifest), &manifest); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := map[string]map[string]string{"TestServicePkg": {"WebAdmin": "AdminUser"}}
+	if actual := manifest.ServiceIdentities(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+
+	tests := []struct {
+		name        string
+		description *ManagedApplicationIdentityDescription
+		valid       bool
+	}{
+		{"assigned", &ManagedApplicationIdentityDescription{ManagedIdentities: []ManagedApplicationIdentity{{Name: "AdminUser", PrincipalID: "guid"}}}, true},
+		{"missing", nil, false},
+		{"unbound", &ManagedApplicationIdentityDescription{ManagedIdentities: []ManagedApplicationIdentity{{Name: "Reader"}}}, false},
+		{"undeclared", &ManagedApplicationIdentityDescription{ManagedIdentities: []ManagedApplicationIdentity{{Name: "AdminUser"}, {Name: "Writer"}}}, false},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			err := test.description.Validate(&manifest)
+			if test.valid && err != nil {
+				t.Errorf("Exception thrown %v", err)
+			}
+			if !test.valid && err == nil {
+				t.Error("Error should have been returned")
+			}
+		})
+	}
+}
+
+func TestApplicationDescriptionIdentityJSON(t *testing.T) {
+	b, err := json.Marshal(ApplicationDescription{
+		Name:        "fabric:/TestApplication",
+		TypeName:    "TestApplicationType",
+		TypeVersion: "1.0.0",
+		ManagedApplicationIdentity: &ManagedApplicationIdentityDescription{
+			ManagedIdentities: []ManagedApplicationIdentity{{Name: "AdminUser", PrincipalID: "guid"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := `{"Name":"fabric:/TestApplication","TypeName":"TestApplicationType","TypeVersion":"1.0.0","ManagedApplicationIdentity":{"ManagedIdentities":[{"Name":"AdminUser","PrincipalId":"guid"}]}}`
+	if string(b) != expected {
+		t.Errorf("Got %+v, want %+v", string(b), expected)
+	}
+}
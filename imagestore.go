@@ -0,0 +1,547 @@
+package servicefabric
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultUploadChunkSize is the size of the chunks files are uploaded in
+// unless UploadOptions sets another
+const DefaultUploadChunkSize = 4 << 20
+
+// ErrUploadSizeMismatch is returned when the image store committed a file
+// of another size than the one uploaded
+var ErrUploadSizeMismatch = errors.New("image store file size does not match the uploaded size")
+
+// UploadChunkRange is a range of bytes of a file, both ends included
+type UploadChunkRange struct {
+	StartPosition string `json:"StartPosition"`
+	EndPosition   string `json:"EndPosition"`
+}
+
+// UploadSessionInfo describes an upload session to the image store
+type UploadSessionInfo struct {
+	StoreRelativePath string `json:"StoreRelativePath"`
+	SessionID         string `json:"SessionId"`
+	ModifiedDate      string `json:"ModifiedDate"`
+	FileSize          string `json:"FileSize"`
+	// ExpectedRanges are the ranges of the file not uploaded yet
+	ExpectedRanges []UploadChunkRange `json:"ExpectedRanges"`
+}
+
+// UploadSession lists the sessions of an upload, one per file
+type UploadSession struct {
+	UploadSessions []UploadSessionInfo `json:"UploadSessions"`
+}
+
+// ImageStoreFile describes a file of the image store
+type ImageStoreFile struct {
+	FileSize          string `json:"FileSize"`
+	StoreRelativePath string `json:"StoreRelativePath"`
+	ModifiedDate      string `json:"ModifiedDate"`
+}
+
+// ImageStoreFolder describes a folder of the image store
+type ImageStoreFolder struct {
+	StoreRelativePath string `json:"StoreRelativePath"`
+	FileCount         string `json:"FileCount"`
+}
+
+// ImageStoreContent lists the files and folders at an image store path
+type ImageStoreContent struct {
+	StoreFiles   []ImageStoreFile   `json:"StoreFiles"`
+	StoreFolders []ImageStoreFolder `json:"StoreFolders"`
+}
+
+// UploadProgress reports how much of a file was uploaded
+type UploadProgress struct {
+	StoreRelativePath string
+	Uploaded          int64
+	Total             int64
+}
+
+// UploadOptions configures UploadFile
+type UploadOptions struct {
+	// ChunkSize is DefaultUploadChunkSize when zero
+	ChunkSize int64
+	// Progress is called after every chunk uploaded, if set
+	Progress func(UploadProgress)
+	// Concurrency is the number of chunks uploaded at once, one when zero.
+	// Chunks may complete out of order, Progress is still called with a
+	// growing Uploaded.
+	Concurrency int
+	// ChunkRetry resends chunks that failed to upload, if set. Only its
+	// MaxAttempts and backoff apply, as every failure is retried.
+	ChunkRetry *RetryPolicy
+	// BytesPerSecond caps the average upload bandwidth, unlimited when zero
+	BytesPerSecond int64
+	// StateFile persists the upload session, if set, so that an interrupted
+	// upload of the same file resumes with the chunks the image store is
+	// still missing. It is removed once the upload is committed.
+	StateFile string
+}
+
+func (o *UploadOptions) chunkSize() int64 {
+	if o == nil || o.ChunkSize <= 0 {
+		return DefaultUploadChunkSize
+	}
+	return o.ChunkSize
+}
+
+func (o *UploadOptions) concurrency() int {
+	if o.Concurrency <= 0 {
+		return 1
+	}
+	return o.Concurrency
+}
+
+// uploadState is the upload session persisted to UploadOptions.StateFile
+type uploadState struct {
+	SessionID         string `json:"SessionId"`
+	StoreRelativePath string `json:"StoreRelativePath"`
+	Size              int64  `json:"Size"`
+}
+
+// GetImageStoreContent returns the files and folders at storeRelativePath
+func (c ServiceFabricClient) GetImageStoreContent(ctx context.Context, storeRelativePath string) (content *ImageStoreContent, err error) {
+	ctx, call := c.startCall(ctx, "GetImageStoreContent")
+	defer func() { call.finish(err) }()
+
+	return c.getImageStoreContent(ctx, storeRelativePath)
+}
+
+func (c ServiceFabricClient) getImageStoreContent(ctx context.Context, storeRelativePath string) (*ImageStoreContent, error) {
+	res, status, err := c.getHTTP(ctx, "ImageStore/"+storeRelativePath)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNoContent {
+		return nil, errors.Wrapf(ErrResourceNotExists, "image store path %s", storeRelativePath)
+	}
+
+	var content ImageStoreContent
+	if err := json.Unmarshal(res, &content); err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return &content, nil
+}
+
+// GetUploadSession returns the upload session sessionID, whose
+// UploadSessions is empty once the session was committed or expired
+func (c ServiceFabricClient) GetUploadSession(ctx context.Context, sessionID string) (session *UploadSession, err error) {
+	ctx, call := c.startCall(ctx, "GetUploadSession")
+	defer func() { call.finish(err) }()
+
+	return c.getUploadSession(ctx, sessionID)
+}
+
+func (c ServiceFabricClient) getUploadSession(ctx context.Context, sessionID string) (*UploadSession, error) {
+	res, _, err := c.getHTTP(ctx, "ImageStore/$/GetUploadSession", withParam("session-id", sessionID))
+	if err != nil {
+		return nil, err
+	}
+
+	var session UploadSession
+	if err := json.Unmarshal(res, &session); err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return &session, nil
+}
+
+// UploadChunk uploads the bytes of a file from start to start+len(data)-1
+// within the upload session sessionID, size being the size of the file
+func (c ServiceFabricClient) UploadChunk(ctx context.Context, sessionID, storeRelativePath string, data []byte, start, size int64) (err error) {
+	ctx, call := c.startCall(ctx, "UploadChunk")
+	defer func() { call.finish(err) }()
+
+	return c.uploadChunk(ctx, sessionID, storeRelativePath, data, start, size)
+}
+
+func (c ServiceFabricClient) uploadChunk(ctx context.Context, sessionID, storeRelativePath string, data []byte, start, size int64) error {
+	if len(data) == 0 || start < 0 || start+int64(len(data)) > size {
+		return fmt.Errorf("chunk of %d bytes at %d exceeds the %d bytes of %s", len(data), start, size, storeRelativePath)
+	}
+
+	ctx = WithHeaders(ctx, http.Header{
+		"Content-Type":  {"application/octet-stream"},
+		"Content-Range": {fmt.Sprintf("bytes %d-%d/%d", start, start+int64(len(data))-1, size)},
+	})
+	_, _, err := c.sendHTTP(ctx, "PUT", opUploadImageStoreFile.on(storeRelativePath), "ImageStore/"+storeRelativePath+"/$/UploadChunk", data, withParam("session-id", sessionID))
+	if err != nil {
+		return errors.Wrapf(err, "failed uploading chunk of %s", storeRelativePath)
+	}
+	return nil
+}
+
+// CommitUploadSession commits the files of the upload session sessionID to the image store
+func (c ServiceFabricClient) CommitUploadSession(ctx context.Context, sessionID string) (err error) {
+	ctx, call := c.startCall(ctx, "CommitUploadSession")
+	defer func() { call.finish(err) }()
+
+	return c.commitUploadSession(ctx, sessionID)
+}
+
+func (c ServiceFabricClient) commitUploadSession(ctx context.Context, sessionID string) error {
+	_, _, err := c.postHTTP(ctx, opUploadImageStoreFile.on(sessionID), "ImageStore/$/CommitUploadSession", []byte{}, withParam("session-id", sessionID))
+	if err != nil {
+		return errors.Wrap(err, "failed committing upload session")
+	}
+	return nil
+}
+
+// DeleteUploadSession cancels the upload session sessionID, dropping its chunks
+func (c ServiceFabricClient) DeleteUploadSession(ctx context.Context, sessionID string) (err error) {
+	ctx, call := c.startCall(ctx, "DeleteUploadSession")
+	defer func() { call.finish(err) }()
+
+	_, _, err = c.sendHTTP(ctx, "DELETE", opDeleteUploadSession.on(sessionID), "ImageStore/$/DeleteUploadSession", nil, withParam("session-id", sessionID))
+	if err != nil {
+		return errors.Wrap(err, "failed deleting upload session")
+	}
+	return nil
+}
+
+// UploadFile uploads the size bytes of r to storeRelativePath in chunks,
+// commits them and checks the image store holds a file of the same size.
+// With UploadOptions.StateFile set, an upload interrupted by a failure or a
+// restart resumes by uploading only the ranges the image store reports as
+// missing. opts may be nil.
+func (c ServiceFabricClient) UploadFile(ctx context.Context, r io.ReaderAt, size int64, storeRelativePath string, opts *UploadOptions) (err error) {
+	ctx, call := c.startCall(ctx, "UploadFile")
+	defer func() { call.finish(err) }()
+
+	return c.uploadFile(ctx, r, size, storeRelativePath, opts)
+}
+
+func (c ServiceFabricClient) uploadFile(ctx context.Context, r io.ReaderAt, size int64, storeRelativePath string, opts *UploadOptions) error {
+	if size <= 0 {
+		return fmt.Errorf("cannot upload %d bytes to %s in chunks", size, storeRelativePath)
+	}
+	if opts == nil {
+		opts = &UploadOptions{}
+	}
+
+	state, missing, err := c.resumeUpload(ctx, storeRelativePath, size, opts.StateFile)
+	if err != nil {
+		return err
+	}
+	if opts.StateFile != "" {
+		if err := saveUploadState(opts.StateFile, state); err != nil {
+			return err
+		}
+	}
+
+	uploaded := size
+	var chunks [][2]int64
+	chunkSize := opts.chunkSize()
+	for _, rng := range missing {
+		uploaded -= rng[1] - rng[0] + 1
+		for start := rng[0]; start <= rng[1]; start += chunkSize {
+			end := start + chunkSize - 1
+			if end > rng[1] {
+				end = rng[1]
+			}
+			chunks = append(chunks, [2]int64{start, end})
+		}
+	}
+
+	var mu sync.Mutex
+	limiter := newRateLimiter(opts.BytesPerSecond)
+	uploader := c
+	uploader.maxConcurrency = opts.concurrency()
+	err = uploader.forEach(len(chunks), func(i int) error {
+		start, end := chunks[i][0], chunks[i][1]
+		data := make([]byte, end-start+1)
+		if _, err := r.ReadAt(data, start); err != nil && err != io.EOF {
+			return errors.Wrapf(err, "failed reading %s", storeRelativePath)
+		}
+		if err := c.uploadChunkRetrying(ctx, state.SessionID, storeRelativePath, data, start, size, opts.ChunkRetry, limiter); err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		uploaded += int64(len(data))
+		if opts.Progress != nil {
+			opts.Progress(UploadProgress{StoreRelativePath: storeRelativePath, Uploaded: uploaded, Total: size})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := c.commitUploadSession(ctx, state.SessionID); err != nil {
+		return err
+	}
+	if err := c.verifyUploadSize(ctx, storeRelativePath, size); err != nil {
+		return err
+	}
+	if opts.StateFile != "" {
+		if err := os.Remove(opts.StateFile); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploadChunkRetrying uploads a chunk once the limiter lets it, resending
+// it as retry allows. Chunks are resent whatever the failure, as uploading
+// the same range twice does no harm.
+func (c ServiceFabricClient) uploadChunkRetrying(ctx context.Context, sessionID, storeRelativePath string, data []byte, start, size int64, retry *RetryPolicy, limiter *rateLimiter) error {
+	for attempt := 1; ; attempt++ {
+		if err := limiter.wait(ctx, len(data)); err != nil {
+			return err
+		}
+		err := c.uploadChunk(ctx, sessionID, storeRelativePath, data, start, size)
+		if err == nil || retry == nil || attempt >= retry.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		timer := time.NewTimer(retry.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// rateLimiter spaces the chunks of an upload so that they are sent at
+// most at rate bytes per second on average, a nil limiter never waits
+type rateLimiter struct {
+	rate int64
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newRateLimiter(rate int64) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &rateLimiter{rate: rate}
+}
+
+// wait blocks until n more bytes may be sent
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	at := l.next
+	l.next = l.next.Add(time.Duration(float64(n) / float64(l.rate) * float64(time.Second)))
+	l.mu.Unlock()
+
+	d := time.Until(at)
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// resumeUpload returns the session to upload storeRelativePath with and the
+// ranges of bytes it is missing, resuming the session persisted to stateFile
+// when it uploads the same file and the image store still knows it
+func (c ServiceFabricClient) resumeUpload(ctx context.Context, storeRelativePath string, size int64, stateFile string) (uploadState, [][2]int64, error) {
+	fresh := uploadState{SessionID: newSessionID(), StoreRelativePath: storeRelativePath, Size: size}
+	whole := [][2]int64{{0, size - 1}}
+	if stateFile == "" {
+		return fresh, whole, nil
+	}
+
+	state, err := loadUploadState(stateFile)
+	if err != nil || state == nil || state.StoreRelativePath != storeRelativePath || state.Size != size {
+		return fresh, whole, err
+	}
+
+	session, err := c.getUploadSession(ctx, state.SessionID)
+	if err != nil {
+		return uploadState{}, nil, err
+	}
+	for _, info := range session.UploadSessions {
+		if info.StoreRelativePath != storeRelativePath {
+			continue
+		}
+		missing, err := parseChunkRanges(info.ExpectedRanges)
+		if err != nil {
+			return uploadState{}, nil, err
+		}
+		return *state, missing, nil
+	}
+	// the session expired, or was committed without the state file being removed
+	return fresh, whole, nil
+}
+
+// verifyUploadSize checks the image store holds size bytes at storeRelativePath
+func (c ServiceFabricClient) verifyUploadSize(ctx context.Context, storeRelativePath string, size int64) error {
+	content, err := c.getImageStoreContent(ctx, storeRelativePath)
+	if err != nil {
+		return err
+	}
+	for _, file := range content.StoreFiles {
+		if !strings.EqualFold(file.StoreRelativePath, storeRelativePath) {
+			continue
+		}
+		if file.FileSize != strconv.FormatInt(size, 10) {
+			return errors.Wrapf(ErrUploadSizeMismatch, "%s holds %s bytes, uploaded %d", storeRelativePath, file.FileSize, size)
+		}
+		return nil
+	}
+	return errors.Wrapf(ErrUploadSizeMismatch, "%s is missing from the image store", storeRelativePath)
+}
+
+func parseChunkRanges(ranges []UploadChunkRange) ([][2]int64, error) {
+	parsed := make([][2]int64, 0, len(ranges))
+	for _, rng := range ranges {
+		start, err := strconv.ParseInt(rng.StartPosition, 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid expected range")
+		}
+		end, err := strconv.ParseInt(rng.EndPosition, 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid expected range")
+		}
+		parsed = append(parsed, [2]int64{start, end})
+	}
+	return parsed, nil
+}
+
+// loadUploadState returns the state persisted to path, nil when there is none
+func loadUploadState(path string) (*uploadState, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state uploadState
+	if err := json.Unmarshal(data, &state); err != nil {
+		// a state file truncated by a crash starts the upload over
+		return nil, nil
+	}
+	return &state, nil
+}
+
+func saveUploadState(path string, state uploadState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// newSessionID returns a random GUID, as upload session ids must be
+func newSessionID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// PackageUploadOptions configures UploadApplicationPackage
+type PackageUploadOptions struct {
+	// Checksums uploads generated checksum files, see PackageLayoutOptions
+	Checksums bool
+	// Progress is called after every file uploaded, if set, with the
+	// number of files uploaded so far out of total
+	Progress func(file PackageFile, uploaded, total int)
+	// Chunks configures the upload of files larger than its chunk size,
+	// which are uploaded in chunks within an upload session while smaller
+	// files are uploaded in a single request
+	Chunks *UploadOptions
+}
+
+// UploadApplicationPackage uploads the uncompressed application package in
+// localDir to storeRelativePath in the image store, in the order and with
+// the generated files PackageLayout lists, so that the _.dir marker of a
+// folder is only written once everything it holds was uploaded. The
+// package can then be provisioned with ProvisionApplicationType. opts may
+// be nil.
+func (c ServiceFabricClient) UploadApplicationPackage(ctx context.Context, localDir, storeRelativePath string, opts *PackageUploadOptions) (err error) {
+	ctx, call := c.startCall(ctx, "UploadApplicationPackage")
+	defer func() { call.finish(err) }()
+
+	if opts == nil {
+		opts = &PackageUploadOptions{}
+	}
+	storeRelativePath = strings.Trim(storeRelativePath, "/")
+	if storeRelativePath == "" {
+		return errors.New("application packages cannot be uploaded to the image store root")
+	}
+
+	files, err := PackageLayout(localDir, &PackageLayoutOptions{Checksums: opts.Checksums})
+	if err != nil {
+		return err
+	}
+
+	for i, file := range files {
+		if err := c.uploadPackageFile(ctx, file, storeRelativePath+"/"+file.RelativePath, opts.Chunks); err != nil {
+			return err
+		}
+		if opts.Progress != nil {
+			opts.Progress(file, i+1, len(files))
+		}
+	}
+	return nil
+}
+
+func (c ServiceFabricClient) uploadPackageFile(ctx context.Context, file PackageFile, storeRelativePath string, chunks *UploadOptions) error {
+	if file.LocalPath == "" {
+		return c.uploadImageStoreFile(ctx, storeRelativePath, file.Content)
+	}
+
+	f, err := os.Open(file.LocalPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if file.Size > chunks.chunkSize() {
+		return c.uploadFile(ctx, f, file.Size, storeRelativePath, chunks)
+	}
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return errors.Wrapf(err, "failed reading %s", file.LocalPath)
+	}
+	return c.uploadImageStoreFile(ctx, storeRelativePath, data)
+}
+
+// uploadImageStoreFile uploads data as the file storeRelativePath in a single request
+func (c ServiceFabricClient) uploadImageStoreFile(ctx context.Context, storeRelativePath string, data []byte) error {
+	ctx = WithHeaders(ctx, http.Header{"Content-Type": {"application/octet-stream"}})
+	_, _, err := c.sendHTTP(ctx, "PUT", opUploadImageStoreFile.on(storeRelativePath), "ImageStore/"+storeRelativePath, data)
+	if err != nil {
+		return errors.Wrapf(err, "failed uploading %s", storeRelativePath)
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package servicefabric
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeImageStore serves the upload calls of the image store,
+// failing the chunk starting at failAt once
+type fakeImageStore struct {
+	mu       sync.Mutex
+	failAt   int64
+	sessions map[string]map[int64][]byte
+	paths    map[string]string
+	files    map[string][]byte
+	chunks   []string
+	// uploads lists the files uploaded in a single request
+	uploads []string
+}
+
+func newFakeImageStore() *fakeImageStore {
+	return &fakeImageStore{failAt: -1, sessions: map[string]map[int64][]byte{}, paths: map[string]string{}, files: map[string][]byte{}}
+}
+
+func (s *fakeImageStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.URL.Query().Get("session-id")
+	path := strings.TrimPrefix(r.URL.Path, "/ImageStore/")
+	switch {
+	case r.Method == "PUT" && r.Header.Get("Content-Range") == "":
+		s.files[path], _ = ioutil.ReadAll(r.Body)
+		s.uploads = append(s.uploads, path)
+	case r.Method == "PUT":
+		var start, end, size int64
+		fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &size)
+		if start == s.failAt {
+			s.failAt = -1
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		data, _ := ioutil.ReadAll(r.Body)
+		if s.sessions[id] == nil {
+			s.sessions[id] = map[int64][]byte{}
+		}
+		s.sessions[id][start] = data
+		s.paths[id] = strings.TrimSuffix(path, "/$/UploadChunk")
+		s.chunks = append(s.chunks, r.Header.Get("Content-Range"))
+	case r.URL.Path == "/ImageStore/$/GetUploadSession":
+		session := UploadSession{UploadSessions: []UploadSessionInfo{}}
+		if chunks, ok := s.sessions[id]; ok {
+			var received int64
+			for data, ok := chunks[0]; ok; data, ok = chunks[received] {
+				received += int64(len(data))
+			}
+			session.UploadSessions = append(session.UploadSessions, UploadSessionInfo{
+				StoreRelativePath: s.paths[id],
+				SessionID:         id,
+				ExpectedRanges:    []UploadChunkRange{{StartPosition: strconv.FormatInt(received, 10), EndPosition: "9"}},
+			})
+		}
+		json.NewEncoder(w).Encode(session)
+	case r.URL.Path == "/ImageStore/$/CommitUploadSession":
+		var content []byte
+		for start := int64(0); ; {
+			data, ok := s.sessions[id][start]
+			if !ok {
+				break
+			}
+			content = append(content, data...)
+			start += int64(len(data))
+		}
+		s.files[s.paths[id]] = content
+		delete(s.sessions, id)
+	case r.Method == "GET":
+		json.NewEncoder(w).Encode(ImageStoreContent{StoreFiles: []ImageStoreFile{
+			{StoreRelativePath: path, FileSize: strconv.Itoa(len(s.files[path]))},
+		}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestUploadFile(t *testing.T) {
+	store := newFakeImageStore()
+	server := httptest.NewServer(store)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	content := []byte("0123456789")
+	var progress []int64
+	err := sfClient.UploadFile(context.Background(), bytes.NewReader(content), int64(len(content)), "Store/Package.sfpkg", &UploadOptions{
+		ChunkSize: 4,
+		Progress:  func(p UploadProgress) { progress = append(progress, p.Uploaded) },
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if !bytes.Equal(store.files["Store/Package.sfpkg"], content) {
+		t.Errorf("Got %q, want %q", store.files["Store/Package.sfpkg"], content)
+	}
+	expected := []string{"bytes 0-3/10", "bytes 4-7/10", "bytes 8-9/10"}
+	if !reflect.DeepEqual(store.chunks, expected) {
+		t.Errorf("Got %+v, want %+v", store.chunks, expected)
+	}
+	if !reflect.DeepEqual(progress, []int64{4, 8, 10}) {
+		t.Errorf("Got %+v, want %+v", progress, []int64{4, 8, 10})
+	}
+}
+
+func TestUploadFileResumes(t *testing.T) {
+	store := newFakeImageStore()
+	store.failAt = 8
+	server := httptest.NewServer(store)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	dir, err := ioutil.TempDir("", "upload")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	defer os.RemoveAll(dir)
+	opts := &UploadOptions{ChunkSize: 4, StateFile: filepath.Join(dir, "upload.json")}
+
+	content := []byte("0123456789")
+	err = sfClient.UploadFile(context.Background(), bytes.NewReader(content), int64(len(content)), "Store/Package.sfpkg", opts)
+	if err == nil {
+		t.Fatal("Error should have been returned")
+	}
+
+	var progress []int64
+	opts.Progress = func(p UploadProgress) { progress = append(progress, p.Uploaded) }
+	err = sfClient.UploadFile(context.Background(), bytes.NewReader(content), int64(len(content)), "Store/Package.sfpkg", opts)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if !bytes.Equal(store.files["Store/Package.sfpkg"], content) {
+		t.Errorf("Got %q, want %q", store.files["Store/Package.sfpkg"], content)
+	}
+	expected := []string{"bytes 0-3/10", "bytes 4-7/10", "bytes 8-9/10"}
+	if !reflect.DeepEqual(store.chunks, expected) {
+		t.Errorf("Got %+v, want %+v", store.chunks, expected)
+	}
+	if !reflect.DeepEqual(progress, []int64{10}) {
+		t.Errorf("Got %+v, want %+v", progress, []int64{10})
+	}
+	if _, err := os.Stat(opts.StateFile); !os.IsNotExist(err) {
+		t.Errorf("Got %v, want the state file removed", err)
+	}
+}
+
+func TestUploadFileParallel(t *testing.T) {
+	store := newFakeImageStore()
+	store.failAt = 4
+	server := httptest.NewServer(store)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	content := []byte("0123456789")
+	var progress []int64
+	err := sfClient.UploadFile(context.Background(), bytes.NewReader(content), int64(len(content)), "Store/Package.sfpkg", &UploadOptions{
+		ChunkSize:   2,
+		Concurrency: 3,
+		ChunkRetry:  &RetryPolicy{MaxAttempts: 2},
+		Progress:    func(p UploadProgress) { progress = append(progress, p.Uploaded) },
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if !bytes.Equal(store.files["Store/Package.sfpkg"], content) {
+		t.Errorf("Got %q, want %q", store.files["Store/Package.sfpkg"], content)
+	}
+	if !reflect.DeepEqual(progress, []int64{2, 4, 6, 8, 10}) {
+		t.Errorf("Got %+v, want %+v", progress, []int64{2, 4, 6, 8, 10})
+	}
+}
+
+func TestUploadFileBandwidth(t *testing.T) {
+	server := httptest.NewServer(newFakeImageStore())
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	content := bytes.Repeat([]byte("0"), 10)
+	began := time.Now()
+	err := sfClient.UploadFile(context.Background(), bytes.NewReader(content), int64(len(content)), "Store/Package.sfpkg", &UploadOptions{
+		ChunkSize:      5,
+		Concurrency:    2,
+		BytesPerSecond: 50,
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	// the second chunk waits for the first one's 5 bytes at 50 bytes per second
+	if elapsed := time.Since(began); elapsed < 100*time.Millisecond {
+		t.Errorf("Got %s, want at least %s", elapsed, 100*time.Millisecond)
+	}
+}
+
+func TestUploadFileSizeMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			json.NewEncoder(w).Encode(ImageStoreContent{StoreFiles: []ImageStoreFile{
+				{StoreRelativePath: "Store/Package.sfpkg", FileSize: "3"},
+			}})
+		}
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	err := sfClient.UploadFile(context.Background(), bytes.NewReader([]byte("0123")), 4, "Store/Package.sfpkg", nil)
+	if !errors.Is(err, ErrUploadSizeMismatch) {
+		t.Errorf("Got %v, want %v", err, ErrUploadSizeMismatch)
+	}
+}
+
+func TestUploadApplicationPackage(t *testing.T) {
+	dir := writePackage(t, map[string]string{
+		"ApplicationManifest.xml":     "<ApplicationManifest/>",
+		"Backend/ServiceManifest.xml": "<ServiceManifest/>",
+		"Backend/Code/backend.exe":    strings.Repeat("0123456789", 4),
+	})
+	defer os.RemoveAll(dir)
+
+	store := newFakeImageStore()
+	server := httptest.NewServer(store)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	var progress []int
+	err := sfClient.UploadApplicationPackage(context.Background(), dir, "/Store/", &PackageUploadOptions{
+		Progress: func(file PackageFile, uploaded, total int) { progress = append(progress, uploaded) },
+		Chunks:   &UploadOptions{ChunkSize: 32},
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := []string{
+		"Store/ApplicationManifest.xml",
+		"Store/Backend/ServiceManifest.xml",
+		"Store/Backend/Code/_.dir",
+		"Store/Backend/_.dir",
+		"Store/_.dir",
+	}
+	if !reflect.DeepEqual(store.uploads, expected) {
+		t.Errorf("Got %+v, want %+v", store.uploads, expected)
+	}
+	if !reflect.DeepEqual(store.chunks, []string{"bytes 0-31/40", "bytes 32-39/40"}) {
+		t.Errorf("Got %+v, want backend.exe uploaded in chunks", store.chunks)
+	}
+	if string(store.files["Store/ApplicationManifest.xml"]) != "<ApplicationManifest/>" {
+		t.Errorf("Got %q, want %q", store.files["Store/ApplicationManifest.xml"], "<ApplicationManifest/>")
+	}
+	if !reflect.DeepEqual(progress, []int{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("Got %+v, want %+v", progress, []int{1, 2, 3, 4, 5, 6})
+	}
+}
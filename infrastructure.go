@@ -0,0 +1,240 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// InvokeInfrastructureQuery runs a read-only command, e.g. "GetJobs", on the
+// InfrastructureService of the cluster, or the one named serviceID, e.g.
+// "System/InfrastructureService/NodeType0" on clusters with one per
+// node type. The document the command returns is passed through as is.
+func (c ServiceFabricClient) InvokeInfrastructureQuery(ctx context.Context, command, serviceID string) (document string, err error) {
+	ctx, call := c.startCall(ctx, "InvokeInfrastructureQuery")
+	defer func() { call.finish(err) }()
+
+	return c.invokeInfrastructureQuery(ctx, command, serviceID)
+}
+
+func (c ServiceFabricClient) invokeInfrastructureQuery(ctx context.Context, command, serviceID string) (string, error) {
+	res, _, err := c.getHTTP(ctx, "$/InvokeInfrastructureQuery",
+		withParam("Command", command), withOptionalParam("ServiceId", serviceID))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed invoking infrastructure query %s", command)
+	}
+
+	// the document is returned as a JSON string, older gateways return it bare
+	var document string
+	if err := json.Unmarshal(res, &document); err != nil {
+		return string(res), nil
+	}
+	return document, nil
+}
+
+// InfrastructureJob is a maintenance job Azure runs on the role
+// instances, the virtual machines, hosting the nodes of the cluster
+type InfrastructureJob struct {
+	ID        string `json:"Id"`
+	ContextID string `json:"ContextId"`
+	// JobStatus is e.g. "Executing", "Suspended" or "Completed"
+	JobStatus string `json:"JobStatus"`
+	// ImpactAction is e.g. "TenantUpdate", "PlatformMaintenance" or "TenantMaintenance"
+	ImpactAction               string                 `json:"ImpactAction"`
+	RoleInstancesToBeImpacted  []string               `json:"RoleInstancesToBeImpacted"`
+	CurrentlyImpactedInstances []ImpactedRoleInstance `json:"CurrentlyImpactedRoleInstances"`
+	JobStep                    *InfrastructureJobStep `json:"JobStep,omitempty"`
+}
+
+// InfrastructureJobStep is the step of a job currently executing
+type InfrastructureJobStep struct {
+	// ImpactStep is e.g. "ImpactStart" or "ImpactEnd"
+	ImpactStep string `json:"ImpactStep"`
+	// AcknowledgementStatus is e.g. "WaitingForAcknowledgement" or "Acknowledged"
+	AcknowledgementStatus          string                 `json:"AcknowledgementStatus"`
+	DeadlineForResponse            string                 `json:"DeadlineForResponse"`
+	CurrentlyImpactedRoleInstances []ImpactedRoleInstance `json:"CurrentlyImpactedRoleInstances"`
+}
+
+// ImpactedRoleInstance is a role instance a job impacts
+type ImpactedRoleInstance struct {
+	Name string `json:"Name"`
+	UD   string `json:"UD"`
+	// ImpactTypes are e.g. "Reboot", "RepaveData" or "Reimage"
+	ImpactTypes []string `json:"ImpactTypes"`
+}
+
+// ImpactedNode is a node of the cluster an infrastructure job impacts
+type ImpactedNode struct {
+	NodeName     string
+	RoleInstance string
+	JobID        string
+	ImpactAction string
+	ImpactTypes  []string
+	// Current is set while the job impacts the node, and unset
+	// for nodes the job will impact in a later step
+	Current bool
+}
+
+// GetInfrastructureJobs returns the Azure maintenance jobs the
+// InfrastructureService, see InvokeInfrastructureQuery, tracks
+func (c ServiceFabricClient) GetInfrastructureJobs(ctx context.Context, serviceID string) (jobs []InfrastructureJob, err error) {
+	ctx, call := c.startCall(ctx, "GetInfrastructureJobs")
+	defer func() { call.finish(err) }()
+
+	return c.getInfrastructureJobs(ctx, serviceID)
+}
+
+func (c ServiceFabricClient) getInfrastructureJobs(ctx context.Context, serviceID string) ([]InfrastructureJob, error) {
+	document, err := c.invokeInfrastructureQuery(ctx, "GetJobs", serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := DecodeInfrastructureDocument("GetJobs", document)
+	if !decoded.Decoded {
+		return nil, fmt.Errorf("could not deserialise infrastructure jobs: %q", document)
+	}
+	return decoded.Jobs, nil
+}
+
+// InfrastructureDocument is a document returned by an InfrastructureService
+// command. GetJobs returns the list of jobs, GetCurrentState an object
+// whose Jobs member lists them along with the state of the coordinator.
+type InfrastructureDocument struct {
+	Command string
+	// Raw is the document as returned
+	Raw string
+	// Decoded is unset when the document is not JSON, or its jobs could
+	// not be decoded, in which case only Raw is set
+	Decoded bool
+	Jobs    []InfrastructureJob
+	// Properties are the undecoded members of object documents
+	Properties map[string]json.RawMessage
+}
+
+// QueryInfrastructure runs command, see InvokeInfrastructureQuery, and
+// decodes the document it returns. Documents that cannot be decoded
+// are returned raw rather than failing the call.
+func (c ServiceFabricClient) QueryInfrastructure(ctx context.Context, command, serviceID string) (document *InfrastructureDocument, err error) {
+	ctx, call := c.startCall(ctx, "QueryInfrastructure")
+	defer func() { call.finish(err) }()
+
+	raw, err := c.invokeInfrastructureQuery(ctx, command, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeInfrastructureDocument(command, raw), nil
+}
+
+// DecodeInfrastructureDocument decodes raw, the document command returned
+func DecodeInfrastructureDocument(command, raw string) *InfrastructureDocument {
+	document := &InfrastructureDocument{Command: command, Raw: raw}
+
+	trimmed := []byte(strings.TrimSpace(raw))
+	if len(trimmed) == 0 {
+		return document
+	}
+	switch trimmed[0] {
+	case '[':
+		if err := json.Unmarshal(trimmed, &document.Jobs); err != nil {
+			document.Jobs = nil
+			return document
+		}
+	case '{':
+		if err := json.Unmarshal(trimmed, &document.Properties); err != nil {
+			document.Properties = nil
+			return document
+		}
+		if jobs, ok := document.Properties["Jobs"]; ok {
+			if err := json.Unmarshal(jobs, &document.Jobs); err != nil {
+				document.Jobs, document.Properties = nil, nil
+				return document
+			}
+			delete(document.Properties, "Jobs")
+		}
+	default:
+		return document
+	}
+	document.Decoded = true
+	return document
+}
+
+// GetImpactedNodes correlates the role instances the current infrastructure
+// jobs impact, or will impact, with the nodes they host, so that the nodes
+// can be drained ahead of the impact. Role instances are matched with nodes
+// by name, case insensitively.
+func (c ServiceFabricClient) GetImpactedNodes(ctx context.Context, serviceID string) (impacted []ImpactedNode, err error) {
+	ctx, call := c.startCall(ctx, "GetImpactedNodes")
+	defer func() { call.finish(err) }()
+
+	jobs, err := c.getInfrastructureJobs(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := c.getNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return correlateImpactedNodes(jobs, nodes), nil
+}
+
+func correlateImpactedNodes(jobs []InfrastructureJob, nodes []NodeItem) []ImpactedNode {
+	nodeNames := map[string]string{}
+	for _, node := range nodes {
+		nodeNames[strings.ToLower(node.Name)] = node.Name
+	}
+
+	var impacted []ImpactedNode
+	for _, job := range jobs {
+		if job.JobStatus == "Completed" {
+			continue
+		}
+
+		seen := map[string]bool{}
+		current := job.CurrentlyImpactedInstances
+		if job.JobStep != nil && len(job.JobStep.CurrentlyImpactedRoleInstances) > 0 {
+			current = job.JobStep.CurrentlyImpactedRoleInstances
+		}
+		for _, instance := range current {
+			nodeName, ok := nodeNames[strings.ToLower(instance.Name)]
+			if !ok || seen[nodeName] {
+				continue
+			}
+			seen[nodeName] = true
+			impacted = append(impacted, ImpactedNode{
+				NodeName:     nodeName,
+				RoleInstance: instance.Name,
+				JobID:        job.ID,
+				ImpactAction: job.ImpactAction,
+				ImpactTypes:  instance.ImpactTypes,
+				Current:      true,
+			})
+		}
+		for _, instance := range job.RoleInstancesToBeImpacted {
+			nodeName, ok := nodeNames[strings.ToLower(instance)]
+			if !ok || seen[nodeName] {
+				continue
+			}
+			seen[nodeName] = true
+			impacted = append(impacted, ImpactedNode{
+				NodeName:     nodeName,
+				RoleInstance: instance,
+				JobID:        job.ID,
+				ImpactAction: job.ImpactAction,
+			})
+		}
+	}
+
+	sort.SliceStable(impacted, func(i, j int) bool {
+		if impacted[i].Current != impacted[j].Current {
+			return impacted[i].Current
+		}
+		return impacted[i].NodeName < impacted[j].NodeName
+	})
+	return impacted
+}
@@ -0,0 +1,105 @@
+package servicefabric
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGetImpactedNodes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/$/InvokeInfrastructureQuery":
+			if r.URL.RawQuery != "api-version=1.0&Command=GetJobs" {
+				http.NotFound(w, r)
+				return
+			}
+			writeFixture(w, "infrastructure_jobs.json")
+		case "/Nodes/":
+			w.Write([]byte(`{"Items":[{"Name":"_NodeType0_0"},{"Name":"_nodetype0_1"},{"Name":"_NodeType0_2"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetImpactedNodes(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := []ImpactedNode{
+		{
+			NodeName:     "_NodeType0_0",
+			RoleInstance: "_NodeType0_0",
+			JobID:        "5a2b0d7e-1c4f-4e2a-9b1d-0c7e6f5a4b3c",
+			ImpactAction: "TenantUpdate",
+			ImpactTypes:  []string{"Reboot"},
+			Current:      true,
+		},
+		{
+			NodeName:     "_nodetype0_1",
+			RoleInstance: "_NodeType0_1",
+			JobID:        "5a2b0d7e-1c4f-4e2a-9b1d-0c7e6f5a4b3c",
+			ImpactAction: "TenantUpdate",
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+}
+
+func TestInvokeInfrastructureQueryBareDocument(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery != "api-version=1.0&Command=GetCurrentState&ServiceId=System%2FInfrastructureService%2FNodeType0" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"Jobs":[]}`))
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.InvokeInfrastructureQuery(context.Background(), "GetCurrentState", "System/InfrastructureService/NodeType0")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if actual != `{"Jobs":[]}` {
+		t.Errorf("Got %+v, want %+v", actual, `{"Jobs":[]}`)
+	}
+}
+
+func TestDecodeInfrastructureDocument(t *testing.T) {
+	tests := []struct {
+		command    string
+		raw        string
+		decoded    bool
+		jobs       int
+		properties []string
+	}{
+		{"GetJobs", `[{"Id":"1","JobStatus":"Executing"},{"Id":"2"}]`, true, 2, nil},
+		{"GetCurrentState", `{"Jobs":[{"Id":"1"}],"LastUpdated":"2020-01-01T10:00:00Z"}`, true, 1, []string{"LastUpdated"}},
+		{"GetCurrentState", `{"Jobs":"none"}`, false, 0, nil},
+		{"GetRoleInstances", "_NodeType0_0,_NodeType0_1", false, 0, nil},
+		{"GetJobs", "", false, 0, nil},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.command, func(t *testing.T) {
+			actual := DecodeInfrastructureDocument(test.command, test.raw)
+			if actual.Raw != test.raw || actual.Decoded != test.decoded || len(actual.Jobs) != test.jobs {
+				t.Errorf("Got %+v, want %d jobs decoded %v", actual, test.jobs, test.decoded)
+			}
+			for _, property := range test.properties {
+				if _, ok := actual.Properties[property]; !ok {
+					t.Errorf("Got %+v, want property %s", actual.Properties, property)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package labels
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const tagName = "label"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Bind validates labels against the schema and then stores them
+// in the struct v points to, see the package documentation for tags
+func (s *Schema) Bind(service string, labels map[string]string, v interface{}) error {
+	if err := s.Validate(service, labels); err != nil {
+		return err
+	}
+	return Bind(service, labels, v)
+}
+
+// Bind stores labels in the struct v points to without validating them
+// against a schema. Fields whose label is absent are left untouched and
+// values that cannot be converted are reported together in an *Errors.
+func Bind(service string, labels map[string]string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("labels can only be bound to a non-nil struct pointer, got %T", v)
+	}
+
+	errs := &Errors{Service: service}
+	rv = rv.Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag, ok := field.Tag.Lookup(tagName)
+		if !ok || tag == "-" || field.PkgPath != "" {
+			continue
+		}
+
+		key, opts := parseTag(tag)
+		target := rv.Field(i)
+		if opts == "prefix" {
+			if err := bindPrefix(target, key, labels); err != nil {
+				errs.add(key, err.Error())
+			}
+			continue
+		}
+
+		value, ok := labels[key]
+		if !ok {
+			continue
+		}
+		if err := setValue(target, value); err != nil {
+			errs.add(key, err.Error())
+		}
+	}
+
+	return errs.orNil()
+}
+
+func parseTag(tag string) (string, string) {
+	if i := strings.Index(tag, ","); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
+
+func bindPrefix(target reflect.Value, prefix string, labels map[string]string) error {
+	if target.Type() != reflect.TypeOf(map[string]string(nil)) {
+		return fmt.Errorf("prefix labels can only be bound to map[string]string, got %s", target.Type())
+	}
+
+	m := map[string]string{}
+	for key, value := range labels {
+		if strings.HasPrefix(key, prefix) {
+			m[strings.TrimPrefix(key, prefix)] = value
+		}
+	}
+	if len(m) > 0 {
+		target.Set(reflect.ValueOf(m))
+	}
+	return nil
+}
+
+func setValue(target reflect.Value, value string) error {
+	if target.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("value %q is not a valid duration", value)
+		}
+		target.SetInt(int64(d))
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("value %q is not a valid bool", value)
+		}
+		target.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, target.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("value %q is not a valid %s", value, target.Type())
+		}
+		target.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, target.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("value %q is not a valid %s", value, target.Type())
+		}
+		target.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, target.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("value %q is not a valid %s", value, target.Type())
+		}
+		target.SetFloat(f)
+	case reflect.Slice:
+		if target.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported field type %s", target.Type())
+		}
+		values := splitList(value)
+		slice := reflect.MakeSlice(target.Type(), len(values), len(values))
+		for i, v := range values {
+			slice.Index(i).SetString(v)
+		}
+		target.Set(slice)
+	default:
+		return fmt.Errorf("unsupported field type %s", target.Type())
+	}
+	return nil
+}
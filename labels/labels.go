@@ -0,0 +1,257 @@
+// Package labels validates Service Fabric extension labels against a
+// caller-registered schema and binds them into tagged structs.
+//
+// Labels are the key/value pairs returned by
+// ServiceFabricClient.GetServiceExtensionMap. A Schema describes which keys
+// are expected, the type their values must parse as and, optionally, the set
+// of values allowed. Struct fields are bound with a `label` tag:
+//
+//	type Frontend struct {
+//		Enabled bool              `label:"proxy.enable"`
+//		Port    int               `label:"proxy.port"`
+//		Hosts   []string          `label:"proxy.hosts"`
+//		Headers map[string]string `label:"proxy.headers.,prefix"`
+//	}
+package labels
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the type a label value must parse as
+type Kind int
+
+const (
+	// String accepts any value
+	String Kind = iota
+	// Bool accepts values understood by strconv.ParseBool
+	Bool
+	// Int accepts base 10 signed integers
+	Int
+	// Float accepts floating point numbers
+	Float
+	// Duration accepts values understood by time.ParseDuration
+	Duration
+	// List accepts comma separated values
+	List
+)
+
+func (k Kind) String() string {
+	switch k {
+	case String:
+		return "string"
+	case Bool:
+		return "bool"
+	case Int:
+		return "int"
+	case Float:
+		return "float"
+	case Duration:
+		return "duration"
+	case List:
+		return "list"
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Rule constrains a single label key, or every key
+// sharing a prefix when Prefix is set
+type Rule struct {
+	Key      string
+	Prefix   bool
+	Kind     Kind
+	Required bool
+	// Allowed restricts the value, or each list element, to one of
+	// the given values. An empty slice allows any value of the Kind.
+	Allowed []string
+}
+
+func (r Rule) matches(key string) bool {
+	if r.Prefix {
+		return strings.HasPrefix(key, r.Key)
+	}
+	return key == r.Key
+}
+
+// Schema is a set of rules label maps are validated against
+type Schema struct {
+	rules        []Rule
+	allowUnknown bool
+}
+
+// NewSchema creates an empty schema. Keys not matched by any
+// registered rule are reported unless AllowUnknown is called.
+func NewSchema() *Schema {
+	return &Schema{}
+}
+
+// AllowUnknown stops the schema from reporting keys no rule matches
+func (s *Schema) AllowUnknown() *Schema {
+	s.allowUnknown = true
+	return s
+}
+
+// Register adds rules to the schema. Exact key rules take precedence
+// over prefix rules, and longer prefixes over shorter ones.
+func (s *Schema) Register(rules ...Rule) error {
+	for _, rule := range rules {
+		if rule.Key == "" {
+			return fmt.Errorf("label rule has an empty key")
+		}
+		if rule.Prefix && rule.Required {
+			return fmt.Errorf("prefix label rule %q cannot be required", rule.Key)
+		}
+		for _, existing := range s.rules {
+			if existing.Key == rule.Key && existing.Prefix == rule.Prefix {
+				return fmt.Errorf("label rule %q is already registered", rule.Key)
+			}
+		}
+		s.rules = append(s.rules, rule)
+	}
+	return nil
+}
+
+func (s *Schema) ruleFor(key string) (Rule, bool) {
+	var match Rule
+	found := false
+	for _, rule := range s.rules {
+		if !rule.matches(key) {
+			continue
+		}
+		if !rule.Prefix {
+			return rule, true
+		}
+		if !found || len(rule.Key) > len(match.Key) {
+			match, found = rule, true
+		}
+	}
+	return match, found
+}
+
+// Validate checks labels against the schema and returns
+// an *Errors listing every violation found for service
+func (s *Schema) Validate(service string, labels map[string]string) error {
+	errs := &Errors{Service: service}
+
+	for _, rule := range s.rules {
+		if !rule.Required {
+			continue
+		}
+		if _, ok := labels[rule.Key]; !ok {
+			errs.add(rule.Key, "required label is missing")
+		}
+	}
+
+	for _, key := range sortedKeys(labels) {
+		rule, ok := s.ruleFor(key)
+		if !ok {
+			if !s.allowUnknown {
+				errs.add(key, "unknown label")
+			}
+			continue
+		}
+		if err := checkValue(rule, labels[key]); err != nil {
+			errs.add(key, err.Error())
+		}
+	}
+
+	return errs.orNil()
+}
+
+func checkValue(rule Rule, value string) error {
+	values := []string{value}
+	if rule.Kind == List {
+		values = splitList(value)
+	}
+
+	for _, v := range values {
+		if err := parseKind(rule.Kind, v); err != nil {
+			return err
+		}
+		if len(rule.Allowed) > 0 && !contains(rule.Allowed, v) {
+			return fmt.Errorf("value %q is not one of %s", v, strings.Join(rule.Allowed, ", "))
+		}
+	}
+	return nil
+}
+
+func parseKind(kind Kind, value string) error {
+	var err error
+	switch kind {
+	case Bool:
+		_, err = strconv.ParseBool(value)
+	case Int:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case Float:
+		_, err = strconv.ParseFloat(value, 64)
+	case Duration:
+		_, err = time.ParseDuration(value)
+	}
+	if err != nil {
+		return fmt.Errorf("value %q is not a valid %s", value, kind)
+	}
+	return nil
+}
+
+// Violation describes why a single label failed validation
+type Violation struct {
+	Key    string
+	Reason string
+}
+
+// Errors aggregates the label violations of a single service
+type Errors struct {
+	Service    string
+	Violations []Violation
+}
+
+func (e *Errors) add(key, reason string) {
+	e.Violations = append(e.Violations, Violation{Key: key, Reason: reason})
+}
+
+func (e *Errors) orNil() error {
+	if len(e.Violations) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *Errors) Error() string {
+	reasons := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		reasons = append(reasons, v.Key+": "+v.Reason)
+	}
+	return fmt.Sprintf("invalid labels for service %s: %s", e.Service, strings.Join(reasons, "; "))
+}
+
+func splitList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,159 @@
+package labels
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestSchema(t *testing.T) *Schema {
+	schema := NewSchema()
+	err := schema.Register(
+		Rule{Key: "proxy.enable", Kind: Bool, Required: true},
+		Rule{Key: "proxy.port", Kind: Int},
+		Rule{Key: "proxy.scheme", Allowed: []string{"http", "https"}},
+		Rule{Key: "proxy.timeout", Kind: Duration},
+		Rule{Key: "proxy.hosts", Kind: List},
+		Rule{Key: "proxy.headers.", Prefix: true},
+	)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	return schema
+}
+
+func TestValidate(t *testing.T) {
+	schema := newTestSchema(t)
+
+	labels := map[string]string{
+		"proxy.enable":       "true",
+		"proxy.port":         "8080",
+		"proxy.scheme":       "https",
+		"proxy.timeout":      "30s",
+		"proxy.hosts":        "a.example.com, b.example.com",
+		"proxy.headers.X-Id": "1",
+	}
+
+	if err := schema.Validate("fabric:/App/Svc", labels); err != nil {
+		t.Errorf("Should not have thrown: %v", err)
+	}
+}
+
+func TestValidateAggregatesViolations(t *testing.T) {
+	schema := newTestSchema(t)
+
+	labels := map[string]string{
+		"proxy.port":   "eighty",
+		"proxy.scheme": "ftp",
+		"proxy.other":  "x",
+	}
+
+	err := schema.Validate("fabric:/App/Svc", labels)
+	errs, ok := err.(*Errors)
+	if !ok {
+		t.Fatalf("Got %T, want *Errors", err)
+	}
+
+	expected := &Errors{
+		Service: "fabric:/App/Svc",
+		Violations: []Violation{
+			{Key: "proxy.enable", Reason: "required label is missing"},
+			{Key: "proxy.other", Reason: "unknown label"},
+			{Key: "proxy.port", Reason: `value "eighty" is not a valid int`},
+			{Key: "proxy.scheme", Reason: `value "ftp" is not one of http, https`},
+		},
+	}
+
+	if !reflect.DeepEqual(errs, expected) {
+		t.Errorf("Got %+v, want %+v", errs, expected)
+	}
+}
+
+func TestRegisterDuplicateRule(t *testing.T) {
+	schema := newTestSchema(t)
+
+	if err := schema.Register(Rule{Key: "proxy.port"}); err == nil {
+		t.Error("Error should have been returned")
+	}
+}
+
+type frontend struct {
+	Enabled bool              `label:"proxy.enable"`
+	Port    int               `label:"proxy.port"`
+	Scheme  string            `label:"proxy.scheme"`
+	Timeout time.Duration     `label:"proxy.timeout"`
+	Hosts   []string          `label:"proxy.hosts"`
+	Headers map[string]string `label:"proxy.headers.,prefix"`
+	Ignored string
+}
+
+func TestBind(t *testing.T) {
+	schema := newTestSchema(t)
+
+	labels := map[string]string{
+		"proxy.enable":       "true",
+		"proxy.port":         "8080",
+		"proxy.timeout":      "30s",
+		"proxy.hosts":        "a.example.com, b.example.com",
+		"proxy.headers.X-Id": "1",
+	}
+
+	actual := frontend{Scheme: "http"}
+	if err := schema.Bind("fabric:/App/Svc", labels, &actual); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := frontend{
+		Enabled: true,
+		Port:    8080,
+		Scheme:  "http",
+		Timeout: 30 * time.Second,
+		Hosts:   []string{"a.example.com", "b.example.com"},
+		Headers: map[string]string{"X-Id": "1"},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+}
+
+type host string
+
+func TestBindNamedElementTypes(t *testing.T) {
+	var actual struct {
+		Hosts []host `label:"proxy.hosts"`
+	}
+	if err := Bind("fabric:/App/Svc", map[string]string{"proxy.hosts": "a.example.com, b.example.com"}, &actual); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := []host{"a.example.com", "b.example.com"}
+	if !reflect.DeepEqual(actual.Hosts, expected) {
+		t.Errorf("Got %+v, want %+v", actual.Hosts, expected)
+	}
+}
+
+func TestBindWithoutSchemaReportsConversionErrors(t *testing.T) {
+	labels := map[string]string{
+		"proxy.enable": "maybe",
+		"proxy.port":   "eighty",
+	}
+
+	var actual frontend
+	err := Bind("fabric:/App/Svc", labels, &actual)
+	errs, ok := err.(*Errors)
+	if !ok {
+		t.Fatalf("Got %T, want *Errors", err)
+	}
+
+	if len(errs.Violations) != 2 {
+		t.Errorf("Got %d violations, want 2", len(errs.Violations))
+	}
+}
+
+func TestBindRequiresStructPointer(t *testing.T) {
+	var actual frontend
+	if err := Bind("fabric:/App/Svc", nil, actual); err == nil {
+		t.Error("Error should have been returned")
+	}
+}
@@ -0,0 +1,420 @@
+package servicefabric
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// HostTypeContainer is the host type of code packages running in a container
+const HostTypeContainer = "ContainerHost"
+
+// containerLogsAPIVersion is the first API version serving container logs
+const containerLogsAPIVersion = "6.2"
+
+// RestartBudget bounds the restarts a helper issues, so that remediation
+// cannot take down every replica of a service at once
+type RestartBudget struct {
+	// MaxConcurrent is the number of restarts in flight at once, one when zero
+	MaxConcurrent int
+	// CoolDown is the least time between the start of two restarts, none when zero
+	CoolDown time.Duration
+	// MinHealthyReplicas is the number of ready replicas or instances in the
+	// Ok health state every partition of the services a code package hosts
+	// must keep on other nodes for the code package to restart, unchecked
+	// when zero. Restarts that would leave fewer fail with
+	// ErrRestartBudgetExceeded.
+	MinHealthyReplicas int
+}
+
+// ErrRestartBudgetExceeded is returned by restarts that would leave a
+// partition with fewer healthy replicas than RestartBudget allows
+var ErrRestartBudgetExceeded = errors.New("restart would leave too few healthy replicas")
+
+// restartGuard enforces a RestartBudget across the restarts of one helper
+// call, or of every call of a client, see WithRestartBudget
+type restartGuard struct {
+	slots      chan struct{}
+	coolDown   time.Duration
+	minHealthy int
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newRestartGuard(budget *RestartBudget) *restartGuard {
+	maxConcurrent := 1
+	var coolDown time.Duration
+	var minHealthy int
+	if budget != nil {
+		if budget.MaxConcurrent > 0 {
+			maxConcurrent = budget.MaxConcurrent
+		}
+		coolDown = budget.CoolDown
+		minHealthy = budget.MinHealthyReplicas
+	}
+	return &restartGuard{slots: make(chan struct{}, maxConcurrent), coolDown: coolDown, minHealthy: minHealthy}
+}
+
+// acquire blocks until a restart may start, release must be called once it ended
+func (g *restartGuard) acquire(ctx context.Context) error {
+	select {
+	case g.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	g.mu.Lock()
+	now := time.Now()
+	if g.next.Before(now) {
+		g.next = now
+	}
+	at := g.next
+	g.next = g.next.Add(g.coolDown)
+	g.mu.Unlock()
+
+	if d := time.Until(at); d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			g.release()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return nil
+}
+
+func (g *restartGuard) release() {
+	<-g.slots
+}
+
+// LogCollectionOptions tunes CollectServiceLogs
+type LogCollectionOptions struct {
+	// Tail limits the container logs collected to their last lines, all when zero
+	Tail int
+	// Previous collects the logs of the container that exited last,
+	// e.g. to find why a container is restarting
+	Previous bool
+	// Restart restarts the code packages once their logs were collected.
+	// Processes crashing on restart are dumped as configured for the
+	// cluster, the REST API cannot take dumps itself.
+	Restart bool
+	// RestartBudget bounds the restarts, one at a time with no cool-down when nil
+	RestartBudget *RestartBudget
+}
+
+// CollectedCodePackage reports a code package CollectServiceLogs visited
+type CollectedCodePackage struct {
+	NodeName            string
+	ServiceManifestName string
+	CodePackageName     string
+	HostType            string
+	// Archived is the name of the archive entry holding the container
+	// logs, empty for code packages not running in a container
+	Archived string
+	// Restarted is set when the code package was restarted
+	Restarted bool
+}
+
+// DeployedCodePackageRestart identifies the code package activation to restart
+type DeployedCodePackageRestart struct {
+	ServiceManifestName        string `json:"ServiceManifestName"`
+	ServicePackageActivationID string `json:"ServicePackageActivationId"`
+	CodePackageName            string `json:"CodePackageName"`
+	// CodePackageInstanceID is the InstanceId of the main entry point,
+	// the restart failing when the code package was activated since
+	CodePackageInstanceID string `json:"CodePackageInstanceId"`
+}
+
+// RestartDeployedCodePackage restarts a code package of an application
+// deployed on a node, within the budget of WithRestartBudget if set
+func (c ServiceFabricClient) RestartDeployedCodePackage(ctx context.Context, nodeName, appID string, restart DeployedCodePackageRestart) (err error) {
+	ctx, call := c.startCall(ctx, "RestartDeployedCodePackage")
+	defer func() { call.finish(err) }()
+
+	return c.restartDeployedCodePackage(ctx, nodeName, appID, restart, nil)
+}
+
+// restartDeployedCodePackage restarts a code package within the budgets of
+// guard, if not nil, and of the client
+func (c ServiceFabricClient) restartDeployedCodePackage(ctx context.Context, nodeName, appID string, restart DeployedCodePackageRestart, guard *restartGuard) error {
+	minHealthy := 0
+	for _, g := range []*restartGuard{guard, c.restartGuard} {
+		if g == nil {
+			continue
+		}
+		if err := g.acquire(ctx); err != nil {
+			return err
+		}
+		defer g.release()
+		if g.minHealthy > minHealthy {
+			minHealthy = g.minHealthy
+		}
+	}
+
+	target := nodeName + "/" + appID + "/" + restart.ServiceManifestName + "/" + restart.CodePackageName
+	if minHealthy > 0 {
+		if err := c.checkHealthyReplicas(ctx, nodeName, appID, restart.ServiceManifestName, minHealthy); err != nil {
+			return errors.Wrapf(err, "failed restarting code package %s", target)
+		}
+	}
+
+	body, err := json.Marshal(restart)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.postHTTP(ctx, opRestartDeployedCodePackage.on(target),
+		"Nodes/"+nodeName+"/$/GetApplications/"+appID+"/$/GetCodePackages/$/Restart", body)
+	if err != nil {
+		return errors.Wrapf(err, "failed restarting code package %s", target)
+	}
+	return nil
+}
+
+// checkHealthyReplicas checks that the partitions of the services of the
+// service manifest keep minHealthy healthy replicas on nodes other than
+// nodeName
+func (c ServiceFabricClient) checkHealthyReplicas(ctx context.Context, nodeName, appID, serviceManifestName string, minHealthy int) error {
+	apps, err := c.getApplications(ctx, func(app *ApplicationItem) bool { return app.ID == appID })
+	if err != nil {
+		return err
+	}
+	if len(apps.Items) == 0 {
+		return errors.Wrapf(ErrResourceNotFound, "application %s", appID)
+	}
+	services, err := c.servicesByManifest(ctx, apps.Items[0])
+	if err != nil {
+		return err
+	}
+
+	for _, serviceName := range services[serviceManifestName] {
+		instances, err := c.getServiceInstances(ctx, serviceName)
+		if err != nil {
+			return err
+		}
+		onNode := map[string]bool{}
+		healthy := map[string]int{}
+		for _, instance := range instances {
+			switch {
+			case instance.NodeName == nodeName:
+				onNode[instance.PartitionID] = true
+			case instance.HealthState == "Ok":
+				healthy[instance.PartitionID]++
+			}
+		}
+		for partitionID := range onNode {
+			if healthy[partitionID] < minHealthy {
+				return errors.Wrapf(ErrRestartBudgetExceeded, "partition %s of %s has %d healthy replicas on other nodes, want %d",
+					partitionID, serviceName, healthy[partitionID], minHealthy)
+			}
+		}
+	}
+	return nil
+}
+
+// GetContainerLogs returns the logs of the container of a code package
+// deployed on a node, limited to its last tail lines unless zero
+func (c ServiceFabricClient) GetContainerLogs(ctx context.Context, nodeName, appID, serviceManifestName, codePackageName string, tail int, previous bool) (logs string, err error) {
+	ctx, call := c.startCall(ctx, "GetContainerLogs")
+	defer func() { call.finish(err) }()
+
+	return c.getContainerLogs(ctx, nodeName, appID, serviceManifestName, codePackageName, tail, previous)
+}
+
+func (c ServiceFabricClient) getContainerLogs(ctx context.Context, nodeName, appID, serviceManifestName, codePackageName string, tail int, previous bool) (string, error) {
+	params := []queryParamsFunc{
+		withMinAPIVersion(containerLogsAPIVersion),
+		withParam("ServiceManifestName", serviceManifestName),
+		withParam("CodePackageName", codePackageName),
+	}
+	if tail > 0 {
+		params = append(params, withParam("Tail", strconv.Itoa(tail)))
+	}
+	if previous {
+		params = append(params, withParam("Previous", "true"))
+	}
+
+	res, _, err := c.getHTTP(ctx, "Nodes/"+nodeName+"/$/GetApplications/"+appID+"/$/GetCodePackages/$/ContainerLogs", params...)
+	if err != nil {
+		return "", errors.Wrap(err, "failed getting container logs")
+	}
+
+	var logs struct {
+		Content string `json:"Content"`
+	}
+	err = json.Unmarshal(res, &logs)
+	if err != nil {
+		return "", fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return logs.Content, nil
+}
+
+// CollectServiceLogs finds the nodes hosting the replicas or instances of
+// a service and writes the logs of their containers to w as a zip archive,
+// with an entry per node and code package. Nodes are visited in parallel,
+// see WithConcurrency, while restarts are bounded by opts.RestartBudget
+// and by WithRestartBudget.
+func (c ServiceFabricClient) CollectServiceLogs(ctx context.Context, appID, serviceName string, w io.Writer, opts LogCollectionOptions) (collected []CollectedCodePackage, err error) {
+	ctx, call := c.startCall(ctx, "CollectServiceLogs")
+	defer func() { call.finish(err) }()
+
+	apps, err := c.getApplications(ctx, func(app *ApplicationItem) bool { return app.ID == appID })
+	if err != nil {
+		return nil, err
+	}
+	if len(apps.Items) == 0 {
+		return nil, errors.Wrapf(ErrResourceNotFound, "application %s", appID)
+	}
+	services, err := c.servicesByManifest(ctx, apps.Items[0])
+	if err != nil {
+		return nil, err
+	}
+	manifestName := ""
+	for manifest, names := range services {
+		for _, name := range names {
+			if name == serviceName {
+				manifestName = manifest
+			}
+		}
+	}
+	if manifestName == "" {
+		return nil, errors.Wrapf(ErrResourceNotFound, "service %s", serviceName)
+	}
+
+	nodes, err := c.serviceNodes(ctx, appID, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	guard := newRestartGuard(opts.RestartBudget)
+	var mu sync.Mutex
+	logs := map[string]string{}
+	err = c.forEach(len(nodes), func(i int) error {
+		nodeName := nodes[i]
+		packages, err := c.getDeployedCodePackages(ctx, nodeName, appID, manifestName, "")
+		if err != nil {
+			return err
+		}
+		for _, pkg := range packages {
+			entry := CollectedCodePackage{
+				NodeName:            nodeName,
+				ServiceManifestName: pkg.ServiceManifestName,
+				CodePackageName:     pkg.Name,
+				HostType:            pkg.HostType,
+			}
+			if pkg.HostType == HostTypeContainer {
+				content, err := c.getContainerLogs(ctx, nodeName, appID, pkg.ServiceManifestName, pkg.Name, opts.Tail, opts.Previous)
+				if err != nil {
+					return err
+				}
+				entry.Archived = path.Join(nodeName, pkg.ServiceManifestName, pkg.Name+".log")
+				mu.Lock()
+				logs[entry.Archived] = content
+				mu.Unlock()
+			}
+			if opts.Restart && pkg.MainEntryPoint != nil {
+				err := c.restartDeployedCodePackage(ctx, nodeName, appID, DeployedCodePackageRestart{
+					ServiceManifestName:        pkg.ServiceManifestName,
+					ServicePackageActivationID: pkg.ServicePackageActivationID,
+					CodePackageName:            pkg.Name,
+					CodePackageInstanceID:      pkg.MainEntryPoint.InstanceID,
+				}, guard)
+				if err != nil {
+					return err
+				}
+				entry.Restarted = true
+			}
+
+			mu.Lock()
+			collected = append(collected, entry)
+			mu.Unlock()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(collected, func(i, j int) bool {
+		a, b := collected[i], collected[j]
+		if a.NodeName != b.NodeName {
+			return a.NodeName < b.NodeName
+		}
+		return a.CodePackageName < b.CodePackageName
+	})
+
+	archive := zip.NewWriter(w)
+	for _, entry := range collected {
+		if entry.Archived == "" {
+			continue
+		}
+		f, err := archive.Create(entry.Archived)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, logs[entry.Archived]); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return collected, nil
+}
+
+// serviceNodes returns the names of the nodes hosting a replica or an
+// instance of serviceName, from the replicas of each of its partitions
+func (c ServiceFabricClient) serviceNodes(ctx context.Context, appID, serviceName string) ([]string, error) {
+	serviceID := serviceIDFromName(serviceName)
+	partitions, err := c.getPartitions(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	hosting := map[string]bool{}
+	for _, partition := range partitions.Items {
+		err := c.getPartitionReplicas(ctx, appID, serviceID, partition.PartitionInformation.ID, func(res []byte) (*string, error) {
+			var replicaItemsPage ReplicaItemsPage
+			if err := json.Unmarshal(res, &replicaItemsPage); err != nil {
+				return nil, err
+			}
+			for _, replica := range replicaItemsPage.Items {
+				if replica.ReplicaItemBase != nil && replica.NodeName != "" {
+					hosting[replica.NodeName] = true
+				}
+			}
+			return replicaItemsPage.ContinuationToken, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	nodes := make([]string, 0, len(hosting))
+	for node := range hosting {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package servicefabric
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// codePackageServer serves TestService, whose single replica runs in the
+// Code container on _Node_0, recording the restarts it receives
+func codePackageServer(t *testing.T, restarts *[]map[string]interface{}) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Applications/", handleApplications)
+	mux.HandleFunc("/Applications/TestApplication/$/GetServices", handleServices)
+	mux.HandleFunc("/ApplicationTypes/", handleServiceTypes)
+	mux.HandleFunc("/Services/TestApplication~TestService/$/GetPartitions", func(w http.ResponseWriter, r *http.Request) {
+		writeFixture(w, "partitions.json")
+	})
+	mux.HandleFunc("/Partitions/bce46a8c-b62d-4996-89dc-7ffc00a96902/$/GetReplicas", handleReplicas)
+	mux.HandleFunc("/Nodes/_Node_0/$/GetApplications/TestApplication/$/GetCodePackages", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery != "api-version=1.0&ServiceManifestName=TestServicePkg" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`[{"Name":"Code","ServiceManifestName":"TestServicePkg","HostType":"ContainerHost","MainEntryPoint":{"InstanceId":"131234567890123457"}}]`))
+	})
+	mux.HandleFunc("/Nodes/_Node_0/$/GetApplications/TestApplication/$/GetCodePackages/$/ContainerLogs", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery != "api-version=6.2&ServiceManifestName=TestServicePkg&CodePackageName=Code&Tail=100" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"Content":"listening on :80\n"}`))
+	})
+	mux.HandleFunc("/Nodes/_Node_0/$/GetApplications/TestApplication/$/GetCodePackages/$/Restart", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Exception thrown %v", err)
+		}
+		*restarts = append(*restarts, body)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestCollectServiceLogs(t *testing.T) {
+	var restarts []map[string]interface{}
+	server := codePackageServer(t, &restarts)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	var archive bytes.Buffer
+	actual, err := sfClient.CollectServiceLogs(context.Background(), "TestApplication", "fabric:/TestApplication/TestService", &archive,
+		LogCollectionOptions{Tail: 100, Restart: true})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := []CollectedCodePackage{{
+		NodeName:            "_Node_0",
+		ServiceManifestName: "TestServicePkg",
+		CodePackageName:     "Code",
+		HostType:            HostTypeContainer,
+		Archived:            "_Node_0/TestServicePkg/Code.log",
+		Restarted:           true,
+	}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+
+	expectedRestarts := []map[string]interface{}{{
+		"ServiceManifestName":        "TestServicePkg",
+		"ServicePackageActivationId": "",
+		"CodePackageName":            "Code",
+		"CodePackageInstanceId":      "131234567890123457",
+	}}
+	if !reflect.DeepEqual(restarts, expectedRestarts) {
+		t.Errorf("Got %+v, want %+v", restarts, expectedRestarts)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if len(reader.File) != 1 || reader.File[0].Name != "_Node_0/TestServicePkg/Code.log" {
+		t.Fatalf("Got %+v, want a single log entry", reader.File)
+	}
+	f, err := reader.File[0].Open()
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	defer f.Close()
+	content, _ := ioutil.ReadAll(f)
+	if string(content) != "listening on :80\n" {
+		t.Errorf("Got %+v, want %+v", string(content), "listening on :80\n")
+	}
+}
+
+func TestCollectServiceLogsServiceNotFound(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Applications/", handleApplications)
+	mux.HandleFunc("/Applications/TestApplication/$/GetServices", handleServices)
+	mux.HandleFunc("/ApplicationTypes/", handleServiceTypes)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	_, err := sfClient.CollectServiceLogs(context.Background(), "TestApplication", "fabric:/TestApplication/Missing", ioutil.Discard, LogCollectionOptions{})
+	if !errors.Is(err, ErrResourceNotFound) {
+		t.Errorf("Got %v, want %v", err, ErrResourceNotFound)
+	}
+}
+
+func TestCollectServiceLogsKeepsHealthyReplicas(t *testing.T) {
+	var restarts []map[string]interface{}
+	server := codePackageServer(t, &restarts)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	_, err := sfClient.CollectServiceLogs(context.Background(), "TestApplication", "fabric:/TestApplication/TestService", ioutil.Discard,
+		LogCollectionOptions{Tail: 100, Restart: true, RestartBudget: &RestartBudget{MinHealthyReplicas: 1}})
+	if !errors.Is(err, ErrRestartBudgetExceeded) {
+		t.Errorf("Got %v, want %v", err, ErrRestartBudgetExceeded)
+	}
+	if len(restarts) != 0 {
+		t.Errorf("Got %+v, want no restart", restarts)
+	}
+}
+
+func TestRestartDeployedCodePackageWithinBudget(t *testing.T) {
+	var restarts []map[string]interface{}
+	server := codePackageServer(t, &restarts)
+	defer server.Close()
+
+	restart := DeployedCodePackageRestart{ServiceManifestName: "TestServicePkg", CodePackageName: "Code", CodePackageInstanceID: "131234567890123457"}
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil, WithRestartBudget(RestartBudget{MinHealthyReplicas: 1}))
+	err := sfClient.RestartDeployedCodePackage(context.Background(), "_Node_0", "TestApplication", restart)
+	if !errors.Is(err, ErrRestartBudgetExceeded) {
+		t.Errorf("Got %v, want %v", err, ErrRestartBudgetExceeded)
+	}
+
+	// the code packages of other service manifests host no replica of TestService
+	restart.ServiceManifestName = "OtherServicePkg"
+	if err := sfClient.RestartDeployedCodePackage(context.Background(), "_Node_0", "TestApplication", restart); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if len(restarts) != 1 {
+		t.Errorf("Got %+v, want a single restart", restarts)
+	}
+}
+
+func TestRestartGuard(t *testing.T) {
+	guard := newRestartGuard(&RestartBudget{MaxConcurrent: 2, CoolDown: 50 * time.Millisecond})
+
+	began := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := guard.acquire(context.Background()); err != nil {
+			t.Fatalf("Exception thrown %v", err)
+		}
+	}
+	// the second restart waits out the cool-down of the first one
+	if elapsed := time.Since(began); elapsed < 50*time.Millisecond {
+		t.Errorf("Got %s, want at least %s", elapsed, 50*time.Millisecond)
+	}
+
+	// both slots are taken until a restart ends
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := guard.acquire(ctx); err == nil {
+		t.Error("Error should have been returned")
+	}
+	guard.release()
+	if err := guard.acquire(context.Background()); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+}
@@ -0,0 +1,430 @@
+package servicefabric
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ChangeKind tells how a manifest item changed between two type versions
+type ChangeKind string
+
+// Manifest change kinds
+const (
+	ChangeAdded    ChangeKind = "Added"
+	ChangeRemoved  ChangeKind = "Removed"
+	ChangeModified ChangeKind = "Modified"
+)
+
+// ManifestDiff lists the differences between the manifests of two versions of an application type
+type ManifestDiff struct {
+	TypeName    string
+	FromVersion string
+	ToVersion   string
+	// Parameters lists the application parameters whose default value changed
+	Parameters []SettingChange
+	// ServiceManifests lists the imported service manifests that changed
+	ServiceManifests []ServiceManifestDiff
+
+	// configParameters holds the application parameters
+	// referenced by the config overrides of either version
+	configParameters map[string]bool
+}
+
+// UpgradeImpact tells whether an upgrade restarts code packages
+type UpgradeImpact struct {
+	// ConfigOnly is set when the upgrade changes nothing but config packages
+	// and settings overrides, which Service Fabric rolls out to running
+	// code packages without restarting them unless the upgrade forces it
+	ConfigOnly bool
+	// Reasons lists the changes that rule out a config only upgrade
+	Reasons []string
+}
+
+// SettingChange describes a changed parameter, From or To
+// being empty when it was added or removed
+type SettingChange struct {
+	Name   string
+	Change ChangeKind
+	From   string
+	To     string
+}
+
+// ServiceManifestDiff lists the differences of a service manifest, the
+// packages and endpoints being compared only when its version changed
+type ServiceManifestDiff struct {
+	Name           string
+	Change         ChangeKind
+	FromVersion    string
+	ToVersion      string
+	CodePackages   []PackageChange
+	ConfigPackages []PackageChange
+	DataPackages   []PackageChange
+	Endpoints      []EndpointChange
+	// Sections lists the settings sections whose
+	// overrides changed in the application manifest
+	Sections []SectionChange
+}
+
+// PackageChange describes a changed code, config or data package
+type PackageChange struct {
+	Name        string
+	Change      ChangeKind
+	FromVersion string
+	ToVersion   string
+}
+
+// EndpointChange describes a changed endpoint resource,
+// From or To being nil when it was added or removed
+type EndpointChange struct {
+	Name   string
+	Change ChangeKind
+	From   *ServiceManifestEndpoint
+	To     *ServiceManifestEndpoint
+}
+
+// SectionChange describes the changed overrides of a settings section
+type SectionChange struct {
+	ConfigPackage string
+	Section       string
+	Change        ChangeKind
+	Parameters    []SettingChange
+}
+
+// DiffApplicationManifests compares the application manifests of two versions
+// of an application type, along with the service manifests whose version differs
+func (c ServiceFabricClient) DiffApplicationManifests(ctx context.Context, typeName, fromVersion, toVersion string) (diff *ManifestDiff, err error) {
+	ctx, call := c.startCall(ctx, "DiffApplicationManifests")
+	defer func() { call.finish(err) }()
+
+	return c.diffApplicationManifests(ctx, typeName, fromVersion, toVersion)
+}
+
+func (c ServiceFabricClient) diffApplicationManifests(ctx context.Context, typeName, fromVersion, toVersion string) (*ManifestDiff, error) {
+	from, err := c.getApplicationManifest(ctx, typeName, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to, err := c.getApplicationManifest(ctx, typeName, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &ManifestDiff{
+		TypeName:         typeName,
+		FromVersion:      fromVersion,
+		ToVersion:        toVersion,
+		Parameters:       diffSettings(parameterValues(from.Parameters), parameterValues(to.Parameters)),
+		configParameters: map[string]bool{},
+	}
+	for _, manifest := range []*ApplicationManifest{from, to} {
+		for _, i := range manifest.ServiceManifestImports {
+			for _, override := range i.ConfigOverrides {
+				for _, section := range override.Sections {
+					for _, parameter := range section.Parameters {
+						if name, ok := parameterReference(parameter.Value); ok {
+							diff.configParameters[name] = true
+						}
+					}
+				}
+			}
+		}
+	}
+
+	fromImports := importsByName(from)
+	toImports := importsByName(to)
+	var names []string
+	for name := range fromImports {
+		names = append(names, name)
+	}
+	for name := range toImports {
+		names = append(names, name)
+	}
+	for _, name := range sortedDistinct(names) {
+		fromImport, inFrom := fromImports[name]
+		toImport, inTo := toImports[name]
+
+		serviceDiff := ServiceManifestDiff{
+			Name:        name,
+			FromVersion: fromImport.ServiceManifestRef.ServiceManifestVersion,
+			ToVersion:   toImport.ServiceManifestRef.ServiceManifestVersion,
+			Sections:    diffConfigOverrides(fromImport.ConfigOverrides, toImport.ConfigOverrides),
+		}
+		switch {
+		case !inFrom:
+			serviceDiff.Change = ChangeAdded
+		case !inTo:
+			serviceDiff.Change = ChangeRemoved
+		case serviceDiff.FromVersion != serviceDiff.ToVersion || len(serviceDiff.Sections) > 0:
+			serviceDiff.Change = ChangeModified
+		default:
+			continue
+		}
+
+		// a service manifest version cannot be provisioned twice with
+		// different content, so only changed versions are fetched
+		if serviceDiff.FromVersion != serviceDiff.ToVersion {
+			var fromManifest, toManifest ServiceManifest
+			if inFrom {
+				m, err := c.getServiceManifest(ctx, typeName, fromVersion, name)
+				if err != nil {
+					return nil, err
+				}
+				fromManifest = *m
+			}
+			if inTo {
+				m, err := c.getServiceManifest(ctx, typeName, toVersion, name)
+				if err != nil {
+					return nil, err
+				}
+				toManifest = *m
+			}
+
+			serviceDiff.CodePackages = diffPackages(fromManifest.CodePackages, toManifest.CodePackages)
+			serviceDiff.ConfigPackages = diffPackages(fromManifest.ConfigPackages, toManifest.ConfigPackages)
+			serviceDiff.DataPackages = diffPackages(fromManifest.DataPackages, toManifest.DataPackages)
+			serviceDiff.Endpoints = diffEndpoints(fromManifest.Endpoints, toManifest.Endpoints)
+		}
+		diff.ServiceManifests = append(diff.ServiceManifests, serviceDiff)
+	}
+	return diff, nil
+}
+
+// Impact tells whether the upgrade described by the diff can roll out as a
+// config only upgrade. Application parameters referenced by config overrides
+// are assumed to be referenced nowhere else in the application manifest.
+func (d *ManifestDiff) Impact() UpgradeImpact {
+	var reasons []string
+	for _, parameter := range d.Parameters {
+		if !d.configParameters[parameter.Name] {
+			reasons = append(reasons, fmt.Sprintf("parameter %s %s", parameter.Name, strings.ToLower(string(parameter.Change))))
+		}
+	}
+
+	for _, service := range d.ServiceManifests {
+		if service.Change != ChangeModified {
+			reasons = append(reasons, fmt.Sprintf("service manifest %s %s", service.Name, strings.ToLower(string(service.Change))))
+			continue
+		}
+		for _, pkg := range service.CodePackages {
+			reasons = append(reasons, fmt.Sprintf("code package %s/%s %s", service.Name, pkg.Name, strings.ToLower(string(pkg.Change))))
+		}
+		for _, pkg := range service.DataPackages {
+			reasons = append(reasons, fmt.Sprintf("data package %s/%s %s", service.Name, pkg.Name, strings.ToLower(string(pkg.Change))))
+		}
+		for _, endpoint := range service.Endpoints {
+			reasons = append(reasons, fmt.Sprintf("endpoint %s/%s %s", service.Name, endpoint.Name, strings.ToLower(string(endpoint.Change))))
+		}
+	}
+
+	return UpgradeImpact{
+		ConfigOnly: len(reasons) == 0 && (len(d.Parameters) > 0 || len(d.ServiceManifests) > 0),
+		Reasons:    reasons,
+	}
+}
+
+// UpgradeDescription returns the description of the upgrade of the
+// application named applicationName to the version the diff compares to,
+// with parameters. Config only upgrades, see Impact, roll out unmonitored
+// without forcing restarts, as running code packages pick up the changes.
+// Other upgrades are monitored, taking the default monitoring and health
+// policies until the caller sets them.
+func (d *ManifestDiff) UpgradeDescription(applicationName string, parameters []AppParameter) ApplicationUpgradeDescription {
+	description := ApplicationUpgradeDescription{
+		Name:                         applicationName,
+		TargetApplicationTypeVersion: d.ToVersion,
+		Parameters:                   parameters,
+		UpgradeKind:                  "Rolling",
+		RollingUpgradeMode:           UpgradeModeMonitored,
+	}
+	if d.Impact().ConfigOnly {
+		description.RollingUpgradeMode = UpgradeModeUnmonitoredAuto
+		description.ForceRestart = false
+	}
+	return description
+}
+
+// parameterReference returns the application parameter name
+// of a setting value of the form "[Name]", if any
+func parameterReference(value string) (string, bool) {
+	if len(value) < 3 || value[0] != '[' || value[len(value)-1] != ']' {
+		return "", false
+	}
+	return value[1 : len(value)-1], true
+}
+
+func importsByName(manifest *ApplicationManifest) map[string]ServiceManifestImport {
+	imports := map[string]ServiceManifestImport{}
+	for _, i := range manifest.ServiceManifestImports {
+		imports[i.ServiceManifestRef.ServiceManifestName] = i
+	}
+	return imports
+}
+
+func parameterValues(parameters []ManifestParameter) map[string]string {
+	values := map[string]string{}
+	for _, parameter := range parameters {
+		values[parameter.Name] = parameter.DefaultValue
+	}
+	return values
+}
+
+// diffSettings compares two sets of named values
+func diffSettings(from, to map[string]string) []SettingChange {
+	var names []string
+	for name := range from {
+		names = append(names, name)
+	}
+	for name := range to {
+		names = append(names, name)
+	}
+
+	var changes []SettingChange
+	for _, name := range sortedDistinct(names) {
+		fromValue, inFrom := from[name]
+		toValue, inTo := to[name]
+		change := SettingChange{Name: name, From: fromValue, To: toValue}
+		switch {
+		case !inFrom:
+			change.Change = ChangeAdded
+		case !inTo:
+			change.Change = ChangeRemoved
+		case fromValue != toValue:
+			change.Change = ChangeModified
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func diffPackages(from, to []ManifestPackage) []PackageChange {
+	fromVersions := map[string]string{}
+	for _, pkg := range from {
+		fromVersions[pkg.Name] = pkg.Version
+	}
+	toVersions := map[string]string{}
+	for _, pkg := range to {
+		toVersions[pkg.Name] = pkg.Version
+	}
+
+	var changes []PackageChange
+	for _, setting := range diffSettings(fromVersions, toVersions) {
+		changes = append(changes, PackageChange{
+			Name:        setting.Name,
+			Change:      setting.Change,
+			FromVersion: setting.From,
+			ToVersion:   setting.To,
+		})
+	}
+	return changes
+}
+
+func diffEndpoints(from, to []ServiceManifestEndpoint) []EndpointChange {
+	var names []string
+	fromEndpoints := map[string]*ServiceManifestEndpoint{}
+	for i := range from {
+		fromEndpoints[from[i].Name] = &from[i]
+		names = append(names, from[i].Name)
+	}
+	toEndpoints := map[string]*ServiceManifestEndpoint{}
+	for i := range to {
+		toEndpoints[to[i].Name] = &to[i]
+		names = append(names, to[i].Name)
+	}
+
+	var changes []EndpointChange
+	for _, name := range sortedDistinct(names) {
+		change := EndpointChange{Name: name, From: fromEndpoints[name], To: toEndpoints[name]}
+		switch {
+		case change.From == nil:
+			change.Change = ChangeAdded
+		case change.To == nil:
+			change.Change = ChangeRemoved
+		case *change.From != *change.To:
+			change.Change = ChangeModified
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func diffConfigOverrides(from, to []ConfigOverride) []SectionChange {
+	fromSections := sectionValues(from)
+	toSections := sectionValues(to)
+	var keys []string
+	for key := range fromSections {
+		keys = append(keys, key)
+	}
+	for key := range toSections {
+		keys = append(keys, key)
+	}
+
+	var changes []SectionChange
+	for _, key := range sortedDistinct(keys) {
+		fromValues, inFrom := fromSections[key]
+		toValues, inTo := toSections[key]
+		names := fromValues
+		if !inFrom {
+			names = toValues
+		}
+		change := SectionChange{
+			ConfigPackage: names.configPackage,
+			Section:       names.section,
+			Parameters:    diffSettings(fromValues.values, toValues.values),
+		}
+		switch {
+		case !inFrom:
+			change.Change = ChangeAdded
+		case !inTo:
+			change.Change = ChangeRemoved
+		case len(change.Parameters) > 0:
+			change.Change = ChangeModified
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+type sectionOverrides struct {
+	configPackage string
+	section       string
+	values        map[string]string
+}
+
+// sectionValues indexes the overridden settings by config package and section
+func sectionValues(overrides []ConfigOverride) map[string]sectionOverrides {
+	sections := map[string]sectionOverrides{}
+	for _, override := range overrides {
+		for _, section := range override.Sections {
+			values := map[string]string{}
+			for _, parameter := range section.Parameters {
+				values[parameter.Name] = parameter.Value
+			}
+			sections[override.Name+"/"+section.Name] = sectionOverrides{
+				configPackage: override.Name,
+				section:       section.Name,
+				values:        values,
+			}
+		}
+	}
+	return sections
+}
+
+// sortedDistinct returns the distinct names, sorted
+func sortedDistinct(names []string) []string {
+	sort.Strings(names)
+	var distinct []string
+	for i, name := range names {
+		if i == 0 || name != names[i-1] {
+			distinct = append(distinct, name)
+		}
+	}
+	return distinct
+}
@@ -0,0 +1,162 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ApplicationManifest is the manifest of an application type version
+type ApplicationManifest struct {
+	XMLName                xml.Name                `xml:"ApplicationManifest"`
+	ApplicationTypeName    string                  `xml:"ApplicationTypeName,attr"`
+	ApplicationTypeVersion string                  `xml:"ApplicationTypeVersion,attr"`
+	Parameters             []ManifestParameter     `xml:"Parameters>Parameter"`
+	ServiceManifestImports []ServiceManifestImport `xml:"ServiceManifestImport"`
+	// ManagedIdentities declares the identities of the application,
+	// which ApplicationDescription.ManagedApplicationIdentity assigns
+	ManagedIdentities []ManifestManagedIdentity `xml:"Principals>ManagedIdentities>ManagedIdentity"`
+}
+
+// ManifestManagedIdentity declares a managed identity of an application
+type ManifestManagedIdentity struct {
+	Name string `xml:"Name,attr"`
+}
+
+// ManifestParameter is an application parameter and its default value
+type ManifestParameter struct {
+	Name         string `xml:"Name,attr"`
+	DefaultValue string `xml:"DefaultValue,attr"`
+}
+
+// ServiceManifestImport imports a service manifest into an
+// application manifest, overriding its config package settings
+type ServiceManifestImport struct {
+	ServiceManifestRef ServiceManifestRef `xml:"ServiceManifestRef"`
+	ConfigOverrides    []ConfigOverride   `xml:"ConfigOverrides>ConfigOverride"`
+	// IdentityBindings binds the service identities of the
+	// service manifest to application managed identities
+	IdentityBindings []IdentityBindingPolicy `xml:"Policies>IdentityBindingPolicy"`
+}
+
+// IdentityBindingPolicy binds a service identity to an application managed identity
+type IdentityBindingPolicy struct {
+	ServiceIdentityRef     string `xml:"ServiceIdentityRef,attr"`
+	ApplicationIdentityRef string `xml:"ApplicationIdentityRef,attr"`
+}
+
+// ServiceManifestRef names the imported service manifest version
+type ServiceManifestRef struct {
+	ServiceManifestName    string `xml:"ServiceManifestName,attr"`
+	ServiceManifestVersion string `xml:"ServiceManifestVersion,attr"`
+}
+
+// ConfigOverride overrides the settings of a config package
+type ConfigOverride struct {
+	Name     string            `xml:"Name,attr"`
+	Sections []SettingsSection `xml:"Settings>Section"`
+}
+
+// SettingsSection is a named group of settings
+type SettingsSection struct {
+	Name       string             `xml:"Name,attr"`
+	Parameters []SettingParameter `xml:"Parameter"`
+}
+
+// SettingParameter is a setting of a SettingsSection
+type SettingParameter struct {
+	Name  string `xml:"Name,attr"`
+	Value string `xml:"Value,attr"`
+}
+
+// ServiceManifest is the manifest of a service package
+type ServiceManifest struct {
+	XMLName        xml.Name                  `xml:"ServiceManifest"`
+	Name           string                    `xml:"Name,attr"`
+	Version        string                    `xml:"Version,attr"`
+	CodePackages   []ManifestPackage         `xml:"CodePackage"`
+	ConfigPackages []ManifestPackage         `xml:"ConfigPackage"`
+	DataPackages   []ManifestPackage         `xml:"DataPackage"`
+	Endpoints      []ServiceManifestEndpoint `xml:"Resources>Endpoints>Endpoint"`
+}
+
+// ManifestPackage is a code, config or data package of a service manifest
+type ManifestPackage struct {
+	Name    string `xml:"Name,attr"`
+	Version string `xml:"Version,attr"`
+}
+
+// ServiceManifestEndpoint is an endpoint resource of a service manifest
+type ServiceManifestEndpoint struct {
+	Name           string `xml:"Name,attr"`
+	Protocol       string `xml:"Protocol,attr"`
+	Type           string `xml:"Type,attr"`
+	Port           string `xml:"Port,attr"`
+	UriScheme      string `xml:"UriScheme,attr"`
+	PathSuffix     string `xml:"PathSuffix,attr"`
+	CodePackageRef string `xml:"CodePackageRef,attr"`
+}
+
+// manifestWrapper is the JSON envelope manifests are returned in
+type manifestWrapper struct {
+	Manifest string `json:"Manifest"`
+}
+
+// GetApplicationManifest returns the manifest of an application type version
+func (c ServiceFabricClient) GetApplicationManifest(ctx context.Context, typeName, typeVersion string) (manifest *ApplicationManifest, err error) {
+	ctx, call := c.startCall(ctx, "GetApplicationManifest")
+	defer func() { call.finish(err) }()
+
+	return c.getApplicationManifest(ctx, typeName, typeVersion)
+}
+
+func (c ServiceFabricClient) getApplicationManifest(ctx context.Context, typeName, typeVersion string) (*ApplicationManifest, error) {
+	var manifest ApplicationManifest
+	err := c.getManifest(ctx, "ApplicationTypes/"+typeName+"/$/GetApplicationManifest", &manifest,
+		withParam("ApplicationTypeVersion", typeVersion))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting application manifest %s %s", typeName, typeVersion)
+	}
+	return &manifest, nil
+}
+
+// GetServiceManifest returns a service manifest of an application type version
+func (c ServiceFabricClient) GetServiceManifest(ctx context.Context, typeName, typeVersion, serviceManifestName string) (manifest *ServiceManifest, err error) {
+	ctx, call := c.startCall(ctx, "GetServiceManifest")
+	defer func() { call.finish(err) }()
+
+	return c.getServiceManifest(ctx, typeName, typeVersion, serviceManifestName)
+}
+
+func (c ServiceFabricClient) getServiceManifest(ctx context.Context, typeName, typeVersion, serviceManifestName string) (*ServiceManifest, error) {
+	var manifest ServiceManifest
+	err := c.getManifest(ctx, "ApplicationTypes/"+typeName+"/$/GetServiceManifest", &manifest,
+		withParam("ApplicationTypeVersion", typeVersion), withParam("ServiceManifestName", serviceManifestName))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting service manifest %s of %s %s", serviceManifestName, typeName, typeVersion)
+	}
+	return &manifest, nil
+}
+
+// getManifest decodes the XML manifest returned by basePath into v
+func (c ServiceFabricClient) getManifest(ctx context.Context, basePath string, v interface{}, params ...queryParamsFunc) error {
+	res, _, err := c.getHTTP(ctx, basePath, params...)
+	if err != nil {
+		return err
+	}
+
+	var wrapper manifestWrapper
+	err = json.Unmarshal(res, &wrapper)
+	if err != nil {
+		return fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+
+	err = xml.Unmarshal([]byte(wrapper.Manifest), v)
+	if err != nil {
+		return fmt.Errorf("could not deserialise XML manifest: %+v", err)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package servicefabric
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGetServiceManifest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleManifests))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetServiceManifest(context.Background(), "TestApplicationType", "1.0.0", "TestServicePkg")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if actual.Name != "TestServicePkg" || actual.Version != "1.0.0" {
+		t.Errorf("Got %+v, want TestServicePkg 1.0.0", actual)
+	}
+
+	expectedCode := []ManifestPackage{{Name: "Code", Version: "1.0.0"}}
+	if !reflect.DeepEqual(actual.CodePackages, expectedCode) {
+		t.Errorf("Got %+v, want %+v", actual.CodePackages, expectedCode)
+	}
+
+	expectedEndpoints := []ServiceManifestEndpoint{{Name: "ServiceEndpoint", Protocol: "http", Type: "Input", Port: "8080"}}
+	if !reflect.DeepEqual(actual.Endpoints, expectedEndpoints) {
+		t.Errorf("Got %+v, want %+v", actual.Endpoints, expectedEndpoints)
+	}
+}
+
+func TestDiffApplicationManifests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleManifests))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.DiffApplicationManifests(context.Background(), "TestApplicationType", "1.0.0", "2.0.0")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := &ManifestDiff{
+		TypeName:    "TestApplicationType",
+		FromVersion: "1.0.0",
+		ToVersion:   "2.0.0",
+		Parameters: []SettingChange{
+			{Name: "InstanceCount", Change: ChangeModified, From: "-1", To: "3"},
+			{Name: "LogLevel", Change: ChangeRemoved, From: "Info"},
+			{Name: "MaxConnections", Change: ChangeAdded, To: "100"},
+		},
+		ServiceManifests: []ServiceManifestDiff{
+			{
+				Name:        "StaticPkg",
+				Change:      ChangeModified,
+				FromVersion: "1.0.0",
+				ToVersion:   "1.0.0",
+				Sections: []SectionChange{
+					{
+						ConfigPackage: "Config",
+						Section:       "Limits",
+						Change:        ChangeAdded,
+						Parameters:    []SettingChange{{Name: "MaxConnections", Change: ChangeAdded, To: "[MaxConnections]"}},
+					},
+					{
+						ConfigPackage: "Config",
+						Section:       "Logging",
+						Change:        ChangeModified,
+						Parameters:    []SettingChange{{Name: "Level", Change: ChangeModified, From: "[LogLevel]", To: "Debug"}},
+					},
+				},
+			},
+			{
+				Name:         "TestServicePkg",
+				Change:       ChangeModified,
+				FromVersion:  "1.0.0",
+				ToVersion:    "2.0.0",
+				CodePackages: []PackageChange{{Name: "Code", Change: ChangeModified, FromVersion: "1.0.0", ToVersion: "2.0.0"}},
+				Endpoints: []EndpointChange{
+					{Name: "ReplicatorEndpoint", Change: ChangeAdded, To: &ServiceManifestEndpoint{Name: "ReplicatorEndpoint"}},
+					{
+						Name:   "ServiceEndpoint",
+						Change: ChangeModified,
+						From:   &ServiceManifestEndpoint{Name: "ServiceEndpoint", Protocol: "http", Type: "Input", Port: "8080"},
+						To:     &ServiceManifestEndpoint{Name: "ServiceEndpoint", Protocol: "http", Type: "Input", Port: "8081"},
+					},
+				},
+			},
+		},
+		configParameters: map[string]bool{"LogLevel": true, "MaxConnections": true},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+}
+
+func TestDiffApplicationManifestsReturnsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleManifests))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	_, err := sfClient.DiffApplicationManifests(context.Background(), "TestApplicationType", "1.0.0", "3.0.0")
+	if err == nil {
+		t.Fatal("Error should have been returned")
+	}
+}
+
+func TestManifestDiffImpact(t *testing.T) {
+	configSection := []SectionChange{{ConfigPackage: "Config", Section: "Logging", Change: ChangeModified}}
+
+	tests := []struct {
+		name       string
+		diff       ManifestDiff
+		configOnly bool
+		reasons    []string
+	}{
+		{
+			name: "config package and overrides",
+			diff: ManifestDiff{
+				Parameters: []SettingChange{{Name: "LogLevel", Change: ChangeModified}},
+				ServiceManifests: []ServiceManifestDiff{
+					{Name: "TestServicePkg", Change: ChangeModified, ConfigPackages: []PackageChange{{Name: "Config", Change: ChangeModified}}},
+					{Name: "StaticPkg", Change: ChangeModified, Sections: configSection},
+				},
+				configParameters: map[string]bool{"LogLevel": true},
+			},
+			configOnly: true,
+		},
+		{
+			name: "code package",
+			diff: ManifestDiff{
+				ServiceManifests: []ServiceManifestDiff{
+					{Name: "TestServicePkg", Change: ChangeModified, CodePackages: []PackageChange{{Name: "Code", Change: ChangeModified}}},
+				},
+			},
+			reasons: []string{"code package TestServicePkg/Code modified"},
+		},
+		{
+			name: "parameter used outside config overrides",
+			diff: ManifestDiff{
+				Parameters: []SettingChange{{Name: "InstanceCount", Change: ChangeModified}},
+				ServiceManifests: []ServiceManifestDiff{
+					{Name: "NewPkg", Change: ChangeAdded},
+				},
+			},
+			reasons: []string{"parameter InstanceCount modified", "service manifest NewPkg added"},
+		},
+		{
+			name: "no changes",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			actual := test.diff.Impact()
+			if actual.ConfigOnly != test.configOnly {
+				t.Errorf("Got %+v, want %+v", actual.ConfigOnly, test.configOnly)
+			}
+			if !reflect.DeepEqual(actual.Reasons, test.reasons) {
+				t.Errorf("Got %+v, want %+v", actual.Reasons, test.reasons)
+			}
+		})
+	}
+}
+
+func TestManifestDiffUpgradeDescription(t *testing.T) {
+	parameters := []AppParameter{{Key: "LogLevel", Value: "Debug"}}
+	configOnly := ManifestDiff{
+		ToVersion:        "1.1.0",
+		Parameters:       []SettingChange{{Name: "LogLevel", Change: ChangeModified}},
+		configParameters: map[string]bool{"LogLevel": true},
+	}
+
+	actual := configOnly.UpgradeDescription("fabric:/TestApplication", parameters)
+	expected := ApplicationUpgradeDescription{
+		Name:                         "fabric:/TestApplication",
+		TargetApplicationTypeVersion: "1.1.0",
+		Parameters:                   parameters,
+		UpgradeKind:                  "Rolling",
+		RollingUpgradeMode:           UpgradeModeUnmonitoredAuto,
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+	if err := actual.validate(); err != nil {
+		t.Errorf("Exception thrown %v", err)
+	}
+
+	codeChange := ManifestDiff{
+		ToVersion: "1.1.0",
+		ServiceManifests: []ServiceManifestDiff{
+			{Name: "TestServicePkg", Change: ChangeModified, CodePackages: []PackageChange{{Name: "Code", Change: ChangeModified}}},
+		},
+	}
+	actual = codeChange.UpgradeDescription("fabric:/TestApplication", nil)
+	if actual.RollingUpgradeMode != UpgradeModeMonitored || actual.ForceRestart {
+		t.Errorf("Got %+v, want a monitored upgrade", actual)
+	}
+}
@@ -0,0 +1,139 @@
+package servicefabric
+
+import "fmt"
+
+// NetworkKindLocal is the kind of networks local to the cluster
+const NetworkKindLocal = "Local"
+
+// NetworkResourceDescription describes an isolated container network
+type NetworkResourceDescription struct {
+	Name       string                    `json:"name"`
+	Properties NetworkResourceProperties `json:"properties"`
+}
+
+// NetworkResourceProperties are the properties of a network resource
+type NetworkResourceProperties struct {
+	// Kind is NetworkKindLocal
+	Kind        string `json:"kind"`
+	Description string `json:"description,omitempty"`
+	// NetworkAddressPrefix is the address range of the network, e.g. "10.0.0.0/22"
+	NetworkAddressPrefix string `json:"networkAddressPrefix,omitempty"`
+}
+
+// ApplicationResourceDescription describes a container application
+type ApplicationResourceDescription struct {
+	Name       string                        `json:"name"`
+	Properties ApplicationResourceProperties `json:"properties"`
+}
+
+// ApplicationResourceProperties are the properties of an application resource
+type ApplicationResourceProperties struct {
+	Description string                       `json:"description,omitempty"`
+	Services    []ServiceResourceDescription `json:"services,omitempty"`
+}
+
+// ServiceResourceDescription describes a container service of an application resource
+type ServiceResourceDescription struct {
+	Name       string                    `json:"name"`
+	Properties ServiceResourceProperties `json:"properties"`
+}
+
+// ServiceResourceProperties are the properties of a service resource
+type ServiceResourceProperties struct {
+	// OSType is "Linux" or "Windows"
+	OSType       string                 `json:"osType"`
+	CodePackages []ContainerCodePackage `json:"codePackages"`
+	// NetworkRefs connects endpoints of the code packages to networks
+	NetworkRefs  []NetworkRef `json:"networkRefs,omitempty"`
+	ReplicaCount *int         `json:"replicaCount,omitempty"`
+}
+
+// ContainerCodePackage is a container of a service resource
+type ContainerCodePackage struct {
+	Name                 string                `json:"name"`
+	Image                string                `json:"image"`
+	Commands             []string              `json:"commands,omitempty"`
+	EnvironmentVariables []EnvironmentVariable `json:"environmentVariables,omitempty"`
+	Endpoints            []EndpointProperties  `json:"endpoints,omitempty"`
+	Resources            ResourceRequirements  `json:"resources"`
+}
+
+// EnvironmentVariable is an environment variable of a container
+type EnvironmentVariable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// EndpointProperties is an endpoint a container listens on
+type EndpointProperties struct {
+	Name string `json:"name"`
+	Port *int   `json:"port,omitempty"`
+}
+
+// ResourceRequirements governs the resources of a container. The requested
+// resources are reserved for it, and it may use up to its limits.
+type ResourceRequirements struct {
+	Requests ResourceRequests `json:"requests"`
+	Limits   *ResourceLimits  `json:"limits,omitempty"`
+}
+
+// ResourceRequests are the resources reserved for a container
+type ResourceRequests struct {
+	MemoryInGB float64 `json:"memoryInGB"`
+	CPU        float64 `json:"cpu"`
+}
+
+// ResourceLimits are the most resources a container may use, zero not limiting
+type ResourceLimits struct {
+	MemoryInGB float64 `json:"memoryInGB,omitempty"`
+	CPU        float64 `json:"cpu,omitempty"`
+}
+
+// NetworkRef connects endpoints of a service resource to a network
+type NetworkRef struct {
+	Name         string        `json:"name"`
+	EndpointRefs []EndpointRef `json:"endpointRefs,omitempty"`
+}
+
+// EndpointRef names an endpoint of a code package
+type EndpointRef struct {
+	Name string `json:"name"`
+}
+
+// Validate checks that the containers of every service have an image and
+// limits no lower than their requests, and that network references name
+// endpoints of the service
+func (d *ApplicationResourceDescription) Validate() error {
+	for _, service := range d.Properties.Services {
+		if len(service.Properties.CodePackages) == 0 {
+			return fmt.Errorf("service %s has no code packages", service.Name)
+		}
+
+		endpoints := map[string]bool{}
+		for _, pkg := range service.Properties.CodePackages {
+			if pkg.Image == "" {
+				return fmt.Errorf("code package %s of service %s has no image", pkg.Name, service.Name)
+			}
+			requests, limits := pkg.Resources.Requests, pkg.Resources.Limits
+			if limits != nil && ((limits.CPU > 0 && limits.CPU < requests.CPU) ||
+				(limits.MemoryInGB > 0 && limits.MemoryInGB < requests.MemoryInGB)) {
+				return fmt.Errorf("code package %s of service %s has limits below its requests", pkg.Name, service.Name)
+			}
+			for _, endpoint := range pkg.Endpoints {
+				endpoints[endpoint.Name] = true
+			}
+		}
+
+		for _, ref := range service.Properties.NetworkRefs {
+			if ref.Name == "" {
+				return fmt.Errorf("network reference of service %s has no name", service.Name)
+			}
+			for _, endpoint := range ref.EndpointRefs {
+				if !endpoints[endpoint.Name] {
+					return fmt.Errorf("service %s references unknown endpoint %s on network %s", service.Name, endpoint.Name, ref.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package servicefabric
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func testApplicationResource() ApplicationResourceDescription {
+	port := 80
+	replicas := 2
+	return ApplicationResourceDescription{
+		Name: "web",
+		Properties: ApplicationResourceProperties{
+			Services: []ServiceResourceDescription{{
+				Name: "frontend",
+				Properties: ServiceResourceProperties{
+					OSType: "Linux",
+					CodePackages: []ContainerCodePackage{{
+						Name:      "nginx",
+						Image:     "nginx:latest",
+						Endpoints: []EndpointProperties{{Name: "http", Port: &port}},
+						Resources: ResourceRequirements{
+							Requests: ResourceRequests{MemoryInGB: 0.5, CPU: 0.5},
+							Limits:   &ResourceLimits{MemoryInGB: 1, CPU: 1},
+						},
+					}},
+					NetworkRefs:  []NetworkRef{{Name: "webnetwork", EndpointRefs: []EndpointRef{{Name: "http"}}}},
+					ReplicaCount: &replicas,
+				},
+			}},
+		},
+	}
+}
+
+func TestApplicationResourceJSON(t *testing.T) {
+	b, err := json.Marshal(testApplicationResource())
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := `{"name":"web","properties":{"services":[{"name":"frontend","properties":{"osType":"Linux",` +
+		`"codePackages":[{"name":"nginx","image":"nginx:latest","endpoints":[{"name":"http","port":80}],` +
+		`"resources":{"requests":{"memoryInGB":0.5,"cpu":0.5},"limits":{"memoryInGB":1,"cpu":1}}}],` +
+		`"networkRefs":[{"name":"webnetwork","endpointRefs":[{"name":"http"}]}],"replicaCount":2}}]}}`
+	if string(b) != expected {
+		t.Errorf("Got %+v, want %+v", string(b), expected)
+	}
+}
+
+func TestApplicationResourceValidate(t *testing.T) {
+	valid := testApplicationResource()
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Exception thrown %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*ServiceResourceProperties)
+	}{
+		{"no image", func(p *ServiceResourceProperties) { p.CodePackages[0].Image = "" }},
+		{"limits below requests", func(p *ServiceResourceProperties) { p.CodePackages[0].Resources.Limits.CPU = 0.25 }},
+		{"unknown endpoint", func(p *ServiceResourceProperties) { p.NetworkRefs[0].EndpointRefs[0].Name = "https" }},
+		{"no code packages", func(p *ServiceResourceProperties) { p.CodePackages = nil }},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			description := testApplicationResource()
+			test.modify(&description.Properties.Services[0].Properties)
+			if err := description.Validate(); err == nil {
+				t.Error("Error should have been returned")
+			}
+		})
+	}
+}
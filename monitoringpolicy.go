@@ -0,0 +1,179 @@
+package servicefabric
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Upgrade failure actions
+const (
+	FailureActionRollback = "Rollback"
+	FailureActionManual   = "Manual"
+)
+
+// InfiniteUpgradeTimeout is the encoding of the longest duration Service
+// Fabric accepts, the default of UpgradeTimeout and UpgradeDomainTimeout
+const InfiniteUpgradeTimeout = "P10675199DT02H48M05.4775807S"
+
+// MonitoringPolicyDescription describes how a monitored rolling upgrade is
+// health checked. Durations are encoded as ISO 8601 durations such as
+// "PT0H2M0S", or as a number of milliseconds, see FormatUpgradeDuration.
+// Unset fields take the cluster defaults.
+type MonitoringPolicyDescription struct {
+	FailureAction                           string `json:"FailureAction,omitempty"`
+	HealthCheckWaitDurationInMilliseconds   string `json:"HealthCheckWaitDurationInMilliseconds,omitempty"`
+	HealthCheckStableDurationInMilliseconds string `json:"HealthCheckStableDurationInMilliseconds,omitempty"`
+	HealthCheckRetryTimeoutInMilliseconds   string `json:"HealthCheckRetryTimeoutInMilliseconds,omitempty"`
+	UpgradeTimeoutInMilliseconds            string `json:"UpgradeTimeoutInMilliseconds,omitempty"`
+	UpgradeDomainTimeoutInMilliseconds      string `json:"UpgradeDomainTimeoutInMilliseconds,omitempty"`
+}
+
+// Validate checks the failure action and the encoding of every duration
+// set, which Service Fabric would otherwise reject when the upgrade starts
+func (p *MonitoringPolicyDescription) Validate() error {
+	switch p.FailureAction {
+	case "", FailureActionRollback, FailureActionManual:
+	default:
+		return fmt.Errorf("invalid failure action %q, want %s or %s", p.FailureAction, FailureActionRollback, FailureActionManual)
+	}
+
+	durations := []struct {
+		name  string
+		value string
+	}{
+		{"HealthCheckWaitDurationInMilliseconds", p.HealthCheckWaitDurationInMilliseconds},
+		{"HealthCheckStableDurationInMilliseconds", p.HealthCheckStableDurationInMilliseconds},
+		{"HealthCheckRetryTimeoutInMilliseconds", p.HealthCheckRetryTimeoutInMilliseconds},
+		{"UpgradeTimeoutInMilliseconds", p.UpgradeTimeoutInMilliseconds},
+		{"UpgradeDomainTimeoutInMilliseconds", p.UpgradeDomainTimeoutInMilliseconds},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		if _, err := ParseUpgradeDuration(d.value); err != nil {
+			return fmt.Errorf("invalid %s: %v", d.name, err)
+		}
+	}
+
+	if p.UpgradeTimeoutInMilliseconds != "" && p.UpgradeDomainTimeoutInMilliseconds != "" {
+		upgrade, _ := ParseUpgradeDuration(p.UpgradeTimeoutInMilliseconds)
+		upgradeDomain, _ := ParseUpgradeDuration(p.UpgradeDomainTimeoutInMilliseconds)
+		if upgradeDomain > upgrade {
+			return fmt.Errorf("upgrade domain timeout %s exceeds upgrade timeout %s", p.UpgradeDomainTimeoutInMilliseconds, p.UpgradeTimeoutInMilliseconds)
+		}
+	}
+	return nil
+}
+
+var upgradeDurationPattern = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// ParseUpgradeDuration decodes an upgrade duration the way Service Fabric
+// does: as a number of milliseconds first, then as an ISO 8601 duration of
+// days, hours, minutes and seconds. Durations beyond the range of
+// time.Duration, such as InfiniteUpgradeTimeout, are capped to its maximum.
+func ParseUpgradeDuration(value string) (time.Duration, error) {
+	if ms, err := strconv.ParseUint(value, 10, 64); err == nil {
+		if ms > math.MaxInt64/uint64(time.Millisecond) {
+			return math.MaxInt64, nil
+		}
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+
+	m := upgradeDurationPattern.FindStringSubmatch(value)
+	if m == nil || value == "P" || value[len(value)-1] == 'T' {
+		return 0, fmt.Errorf("%q is neither an ISO 8601 duration nor a number of milliseconds", value)
+	}
+
+	var seconds float64
+	for i, unit := range []float64{24 * 3600, 3600, 60, 1} {
+		if m[i+1] == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(m[i+1], 64)
+		if err != nil {
+			return 0, err
+		}
+		seconds += n * unit
+	}
+
+	if seconds >= float64(math.MaxInt64)/float64(time.Second) {
+		return math.MaxInt64, nil
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// FormatUpgradeDuration encodes d as an ISO 8601 duration, e.g. "PT0H2M0S"
+func FormatUpgradeDuration(d time.Duration) string {
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+	d -= minutes * time.Minute
+	return fmt.Sprintf("PT%dH%dM%sS", hours, minutes, strconv.FormatFloat(d.Seconds(), 'f', -1, 64))
+}
+
+// MonitoringPolicyBuilder builds a MonitoringPolicyDescription from typed
+// durations, the first invalid value failing Build
+type MonitoringPolicyBuilder struct {
+	policy MonitoringPolicyDescription
+	err    error
+}
+
+// NewMonitoringPolicy starts a monitoring policy taking failureAction,
+// FailureActionRollback or FailureActionManual, when health checks fail
+func NewMonitoringPolicy(failureAction string) *MonitoringPolicyBuilder {
+	return &MonitoringPolicyBuilder{policy: MonitoringPolicyDescription{FailureAction: failureAction}}
+}
+
+func (b *MonitoringPolicyBuilder) duration(field *string, name string, d time.Duration) *MonitoringPolicyBuilder {
+	if d < 0 && b.err == nil {
+		b.err = fmt.Errorf("%s must not be negative, got %s", name, d)
+	}
+	*field = FormatUpgradeDuration(d)
+	return b
+}
+
+// HealthCheckWait sets how long to wait after an upgrade domain
+// completed before health policies are first evaluated
+func (b *MonitoringPolicyBuilder) HealthCheckWait(d time.Duration) *MonitoringPolicyBuilder {
+	return b.duration(&b.policy.HealthCheckWaitDurationInMilliseconds, "health check wait duration", d)
+}
+
+// HealthCheckStable sets how long health checks must keep
+// passing before the next upgrade domain is upgraded
+func (b *MonitoringPolicyBuilder) HealthCheckStable(d time.Duration) *MonitoringPolicyBuilder {
+	return b.duration(&b.policy.HealthCheckStableDurationInMilliseconds, "health check stable duration", d)
+}
+
+// HealthCheckRetryTimeout sets how long failing health checks are
+// retried before the failure action is taken
+func (b *MonitoringPolicyBuilder) HealthCheckRetryTimeout(d time.Duration) *MonitoringPolicyBuilder {
+	return b.duration(&b.policy.HealthCheckRetryTimeoutInMilliseconds, "health check retry timeout", d)
+}
+
+// UpgradeTimeout sets how long the whole upgrade may take
+// before the failure action is taken
+func (b *MonitoringPolicyBuilder) UpgradeTimeout(d time.Duration) *MonitoringPolicyBuilder {
+	return b.duration(&b.policy.UpgradeTimeoutInMilliseconds, "upgrade timeout", d)
+}
+
+// UpgradeDomainTimeout sets how long each upgrade domain may
+// take before the failure action is taken
+func (b *MonitoringPolicyBuilder) UpgradeDomainTimeout(d time.Duration) *MonitoringPolicyBuilder {
+	return b.duration(&b.policy.UpgradeDomainTimeoutInMilliseconds, "upgrade domain timeout", d)
+}
+
+// Build returns the policy, or the first error found in its values
+func (b *MonitoringPolicyBuilder) Build() (*MonitoringPolicyDescription, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	policy := b.policy
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
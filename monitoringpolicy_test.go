@@ -0,0 +1,121 @@
+package servicefabric
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseUpgradeDuration(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+		valid    bool
+	}{
+		{value: "PT0H2M0S", expected: 2 * time.Minute, valid: true},
+		{value: "PT1.5S", expected: 1500 * time.Millisecond, valid: true},
+		{value: "P1DT2H", expected: 26 * time.Hour, valid: true},
+		{value: "120000", expected: 2 * time.Minute, valid: true},
+		{value: InfiniteUpgradeTimeout, expected: math.MaxInt64, valid: true},
+		{value: "P"},
+		{value: "PT"},
+		{value: "2m"},
+		{value: "P1M"},
+		{value: "-1000"},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.value, func(t *testing.T) {
+			actual, err := ParseUpgradeDuration(test.value)
+			if !test.valid {
+				if err == nil {
+					t.Fatal("Error should have been returned")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exception thrown %v", err)
+			}
+			if actual != test.expected {
+				t.Errorf("Got %+v, want %+v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestFormatUpgradeDuration(t *testing.T) {
+	for d, expected := range map[time.Duration]string{
+		2 * time.Minute:                      "PT0H2M0S",
+		26*time.Hour + 1500*time.Millisecond: "PT26H0M1.5S",
+	} {
+		actual := FormatUpgradeDuration(d)
+		if actual != expected {
+			t.Errorf("Got %+v, want %+v", actual, expected)
+		}
+
+		parsed, err := ParseUpgradeDuration(actual)
+		if err != nil {
+			t.Fatalf("Exception thrown %v", err)
+		}
+		if parsed != d {
+			t.Errorf("Got %+v, want %+v", parsed, d)
+		}
+	}
+}
+
+func TestMonitoringPolicyBuilder(t *testing.T) {
+	actual, err := NewMonitoringPolicy(FailureActionRollback).
+		HealthCheckWait(30 * time.Second).
+		HealthCheckStable(2 * time.Minute).
+		HealthCheckRetryTimeout(10 * time.Minute).
+		UpgradeDomainTimeout(time.Hour).
+		UpgradeTimeout(4 * time.Hour).
+		Build()
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := &MonitoringPolicyDescription{
+		FailureAction:                           FailureActionRollback,
+		HealthCheckWaitDurationInMilliseconds:   "PT0H0M30S",
+		HealthCheckStableDurationInMilliseconds: "PT0H2M0S",
+		HealthCheckRetryTimeoutInMilliseconds:   "PT0H10M0S",
+		UpgradeTimeoutInMilliseconds:            "PT4H0M0S",
+		UpgradeDomainTimeoutInMilliseconds:      "PT1H0M0S",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+}
+
+func TestMonitoringPolicyBuilderReturnsError(t *testing.T) {
+	builders := map[string]*MonitoringPolicyBuilder{
+		"failure action":   NewMonitoringPolicy("Ignore"),
+		"negative":         NewMonitoringPolicy(FailureActionManual).HealthCheckWait(-time.Second),
+		"domain exceeding": NewMonitoringPolicy(FailureActionManual).UpgradeTimeout(time.Hour).UpgradeDomainTimeout(2 * time.Hour),
+	}
+
+	for name, builder := range builders {
+		if _, err := builder.Build(); err == nil {
+			t.Errorf("Error should have been returned for %s", name)
+		}
+	}
+}
+
+func TestMonitoringPolicyValidate(t *testing.T) {
+	policy := MonitoringPolicyDescription{
+		FailureAction:                         FailureActionManual,
+		HealthCheckRetryTimeoutInMilliseconds: "600000",
+		UpgradeTimeoutInMilliseconds:          InfiniteUpgradeTimeout,
+	}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	policy.HealthCheckStableDurationInMilliseconds = "2 minutes"
+	if err := policy.Validate(); err == nil {
+		t.Fatal("Error should have been returned")
+	}
+}
@@ -0,0 +1,109 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// GetNodes returns every node of the cluster, aggregating every page
+func (c ServiceFabricClient) GetNodes(ctx context.Context) (page *NodeItemsPage, err error) {
+	ctx, call := c.startCall(ctx, "GetNodes")
+	defer func() { call.finish(err) }()
+
+	nodes, err := c.getNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &NodeItemsPage{Items: nodes}, nil
+}
+
+// getNodes returns every node of the cluster
+func (c ServiceFabricClient) getNodes(ctx context.Context) ([]NodeItem, error) {
+	var nodes []NodeItem
+	var continueToken string
+	for {
+		res, _, err := c.getHTTP(ctx, "Nodes/", withContinue(continueToken))
+		if err != nil {
+			return nil, err
+		}
+
+		var nodeItemsPage NodeItemsPage
+		err = json.Unmarshal(res, &nodeItemsPage)
+		if err != nil {
+			return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+		}
+
+		nodes = append(nodes, nodeItemsPage.Items...)
+
+		continueToken = getString(nodeItemsPage.ContinuationToken)
+		if continueToken == "" {
+			break
+		}
+	}
+	return nodes, nil
+}
+
+// NodeInfo is the full record of a node
+type NodeInfo struct {
+	NodeItem
+	ID                    NodeID `json:"Id"`
+	InstanceID            string `json:"InstanceId"`
+	NodeUpTimeInSeconds   int64  `json:"NodeUpTimeInSeconds,string"`
+	NodeDownTimeInSeconds int64  `json:"NodeDownTimeInSeconds,string"`
+	NodeUpAt              string `json:"NodeUpAt"`
+	NodeDownAt            string `json:"NodeDownAt"`
+	IsStopped             bool   `json:"IsStopped"`
+	// NodeDeactivationInfo is set while the node is being deactivated,
+	// e.g. for repairs, and after it was deactivated
+	NodeDeactivationInfo *NodeDeactivationInfo `json:"NodeDeactivationInfo,omitempty"`
+	NodeTags             []string              `json:"NodeTags"`
+}
+
+// NodeID identifies a node internally
+type NodeID struct {
+	ID string `json:"Id"`
+}
+
+// NodeDeactivationInfo reports the deactivation of a node
+type NodeDeactivationInfo struct {
+	// NodeDeactivationIntent is the strongest intent of the tasks, e.g. "Restart"
+	NodeDeactivationIntent string `json:"NodeDeactivationIntent"`
+	// NodeDeactivationStatus is e.g. "SafetyCheckInProgress" or "Completed"
+	NodeDeactivationStatus string                   `json:"NodeDeactivationStatus"`
+	NodeDeactivationTask   []NodeDeactivationTask   `json:"NodeDeactivationTask"`
+	PendingSafetyChecks    []map[string]interface{} `json:"PendingSafetyChecks"`
+}
+
+// NodeDeactivationTask is a request to deactivate a node
+type NodeDeactivationTask struct {
+	NodeDeactivationTaskID struct {
+		ID                       string `json:"Id"`
+		NodeDeactivationTaskType string `json:"NodeDeactivationTaskType"`
+	} `json:"NodeDeactivationTaskId"`
+	NodeDeactivationIntent string `json:"NodeDeactivationIntent"`
+}
+
+// GetNodeInfo returns the record of a node, failing with
+// ErrResourceNotExists when the cluster has no such node
+func (c ServiceFabricClient) GetNodeInfo(ctx context.Context, nodeName string) (node *NodeInfo, err error) {
+	ctx, call := c.startCall(ctx, "GetNodeInfo")
+	defer func() { call.finish(err) }()
+
+	res, status, err := c.getHTTP(ctx, "Nodes/"+nodeName)
+	if status == http.StatusNoContent {
+		return nil, ErrResourceNotExists
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting node info")
+	}
+
+	err = json.Unmarshal(res, &node)
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return node, nil
+}
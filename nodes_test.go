@@ -0,0 +1,99 @@
+package servicefabric
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGetNodes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.RawQuery {
+		case "api-version=1.0":
+			writeFixture(w, "nodes_page_1.json")
+		case "api-version=1.0&continue=00001234":
+			writeFixture(w, "nodes.json")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetNodes(context.Background())
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := &NodeItemsPage{
+		Items: []NodeItem{
+			{
+				Name:            "_Node_1",
+				IPAddressOrFQDN: "10.0.0.5",
+				Type:            "NodeType0",
+				CodeVersion:     "7.2.457.9590",
+				ConfigVersion:   "1",
+				NodeStatus:      "Down",
+				HealthState:     "Error",
+				UpgradeDomain:   "1",
+				FaultDomain:     "fd:/1",
+			},
+			{
+				Name:            "_Node_0",
+				IPAddressOrFQDN: "10.0.0.4",
+				Type:            "NodeType0",
+				CodeVersion:     "7.2.457.9590",
+				ConfigVersion:   "1",
+				NodeStatus:      "Up",
+				HealthState:     "Ok",
+				IsSeedNode:      true,
+				UpgradeDomain:   "0",
+				FaultDomain:     "fd:/0",
+			},
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+}
+
+func TestGetNodeInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/Nodes/_Node_1":
+			writeFixture(w, "node_info.json")
+		case "/Nodes/_Node_9":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetNodeInfo(context.Background(), "_Node_1")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if actual.Name != "_Node_1" || !actual.IsSeedNode || actual.NodeStatus != "Disabling" ||
+		actual.ID.ID != "2b1c0e3f5a7d9e8c1d2b3a4f5e6d7c8b" || actual.NodeUpTimeInSeconds != 3600 {
+		t.Errorf("Got %+v, want the _Node_1 seed node", actual)
+	}
+	deactivation := actual.NodeDeactivationInfo
+	if deactivation == nil || deactivation.NodeDeactivationStatus != "SafetyCheckInProgress" ||
+		len(deactivation.NodeDeactivationTask) != 1 ||
+		deactivation.NodeDeactivationTask[0].NodeDeactivationTaskID.ID != "Azure/PlatformUpdate/1" ||
+		len(deactivation.PendingSafetyChecks) != 1 {
+		t.Errorf("Got %+v, want a restart awaiting safety checks", deactivation)
+	}
+
+	_, err = sfClient.GetNodeInfo(context.Background(), "_Node_9")
+	if err != ErrResourceNotExists {
+		t.Errorf("Got %v, want %v", err, ErrResourceNotExists)
+	}
+}
@@ -0,0 +1,179 @@
+package servicefabric
+
+import (
+	"io"
+	"reflect"
+	"time"
+)
+
+// ClientOption configures optional behaviour of a ServiceFabricClient
+type ClientOption func(*ServiceFabricClient)
+
+// WithManagedCluster marks the cluster as a Service Fabric managed cluster,
+// see ManagedClusterEndpoint and ManagedClusterTLSConfig for connecting to one
+func WithManagedCluster() ClientOption {
+	return func(c *ServiceFabricClient) {
+		c.managedCluster = true
+	}
+}
+
+// WithReadOnly makes every mutating call fail with ErrReadOnlyClient before
+// any request is sent, for dashboards and exporters that must never change
+// the cluster
+func WithReadOnly() ClientOption {
+	return func(c *ServiceFabricClient) {
+		c.readOnly = true
+	}
+}
+
+// WithOperationPolicy restricts the mutating calls the client will send,
+// failing the others with ErrOperationNotPermitted
+func WithOperationPolicy(policy OperationPolicy) ClientOption {
+	return func(c *ServiceFabricClient) {
+		c.policy = &policy
+	}
+}
+
+// WithAuditSink reports every mutating call to sink, attributed to caller
+func WithAuditSink(sink AuditSink, caller string) ClientOption {
+	return func(c *ServiceFabricClient) {
+		c.auditSink = sink
+		c.auditCaller = caller
+	}
+}
+
+// WithConnectivityCheck makes NewServiceFabricClient query the cluster once,
+// waiting at most timeout, and fail when the endpoint cannot be reached
+func WithConnectivityCheck(timeout time.Duration) ClientOption {
+	return func(c *ServiceFabricClient) {
+		c.connectivityTimeout = timeout
+	}
+}
+
+// WithCallObserver reports the timing and size of every completed call to observer
+func WithCallObserver(observer CallObserver) ClientOption {
+	return func(c *ServiceFabricClient) {
+		c.callObserver = observer
+	}
+}
+
+// WithSlowCallThreshold flags calls taking longer than threshold as Slow in
+// the CallInfo reported to the observer, see WithCallObserver
+func WithSlowCallThreshold(threshold time.Duration) ClientOption {
+	return func(c *ServiceFabricClient) {
+		c.slowCallThreshold = threshold
+	}
+}
+
+// WithConcurrency bounds the number of requests aggregate queries such as
+// GetServicesForAllApplications send at once, trading throughput for
+// gateway load. Values below one restore DefaultConcurrency.
+func WithConcurrency(n int) ClientOption {
+	return func(c *ServiceFabricClient) {
+		c.maxConcurrency = n
+	}
+}
+
+// WithRestartBudget bounds every code package restart the client issues,
+// across calls, such as RestartDeployedCodePackage and the restarts of
+// CollectServiceLogs, which also applies its own budget
+func WithRestartBudget(budget RestartBudget) ClientOption {
+	return func(c *ServiceFabricClient) {
+		c.restartGuard = newRestartGuard(&budget)
+	}
+}
+
+// WithTokenCredential authenticates every request with a bearer token from
+// credential, for clusters secured with Azure Active Directory. Tokens are
+// cached and refreshed shortly before they expire.
+func WithTokenCredential(credential TokenCredential) ClientOption {
+	return func(c *ServiceFabricClient) {
+		c.tokens = &tokenCache{credential: credential}
+	}
+}
+
+// WithCredentialRefresher refreshes credentials the cluster rejects with
+// refresher before the rejected request is sent again, see
+// CredentialRefresher. NewClientWithCredentials sets it to credentials
+// loaded from files.
+func WithCredentialRefresher(refresher CredentialRefresher) ClientOption {
+	return func(c *ServiceFabricClient) {
+		c.refresher = refresher
+	}
+}
+
+// WithRetryPolicy resends reads and idempotent operations failing with a
+// transient error as policy allows, see DefaultRetryPolicy. Other mutations
+// are never resent. Retries are counted in CallInfo.Retries.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *ServiceFabricClient) {
+		c.retryPolicy = &policy
+	}
+}
+
+// WithFailoverEndpoints adds management endpoints of other gateway nodes of
+// the cluster. Requests are spread over every endpoint in round robin order
+// and fail over to the next one when an endpoint is unreachable, which is
+// then tried last until it cooled down, see WithEndpointCooldown.
+func WithFailoverEndpoints(endpoints ...string) ClientOption {
+	return func(c *ServiceFabricClient) {
+		c.failoverEndpoints = endpoints
+	}
+}
+
+// WithEndpointCooldown sets how long an unreachable endpoint is tried last,
+// DefaultEndpointCooldown when zero
+func WithEndpointCooldown(cooldown time.Duration) ClientOption {
+	return func(c *ServiceFabricClient) {
+		c.endpointCooldown = cooldown
+	}
+}
+
+// WithSessionHeader sends the session id of requests made WithSession in
+// the header name, for load balancers that pin sessions by header
+func WithSessionHeader(name string) ClientOption {
+	return func(c *ServiceFabricClient) {
+		c.sessionHeader = name
+	}
+}
+
+// WithRedactionPatterns replaces DefaultRedactionPatterns, the patterns
+// of the parameter names whose values are masked in audit events and
+// debug dumps
+func WithRedactionPatterns(patterns ...string) ClientOption {
+	return func(c *ServiceFabricClient) {
+		c.redactor = NewRedactor(patterns...)
+	}
+}
+
+// WithDebugDump writes every request sent and every response received
+// to w, with secrets masked, see Redactor
+func WithDebugDump(w io.Writer) ClientOption {
+	return func(c *ServiceFabricClient) {
+		c.debugDump = w
+	}
+}
+
+// WithUnmarshalHook decodes the values decoded into the type of v, such as
+// the service type extensions of GetServiceExtension and the properties of
+// GetPropertyObject, with hook. v is typically a nil pointer to the type,
+// e.g. (*MyExtension)(nil).
+func WithUnmarshalHook(v interface{}, hook UnmarshalHook) ClientOption {
+	return func(c *ServiceFabricClient) {
+		if c.typeHooks == nil {
+			c.typeHooks = map[reflect.Type]UnmarshalHook{}
+		}
+		c.typeHooks[reflect.TypeOf(v)] = hook
+	}
+}
+
+// WithCustomTypeHook decodes the properties whose CustomTypeId is
+// customTypeID with hook in GetPropertyObject
+func WithCustomTypeHook(customTypeID string, hook UnmarshalHook) ClientOption {
+	return func(c *ServiceFabricClient) {
+		if c.customTypeHooks == nil {
+			c.customTypeHooks = map[string]UnmarshalHook{}
+		}
+		c.customTypeHooks[customTypeID] = hook
+	}
+}
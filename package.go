@@ -0,0 +1,171 @@
+package servicefabric
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Names of the files generated in application packages
+const (
+	// PackageDirectoryMarker marks a folder of a package uploaded to the
+	// image store as complete, provisioning fails on folders without one
+	PackageDirectoryMarker = "_.dir"
+	// PackageChecksumSuffix suffixes the checksum file of a manifest or a
+	// code, config or data package
+	PackageChecksumSuffix = ".checksum"
+)
+
+// PackageFile is a file of an application package to upload
+type PackageFile struct {
+	// RelativePath is the path of the file within the package, slash separated
+	RelativePath string
+	// LocalPath is the file to upload, empty for generated files
+	LocalPath string
+	// Content is the content of generated files
+	Content []byte
+	Size    int64
+}
+
+// PackageLayoutOptions configures PackageLayout
+type PackageLayoutOptions struct {
+	// Checksums generates the checksum files of the application and
+	// service manifests and of the code, config and data packages that
+	// the package does not hold already
+	Checksums bool
+}
+
+// PackageLayout lists the files to upload for the uncompressed application
+// package in localDir: its own files, an empty PackageDirectoryMarker in
+// every folder, the root included, and optionally checksum files. Markers
+// come last and deepest first, so a folder is only marked once everything
+// it holds was uploaded. Markers and checksums already in localDir are
+// replaced by generated ones.
+//
+// A checksum holds the uppercase hex SHA-256 of a manifest, or of the paths
+// and contents of the files of a package folder, in path order.
+func PackageLayout(localDir string, opts *PackageLayoutOptions) ([]PackageFile, error) {
+	info, err := os.Stat(localDir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, errors.Errorf("application package %s is not a directory", localDir)
+	}
+	if _, err := os.Stat(filepath.Join(localDir, "ApplicationManifest.xml")); err != nil {
+		return nil, errors.Wrapf(err, "application package %s has no application manifest", localDir)
+	}
+
+	var files, markers []PackageFile
+	dirs := map[string]bool{}
+	err = filepath.Walk(localDir, func(local string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(localDir, local)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if info.IsDir() {
+			if rel != "." {
+				dirs[rel] = true
+			}
+			markers = append(markers, PackageFile{RelativePath: path.Join(rel, PackageDirectoryMarker), Content: []byte{}})
+			return nil
+		}
+		if path.Base(rel) == PackageDirectoryMarker || strings.HasSuffix(rel, PackageChecksumSuffix) {
+			return nil
+		}
+		files = append(files, PackageFile{RelativePath: rel, LocalPath: local, Size: info.Size()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if opts != nil && opts.Checksums {
+		checksums, err := packageChecksums(files, dirs)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, checksums...)
+	}
+
+	sort.SliceStable(markers, func(i, j int) bool {
+		return strings.Count(markers[i].RelativePath, "/") > strings.Count(markers[j].RelativePath, "/")
+	})
+	return append(files, markers...), nil
+}
+
+// packageChecksums returns the checksum files of the manifests among files,
+// and of the package folders, the folders of the service folders
+func packageChecksums(files []PackageFile, dirs map[string]bool) ([]PackageFile, error) {
+	packages := map[string][]PackageFile{}
+	var checksums []PackageFile
+	for _, file := range files {
+		if file.RelativePath == "ApplicationManifest.xml" || path.Base(file.RelativePath) == "ServiceManifest.xml" {
+			sum, err := checksum([]PackageFile{file}, false)
+			if err != nil {
+				return nil, err
+			}
+			checksums = append(checksums, checksumFile(file.RelativePath, sum))
+		}
+		if parts := strings.SplitN(file.RelativePath, "/", 3); len(parts) == 3 {
+			pkg := parts[0] + "/" + parts[1]
+			packages[pkg] = append(packages[pkg], file)
+		}
+	}
+
+	names := make([]string, 0, len(packages))
+	for pkg := range packages {
+		if dirs[pkg] {
+			names = append(names, pkg)
+		}
+	}
+	sort.Strings(names)
+	for _, pkg := range names {
+		sum, err := checksum(packages[pkg], true)
+		if err != nil {
+			return nil, err
+		}
+		checksums = append(checksums, checksumFile(pkg, sum))
+	}
+	return checksums, nil
+}
+
+func checksumFile(relativePath, sum string) PackageFile {
+	return PackageFile{RelativePath: relativePath + PackageChecksumSuffix, Content: []byte(sum), Size: int64(len(sum))}
+}
+
+// checksum hashes the contents of files in path order, and their
+// paths relative to the folder holding them when withPaths is set
+func checksum(files []PackageFile, withPaths bool) (string, error) {
+	sorted := append([]PackageFile(nil), files...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].RelativePath < sorted[j].RelativePath })
+
+	h := sha256.New()
+	for _, file := range sorted {
+		if withPaths {
+			parts := strings.SplitN(file.RelativePath, "/", 3)
+			io.WriteString(h, parts[len(parts)-1])
+		}
+		f, err := os.Open(file.LocalPath)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.ToUpper(hex.EncodeToString(h.Sum(nil))), nil
+}
@@ -0,0 +1,79 @@
+package servicefabric
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writePackage(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "package")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	for name, content := range files {
+		local := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(local), 0700); err != nil {
+			t.Fatalf("Exception thrown %v", err)
+		}
+		if err := ioutil.WriteFile(local, []byte(content), 0600); err != nil {
+			t.Fatalf("Exception thrown %v", err)
+		}
+	}
+	return dir
+}
+
+func TestPackageLayout(t *testing.T) {
+	dir := writePackage(t, map[string]string{
+		"ApplicationManifest.xml":           "<ApplicationManifest/>",
+		"Backend/ServiceManifest.xml":       "<ServiceManifest/>",
+		"Backend/Code/backend.exe":          "code",
+		"Backend/Config/Settings.xml":       "<Settings/>",
+		"Backend/Config/_.dir":              "",
+		"Backend/Code/backend.exe.checksum": "stale",
+	})
+	defer os.RemoveAll(dir)
+
+	files, err := PackageLayout(dir, &PackageLayoutOptions{Checksums: true})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	var actual []string
+	for _, file := range files {
+		actual = append(actual, file.RelativePath)
+	}
+	expected := []string{
+		"ApplicationManifest.xml",
+		"Backend/Code/backend.exe",
+		"Backend/Config/Settings.xml",
+		"Backend/ServiceManifest.xml",
+		"ApplicationManifest.xml.checksum",
+		"Backend/ServiceManifest.xml.checksum",
+		"Backend/Code.checksum",
+		"Backend/Config.checksum",
+		"Backend/Code/_.dir",
+		"Backend/Config/_.dir",
+		"Backend/_.dir",
+		"_.dir",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+
+	expectedSum := "C856D074047B8D4B8CCE43074AD7D04AD1B262958280B2F00D778E2276E3334B"
+	if string(files[4].Content) != expectedSum {
+		t.Errorf("Got %s, want %s", files[4].Content, expectedSum)
+	}
+}
+
+func TestPackageLayoutWithoutManifest(t *testing.T) {
+	dir := writePackage(t, map[string]string{"Backend/ServiceManifest.xml": "<ServiceManifest/>"})
+	defer os.RemoveAll(dir)
+
+	if _, err := PackageLayout(dir, nil); err == nil {
+		t.Error("Error should have been returned")
+	}
+}
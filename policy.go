@@ -0,0 +1,121 @@
+package servicefabric
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrOperationNotPermitted is returned by mutating calls
+// the client's OperationPolicy does not permit
+var ErrOperationNotPermitted = errors.New("operation is not permitted by the client operation policy")
+
+// OperationCategory groups mutating operations by their blast radius
+type OperationCategory string
+
+// Operation categories
+const (
+	CategoryCreate  OperationCategory = "Create"
+	CategoryUpdate  OperationCategory = "Update"
+	CategoryDelete  OperationCategory = "Delete"
+	CategoryUpgrade OperationCategory = "Upgrade"
+	CategoryRestart OperationCategory = "Restart"
+)
+
+// Operation identifies a mutating call made by the client
+type Operation struct {
+	Name     string
+	Category OperationCategory
+	// Target is the entity the call acts on, when known
+	Target string
+	// idempotent operations leave the cluster in the same state when
+	// sent twice, so the retry policy may resend them
+	idempotent bool
+}
+
+func (op Operation) String() string {
+	return op.Name
+}
+
+// on returns a copy of op acting on target
+func (op Operation) on(target string) Operation {
+	op.Target = target
+	return op
+}
+
+var (
+	opCreateComposeDeployment = Operation{Name: "CreateComposeDeployment", Category: CategoryCreate}
+	opCreateService           = Operation{Name: "CreateService", Category: CategoryCreate}
+	opCreateApplication       = Operation{Name: "CreateApplication", Category: CategoryCreate}
+	opUpdateService           = Operation{Name: "UpdateService", Category: CategoryUpdate}
+	opDeleteService           = Operation{Name: "DeleteService", Category: CategoryDelete}
+	opDeleteApplication       = Operation{Name: "DeleteApplication", Category: CategoryDelete}
+	opDeleteComposeDeployment = Operation{Name: "DeleteComposeDeployment", Category: CategoryDelete}
+
+	opProvisionApplicationType   = Operation{Name: "ProvisionApplicationType", Category: CategoryCreate}
+	opUnprovisionApplicationType = Operation{Name: "UnprovisionApplicationType", Category: CategoryDelete}
+
+	opStartClusterUpgrade        = Operation{Name: "StartClusterUpgrade", Category: CategoryUpgrade}
+	opUpdateClusterUpgrade       = Operation{Name: "UpdateClusterUpgrade", Category: CategoryUpgrade}
+	opStartApplicationUpgrade    = Operation{Name: "StartApplicationUpgrade", Category: CategoryUpgrade}
+	opUpdateApplicationUpgrade   = Operation{Name: "UpdateApplicationUpgrade", Category: CategoryUpgrade}
+	opResumeApplicationUpgrade   = Operation{Name: "ResumeApplicationUpgrade", Category: CategoryUpgrade}
+	opRollbackApplicationUpgrade = Operation{Name: "RollbackApplicationUpgrade", Category: CategoryUpgrade}
+
+	opRestartDeployedCodePackage = Operation{Name: "RestartDeployedCodePackage", Category: CategoryRestart}
+
+	opCreateRepairTask           = Operation{Name: "CreateRepairTask", Category: CategoryCreate}
+	opUpdateRepairExecutionState = Operation{Name: "UpdateRepairExecutionState", Category: CategoryUpdate}
+
+	opReportClusterHealth     = Operation{Name: "ReportClusterHealth", Category: CategoryUpdate, idempotent: true}
+	opReportApplicationHealth = Operation{Name: "ReportApplicationHealth", Category: CategoryUpdate, idempotent: true}
+	opReportServiceHealth     = Operation{Name: "ReportServiceHealth", Category: CategoryUpdate, idempotent: true}
+	opReportNodeHealth        = Operation{Name: "ReportNodeHealth", Category: CategoryUpdate, idempotent: true}
+	opReportReplicaHealth     = Operation{Name: "ReportReplicaHealth", Category: CategoryUpdate, idempotent: true}
+
+	opUploadImageStoreFile = Operation{Name: "UploadImageStoreFile", Category: CategoryCreate, idempotent: true}
+	opDeleteUploadSession  = Operation{Name: "DeleteUploadSession", Category: CategoryDelete}
+
+	opCreateName          = Operation{Name: "CreateName", Category: CategoryCreate}
+	opPutProperty         = Operation{Name: "PutProperty", Category: CategoryUpdate, idempotent: true}
+	opDeleteProperty      = Operation{Name: "DeleteProperty", Category: CategoryDelete}
+	opSubmitPropertyBatch = Operation{Name: "SubmitPropertyBatch", Category: CategoryUpdate}
+)
+
+// OperationPolicy decides client side which mutating operations may be sent.
+// Entries are operation names such as "DeleteService", categories such as
+// "Delete", or "*" for every operation, and are matched case insensitively.
+// Deny entries take precedence over Allow entries, and an empty Allow list
+// permits every operation that is not denied.
+type OperationPolicy struct {
+	Allow []string
+	Deny  []string
+}
+
+// Permits reports whether the policy allows op to be sent
+func (p OperationPolicy) Permits(op Operation) bool {
+	if matchesOperation(p.Deny, op) {
+		return false
+	}
+	return len(p.Allow) == 0 || matchesOperation(p.Allow, op)
+}
+
+func matchesOperation(entries []string, op Operation) bool {
+	for _, entry := range entries {
+		if entry == "*" || strings.EqualFold(entry, op.Name) || strings.EqualFold(entry, string(op.Category)) {
+			return true
+		}
+	}
+	return false
+}
+
+// authorize checks op against the read-only flag and the operation policy
+func (c ServiceFabricClient) authorize(op Operation) error {
+	if c.readOnly {
+		return errors.Wrap(ErrReadOnlyClient, op.Name)
+	}
+	if c.policy != nil && !c.policy.Permits(op) {
+		return errors.Wrap(ErrOperationNotPermitted, op.Name)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Property value kinds
+const (
+	PropertyKindString = "String"
+	PropertyKindInt64  = "Int64"
+	PropertyKindDouble = "Double"
+	PropertyKindBinary = "Binary"
+	PropertyKindGUID   = "Guid"
+)
+
+// UnmarshalJSON keeps string data as is and the JSON text of other data,
+// such as the byte array of Binary values, so no page fails to decode
+func (v *PropValue) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Kind string          `json:"Kind"`
+		Data json.RawMessage `json:"Data"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	v.Kind = raw.Kind
+	if err := json.Unmarshal(raw.Data, &v.Data); err != nil {
+		v.Data = string(raw.Data)
+	}
+	return nil
+}
+
+// PropertySet holds the properties stored under a Service Fabric name
+type PropertySet struct {
+	Name       string
+	Properties map[string]*PropertyValue
+}
+
+// Get returns the property called name, if any
+func (s *PropertySet) Get(name string) (*PropertyValue, bool) {
+	v, ok := s.Properties[name]
+	return v, ok
+}
+
+// PropertyValue is a property of a PropertySet, whose value
+// is fetched on first access when the set was listed lazily
+type PropertyValue struct {
+	Name     string
+	Kind     string
+	Metadata Metadata
+
+	mu    sync.Mutex
+	value *PropValue
+	fetch func() (*PropValue, error)
+}
+
+func (v *PropertyValue) load(kind string) (string, error) {
+	if v.Kind != kind {
+		return "", fmt.Errorf("property %s is of kind %s, not %s", v.Name, v.Kind, kind)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.value == nil {
+		value, err := v.fetch()
+		if err != nil {
+			return "", err
+		}
+		v.value = value
+	}
+	return v.value.Data, nil
+}
+
+// AsString returns the value of a String or Guid property
+func (v *PropertyValue) AsString() (string, error) {
+	if v.Kind == PropertyKindGUID {
+		return v.load(PropertyKindGUID)
+	}
+	return v.load(PropertyKindString)
+}
+
+// AsInt64 returns the value of an Int64 property
+func (v *PropertyValue) AsInt64() (int64, error) {
+	data, err := v.load(PropertyKindInt64)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(data, 10, 64)
+}
+
+// AsDouble returns the value of a Double property
+func (v *PropertyValue) AsDouble() (float64, error) {
+	data, err := v.load(PropertyKindDouble)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(data, 64)
+}
+
+// AsBinary returns the value of a Binary property
+func (v *PropertyValue) AsBinary() ([]byte, error) {
+	data, err := v.load(PropertyKindBinary)
+	if err != nil {
+		return nil, err
+	}
+	return decodeBinary(v.Name, data)
+}
+
+// decodeBinary decodes the data of a Binary property, which is
+// sent as an array of numbers rather than as base64
+func decodeBinary(propertyName, data string) ([]byte, error) {
+	var values []int
+	if err := json.Unmarshal([]byte(data), &values); err != nil {
+		return nil, fmt.Errorf("could not deserialise binary property %s: %+v", propertyName, err)
+	}
+	b := make([]byte, len(values))
+	for i, value := range values {
+		if value < 0 || value > 255 {
+			return nil, fmt.Errorf("binary property %s holds %d, not a byte", propertyName, value)
+		}
+		b[i] = byte(value)
+	}
+	return b, nil
+}
+
+// encodeBinary encodes b as the data of a Binary property
+func encodeBinary(b []byte) json.RawMessage {
+	data := make([]byte, 0, 4*len(b)+2)
+	data = append(data, '[')
+	for i, value := range b {
+		if i > 0 {
+			data = append(data, ',')
+		}
+		data = strconv.AppendUint(data, uint64(value), 10)
+	}
+	return append(data, ']')
+}
+
+// GetProperty returns a property stored under a Service Fabric name, value included
+func (c ServiceFabricClient) GetProperty(ctx context.Context, name, propertyName string) (property *Property, err error) {
+	ctx, call := c.startCall(ctx, "GetProperty")
+	defer func() { call.finish(err) }()
+
+	return c.getProperty(ctx, name, propertyName)
+}
+
+func (c ServiceFabricClient) getProperty(ctx context.Context, name, propertyName string) (*Property, error) {
+	res, _, err := c.getHTTP(ctx, "Names/"+name+"/$/GetProperty", withParam("PropertyName", propertyName))
+	if err != nil {
+		return nil, err
+	}
+
+	var property Property
+	err = json.Unmarshal(res, &property)
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return &property, nil
+}
+
+// GetPropertySet returns every property stored under a Service Fabric name,
+// failing with ErrParentNotFound when the name does not exist. With lazy set,
+// pages are listed without values and each value is fetched with GetProperty
+// when first accessed, which keeps large binary properties off the wire
+// until they are needed. Lazy values are fetched with ctx, which must
+// outlive the accesses.
+func (c ServiceFabricClient) GetPropertySet(ctx context.Context, name string, lazy bool) (set *PropertySet, err error) {
+	ctx, call := c.startCall(ctx, "GetPropertySet")
+	defer func() { call.finish(err) }()
+
+	set = &PropertySet{Name: name, Properties: map[string]*PropertyValue{}}
+
+	var continueToken string
+	for {
+		res, status, err := c.getHTTP(ctx, "Names/"+name+"/$/GetProperties", withContinue(continueToken), withParam("IncludeValues", strconv.FormatBool(!lazy)))
+		if status == http.StatusNotFound {
+			return nil, errors.Wrapf(ErrParentNotFound, "name %s", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var propertiesListPage PropertiesListPage
+		err = json.Unmarshal(res, &propertiesListPage)
+		if err != nil {
+			return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+		}
+
+		for _, property := range propertiesListPage.Properties {
+			set.Properties[property.Name] = c.newPropertyValue(ctx, name, property, lazy)
+		}
+
+		continueToken = propertiesListPage.ContinuationToken
+		if continueToken == "" {
+			break
+		}
+	}
+	return set, nil
+}
+
+func (c ServiceFabricClient) newPropertyValue(ctx context.Context, name string, property Property, lazy bool) *PropertyValue {
+	v := &PropertyValue{
+		Name:     property.Name,
+		Kind:     property.Metadata.TypeID,
+		Metadata: property.Metadata,
+	}
+	if v.Kind == "" {
+		v.Kind = property.Value.Kind
+	}
+
+	if !lazy {
+		value := property.Value
+		v.value = &value
+		return v
+	}
+
+	v.fetch = func() (*PropValue, error) {
+		p, err := c.GetProperty(ctx, name, property.Name)
+		if err != nil {
+			return nil, err
+		}
+		return &p.Value, nil
+	}
+	return v
+}
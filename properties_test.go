@@ -0,0 +1,77 @@
+package servicefabric
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGetPropertySet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleProperties))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	set, err := sfClient.GetPropertySet(context.Background(), "TestApplication/TestService", false)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if len(set.Properties) != 4 {
+		t.Fatalf("Got %d properties, want 4", len(set.Properties))
+	}
+
+	enabled, _ := set.Get("traefik.enable")
+	if s, err := enabled.AsString(); err != nil || s != "true" {
+		t.Errorf("Got %q %v, want true", s, err)
+	}
+
+	counter, _ := set.Get("counter")
+	if n, err := counter.AsInt64(); err != nil || n != 42 {
+		t.Errorf("Got %d %v, want 42", n, err)
+	}
+	if counter.Metadata.SequenceNumber != "13" {
+		t.Errorf("Got %q, want 13", counter.Metadata.SequenceNumber)
+	}
+
+	weight, _ := set.Get("weight")
+	if f, err := weight.AsDouble(); err != nil || f != 0.5 {
+		t.Errorf("Got %v %v, want 0.5", f, err)
+	}
+
+	blob, _ := set.Get("blob")
+	if b, err := blob.AsBinary(); err != nil || !reflect.DeepEqual(b, []byte("hi")) {
+		t.Errorf("Got %v %v, want %v", b, err, []byte("hi"))
+	}
+
+	if _, err := blob.AsString(); err == nil {
+		t.Error("Error should have been returned")
+	}
+}
+
+func TestGetPropertySetLazy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleProperties))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	set, err := sfClient.GetPropertySet(context.Background(), "TestApplication/TestService", true)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	blob, ok := set.Get("blob")
+	if !ok {
+		t.Fatal("Property blob should have been listed")
+	}
+
+	b, err := blob.AsBinary()
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if !reflect.DeepEqual(b, []byte("hi")) {
+		t.Errorf("Got %v, want %v", b, []byte("hi"))
+	}
+}
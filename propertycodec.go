@@ -0,0 +1,413 @@
+package servicefabric
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MaxPropertySize is the largest value, in bytes, a property can hold
+const MaxPropertySize = 1024 * 1024
+
+// maxPropertyChunks bounds the number of properties StoreObject spreads a value over
+const maxPropertyChunks = 64
+
+// maxGatewayRequestSize is the largest request body, in bytes, the HTTP
+// gateway of a cluster accepts by default, see MaxEntityBodySize
+const maxGatewayRequestSize = 4 * 1024 * 1024
+
+// propertyChunkSize is the size StoreObject cuts values into. Binary values
+// are sent as JSON arrays of up to four bytes per byte, so a chunk and the
+// operations batched with it stay under maxGatewayRequestSize.
+const propertyChunkSize = 768 * 1024
+
+// maxLoadAttempts bounds how often LoadObject reads a value again
+// after a concurrent StoreObject replaced the chunks it was reading
+const maxLoadAttempts = 3
+
+// Parameters appended to the custom type id of values spread over several
+// properties, the number of chunks and the generation of the store
+const (
+	chunksParam     = "chunks"
+	generationParam = "generation"
+)
+
+// ErrPropertyTooLarge is returned when a value exceeds MaxPropertySize,
+// or the chunks StoreObject is allowed to spread it over
+var ErrPropertyTooLarge = errors.New("service fabric property value is too large")
+
+// PropertyCodec encodes the values StoreObject and LoadObject
+// keep in Binary properties
+type PropertyCodec interface {
+	// Name is recorded as the custom type id of the properties
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// Codecs provided for StoreObject and LoadObject, callers may implement
+// PropertyCodec for other encodings such as protocol buffers
+var (
+	JSONCodec PropertyCodec = jsonCodec{}
+	GobCodec  PropertyCodec = gobCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                               { return "json" }
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+type gobCodec struct{}
+
+func (gobCodec) Name() string { return "gob" }
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// PutBinaryProperty stores data as a Binary property of a Service Fabric name
+func (c ServiceFabricClient) PutBinaryProperty(ctx context.Context, name, propertyName string, data []byte, customTypeID string) (err error) {
+	ctx, call := c.startCall(ctx, "PutBinaryProperty")
+	defer func() { call.finish(err) }()
+
+	return c.putBinaryProperty(ctx, name, propertyName, data, customTypeID)
+}
+
+func (c ServiceFabricClient) putBinaryProperty(ctx context.Context, name, propertyName string, data []byte, customTypeID string) error {
+	if len(data) > MaxPropertySize {
+		return errors.Wrapf(ErrPropertyTooLarge, "%s holds %d bytes", propertyName, len(data))
+	}
+
+	body, err := json.Marshal(struct {
+		PropertyName string          `json:"PropertyName"`
+		Value        json.RawMessage `json:"Value"`
+		CustomTypeID string          `json:"CustomTypeId,omitempty"`
+	}{
+		PropertyName: propertyName,
+		Value:        json.RawMessage(`{"Kind":"Binary","Data":` + string(encodeBinary(data)) + `}`),
+		CustomTypeID: customTypeID,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.sendHTTP(ctx, "PUT", opPutProperty.on(name+"/"+propertyName), "Names/"+name+"/$/GetProperty", body)
+	if err != nil {
+		return errors.Wrap(err, "failed putting property")
+	}
+	return nil
+}
+
+// DeleteProperty removes a property of a Service Fabric name
+func (c ServiceFabricClient) DeleteProperty(ctx context.Context, name, propertyName string) (err error) {
+	ctx, call := c.startCall(ctx, "DeleteProperty")
+	defer func() { call.finish(err) }()
+
+	_, err = c.deleteProperty(ctx, name, propertyName)
+	return err
+}
+
+func (c ServiceFabricClient) deleteProperty(ctx context.Context, name, propertyName string) (int, error) {
+	_, status, err := c.sendHTTP(ctx, "DELETE", opDeleteProperty.on(name+"/"+propertyName), "Names/"+name+"/$/GetProperty", nil, withParam("PropertyName", propertyName))
+	if err != nil {
+		return status, errors.Wrap(err, "failed deleting property")
+	}
+	return status, nil
+}
+
+// propertyBatchOperation is an operation of a property batch, the
+// fields other than Kind and PropertyName depending on the kind
+type propertyBatchOperation struct {
+	Kind           string          `json:"Kind"`
+	PropertyName   string          `json:"PropertyName"`
+	Value          json.RawMessage `json:"Value,omitempty"`
+	CustomTypeID   string          `json:"CustomTypeId,omitempty"`
+	SequenceNumber string          `json:"SequenceNumber,omitempty"`
+	Exists         *bool           `json:"Exists,omitempty"`
+}
+
+// StoreObject encodes v with codec into the Binary property propertyName,
+// creating the Service Fabric name when it does not exist. Values larger
+// than 768 KiB are spread over additional properties named after
+// propertyName and a generation drawn for every store. The chunks are
+// written first, in batches the cluster gateway accepts, then the value
+// is switched to them and the chunks of the previous value are removed in
+// a single property batch, which the cluster applies atomically. That
+// batch only applies if propertyName did not change since it was read, a
+// concurrent StoreObject making it fail instead. LoadObject checks the
+// generation of every chunk it reads, so readers see either the previous
+// or the new value.
+func (c ServiceFabricClient) StoreObject(ctx context.Context, name, propertyName string, v interface{}, codec PropertyCodec) (err error) {
+	ctx, call := c.startCall(ctx, "StoreObject")
+	defer func() { call.finish(err) }()
+
+	data, err := codec.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("could not encode %s: %v", propertyName, err)
+	}
+	if len(data) > maxPropertyChunks*propertyChunkSize {
+		return errors.Wrapf(ErrPropertyTooLarge, "%s encodes to %d bytes", propertyName, len(data))
+	}
+
+	var chunks [][]byte
+	for len(data) > propertyChunkSize {
+		chunks = append(chunks, data[:propertyChunkSize])
+		data = data[propertyChunkSize:]
+	}
+	chunks = append(chunks, data)
+
+	// the chunks of the previous value are known from its head
+	var check propertyBatchOperation
+	var oldChunks []string
+	old, err := c.getProperty(ctx, name, propertyName)
+	switch {
+	case err == nil:
+		check = propertyBatchOperation{Kind: "CheckSequence", PropertyName: propertyName, SequenceNumber: old.Metadata.SequenceNumber}
+		if _, n, generation, err := parseStoredTypeID(old.Metadata.CustomTypeID); err == nil {
+			for i := 1; i < n; i++ {
+				oldChunks = append(oldChunks, chunkName(propertyName, generation, i))
+			}
+		}
+	case errors.Is(err, ErrResourceNotFound):
+		if err := c.createName(ctx, name); err != nil {
+			return err
+		}
+		exists := false
+		check = propertyBatchOperation{Kind: "CheckExists", PropertyName: propertyName, Exists: &exists}
+	default:
+		return err
+	}
+
+	customTypeID := codec.Name()
+	var newChunks []string
+	if len(chunks) > 1 {
+		generation, err := newGeneration()
+		if err != nil {
+			return err
+		}
+		customTypeID += ";" + generationParam + "=" + generation
+		for i := 1; i < len(chunks); i++ {
+			newChunks = append(newChunks, chunkName(propertyName, generation, i))
+		}
+	}
+
+	// chunks go under names no reader of the previous value reads
+	var batch []propertyBatchOperation
+	batchSize := 0
+	for i, chunkName := range newChunks {
+		operation := binaryPutOperation(chunkName, chunks[i+1], customTypeID)
+		if len(batch) > 0 && batchSize+operation.size() > maxGatewayRequestSize {
+			if err := c.submitPropertyBatch(ctx, name, propertyName, batch); err != nil {
+				c.deleteChunks(ctx, name, propertyName, newChunks[:i])
+				return err
+			}
+			batch, batchSize = nil, 0
+		}
+		batch = append(batch, operation)
+		batchSize += operation.size()
+	}
+	if len(batch) > 0 {
+		if err := c.submitPropertyBatch(ctx, name, propertyName, batch); err != nil {
+			c.deleteChunks(ctx, name, propertyName, newChunks)
+			return err
+		}
+	}
+
+	headTypeID := customTypeID
+	if len(chunks) > 1 {
+		headTypeID += ";" + chunksParam + "=" + strconv.Itoa(len(chunks))
+	}
+	operations := []propertyBatchOperation{check, binaryPutOperation(propertyName, chunks[0], headTypeID)}
+	for _, chunkName := range oldChunks {
+		operations = append(operations, propertyBatchOperation{Kind: "Delete", PropertyName: chunkName})
+	}
+	if err := c.submitPropertyBatch(ctx, name, propertyName, operations); err != nil {
+		c.deleteChunks(ctx, name, propertyName, newChunks)
+		return err
+	}
+	return nil
+}
+
+func binaryPutOperation(propertyName string, data []byte, customTypeID string) propertyBatchOperation {
+	return propertyBatchOperation{
+		Kind:         "Put",
+		PropertyName: propertyName,
+		Value:        json.RawMessage(`{"Kind":"Binary","Data":` + string(encodeBinary(data)) + `}`),
+		CustomTypeID: customTypeID,
+	}
+}
+
+// size bounds the length of the JSON encoding of o in a batch
+func (o propertyBatchOperation) size() int {
+	return len(o.Value) + len(o.PropertyName) + len(o.CustomTypeID) + 128
+}
+
+// deleteChunks removes the chunks of a store that failed, as far as it can
+func (c ServiceFabricClient) deleteChunks(ctx context.Context, name, propertyName string, chunks []string) {
+	if len(chunks) == 0 {
+		return
+	}
+	var operations []propertyBatchOperation
+	for _, chunkName := range chunks {
+		operations = append(operations, propertyBatchOperation{Kind: "Delete", PropertyName: chunkName})
+	}
+	_ = c.submitPropertyBatch(ctx, name, propertyName, operations)
+}
+
+// createName creates the Service Fabric name properties are stored under,
+// a name that already exists being left as it is
+func (c ServiceFabricClient) createName(ctx context.Context, name string) error {
+	body, err := json.Marshal(struct {
+		Name string `json:"Name"`
+	}{fabricScheme + strings.TrimPrefix(name, fabricScheme)})
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.postHTTP(ctx, opCreateName.on(name), "Names/$/Create", body)
+	if err != nil && !IsFabricError(err, FabricErrorNameAlreadyExists) && ClassifyError(err) != ErrorClassConflict {
+		return errors.Wrapf(err, "failed creating name %s", name)
+	}
+	return nil
+}
+
+// newGeneration returns a random id telling the chunks of a store apart
+func newGeneration() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// submitPropertyBatch applies operations to the properties of name atomically
+func (c ServiceFabricClient) submitPropertyBatch(ctx context.Context, name, propertyName string, operations []propertyBatchOperation) error {
+	body, err := json.Marshal(struct {
+		Operations []propertyBatchOperation `json:"Operations"`
+	}{operations})
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.postHTTP(ctx, opSubmitPropertyBatch.on(name+"/"+propertyName), "Names/"+name+"/$/GetProperties/$/SubmitBatch", body)
+	if err != nil {
+		return errors.Wrapf(err, "failed storing property %s", propertyName)
+	}
+	return nil
+}
+
+// LoadObject decodes the value StoreObject stored in propertyName into v.
+// A value replaced while its chunks are read is read again.
+func (c ServiceFabricClient) LoadObject(ctx context.Context, name, propertyName string, v interface{}, codec PropertyCodec) (err error) {
+	ctx, call := c.startCall(ctx, "LoadObject")
+	defer func() { call.finish(err) }()
+
+	for attempt := 1; ; attempt++ {
+		data, err := c.loadObjectData(ctx, name, propertyName, codec)
+		if errors.Cause(err) == errChunkReplaced && attempt < maxLoadAttempts {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := codec.Unmarshal(data, v); err != nil {
+			return fmt.Errorf("could not decode %s: %v", propertyName, err)
+		}
+		return nil
+	}
+}
+
+// errChunkReplaced is returned when a chunk read belongs to another store
+// than the head read before it
+var errChunkReplaced = errors.New("property value changed while loading")
+
+// loadObjectData reads the head of a value and its chunks
+func (c ServiceFabricClient) loadObjectData(ctx context.Context, name, propertyName string, codec PropertyCodec) ([]byte, error) {
+	head, err := c.getProperty(ctx, name, propertyName)
+	if err != nil {
+		return nil, err
+	}
+	if head.Value.Kind != PropertyKindBinary {
+		return nil, fmt.Errorf("property %s is of kind %s, not %s", propertyName, head.Value.Kind, PropertyKindBinary)
+	}
+
+	codecName, chunks, generation, err := parseStoredTypeID(head.Metadata.CustomTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("property %s: %v", propertyName, err)
+	}
+	if codecName != codec.Name() {
+		return nil, fmt.Errorf("property %s is encoded with %s, not %s", propertyName, codecName, codec.Name())
+	}
+
+	data, err := decodeBinary(propertyName, head.Value.Data)
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i < chunks; i++ {
+		chunk, err := c.getProperty(ctx, name, chunkName(propertyName, generation, i))
+		if errors.Is(err, ErrResourceNotFound) {
+			// removed by a store that replaced the head
+			return nil, errors.Wrapf(errChunkReplaced, "chunk %d of %s", i, propertyName)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed getting chunk %d of %s", i, propertyName)
+		}
+		if _, _, chunkGeneration, err := parseStoredTypeID(chunk.Metadata.CustomTypeID); err != nil || chunkGeneration != generation {
+			return nil, errors.Wrapf(errChunkReplaced, "chunk %d of %s", i, propertyName)
+		}
+		b, err := decodeBinary(chunk.Name, chunk.Value.Data)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return data, nil
+}
+
+func chunkName(propertyName, generation string, i int) string {
+	return propertyName + "." + generation + ".chunk" + strconv.Itoa(i)
+}
+
+// parseStoredTypeID splits a custom type id written by StoreObject into the
+// codec name, the number of chunks and the generation of chunked values
+func parseStoredTypeID(customTypeID string) (string, int, string, error) {
+	fields := strings.Split(customTypeID, ";")
+	chunks := 1
+	var generation string
+	for _, field := range fields[1:] {
+		i := strings.Index(field, "=")
+		if i < 0 {
+			return "", 0, "", fmt.Errorf("invalid custom type id %q", customTypeID)
+		}
+		switch field[:i] {
+		case chunksParam:
+			n, err := strconv.Atoi(field[i+1:])
+			if err != nil || n < 1 || n > maxPropertyChunks {
+				return "", 0, "", fmt.Errorf("invalid custom type id %q", customTypeID)
+			}
+			chunks = n
+		case generationParam:
+			generation = field[i+1:]
+		}
+	}
+	if chunks > 1 && generation == "" {
+		return "", 0, "", fmt.Errorf("invalid custom type id %q", customTypeID)
+	}
+	return fields[0], chunks, generation, nil
+}
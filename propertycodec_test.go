@@ -0,0 +1,320 @@
+package servicefabric
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// propertyServer keeps the properties of a single name in memory
+type propertyServer struct {
+	mu         sync.Mutex
+	properties map[string]Property
+	sequence   int
+	// deletes counts the DELETE requests received
+	deletes int
+	// nameCreated is set once the name was created
+	nameCreated bool
+	// largestBatch is the size of the largest batch request received
+	largestBatch int
+}
+
+func (s *propertyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method == http.MethodPost && r.URL.Path == "/Names/$/Create" {
+		if s.nameCreated {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"Error":{"Code":"FABRIC_E_NAME_ALREADY_EXISTS"}}`))
+			return
+		}
+		s.nameCreated = true
+		return
+	}
+	if r.Method == http.MethodPost && r.URL.Path == "/Names/TestName/$/GetProperties/$/SubmitBatch" {
+		if r.ContentLength > int64(s.largestBatch) {
+			s.largestBatch = int(r.ContentLength)
+		}
+		s.submitBatch(w, r)
+		return
+	}
+	if r.URL.Path != "/Names/TestName/$/GetProperty" {
+		http.NotFound(w, r)
+		return
+	}
+
+	propertyName := r.URL.Query().Get("PropertyName")
+	switch r.Method {
+	case http.MethodPut:
+		var description struct {
+			PropertyName string
+			Value        PropValue
+			CustomTypeID string `json:"CustomTypeId"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&description); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.put(description.PropertyName, description.Value, description.CustomTypeID)
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		property, ok := s.properties[propertyName]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"Name":"` + property.Name + `","Value":{"Kind":"` + property.Value.Kind + `","Data":` + property.Value.Data +
+			`},"Metadata":{"TypeId":"` + property.Metadata.TypeID + `","CustomTypeId":"` + property.Metadata.CustomTypeID +
+			`","SequenceNumber":"` + property.Metadata.SequenceNumber + `"}}`))
+	case http.MethodDelete:
+		s.deletes++
+		if _, ok := s.properties[propertyName]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		delete(s.properties, propertyName)
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *propertyServer) put(propertyName string, value PropValue, customTypeID string) {
+	s.sequence++
+	s.properties[propertyName] = Property{
+		Name:     propertyName,
+		Value:    value,
+		Metadata: Metadata{TypeID: value.Kind, CustomTypeID: customTypeID, SequenceNumber: strconv.Itoa(s.sequence)},
+	}
+}
+
+// submitBatch checks every operation of a batch before applying any
+func (s *propertyServer) submitBatch(w http.ResponseWriter, r *http.Request) {
+	var batch struct {
+		Operations []struct {
+			Kind         string
+			PropertyName string
+			Value        struct {
+				Kind string
+				Data json.RawMessage
+			}
+			CustomTypeID   string `json:"CustomTypeId"`
+			SequenceNumber string
+			Exists         bool
+		}
+	}
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	for _, op := range batch.Operations {
+		property, exists := s.properties[op.PropertyName]
+		if op.Kind == "CheckExists" && exists != op.Exists ||
+			op.Kind == "CheckSequence" && property.Metadata.SequenceNumber != op.SequenceNumber ||
+			op.Kind == "Delete" && !exists {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+	}
+	for _, op := range batch.Operations {
+		switch op.Kind {
+		case "Put":
+			s.put(op.PropertyName, PropValue{Kind: op.Value.Kind, Data: string(op.Value.Data)}, op.CustomTypeID)
+		case "Delete":
+			delete(s.properties, op.PropertyName)
+		}
+	}
+}
+
+func (s *propertyServer) names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var names []string
+	for name := range s.properties {
+		names = append(names, name)
+	}
+	return names
+}
+
+type storedObject struct {
+	Name    string
+	Payload []byte
+}
+
+func TestStoreAndLoadObject(t *testing.T) {
+	for _, codec := range []PropertyCodec{JSONCodec, GobCodec} {
+		codec := codec
+		t.Run(codec.Name(), func(t *testing.T) {
+			properties := &propertyServer{properties: map[string]Property{}}
+			server := httptest.NewServer(properties)
+			defer server.Close()
+
+			sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+			expected := storedObject{Name: "routes", Payload: []byte{0, 1, 2, 255}}
+			if err := sfClient.StoreObject(context.Background(), "TestName", "state", expected, codec); err != nil {
+				t.Fatalf("Exception thrown %v", err)
+			}
+
+			var actual storedObject
+			if err := sfClient.LoadObject(context.Background(), "TestName", "state", &actual, codec); err != nil {
+				t.Fatalf("Exception thrown %v", err)
+			}
+
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("Got %+v, want %+v", actual, expected)
+			}
+		})
+	}
+}
+
+func TestStoreObjectChunksLargeValues(t *testing.T) {
+	properties := &propertyServer{properties: map[string]Property{}}
+	server := httptest.NewServer(properties)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	// bytes of 255 take the most room once encoded as a JSON array
+	expected := storedObject{Name: "large", Payload: bytes.Repeat([]byte{255}, 2*MaxPropertySize+10)}
+	if err := sfClient.StoreObject(context.Background(), "TestName", "state", expected, GobCodec); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if names := properties.names(); len(names) != 3 {
+		t.Errorf("Got properties %v, want 3", names)
+	}
+	if properties.largestBatch > maxGatewayRequestSize {
+		t.Errorf("Got a batch of %d bytes, want at most %d", properties.largestBatch, maxGatewayRequestSize)
+	}
+
+	var actual storedObject
+	if err := sfClient.LoadObject(context.Background(), "TestName", "state", &actual, GobCodec); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Error("Loaded value differs from the stored value")
+	}
+
+	if err := sfClient.StoreObject(context.Background(), "TestName", "state", storedObject{Name: "small"}, GobCodec); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if names := properties.names(); !reflect.DeepEqual(names, []string{"state"}) {
+		t.Errorf("Got properties %v, want [state]", names)
+	}
+	if properties.deletes != 0 {
+		t.Errorf("Got %d DELETE requests, want the chunks deleted in the batch", properties.deletes)
+	}
+}
+
+func TestStoreObjectCreatesName(t *testing.T) {
+	for _, nameCreated := range []bool{false, true} {
+		properties := &propertyServer{properties: map[string]Property{}, nameCreated: nameCreated}
+		server := httptest.NewServer(properties)
+
+		sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+		if err := sfClient.StoreObject(context.Background(), "TestName", "state", storedObject{Name: "routes"}, JSONCodec); err != nil {
+			t.Errorf("Exception thrown %v", err)
+		}
+		if !properties.nameCreated {
+			t.Error("Name should have been created")
+		}
+		server.Close()
+	}
+}
+
+func TestLoadObjectRetriesOnConcurrentStore(t *testing.T) {
+	properties := &propertyServer{properties: map[string]Property{}}
+	var sfClient *ServiceFabricClient
+	var replaced bool
+	expected := storedObject{Name: "second", Payload: bytes.Repeat([]byte{2}, MaxPropertySize)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.Contains(r.URL.Query().Get("PropertyName"), ".chunk") && !replaced {
+			// another writer replaces the value after its head was read
+			replaced = true
+			if err := sfClient.StoreObject(context.Background(), "TestName", "state", expected, GobCodec); err != nil {
+				t.Errorf("Exception thrown %v", err)
+			}
+		}
+		properties.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	sfClient, _ = NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	first := storedObject{Name: "first", Payload: bytes.Repeat([]byte{1}, MaxPropertySize)}
+	if err := sfClient.StoreObject(context.Background(), "TestName", "state", first, GobCodec); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	var actual storedObject
+	if err := sfClient.LoadObject(context.Background(), "TestName", "state", &actual, GobCodec); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if !replaced {
+		t.Fatal("Value should have been replaced while loading")
+	}
+	if actual.Name != expected.Name || !bytes.Equal(actual.Payload, expected.Payload) {
+		t.Errorf("Got %s, want %s", actual.Name, expected.Name)
+	}
+}
+
+func TestStoreObjectFailsOnConcurrentChange(t *testing.T) {
+	properties := &propertyServer{properties: map[string]Property{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		properties.ServeHTTP(w, r)
+		if r.Method == http.MethodGet {
+			// another writer stores a value between the read and the batch
+			properties.mu.Lock()
+			properties.put("state", PropValue{Kind: "Binary", Data: "[1]"}, "gob")
+			properties.mu.Unlock()
+		}
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	if err := sfClient.StoreObject(context.Background(), "TestName", "state", storedObject{Name: "first"}, GobCodec); err == nil {
+		t.Fatal("Error should have been returned")
+	}
+	if data := properties.properties["state"].Value.Data; data != "[1]" {
+		t.Errorf("Got %s, want the concurrent value kept", data)
+	}
+}
+
+func TestLoadObjectWithOtherCodecReturnsError(t *testing.T) {
+	properties := &propertyServer{properties: map[string]Property{}}
+	server := httptest.NewServer(properties)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	if err := sfClient.StoreObject(context.Background(), "TestName", "state", storedObject{Name: "routes"}, JSONCodec); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	var actual storedObject
+	if err := sfClient.LoadObject(context.Background(), "TestName", "state", &actual, GobCodec); err == nil {
+		t.Error("Error should have been returned")
+	}
+}
+
+func TestPutBinaryPropertyTooLarge(t *testing.T) {
+	sfClient, _ := NewServiceFabricClient(nil, "https://cluster.example.com:19080", "")
+
+	err := sfClient.PutBinaryProperty(context.Background(), "TestName", "state", make([]byte, MaxPropertySize+1), "")
+	if errors.Cause(err) != ErrPropertyTooLarge {
+		t.Errorf("Got %v, want %v", err, ErrPropertyTooLarge)
+	}
+}
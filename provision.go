@@ -0,0 +1,101 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// provisionAPIVersion is the first API version taking
+// Kind based provision descriptions
+const provisionAPIVersion = "6.2"
+
+// ProvisionDescription describes where the cluster provisions an
+// application type version from, either an application package uploaded
+// to the image store or an sfpkg it downloads
+type ProvisionDescription struct {
+	// ImageStorePath is the folder of the application package,
+	// relative to the image store root
+	ImageStorePath string
+	// DownloadURI is an http or https URI of an sfpkg, used when
+	// ImageStorePath is empty together with the type name and version
+	DownloadURI            string
+	ApplicationTypeName    string
+	ApplicationTypeVersion string
+	// Async returns once the cluster accepted the request, the type
+	// version reporting the Provisioning status until it is ready
+	Async bool
+}
+
+// imageStoreProvisionDescription provisions an application type
+// from an application package in the image store
+type imageStoreProvisionDescription struct {
+	Kind                     string `json:"Kind"`
+	Async                    bool   `json:"Async"`
+	ApplicationTypeBuildPath string `json:"ApplicationTypeBuildPath"`
+}
+
+// externalStoreProvisionDescription provisions an application type
+// from an sfpkg the cluster downloads
+type externalStoreProvisionDescription struct {
+	Kind                          string `json:"Kind"`
+	Async                         bool   `json:"Async"`
+	ApplicationPackageDownloadURI string `json:"ApplicationPackageDownloadUri"`
+	ApplicationTypeName           string `json:"ApplicationTypeName"`
+	ApplicationTypeVersion        string `json:"ApplicationTypeVersion"`
+}
+
+// body returns the target of the operation provisioning d,
+// and its request body
+func (d ProvisionDescription) body() (string, interface{}, error) {
+	switch {
+	case d.ImageStorePath != "" && d.DownloadURI != "":
+		return "", nil, errors.New("either an image store path or a download URI is required, not both")
+	case d.ImageStorePath != "":
+		return d.ImageStorePath, imageStoreProvisionDescription{
+			Kind:                     "ImageStorePath",
+			Async:                    d.Async,
+			ApplicationTypeBuildPath: d.ImageStorePath,
+		}, nil
+	case d.DownloadURI != "":
+		if d.ApplicationTypeName == "" || d.ApplicationTypeVersion == "" {
+			return "", nil, errors.New("application type name and version are required to provision from a download URI")
+		}
+		return d.ApplicationTypeName + "@" + d.ApplicationTypeVersion, externalStoreProvisionDescription{
+			Kind:                          "ExternalStore",
+			Async:                         d.Async,
+			ApplicationPackageDownloadURI: d.DownloadURI,
+			ApplicationTypeName:           d.ApplicationTypeName,
+			ApplicationTypeVersion:        d.ApplicationTypeVersion,
+		}, nil
+	}
+	return "", nil, errors.New("an image store path or a download URI is required")
+}
+
+// ProvisionApplicationType provisions the application type version of
+// the package at description.ImageStorePath, or of the sfpkg at
+// description.DownloadURI. UnprovisionApplicationType removes it again.
+func (c ServiceFabricClient) ProvisionApplicationType(ctx context.Context, description ProvisionDescription) (err error) {
+	ctx, call := c.startCall(ctx, "ProvisionApplicationType")
+	defer func() { call.finish(err) }()
+
+	return c.provisionApplicationType(ctx, description)
+}
+
+func (c ServiceFabricClient) provisionApplicationType(ctx context.Context, description ProvisionDescription) error {
+	target, request, err := description.body()
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.postHTTP(ctx, opProvisionApplicationType.on(target), "ApplicationTypes/$/Provision", body, withMinAPIVersion(provisionAPIVersion))
+	if err != nil {
+		return errors.Wrap(err, "failed provisioning application type")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestProvisionApplicationType(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/ApplicationTypes/$/Provision" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("api-version") != "6.2" {
+			t.Errorf("Got api-version %s, want 6.2", r.URL.Query().Get("api-version"))
+		}
+		body = nil
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Exception thrown %v", err)
+		}
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	err := sfClient.ProvisionApplicationType(context.Background(), ProvisionDescription{ImageStorePath: "Store/TestApplication", Async: true})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	expected := map[string]interface{}{"Kind": "ImageStorePath", "Async": true, "ApplicationTypeBuildPath": "Store/TestApplication"}
+	if !reflect.DeepEqual(body, expected) {
+		t.Errorf("Got %+v, want %+v", body, expected)
+	}
+
+	err = sfClient.ProvisionApplicationType(context.Background(), ProvisionDescription{
+		DownloadURI:            "https://packages.example.com/TestApplication.sfpkg",
+		ApplicationTypeName:    "TestApplicationType",
+		ApplicationTypeVersion: "1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	expected = map[string]interface{}{
+		"Kind":                          "ExternalStore",
+		"Async":                         false,
+		"ApplicationPackageDownloadUri": "https://packages.example.com/TestApplication.sfpkg",
+		"ApplicationTypeName":           "TestApplicationType",
+		"ApplicationTypeVersion":        "1.0.0",
+	}
+	if !reflect.DeepEqual(body, expected) {
+		t.Errorf("Got %+v, want %+v", body, expected)
+	}
+
+	for _, description := range []ProvisionDescription{
+		{},
+		{ImageStorePath: "Store/TestApplication", DownloadURI: "https://packages.example.com/TestApplication.sfpkg"},
+		{DownloadURI: "https://packages.example.com/TestApplication.sfpkg"},
+	} {
+		if err := sfClient.ProvisionApplicationType(context.Background(), description); err == nil {
+			t.Errorf("Error should have been returned for %+v", description)
+		}
+	}
+}
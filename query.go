@@ -1,5 +1,10 @@
 package servicefabric
 
+import (
+	"net/url"
+	"strings"
+)
+
 type queryParamsFunc func(params []string) []string
 
 func withContinue(token string) queryParamsFunc {
@@ -11,10 +16,34 @@ func withContinue(token string) queryParamsFunc {
 
 func withParam(name, value string) queryParamsFunc {
 	return func(params []string) []string {
-		return append(params, name+"="+value)
+		return append(params, url.QueryEscape(name)+"="+url.QueryEscape(value))
+	}
+}
+
+// withOptionalParam adds the parameter only when value is set
+func withOptionalParam(name, value string) queryParamsFunc {
+	if len(value) == 0 {
+		return noOp
 	}
+	return withParam(name, value)
 }
 
 func noOp(params []string) []string {
 	return params
 }
+
+// withMinAPIVersion raises the api-version of the request to minimum,
+// for calls the default API version does not serve
+func withMinAPIVersion(minimum string) queryParamsFunc {
+	return func(params []string) []string {
+		for i, param := range params {
+			if version := strings.TrimPrefix(param, "api-version="); version != param {
+				if compareVersions(trimPreview(version), minimum) < 0 {
+					params[i] = "api-version=" + url.QueryEscape(minimum)
+				}
+				return params
+			}
+		}
+		return append(params, "api-version="+url.QueryEscape(minimum))
+	}
+}
@@ -0,0 +1,440 @@
+package servicefabric
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Kinds of the actions of a ReconcilePlan, in the order Apply takes them
+const (
+	ReconcileProvisionType      = "ProvisionType"
+	ReconcileCreateApplication  = "CreateApplication"
+	ReconcileUpgradeApplication = "UpgradeApplication"
+	ReconcileCreateService      = "CreateService"
+	ReconcileUpdateService      = "UpdateService"
+	ReconcileDeleteService      = "DeleteService"
+	ReconcileDeleteApplication  = "DeleteApplication"
+	ReconcileUnprovisionType    = "UnprovisionType"
+)
+
+// DesiredApplication is the state Reconciler converges an application to
+type DesiredApplication struct {
+	// Application is created, or upgraded when its type version or
+	// parameters differ from those of the running application
+	Application ApplicationDescription
+	// Provision provisions the application type version when the cluster
+	// does not have it, which must otherwise be provisioned already
+	Provision *ProvisionDescription
+	// Services are created, or updated when their instance count, replica
+	// set sizes, placement constraints, correlations or instance close
+	// delay differ. Their kind, type and partitioning cannot be updated.
+	Services []ServiceDescription
+}
+
+// DesiredState lists the applications a Reconciler manages
+type DesiredState struct {
+	Applications []DesiredApplication
+	// Prune deletes the services of desired applications that are not
+	// listed, and the applications under Prefix that are not listed
+	Prune bool
+	// Prefix scopes the applications Prune deletes, see
+	// GetApplicationsWithPrefix. No application is deleted when empty.
+	Prefix string
+	// UnprovisionUnused unprovisions the versions of the application
+	// types of desired applications that no application runs once the
+	// plan is applied
+	UnprovisionUnused bool
+	// Upgrade tunes the upgrades of applications, whose names, versions
+	// and parameters are taken from the desired applications
+	Upgrade ApplicationUpgradeDescription
+}
+
+// ReconcileAction is a change a ReconcilePlan makes to the cluster
+type ReconcileAction struct {
+	// Kind is one of the Reconcile constants
+	Kind string
+	// Name is the fabric name of the application or service,
+	// or the name of the application type
+	Name string
+	// Version is the application type version provisioned, unprovisioned
+	// or upgraded to
+	Version string
+	// Changes describes the fields an update changes
+	Changes []string
+
+	provision   ProvisionDescription
+	application ApplicationDescription
+	upgrade     ApplicationUpgradeDescription
+	service     ServiceDescription
+	update      ServiceUpdateDescription
+}
+
+// String describes the action for plan reports
+func (a ReconcileAction) String() string {
+	s := a.Kind + " " + a.Name
+	if a.Version != "" {
+		s += " " + a.Version
+	}
+	if len(a.Changes) > 0 {
+		s += " (" + strings.Join(a.Changes, ", ") + ")"
+	}
+	return s
+}
+
+// ReconcilePlan lists the actions converging the cluster to a DesiredState,
+// empty when the cluster is in that state already
+type ReconcilePlan struct {
+	Actions []ReconcileAction
+}
+
+// Reconciler converges the applications and services of a cluster to a
+// DesiredState, reporting the plan of the changes before applying it
+type Reconciler struct {
+	client *ServiceFabricClient
+	// UpgradeWait tunes the wait for every application upgrade Apply starts
+	UpgradeWait *UpgradeWaitOptions
+}
+
+// NewReconciler returns a Reconciler changing the cluster of client
+func NewReconciler(client *ServiceFabricClient) *Reconciler {
+	return &Reconciler{client: client}
+}
+
+// Plan compares desired with the cluster and returns the actions
+// converging it, without changing anything. Service changes that need
+// a service to be recreated fail the plan.
+func (r *Reconciler) Plan(ctx context.Context, desired DesiredState) (*ReconcilePlan, error) {
+	types, err := r.client.GetApplicationTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	provisioned := map[string][]string{}
+	for _, appType := range types.Items {
+		provisioned[appType.Name] = append(provisioned[appType.Name], appType.Version)
+	}
+
+	apps, err := r.client.GetApplications(ctx)
+	if err != nil {
+		return nil, err
+	}
+	running := map[string]*ApplicationItem{}
+	for i := range apps.Items {
+		running[apps.Items[i].Name] = &apps.Items[i]
+	}
+
+	plan := &ReconcilePlan{}
+	wanted := map[string]bool{}
+	// versions maps the desired type names to the versions in use once applied
+	versions := map[string]map[string]bool{}
+	for _, app := range desired.Applications {
+		description := app.Application
+		if err := description.validate(); err != nil {
+			return nil, err
+		}
+		if wanted[description.Name] {
+			return nil, fmt.Errorf("application %s is listed twice", description.Name)
+		}
+		wanted[description.Name] = true
+		if versions[description.TypeName] == nil {
+			versions[description.TypeName] = map[string]bool{}
+		}
+		versions[description.TypeName][description.TypeVersion] = true
+
+		if !containsString(provisioned[description.TypeName], description.TypeVersion) {
+			if app.Provision == nil {
+				return nil, fmt.Errorf("application type %s version %s is not provisioned", description.TypeName, description.TypeVersion)
+			}
+			provision := *app.Provision
+			provision.ApplicationTypeName, provision.ApplicationTypeVersion = description.TypeName, description.TypeVersion
+			provision.Async = false
+			plan.Actions = append(plan.Actions, ReconcileAction{
+				Kind:      ReconcileProvisionType,
+				Name:      description.TypeName,
+				Version:   description.TypeVersion,
+				provision: provision,
+			})
+			provisioned[description.TypeName] = append(provisioned[description.TypeName], description.TypeVersion)
+		}
+
+		current, ok := running[description.Name]
+		if !ok {
+			plan.Actions = append(plan.Actions, ReconcileAction{
+				Kind:        ReconcileCreateApplication,
+				Name:        description.Name,
+				Version:     description.TypeVersion,
+				application: description,
+			})
+		} else if action, ok := planUpgrade(current, description, desired.Upgrade); ok {
+			plan.Actions = append(plan.Actions, action)
+		}
+
+		actions, err := r.planServices(ctx, app, ok, desired.Prune)
+		if err != nil {
+			return nil, err
+		}
+		plan.Actions = append(plan.Actions, actions...)
+	}
+
+	for _, app := range apps.Items {
+		if wanted[app.Name] {
+			continue
+		}
+		if desired.Prune && desired.Prefix != "" && hasNamePrefix(app.Name, desired.Prefix) {
+			plan.Actions = append(plan.Actions, ReconcileAction{Kind: ReconcileDeleteApplication, Name: app.Name})
+			continue
+		}
+		if versions[app.TypeName] != nil {
+			versions[app.TypeName][app.TypeVersion] = true
+		}
+	}
+
+	if desired.UnprovisionUnused {
+		typeNames := make([]string, 0, len(versions))
+		for typeName := range versions {
+			typeNames = append(typeNames, typeName)
+		}
+		sort.Strings(typeNames)
+		for _, typeName := range typeNames {
+			for _, version := range provisioned[typeName] {
+				if !versions[typeName][version] {
+					plan.Actions = append(plan.Actions, ReconcileAction{Kind: ReconcileUnprovisionType, Name: typeName, Version: version})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(plan.Actions, func(i, j int) bool {
+		return reconcileOrder[plan.Actions[i].Kind] < reconcileOrder[plan.Actions[j].Kind]
+	})
+	return plan, nil
+}
+
+// reconcileOrder ranks the kinds of actions so that types are provisioned
+// before their applications are created, and applications are created
+// before their services
+var reconcileOrder = map[string]int{
+	ReconcileProvisionType:      0,
+	ReconcileCreateApplication:  1,
+	ReconcileUpgradeApplication: 2,
+	ReconcileCreateService:      3,
+	ReconcileUpdateService:      4,
+	ReconcileDeleteService:      5,
+	ReconcileDeleteApplication:  6,
+	ReconcileUnprovisionType:    7,
+}
+
+// planUpgrade returns the upgrade of current to the type version
+// and parameters of description, if they differ
+func planUpgrade(current *ApplicationItem, description ApplicationDescription, upgrade ApplicationUpgradeDescription) (ReconcileAction, bool) {
+	var changes []string
+	if current.TypeVersion != description.TypeVersion {
+		changes = append(changes, "TypeVersion "+current.TypeVersion+" -> "+description.TypeVersion)
+	}
+	parameters := map[string]string{}
+	for _, parameter := range current.Parameters {
+		if parameter != nil {
+			parameters[parameter.Key] = parameter.Value
+		}
+	}
+	desiredParameters := map[string]string{}
+	for _, parameter := range description.ParameterList {
+		desiredParameters[parameter.Key] = parameter.Value
+	}
+	if !reflect.DeepEqual(parameters, desiredParameters) {
+		changes = append(changes, "Parameters")
+	}
+	if len(changes) == 0 {
+		return ReconcileAction{}, false
+	}
+
+	upgrade.Name = description.Name
+	upgrade.TargetApplicationTypeVersion = description.TypeVersion
+	upgrade.Parameters = description.ParameterList
+	return ReconcileAction{
+		Kind:        ReconcileUpgradeApplication,
+		Name:        description.Name,
+		Version:     description.TypeVersion,
+		Changes:     changes,
+		application: description,
+		upgrade:     upgrade,
+	}, true
+}
+
+// planServices returns the actions converging the services of app,
+// whose application exists when exists is set
+func (r *Reconciler) planServices(ctx context.Context, app DesiredApplication, exists, prune bool) ([]ReconcileAction, error) {
+	current := map[string]ServiceItem{}
+	if exists {
+		services, err := r.client.GetServices(ctx, serviceIDFromName(app.Application.Name))
+		if err != nil {
+			return nil, err
+		}
+		for _, service := range services.Items {
+			current[service.Name] = service
+		}
+	}
+
+	var actions []ReconcileAction
+	wanted := map[string]bool{}
+	for _, service := range app.Services {
+		if service.ApplicationName == "" {
+			service.ApplicationName = app.Application.Name
+		}
+		if service.PartitionDescription == nil {
+			service.PartitionDescription = &PartitionDescription{PartitionScheme: PartitionSchemeSingleton}
+		}
+		if err := service.Validate(); err != nil {
+			return nil, err
+		}
+		if !hasNamePrefix(service.ServiceName, app.Application.Name) {
+			return nil, fmt.Errorf("service %s is not a service of application %s", service.ServiceName, app.Application.Name)
+		}
+		wanted[service.ServiceName] = true
+
+		if _, ok := current[service.ServiceName]; !ok {
+			actions = append(actions, ReconcileAction{Kind: ReconcileCreateService, Name: service.ServiceName, service: service})
+			continue
+		}
+		actual, err := r.client.GetServiceDescription(ctx, serviceIDFromName(service.ServiceName))
+		if err != nil {
+			return nil, err
+		}
+		update, changes, err := diffService(*actual, service)
+		if err != nil {
+			return nil, err
+		}
+		if len(changes) > 0 {
+			actions = append(actions, ReconcileAction{Kind: ReconcileUpdateService, Name: service.ServiceName, Changes: changes, update: update})
+		}
+	}
+
+	if prune {
+		names := make([]string, 0, len(current))
+		for name := range current {
+			if !wanted[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			actions = append(actions, ReconcileAction{Kind: ReconcileDeleteService, Name: name})
+		}
+	}
+	return actions, nil
+}
+
+// diffService returns the update turning actual into desired, and the
+// changes it makes, failing when desired can only be reached by
+// recreating the service
+func diffService(actual, desired ServiceDescription) (ServiceUpdateDescription, []string, error) {
+	update := ServiceUpdateDescription{ServiceKind: desired.ServiceKind}
+	if actual.ServiceKind != desired.ServiceKind || actual.ServiceTypeName != desired.ServiceTypeName {
+		return update, nil, fmt.Errorf("service %s is a %s service of type %s, recreate it to change its kind or type",
+			desired.ServiceName, actual.ServiceKind, actual.ServiceTypeName)
+	}
+	if actual.PartitionDescription != nil && !samePartitioning(*actual.PartitionDescription, *desired.PartitionDescription) {
+		return update, nil, fmt.Errorf("service %s is partitioned differently, recreate it to change its partitioning", desired.ServiceName)
+	}
+
+	var changes []string
+	if desired.ServiceKind == ServiceKindStateless {
+		if actual.InstanceCount != desired.InstanceCount {
+			update.InstanceCount = &desired.InstanceCount
+			changes = append(changes, fmt.Sprintf("InstanceCount %d -> %d", actual.InstanceCount, desired.InstanceCount))
+		}
+		if desired.InstanceCloseDelayDurationSeconds != nil && (actual.InstanceCloseDelayDurationSeconds == nil ||
+			*actual.InstanceCloseDelayDurationSeconds != *desired.InstanceCloseDelayDurationSeconds) {
+			delay := time.Duration(*desired.InstanceCloseDelayDurationSeconds) * time.Second
+			update.InstanceCloseDelay = &delay
+			changes = append(changes, "InstanceCloseDelay "+delay.String())
+		}
+	} else {
+		if actual.TargetReplicaSetSize != desired.TargetReplicaSetSize {
+			update.TargetReplicaSetSize = &desired.TargetReplicaSetSize
+			changes = append(changes, fmt.Sprintf("TargetReplicaSetSize %d -> %d", actual.TargetReplicaSetSize, desired.TargetReplicaSetSize))
+		}
+		if actual.MinReplicaSetSize != desired.MinReplicaSetSize {
+			update.MinReplicaSetSize = &desired.MinReplicaSetSize
+			changes = append(changes, fmt.Sprintf("MinReplicaSetSize %d -> %d", actual.MinReplicaSetSize, desired.MinReplicaSetSize))
+		}
+	}
+	if actual.PlacementConstraints != desired.PlacementConstraints {
+		update.PlacementConstraints = &desired.PlacementConstraints
+		changes = append(changes, "PlacementConstraints")
+	}
+	if len(actual.CorrelationScheme) != len(desired.CorrelationScheme) ||
+		len(desired.CorrelationScheme) > 0 && !reflect.DeepEqual(actual.CorrelationScheme, desired.CorrelationScheme) {
+		update.CorrelationScheme = desired.CorrelationScheme
+		if update.CorrelationScheme == nil {
+			update.CorrelationScheme = []ServiceCorrelationDescription{}
+		}
+		changes = append(changes, "CorrelationScheme")
+	}
+	return update, changes, nil
+}
+
+// samePartitioning compares partition descriptions, regardless of the
+// order of partition names
+func samePartitioning(a, b PartitionDescription) bool {
+	if a.PartitionScheme != b.PartitionScheme {
+		return false
+	}
+	switch a.PartitionScheme {
+	case PartitionSchemeNamed:
+		return reflect.DeepEqual(sortedDistinct(a.Names), sortedDistinct(b.Names))
+	case PartitionSchemeUniformInt64Range:
+		return a.Count == b.Count && a.LowKey == b.LowKey && a.HighKey == b.HighKey
+	}
+	return true
+}
+
+// Apply takes the actions of plan in order, waiting for provisions and
+// upgrades to complete, and stops at the first that fails
+func (r *Reconciler) Apply(ctx context.Context, plan *ReconcilePlan) error {
+	for _, action := range plan.Actions {
+		if err := r.apply(ctx, action); err != nil {
+			return errors.Wrapf(err, "failed applying %s", action)
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) apply(ctx context.Context, action ReconcileAction) error {
+	switch action.Kind {
+	case ReconcileProvisionType:
+		return r.client.ProvisionApplicationType(ctx, action.provision)
+	case ReconcileCreateApplication:
+		return r.client.CreateApplication(ctx, action.application)
+	case ReconcileUpgradeApplication:
+		appID := serviceIDFromName(action.Name)
+		if err := r.client.StartApplicationUpgrade(ctx, appID, action.upgrade); err != nil {
+			return err
+		}
+		_, err := r.client.WaitForUpgradeCompletion(ctx, appID, r.UpgradeWait)
+		return err
+	case ReconcileCreateService:
+		return r.client.CreateService(ctx, serviceIDFromName(action.service.ApplicationName), action.service)
+	case ReconcileUpdateService:
+		return r.client.UpdateService(ctx, serviceIDFromName(action.Name), action.update)
+	case ReconcileDeleteService:
+		return r.client.DeleteService(ctx, serviceIDFromName(action.Name))
+	case ReconcileDeleteApplication:
+		return r.client.DeleteApplication(ctx, serviceIDFromName(action.Name))
+	case ReconcileUnprovisionType:
+		return r.client.UnprovisionApplicationType(ctx, action.Name, action.Version, false)
+	}
+	return fmt.Errorf("unknown reconcile action %q", action.Kind)
+}
+
+// hasNamePrefix reports whether the fabric name lies under prefix,
+// e.g. "fabric:/Team1" or "Team1/" both match fabric:/Team1/App
+func hasNamePrefix(name, prefix string) bool {
+	prefix = fabricScheme + strings.Trim(strings.TrimPrefix(prefix, fabricScheme), "/")
+	return name == prefix || strings.HasPrefix(name, prefix+"/")
+}
@@ -0,0 +1,177 @@
+package servicefabric
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// reconcileServer runs TestApp 1.0.0 with a stateless Frontend of 2
+// instances and a stateless Legacy service, and records the POSTs received
+func reconcileServer() (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var posts []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ApplicationTypes/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Items":[{"Name":"TestAppType","Version":"0.9.0","Status":"Available"},
+			{"Name":"TestAppType","Version":"1.0.0","Status":"Available"}]}`))
+	})
+	mux.HandleFunc("/Applications/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Items":[{"Id":"TestApp","Name":"fabric:/TestApp","TypeName":"TestAppType","TypeVersion":"1.0.0",
+			"Parameters":[{"Key":"Port","Value":"80"}]},
+			{"Id":"Team~Old","Name":"fabric:/Team/Old","TypeName":"OtherType","TypeVersion":"1.0.0"}]}`))
+	})
+	mux.HandleFunc("/Applications/TestApp/$/GetServices", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Items":[{"Name":"fabric:/TestApp/Frontend","ServiceKind":"Stateless"},
+			{"Name":"fabric:/TestApp/Legacy","ServiceKind":"Stateless"}]}`))
+	})
+	mux.HandleFunc("/Services/TestApp~Frontend/$/GetDescription", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ServiceKind":"Stateless","ApplicationName":"fabric:/TestApp","ServiceName":"fabric:/TestApp/Frontend",
+			"ServiceTypeName":"FrontendType","PartitionDescription":{"PartitionScheme":"Singleton"},"InstanceCount":2}`))
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			mu.Lock()
+			posts = append(posts, r.URL.Path)
+			mu.Unlock()
+			if strings.HasSuffix(r.URL.Path, "/$/Upgrade") {
+				return
+			}
+		}
+		if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/$/GetUpgradeProgress") {
+			_, _ = w.Write([]byte(`{"UpgradeState":"RollingForwardCompleted"}`))
+			return
+		}
+		if r.Method == http.MethodGet {
+			mux.ServeHTTP(w, r)
+		}
+	}))
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), posts...)
+	}
+}
+
+func desiredReconcileState() DesiredState {
+	return DesiredState{
+		Applications: []DesiredApplication{
+			{
+				Application: ApplicationDescription{
+					Name: "fabric:/TestApp", TypeName: "TestAppType", TypeVersion: "1.0.0",
+					ParameterList: []AppParameter{{Key: "Port", Value: "80"}},
+				},
+				Services: []ServiceDescription{
+					{ServiceKind: ServiceKindStateless, ServiceName: "fabric:/TestApp/Frontend", ServiceTypeName: "FrontendType", InstanceCount: 3},
+					{ServiceKind: ServiceKindStateful, ServiceName: "fabric:/TestApp/Store", ServiceTypeName: "StoreType", TargetReplicaSetSize: 3, MinReplicaSetSize: 2},
+				},
+			},
+			{
+				Application: ApplicationDescription{Name: "fabric:/Team/New", TypeName: "NewType", TypeVersion: "2.0.0"},
+				Provision:   &ProvisionDescription{ImageStorePath: "NewType"},
+			},
+		},
+		Prune:             true,
+		Prefix:            "fabric:/Team",
+		UnprovisionUnused: true,
+	}
+}
+
+func TestReconcilerPlan(t *testing.T) {
+	server, _ := reconcileServer()
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	plan, err := NewReconciler(sfClient).Plan(context.Background(), desiredReconcileState())
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	var actual []string
+	for _, action := range plan.Actions {
+		actual = append(actual, action.String())
+	}
+	expected := []string{
+		"ProvisionType NewType 2.0.0",
+		"CreateApplication fabric:/Team/New 2.0.0",
+		"CreateService fabric:/TestApp/Store",
+		"UpdateService fabric:/TestApp/Frontend (InstanceCount 2 -> 3)",
+		"DeleteService fabric:/TestApp/Legacy",
+		"DeleteApplication fabric:/Team/Old",
+		"UnprovisionType TestAppType 0.9.0",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %q, want %q", actual, expected)
+	}
+}
+
+func TestReconcilerApply(t *testing.T) {
+	server, posts := reconcileServer()
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+	reconciler := NewReconciler(sfClient)
+
+	desired := desiredReconcileState()
+	desired.Applications[0].Application.TypeVersion = "0.9.0"
+	plan, err := reconciler.Plan(context.Background(), desired)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if err := reconciler.Apply(context.Background(), plan); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := []string{
+		"/ApplicationTypes/$/Provision",
+		"/Applications/$/Create",
+		"/Applications/TestApp/$/Upgrade",
+		"/Applications/TestApp/$/GetServices/$/Create",
+		"/Services/TestApp~Frontend/$/Update",
+		"/Services/TestApp~Legacy/$/Delete",
+		"/Applications/Team~Old/$/Delete",
+		"/ApplicationTypes/TestAppType/$/Unprovision",
+	}
+	if actual := posts(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %q, want %q", actual, expected)
+	}
+}
+
+func TestReconcilerPlanRejectsRecreation(t *testing.T) {
+	server, _ := reconcileServer()
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	desired := desiredReconcileState()
+	desired.Applications[0].Services[0].PartitionDescription = &PartitionDescription{PartitionScheme: PartitionSchemeNamed, Count: 1, Names: []string{"a"}}
+	if _, err := NewReconciler(sfClient).Plan(context.Background(), desired); err == nil {
+		t.Error("Error should have been returned")
+	}
+}
+
+func TestDiffServiceCorrelations(t *testing.T) {
+	actual := ServiceDescription{ServiceKind: ServiceKindStateless, ServiceTypeName: "T", InstanceCount: 1,
+		CorrelationScheme: []ServiceCorrelationDescription{{Scheme: CorrelationSchemeAffinity, ServiceName: "fabric:/App/Other"}}}
+	desired := actual
+	desired.CorrelationScheme = nil
+	desired.PartitionDescription = &PartitionDescription{PartitionScheme: PartitionSchemeSingleton}
+
+	update, changes, err := diffService(actual, desired)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if !reflect.DeepEqual(changes, []string{"CorrelationScheme"}) {
+		t.Errorf("Got %v, want the correlations removed", changes)
+	}
+	if update.CorrelationScheme == nil || len(update.CorrelationScheme) != 0 {
+		t.Errorf("Got %v, want an empty correlation scheme", update.CorrelationScheme)
+	}
+}
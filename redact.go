@@ -0,0 +1,195 @@
+package servicefabric
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Redacted replaces the secrets masked by a Redactor
+const Redacted = "[REDACTED]"
+
+// DefaultRedactionPatterns are the parameter name patterns a client
+// masks the values of unless WithRedactionPatterns is used
+var DefaultRedactionPatterns = []string{"password", "secret", "key", "token"}
+
+// sensitiveFields are request and response fields always masked
+var sensitiveFields = map[string]bool{
+	"RegistryPassword": true,
+	// download URLs of external stores carry SAS signatures
+	"ApplicationPackageDownloadUri": true,
+}
+
+// sensitiveHeaders are the headers always masked
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Redactor masks secrets before requests and responses are written to audit
+// events and debug dumps: credentials in headers, the values of application
+// and query parameters whose name contains one of Patterns, case
+// insensitively, and property values
+type Redactor struct {
+	Patterns []string
+}
+
+// NewRedactor returns a Redactor masking the parameters matching patterns
+func NewRedactor(patterns ...string) *Redactor {
+	return &Redactor{Patterns: patterns}
+}
+
+// Matches reports whether the values of the parameter name are masked
+func (r *Redactor) Matches(name string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range r.Patterns {
+		if pattern != "" && strings.Contains(name, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Header returns a copy of header with credentials masked
+func (r *Redactor) Header(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range sensitiveHeaders {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, Redacted)
+		}
+	}
+	return redacted
+}
+
+// Parameters returns a copy of parameters with the values of matching names masked
+func (r *Redactor) Parameters(parameters map[string]string) map[string]string {
+	if parameters == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(parameters))
+	for name, value := range parameters {
+		if r.Matches(name) {
+			value = Redacted
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+// URL returns rawURL with the values of matching query parameters masked
+func (r *Redactor) URL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return rawURL
+	}
+	query := u.Query()
+	for name := range query {
+		if r.Matches(name) {
+			query.Set(name, Redacted)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// Body returns a copy of the JSON body with application parameters
+// matching the patterns, property values and known secret fields
+// masked. Bodies that are not JSON are masked as a whole.
+func (r *Redactor) Body(body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return []byte(Redacted)
+	}
+	b, err := json.Marshal(r.value(v))
+	if err != nil {
+		return []byte(Redacted)
+	}
+	return b
+}
+
+func (r *Redactor) value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		for i := range v {
+			v[i] = r.value(v[i])
+		}
+		return v
+	case map[string]interface{}:
+		return r.object(v)
+	default:
+		return v
+	}
+}
+
+func (r *Redactor) object(o map[string]interface{}) map[string]interface{} {
+	// application parameters, as in ParameterList
+	if key, ok := o["Key"].(string); ok && r.Matches(key) {
+		if _, ok := o["Value"].(string); ok {
+			o["Value"] = Redacted
+		}
+	}
+	for name, v := range o {
+		if sensitiveFields[name] {
+			o[name] = Redacted
+			continue
+		}
+		// property values, as in PutProperty and GetProperty
+		if value, ok := v.(map[string]interface{}); ok && name == "Value" {
+			if _, ok := value["Kind"]; ok {
+				if _, ok := value["Data"]; ok {
+					value["Data"] = Redacted
+				}
+			}
+		}
+		o[name] = r.value(o[name])
+	}
+	return o
+}
+
+// dumpRequest writes req and its body to the debug dump, if any
+func (c ServiceFabricClient) dumpRequest(req *http.Request, body []byte) {
+	if c.debugDump == nil {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "> %s %s\n", req.Method, c.redactor.URL(req.URL.String()))
+	writeHeader(&b, ">", c.redactor.Header(req.Header))
+	if len(body) > 0 {
+		fmt.Fprintf(&b, "> %s\n", c.redactor.Body(body))
+	}
+	io.WriteString(c.debugDump, b.String())
+}
+
+// dumpResponse writes the response to a request and its body to the debug dump, if any
+func (c ServiceFabricClient) dumpResponse(res *http.Response, body []byte) {
+	if c.debugDump == nil {
+		return
+	}
+	var b strings.Builder
+	if res.Request != nil {
+		fmt.Fprintf(&b, "< %s %s %s\n", res.Status, res.Request.Method, c.redactor.URL(res.Request.URL.String()))
+	} else {
+		// Doers other than *http.Client may not link the request
+		fmt.Fprintf(&b, "< %s\n", res.Status)
+	}
+	writeHeader(&b, "<", c.redactor.Header(res.Header))
+	if len(body) > 0 {
+		fmt.Fprintf(&b, "< %s\n", c.redactor.Body(body))
+	}
+	io.WriteString(c.debugDump, b.String())
+}
+
+func writeHeader(w io.Writer, prefix string, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s %s: %s\n", prefix, name, strings.Join(header[name], ", "))
+	}
+}
@@ -0,0 +1,135 @@
+package servicefabric
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRedactorBody(t *testing.T) {
+	redactor := NewRedactor(DefaultRedactionPatterns...)
+
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "application parameters",
+			body:     `{"ParameterList":[{"Key":"DbPassword","Value":"hunter2"},{"Key":"InstanceCount","Value":"3"}]}`,
+			expected: `{"ParameterList":[{"Key":"DbPassword","Value":"[REDACTED]"},{"Key":"InstanceCount","Value":"3"}]}`,
+		},
+		{
+			name:     "property value",
+			body:     `{"PropertyName":"ConnectionString","Value":{"Kind":"String","Data":"Server=db;Pwd=hunter2"}}`,
+			expected: `{"PropertyName":"ConnectionString","Value":{"Data":"[REDACTED]","Kind":"String"}}`,
+		},
+		{
+			name:     "registry credential",
+			body:     `{"RegistryCredential":{"RegistryUserName":"user","RegistryPassword":"hunter2"}}`,
+			expected: `{"RegistryCredential":{"RegistryPassword":"[REDACTED]","RegistryUserName":"user"}}`,
+		},
+		{
+			name:     "not json",
+			body:     `Pwd=hunter2`,
+			expected: Redacted,
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			actual := string(redactor.Body([]byte(test.body)))
+			if actual != test.expected {
+				t.Errorf("Got %+v, want %+v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestRedactorParameters(t *testing.T) {
+	redactor := NewRedactor("password", "SAS")
+
+	actual := redactor.Parameters(map[string]string{"AdminPassword": "hunter2", "sasToken": "sig", "Force": "true"})
+	expected := map[string]string{"AdminPassword": Redacted, "sasToken": Redacted, "Force": "true"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+
+	url := redactor.URL("https://cluster:19080/Names/App?api-version=6.0&SasToken=sig")
+	if strings.Contains(url, "sig") {
+		t.Errorf("Got %+v, want the token masked", url)
+	}
+}
+
+func TestAuditEventRedacted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var events []AuditEvent
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil,
+		WithAuditSink(AuditSinkFunc(func(event AuditEvent) {
+			events = append(events, event)
+		}), "deploy-bot"))
+
+	err := sfClient.PutBinaryProperty(context.Background(), "TestApplication", "ConnectionString", []byte("Server=db;Pwd=hunter2"), "")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Got %d audit events, want 1", len(events))
+	}
+
+	var body struct {
+		Value PropValue
+	}
+	if err := json.Unmarshal(events[0].Body, &body); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if body.Value.Data != Redacted {
+		t.Errorf("Got %+v, want %+v", body.Value.Data, Redacted)
+	}
+}
+
+func TestDebugDump(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Name":"fabric:/TestApplication/ConnectionString","Value":{"Kind":"String","Data":"Server=db;Pwd=hunter2"}}`))
+	}))
+	defer server.Close()
+
+	var dump bytes.Buffer
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil,
+		WithDebugDump(&dump),
+		WithTokenCredential(TokenCredentialFunc(func(ctx context.Context) (Token, error) {
+			return Token{AccessToken: "secret-token"}, nil
+		})))
+
+	_, _, err := sfClient.getHTTP(context.Background(), "Names/TestApplication/$/GetProperty", withParam("PropertyName", "ConnectionString"))
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	actual := dump.String()
+	if !strings.Contains(actual, "> GET ") || !strings.Contains(actual, "< 200 OK GET ") {
+		t.Errorf("Got %+v, want the request and response dumped", actual)
+	}
+	if strings.Contains(actual, "secret-token") || strings.Contains(actual, "hunter2") {
+		t.Errorf("Got %+v, want secrets masked", actual)
+	}
+}
+
+func TestRequestErrorRedacted(t *testing.T) {
+	sfClient, _ := NewClient(http.DefaultClient, "http://127.0.0.1:1", "1.0", nil)
+
+	_, _, err := sfClient.getHTTP(context.Background(), "ImageStore/$/GetUploadSession", withParam("SasToken", "sig=hunter2"))
+	if err == nil {
+		t.Fatal("Error should have been returned")
+	}
+	if strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("Got %v, want the token masked", err)
+	}
+}
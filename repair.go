@@ -0,0 +1,179 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Repair task states, in the order a repair goes through them
+const (
+	RepairStateCreated   = "Created"
+	RepairStateClaimed   = "Claimed"
+	RepairStatePreparing = "Preparing"
+	RepairStateApproved  = "Approved"
+	RepairStateExecuting = "Executing"
+	RepairStateRestoring = "Restoring"
+	RepairStateCompleted = "Completed"
+)
+
+// repairStateFilters maps repair task states to their filter bit
+var repairStateFilters = map[string]int{
+	RepairStateCreated:   1,
+	RepairStateClaimed:   2,
+	RepairStatePreparing: 4,
+	RepairStateApproved:  8,
+	RepairStateExecuting: 16,
+	RepairStateRestoring: 32,
+	RepairStateCompleted: 64,
+}
+
+// Repair task results
+const (
+	RepairResultSucceeded   = "Succeeded"
+	RepairResultCancelled   = "Cancelled"
+	RepairResultInterrupted = "Interrupted"
+	RepairResultFailed      = "Failed"
+)
+
+// RepairTask is a repair of cluster nodes, such as a reboot, coordinated
+// with the cluster so that it is only executed once it is safe
+type RepairTask struct {
+	TaskID      string `json:"TaskId"`
+	Version     string `json:"Version,omitempty"`
+	Description string `json:"Description,omitempty"`
+	State       string `json:"State"`
+	// Action is the repair to perform, e.g. "System.Reboot" or a custom action
+	Action       string                   `json:"Action"`
+	Target       *RepairTargetDescription `json:"Target,omitempty"`
+	Executor     string                   `json:"Executor,omitempty"`
+	ExecutorData string                   `json:"ExecutorData,omitempty"`
+	// Impact is declared by the executor when preparing,
+	// the cluster preparing the impacted nodes accordingly
+	Impact        *RepairImpactDescription `json:"Impact,omitempty"`
+	ResultStatus  string                   `json:"ResultStatus,omitempty"`
+	ResultCode    int                      `json:"ResultCode,omitempty"`
+	ResultDetails string                   `json:"ResultDetails,omitempty"`
+
+	PerformPreparingHealthCheck bool `json:"PerformPreparingHealthCheck,omitempty"`
+	PerformRestoringHealthCheck bool `json:"PerformRestoringHealthCheck,omitempty"`
+}
+
+// RepairTargetDescription lists the nodes a repair targets
+type RepairTargetDescription struct {
+	// Kind is "Node"
+	Kind      string   `json:"Kind"`
+	NodeNames []string `json:"NodeNames"`
+}
+
+// RepairImpactDescription describes the impact of a repair on nodes
+type RepairImpactDescription struct {
+	// Kind is "Node"
+	Kind           string             `json:"Kind"`
+	NodeImpactList []RepairNodeImpact `json:"NodeImpactList"`
+}
+
+// RepairNodeImpact is the impact of a repair on a node
+type RepairNodeImpact struct {
+	NodeName string `json:"NodeName"`
+	// ImpactLevel is "None", "Restart", "RemoveData" or "RemoveNode"
+	ImpactLevel string `json:"ImpactLevel"`
+}
+
+// RepairTaskFilter narrows GetRepairTasks, empty fields not filtering
+type RepairTaskFilter struct {
+	// TaskIDPrefix matches the tasks whose id starts with it
+	TaskIDPrefix string
+	States       []string
+	Executor     string
+}
+
+// GetRepairTasks returns the repair tasks matching filter
+func (c ServiceFabricClient) GetRepairTasks(ctx context.Context, filter RepairTaskFilter) (tasks []RepairTask, err error) {
+	ctx, call := c.startCall(ctx, "GetRepairTasks")
+	defer func() { call.finish(err) }()
+
+	return c.getRepairTasks(ctx, filter)
+}
+
+func (c ServiceFabricClient) getRepairTasks(ctx context.Context, filter RepairTaskFilter) ([]RepairTask, error) {
+	stateFilter := 0
+	for _, state := range filter.States {
+		bit, ok := repairStateFilters[state]
+		if !ok {
+			return nil, fmt.Errorf("unknown repair task state %q", state)
+		}
+		stateFilter |= bit
+	}
+	params := []queryParamsFunc{
+		withOptionalParam("TaskIdFilter", filter.TaskIDPrefix),
+		withOptionalParam("ExecutorFilter", filter.Executor),
+	}
+	if stateFilter != 0 {
+		params = append(params, withParam("StateFilter", strconv.Itoa(stateFilter)))
+	}
+
+	res, _, err := c.getHTTP(ctx, "$/GetRepairTaskList", params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting repair tasks")
+	}
+
+	var tasks []RepairTask
+	err = json.Unmarshal(res, &tasks)
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return tasks, nil
+}
+
+// CreateRepairTask creates a repair task and returns its version
+func (c ServiceFabricClient) CreateRepairTask(ctx context.Context, task RepairTask) (version string, err error) {
+	ctx, call := c.startCall(ctx, "CreateRepairTask")
+	defer func() { call.finish(err) }()
+
+	if task.TaskID == "" || task.Action == "" {
+		return "", errors.New("repair task id and action are required")
+	}
+	if task.State == "" {
+		task.State = RepairStateCreated
+	}
+	return c.postRepairTask(ctx, opCreateRepairTask.on(task.TaskID), "$/CreateRepairTask", task)
+}
+
+// UpdateRepairExecutionState moves a repair task the executor claimed on,
+// failing when task.Version is no longer the current version. It returns
+// the new version of the task.
+func (c ServiceFabricClient) UpdateRepairExecutionState(ctx context.Context, task RepairTask) (version string, err error) {
+	ctx, call := c.startCall(ctx, "UpdateRepairExecutionState")
+	defer func() { call.finish(err) }()
+
+	return c.updateRepairExecutionState(ctx, task)
+}
+
+func (c ServiceFabricClient) updateRepairExecutionState(ctx context.Context, task RepairTask) (string, error) {
+	return c.postRepairTask(ctx, opUpdateRepairExecutionState.on(task.TaskID), "$/UpdateRepairExecutionState", task)
+}
+
+func (c ServiceFabricClient) postRepairTask(ctx context.Context, op Operation, basePath string, task RepairTask) (string, error) {
+	body, err := json.Marshal(task)
+	if err != nil {
+		return "", err
+	}
+
+	res, _, err := c.postHTTP(ctx, op, basePath, body)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed updating repair task %s", task.TaskID)
+	}
+
+	var result struct {
+		Version string `json:"Version"`
+	}
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return "", fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return result.Version, nil
+}
@@ -0,0 +1,282 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRepairManager keeps repair tasks the way the repair manager does,
+// rejecting updates of stale versions
+type fakeRepairManager struct {
+	mu      sync.Mutex
+	tasks   map[string]*RepairTask
+	updates []RepairTask
+}
+
+func (m *fakeRepairManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/$/GetRepairTaskList":
+		query := r.URL.Query()
+		stateFilter, _ := strconv.Atoi(query.Get("StateFilter"))
+		tasks := []RepairTask{}
+		for _, id := range []string{"reboot-1", "reimage-1"} {
+			task, ok := m.tasks[id]
+			if !ok {
+				continue
+			}
+			if stateFilter != 0 && stateFilter&repairStateFilters[task.State] == 0 {
+				continue
+			}
+			if executor := query.Get("ExecutorFilter"); executor != "" && task.Executor != executor {
+				continue
+			}
+			tasks = append(tasks, *task)
+		}
+		json.NewEncoder(w).Encode(tasks)
+	case "/$/UpdateRepairExecutionState":
+		var update RepairTask
+		json.NewDecoder(r.Body).Decode(&update)
+		task, ok := m.tasks[update.TaskID]
+		if !ok || task.Version != update.Version {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		m.updates = append(m.updates, update)
+		version, _ := strconv.Atoi(task.Version)
+		update.Version = strconv.Itoa(version + 1)
+		*task = update
+		w.Write([]byte(`{"Version":"` + update.Version + `"}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+type testRepairHandler struct {
+	mu       sync.Mutex
+	executed []string
+	err      error
+	delay    time.Duration
+}
+
+func (h *testRepairHandler) Impact(ctx context.Context, task RepairTask) (*RepairImpactDescription, error) {
+	impact := &RepairImpactDescription{Kind: "Node"}
+	for _, node := range task.Target.NodeNames {
+		impact.NodeImpactList = append(impact.NodeImpactList, RepairNodeImpact{NodeName: node, ImpactLevel: "Restart"})
+	}
+	return impact, nil
+}
+
+func (h *testRepairHandler) Execute(ctx context.Context, task RepairTask) error {
+	h.mu.Lock()
+	h.executed = append(h.executed, task.TaskID)
+	h.mu.Unlock()
+	select {
+	case <-time.After(h.delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return h.err
+}
+
+func TestRepairExecutor(t *testing.T) {
+	manager := &fakeRepairManager{tasks: map[string]*RepairTask{
+		"reboot-1": {
+			TaskID: "reboot-1", Version: "1", State: RepairStateCreated, Action: "Custom.Reboot",
+			Target: &RepairTargetDescription{Kind: "Node", NodeNames: []string{"_Node_0"}},
+		},
+		"reimage-1": {TaskID: "reimage-1", Version: "1", State: RepairStateCreated, Action: "Custom.Reimage"},
+	}}
+	server := httptest.NewServer(manager)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	handler := &testRepairHandler{delay: 30 * time.Millisecond}
+	executor := NewRepairExecutor("TestExecutor", map[string]RepairActionHandler{"Custom.Reboot": handler})
+	opts := RepairLoopOptions{
+		HeartbeatInterval: 5 * time.Millisecond,
+		OnError:           func(err error) { t.Errorf("Exception thrown %v", err) },
+	}
+	executions := newRepairExecutions()
+
+	// claims and prepares the task of the handled action only
+	if err := sfClient.stepRepairExecutor(context.Background(), executor, opts, executions); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	reboot := manager.tasks["reboot-1"]
+	expectedImpact := &RepairImpactDescription{Kind: "Node", NodeImpactList: []RepairNodeImpact{{NodeName: "_Node_0", ImpactLevel: "Restart"}}}
+	if reboot.State != RepairStatePreparing || reboot.Executor != "TestExecutor" || !reflect.DeepEqual(reboot.Impact, expectedImpact) {
+		t.Errorf("Got %+v, want a task preparing the restart of _Node_0", reboot)
+	}
+	if manager.tasks["reimage-1"].State != RepairStateCreated {
+		t.Errorf("Got %+v, want the unhandled task left created", manager.tasks["reimage-1"])
+	}
+
+	// nothing happens until the cluster approved the task
+	if err := sfClient.stepRepairExecutor(context.Background(), executor, opts, executions); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if len(handler.executed) != 0 {
+		t.Errorf("Got %+v, want no execution", handler.executed)
+	}
+
+	manager.tasks["reboot-1"].State = RepairStateApproved
+	if err := sfClient.stepRepairExecutor(context.Background(), executor, opts, executions); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	// polls while the task executes leave the execution running alone
+	if err := sfClient.stepRepairExecutor(context.Background(), executor, opts, executions); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	executions.wait()
+	if !reflect.DeepEqual(handler.executed, []string{"reboot-1"}) {
+		t.Errorf("Got %+v, want reboot-1 executed", handler.executed)
+	}
+	reboot = manager.tasks["reboot-1"]
+	if reboot.State != RepairStateRestoring || reboot.ResultStatus != RepairResultSucceeded {
+		t.Errorf("Got %+v, want a task restoring after succeeding", reboot)
+	}
+
+	// the first executing update moved the task from approved
+	heartbeats := -1
+	for _, update := range manager.updates {
+		if update.State == RepairStateExecuting {
+			heartbeats++
+		}
+	}
+	if heartbeats <= 0 {
+		t.Error("Got no heartbeat, want the executing task updated")
+	}
+}
+
+func TestRepairExecutorReportsFailure(t *testing.T) {
+	manager := &fakeRepairManager{tasks: map[string]*RepairTask{
+		"reboot-1": {TaskID: "reboot-1", Version: "3", State: RepairStateExecuting, Action: "Custom.Reboot", Executor: "TestExecutor"},
+	}}
+	server := httptest.NewServer(manager)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	// executing tasks are resumed, e.g. after the executor restarted
+	handler := &testRepairHandler{err: errors.New("host unreachable")}
+	executor := NewRepairExecutor("TestExecutor", map[string]RepairActionHandler{"Custom.Reboot": handler})
+	var executionErr error
+	opts := RepairLoopOptions{OnError: func(err error) { executionErr = err }}
+	executions := newRepairExecutions()
+	if err := sfClient.stepRepairExecutor(context.Background(), executor, opts, executions); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	executions.wait()
+	if executionErr != nil {
+		t.Errorf("Exception thrown %v", executionErr)
+	}
+
+	reboot := manager.tasks["reboot-1"]
+	if reboot.State != RepairStateRestoring || reboot.ResultStatus != RepairResultFailed || reboot.ResultDetails != "host unreachable" {
+		t.Errorf("Got %+v, want a task restoring after failing", reboot)
+	}
+}
+
+func TestRepairExecutorExecutesConcurrently(t *testing.T) {
+	manager := &fakeRepairManager{tasks: map[string]*RepairTask{
+		"reboot-1":  {TaskID: "reboot-1", Version: "1", State: RepairStateExecuting, Action: "Custom.Reboot", Executor: "TestExecutor"},
+		"reimage-1": {TaskID: "reimage-1", Version: "1", State: RepairStateExecuting, Action: "Custom.Reboot", Executor: "TestExecutor"},
+	}}
+	server := httptest.NewServer(manager)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	// executions outlive the poll starting them
+	handler := &testRepairHandler{delay: time.Hour}
+	executor := NewRepairExecutor("TestExecutor", map[string]RepairActionHandler{"Custom.Reboot": handler})
+	ctx, cancel := context.WithCancel(context.Background())
+	executions := newRepairExecutions()
+	if err := sfClient.stepRepairExecutor(ctx, executor, RepairLoopOptions{OnError: func(err error) {}}, executions); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		handler.mu.Lock()
+		executed := len(handler.executed)
+		handler.mu.Unlock()
+		if executed == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Got %d executions, want 2", executed)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	executions.wait()
+}
+
+func TestRunRepairExecutorStopsOnContextDone(t *testing.T) {
+	manager := &fakeRepairManager{tasks: map[string]*RepairTask{}}
+	server := httptest.NewServer(manager)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	executor := NewRepairExecutor("TestExecutor", nil)
+	err := sfClient.RunRepairExecutor(ctx, executor, RepairLoopOptions{PollInterval: time.Millisecond})
+	if err != context.DeadlineExceeded {
+		t.Errorf("Got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestCreateRepairTask(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/$/CreateRepairTask" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Exception thrown %v", err)
+		}
+		w.Write([]byte(`{"Version":"1"}`))
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	version, err := sfClient.CreateRepairTask(context.Background(), RepairTask{
+		TaskID: "reboot-1",
+		Action: "Custom.Reboot",
+		Target: &RepairTargetDescription{Kind: "Node", NodeNames: []string{"_Node_0"}},
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if version != "1" {
+		t.Errorf("Got %+v, want %+v", version, "1")
+	}
+
+	expected := map[string]interface{}{
+		"TaskId": "reboot-1",
+		"State":  "Created",
+		"Action": "Custom.Reboot",
+		"Target": map[string]interface{}{"Kind": "Node", "NodeNames": []interface{}{"_Node_0"}},
+	}
+	if !reflect.DeepEqual(body, expected) {
+		t.Errorf("Got %+v, want %+v", body, expected)
+	}
+}
@@ -0,0 +1,255 @@
+package servicefabric
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Defaults of RepairLoopOptions
+const (
+	DefaultRepairPollInterval      = 30 * time.Second
+	DefaultRepairHeartbeatInterval = time.Minute
+)
+
+// RepairActionHandler performs the repairs of an action. Execute may be
+// called again for a task it was executing when the executor restarted, so
+// it must be idempotent.
+type RepairActionHandler interface {
+	// Impact returns the impact executing task has on its nodes,
+	// which the cluster prepares the nodes for before approving it
+	Impact(ctx context.Context, task RepairTask) (*RepairImpactDescription, error)
+	// Execute performs the repair, its context being cancelled
+	// when the task can no longer be updated, e.g. once cancelled
+	Execute(ctx context.Context, task RepairTask) error
+}
+
+// RepairExecutor claims and executes the repair tasks of the actions it handles
+type RepairExecutor interface {
+	// Name is recorded as the executor of the tasks it claims
+	Name() string
+	// Handler returns the handler of action, nil when not handled
+	Handler(action string) RepairActionHandler
+}
+
+type repairExecutor struct {
+	name     string
+	handlers map[string]RepairActionHandler
+}
+
+// NewRepairExecutor returns an executor handling actions with their handler
+func NewRepairExecutor(name string, handlers map[string]RepairActionHandler) RepairExecutor {
+	return repairExecutor{name: name, handlers: handlers}
+}
+
+func (e repairExecutor) Name() string {
+	return e.name
+}
+
+func (e repairExecutor) Handler(action string) RepairActionHandler {
+	return e.handlers[action]
+}
+
+// RepairLoopOptions tunes RunRepairExecutor
+type RepairLoopOptions struct {
+	// PollInterval is how often repair tasks are polled, defaults to 30s
+	PollInterval time.Duration
+	// HeartbeatInterval is how often executing tasks are updated to show
+	// the executor is alive, defaults to one minute
+	HeartbeatInterval time.Duration
+	// OnError receives the errors of every poll and execution,
+	// which are dropped when nil
+	OnError func(err error)
+}
+
+// repairExecutions tracks the tasks executing, by task id, so a task is
+// executed once however many polls see it executing
+type repairExecutions struct {
+	mu      sync.Mutex
+	running map[string]struct{}
+	wg      sync.WaitGroup
+}
+
+func newRepairExecutions() *repairExecutions {
+	return &repairExecutions{running: map[string]struct{}{}}
+}
+
+// start runs execute in its own goroutine unless taskID is already executing
+func (e *repairExecutions) start(taskID string, execute func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.running[taskID]; ok {
+		return
+	}
+	e.running[taskID] = struct{}{}
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		defer func() {
+			e.mu.Lock()
+			delete(e.running, taskID)
+			e.mu.Unlock()
+		}()
+		execute()
+	}()
+}
+
+// wait returns once every execution started returned
+func (e *repairExecutions) wait() {
+	e.wg.Wait()
+}
+
+// RunRepairExecutor drives the repair tasks of executor until ctx is done:
+// it claims the created tasks of the actions it handles, declares their
+// impact, executes them once the cluster approved them, heartbeating while
+// they execute, and hands them back to the cluster to restore the nodes.
+// Tasks execute concurrently, and RunRepairExecutor returns once the
+// executions stopped after ctx is done.
+func (c ServiceFabricClient) RunRepairExecutor(ctx context.Context, executor RepairExecutor, opts RepairLoopOptions) error {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultRepairPollInterval
+	}
+	if opts.OnError == nil {
+		opts.OnError = func(err error) {}
+	}
+	ticker := time.NewTicker(opts.PollInterval)
+	defer ticker.Stop()
+
+	executions := newRepairExecutions()
+	defer executions.wait()
+
+	for {
+		if err := c.stepRepairExecutor(ctx, executor, opts, executions); err != nil && ctx.Err() == nil {
+			opts.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// stepRepairExecutor moves every task of executor one state forward,
+// starting the execution of the executing tasks not in executions yet
+func (c ServiceFabricClient) stepRepairExecutor(ctx context.Context, executor RepairExecutor, opts RepairLoopOptions, executions *repairExecutions) error {
+	created, err := c.getRepairTasks(ctx, RepairTaskFilter{States: []string{RepairStateCreated}})
+	if err != nil {
+		return err
+	}
+	for _, task := range created {
+		if executor.Handler(task.Action) == nil {
+			continue
+		}
+		task.State = RepairStateClaimed
+		task.Executor = executor.Name()
+		if _, err := c.updateRepairExecutionState(ctx, task); err != nil {
+			return err
+		}
+	}
+
+	claimed, err := c.getRepairTasks(ctx, RepairTaskFilter{
+		Executor: executor.Name(),
+		States:   []string{RepairStateClaimed, RepairStateApproved, RepairStateExecuting},
+	})
+	if err != nil {
+		return err
+	}
+	for _, task := range claimed {
+		handler := executor.Handler(task.Action)
+		if handler == nil {
+			continue
+		}
+
+		switch task.State {
+		case RepairStateClaimed:
+			impact, err := handler.Impact(ctx, task)
+			if err != nil {
+				return err
+			}
+			task.State = RepairStatePreparing
+			task.Impact = impact
+			if _, err := c.updateRepairExecutionState(ctx, task); err != nil {
+				return err
+			}
+		case RepairStateApproved:
+			task.State = RepairStateExecuting
+			task.Version, err = c.updateRepairExecutionState(ctx, task)
+			if err != nil {
+				return err
+			}
+			fallthrough
+		case RepairStateExecuting:
+			task := task
+			executions.start(task.TaskID, func() {
+				if err := c.executeRepair(ctx, handler, task, opts.HeartbeatInterval); err != nil && ctx.Err() == nil {
+					opts.OnError(err)
+				}
+			})
+		}
+	}
+	return nil
+}
+
+// executeRepair executes task with handler, updating it every heartbeat
+// while it executes, and moves it to restoring with the result
+func (c ServiceFabricClient) executeRepair(ctx context.Context, handler RepairActionHandler, task RepairTask, heartbeat time.Duration) error {
+	if heartbeat <= 0 {
+		heartbeat = DefaultRepairHeartbeatInterval
+	}
+
+	execCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	current := task
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			// an update leaving the task unchanged shows the executor is alive
+			mu.Lock()
+			version, err := c.updateRepairExecutionState(execCtx, current)
+			if err == nil {
+				current.Version = version
+			}
+			mu.Unlock()
+			if err != nil {
+				// the task changed under the executor, e.g. it was cancelled
+				cancel()
+				return
+			}
+		}
+	}()
+
+	err := handler.Execute(execCtx, task)
+	close(done)
+	<-stopped
+
+	if execCtx.Err() != nil && ctx.Err() == nil {
+		// the heartbeat failed, the task is no longer the executor's to finish
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	current.State = RepairStateRestoring
+	current.ResultStatus = RepairResultSucceeded
+	if err != nil {
+		current.ResultStatus = RepairResultFailed
+		current.ResultDetails = err.Error()
+	}
+	_, err = c.updateRepairExecutionState(ctx, current)
+	return err
+}
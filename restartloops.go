@@ -0,0 +1,196 @@
+package servicefabric
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultMinContinuousFailures = 3
+
+// RestartLoopOptions tunes DetectRestartLoops
+type RestartLoopOptions struct {
+	// MinContinuousFailures flags entry points that failed to activate, or
+	// exited with a failure, this many times in a row, defaults to 3
+	MinContinuousFailures int64
+}
+
+// RestartLoop reports a code package entry point caught in a restart loop
+type RestartLoop struct {
+	NodeName                   string
+	ApplicationName            string
+	ServiceManifestName        string
+	ServicePackageActivationID string
+	CodePackageName            string
+	// EntryPoint is either "Setup" or "Main"
+	EntryPoint string
+	// ContinuousFailures is the larger of the continuous
+	// activation and exit failure counts
+	ContinuousFailures int64
+	LastExitCode       string
+	// NextActivationTime is set when the entry point
+	// waits for its activation backoff to elapse
+	NextActivationTime time.Time
+	// Services lists the services whose type the service manifest provides
+	Services []string
+}
+
+// DetectRestartLoops scans the code packages deployed on every node and
+// reports the entry points failing repeatedly, or held back by activation
+// backoff after failing, along with the services they implement. Nodes are
+// scanned in parallel, see WithConcurrency.
+func (c ServiceFabricClient) DetectRestartLoops(ctx context.Context, opts RestartLoopOptions) (loops []RestartLoop, err error) {
+	ctx, call := c.startCall(ctx, "DetectRestartLoops")
+	defer func() { call.finish(err) }()
+
+	if opts.MinContinuousFailures <= 0 {
+		opts.MinContinuousFailures = defaultMinContinuousFailures
+	}
+
+	apps, err := c.getApplications(ctx, func(*ApplicationItem) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	appsByID := map[string]ApplicationItem{}
+	for _, app := range apps.Items {
+		appsByID[app.ID] = app
+	}
+
+	nodes, err := c.getNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	loopAppIDs := map[string][]int{}
+	err = c.forEach(len(nodes), func(i int) error {
+		nodeName := nodes[i].Name
+		appIDs, err := c.getDeployedApplicationIDs(ctx, nodeName)
+		if err != nil {
+			return err
+		}
+
+		for _, appID := range appIDs {
+			packages, err := c.getDeployedCodePackages(ctx, nodeName, appID, "", "")
+			if err != nil {
+				return err
+			}
+
+			for _, pkg := range packages {
+				for _, ep := range []struct {
+					name       string
+					entryPoint *CodePackageEntryPoint
+				}{{"Setup", pkg.SetupEntryPoint}, {"Main", pkg.MainEntryPoint}} {
+					loop, ok := detectRestartLoop(ep.entryPoint, opts.MinContinuousFailures)
+					if !ok {
+						continue
+					}
+					loop.NodeName = nodeName
+					loop.ApplicationName = appsByID[appID].Name
+					loop.ServiceManifestName = pkg.ServiceManifestName
+					loop.ServicePackageActivationID = pkg.ServicePackageActivationID
+					loop.CodePackageName = pkg.Name
+					loop.EntryPoint = ep.name
+
+					mu.Lock()
+					loopAppIDs[appID] = append(loopAppIDs[appID], len(loops))
+					loops = append(loops, loop)
+					mu.Unlock()
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for appID, indexes := range loopAppIDs {
+		app, ok := appsByID[appID]
+		if !ok {
+			continue
+		}
+		services, err := c.servicesByManifest(ctx, app)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range indexes {
+			loops[i].Services = services[loops[i].ServiceManifestName]
+		}
+	}
+
+	sort.Slice(loops, func(i, j int) bool {
+		a, b := loops[i], loops[j]
+		if a.NodeName != b.NodeName {
+			return a.NodeName < b.NodeName
+		}
+		if a.ApplicationName != b.ApplicationName {
+			return a.ApplicationName < b.ApplicationName
+		}
+		if a.ServiceManifestName != b.ServiceManifestName {
+			return a.ServiceManifestName < b.ServiceManifestName
+		}
+		if a.CodePackageName != b.CodePackageName {
+			return a.CodePackageName < b.CodePackageName
+		}
+		return a.EntryPoint < b.EntryPoint
+	})
+	return loops, nil
+}
+
+// detectRestartLoop reports whether ep failed at least minFailures times
+// in a row, or failed and waits for its activation backoff to elapse
+func detectRestartLoop(ep *CodePackageEntryPoint, minFailures int64) (RestartLoop, bool) {
+	if ep == nil || ep.Statistics == nil {
+		return RestartLoop{}, false
+	}
+
+	stats := ep.Statistics
+	failures := stats.ContinuousExitFailureCount
+	if stats.ContinuousActivationFailureCount > failures {
+		failures = stats.ContinuousActivationFailureCount
+	}
+
+	next, _ := time.Parse(time.RFC3339, ep.NextActivationTime)
+	lastExit, _ := time.Parse(time.RFC3339, stats.LastExitTime)
+	backingOff := ep.Status == "Pending" && !next.IsZero() && next.After(lastExit)
+
+	if failures < minFailures && !(backingOff && failures > 0) {
+		return RestartLoop{}, false
+	}
+
+	loop := RestartLoop{
+		ContinuousFailures: failures,
+		LastExitCode:       stats.LastExitCode,
+	}
+	if backingOff {
+		loop.NextActivationTime = next
+	}
+	return loop, true
+}
+
+// servicesByManifest maps the service manifests of app to the
+// names of the services whose type they provide
+func (c ServiceFabricClient) servicesByManifest(ctx context.Context, app ApplicationItem) (map[string][]string, error) {
+	serviceTypes, err := c.getServiceTypes(ctx, app.TypeName, app.TypeVersion)
+	if err != nil {
+		return nil, err
+	}
+	manifests := map[string]string{}
+	for _, serviceType := range serviceTypes {
+		manifests[serviceType.ServiceTypeDescription.ServiceTypeName] = serviceType.ServiceManifestName
+	}
+
+	services, err := c.getServices(ctx, app.ID)
+	if err != nil {
+		return nil, err
+	}
+	byManifest := map[string][]string{}
+	for _, service := range services.Items {
+		if manifest, ok := manifests[service.TypeName]; ok {
+			byManifest[manifest] = append(byManifest[manifest], service.Name)
+		}
+	}
+	return byManifest, nil
+}
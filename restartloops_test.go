@@ -0,0 +1,95 @@
+package servicefabric
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDetectRestartLoops(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Applications/", handleApplications)
+	mux.HandleFunc("/Applications/TestApplication/$/GetServices", handleServices)
+	mux.HandleFunc("/ApplicationTypes/", handleServiceTypes)
+	mux.HandleFunc("/Nodes/", handleNodes)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	expected := []RestartLoop{
+		{
+			NodeName:            "_Node_0",
+			ApplicationName:     "fabric:/TestApplication",
+			ServiceManifestName: "TestServicePkg",
+			CodePackageName:     "Code",
+			EntryPoint:          "Main",
+			ContinuousFailures:  7,
+			LastExitCode:        "3221225477",
+			NextActivationTime:  time.Date(2020, 1, 1, 10, 5, 0, 0, time.UTC),
+			Services:            []string{"fabric:/TestApplication/TestService"},
+		},
+	}
+
+	actual, err := sfClient.DetectRestartLoops(context.Background(), RestartLoopOptions{})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+}
+
+func TestDetectRestartLoop(t *testing.T) {
+	tests := []struct {
+		name       string
+		entryPoint *CodePackageEntryPoint
+		expected   bool
+	}{
+		{
+			name:       "healthy",
+			entryPoint: &CodePackageEntryPoint{Status: "Started", Statistics: &CodePackageEntryPointStatistics{ExitCount: 4}},
+		},
+		{
+			name:       "failing",
+			entryPoint: &CodePackageEntryPoint{Status: "Started", Statistics: &CodePackageEntryPointStatistics{ContinuousActivationFailureCount: 3}},
+			expected:   true,
+		},
+		{
+			name: "backing off",
+			entryPoint: &CodePackageEntryPoint{
+				Status:             "Pending",
+				NextActivationTime: "2020-01-01T10:05:00.000Z",
+				Statistics: &CodePackageEntryPointStatistics{
+					LastExitTime:               "2020-01-01T10:03:05.000Z",
+					ContinuousExitFailureCount: 1,
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "starting",
+			entryPoint: &CodePackageEntryPoint{
+				Status:             "Pending",
+				NextActivationTime: "2020-01-01T10:05:00.000Z",
+				Statistics:         &CodePackageEntryPointStatistics{},
+			},
+		},
+		{
+			name: "no statistics",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			if _, actual := detectRestartLoop(test.entryPoint, defaultMinContinuousFailures); actual != test.expected {
+				t.Errorf("Got %v, want %v", actual, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package servicefabric
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy resends requests failing with a transient error, waiting an
+// exponentially growing, jittered backoff between attempts
+type RetryPolicy struct {
+	// MaxAttempts counts the first attempt, one or less disables retries
+	MaxAttempts int
+	// InitialBackoff is the longest wait before the first retry,
+	// doubling on every retry up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// RetryableStatusCodes are the response statuses retried
+	RetryableStatusCodes []int
+	// RetryableFabricErrors are the FabricError codes retried,
+	// whatever the response status
+	RetryableFabricErrors []string
+	// RetryableClasses are the classes of the failures retried,
+	// see ClassifyError, in addition to the statuses and codes above
+	RetryableClasses []ErrorClass
+}
+
+// DefaultRetryPolicy retries unavailable gateways, timeouts and
+// throttling up to three times, backing off from half a second
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryableFabricErrors: []string{
+			FabricErrorGatewayNotReachable,
+			FabricErrorTimeout,
+			FabricErrorServiceTooBusy,
+			FabricErrorNotReady,
+			FabricErrorReconfigurationPending,
+		},
+	}
+}
+
+// retryable reports whether a request that failed with err, status and
+// body should be resent. Only reads and idempotent operations are resent,
+// as the cluster may have applied a mutation before failing, and requests
+// that got no answer only when they failed in transit.
+func (p *RetryPolicy) retryable(idempotent bool, status int, body []byte, err error) bool {
+	if !idempotent {
+		return false
+	}
+	if status <= 0 {
+		return transportError(err)
+	}
+	for _, code := range p.RetryableStatusCodes {
+		if status == code {
+			return true
+		}
+	}
+	var code string
+	if fabricErr := parseFabricError(body); fabricErr != nil {
+		code = fabricErr.Code
+		for _, retryable := range p.RetryableFabricErrors {
+			if code == retryable {
+				return true
+			}
+		}
+	}
+	class := classify(status, code)
+	for _, retryable := range p.RetryableClasses {
+		if class == retryable {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns how long to wait before retry, counting from one
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < retry && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	// jitter within the upper half keeps clients retrying together apart
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// doRetrying sends a request with do, resending it as the retry policy
+// allows when the request is idempotent
+func (c ServiceFabricClient) doRetrying(ctx context.Context, method, target string, body []byte, idempotent bool) ([]byte, int, error) {
+	for attempt := 1; ; attempt++ {
+		res, status, err := c.do(ctx, method, target, body)
+		if err == nil || c.retryPolicy == nil || attempt >= c.retryPolicy.MaxAttempts ||
+			!c.retryPolicy.retryable(idempotent, status, res, err) {
+			return res, status, err
+		}
+
+		timer := time.NewTimer(c.retryPolicy.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return res, status, err
+		case <-timer.C:
+		}
+		callTrackerFromContext(ctx).retry()
+	}
+}
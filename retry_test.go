@@ -0,0 +1,228 @@
+package servicefabric
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func testRetryPolicy() RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = 2 * time.Millisecond
+	return policy
+}
+
+func TestRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures []func(w http.ResponseWriter)
+		attempts int
+		fails    bool
+	}{
+		{
+			name: "unavailable",
+			failures: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) },
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) },
+			},
+			attempts: 3,
+		},
+		{
+			name: "gateway not reachable",
+			failures: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.WriteHeader(http.StatusInternalServerError)
+					w.Write([]byte(`{"Error":{"Code":"FABRIC_E_GATEWAY_NOT_REACHABLE"}}`))
+				},
+			},
+			attempts: 2,
+		},
+		{
+			name: "not retryable",
+			failures: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusInternalServerError) },
+			},
+			attempts: 1,
+			fails:    true,
+		},
+		{
+			name: "attempts exhausted",
+			failures: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusGatewayTimeout) },
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusGatewayTimeout) },
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusGatewayTimeout) },
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusGatewayTimeout) },
+			},
+			attempts: 4,
+			fails:    true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				if attempts <= len(test.failures) {
+					test.failures[attempts-1](w)
+					return
+				}
+				handleServiceDescription(w, r)
+			}))
+			defer server.Close()
+
+			var info CallInfo
+			observer := CallObserverFunc(func(i CallInfo) { info = i })
+			sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil,
+				WithRetryPolicy(testRetryPolicy()), WithCallObserver(observer))
+
+			_, err := sfClient.GetServiceDescription(context.Background(), "TestApplication~TestService")
+			if test.fails && err == nil {
+				t.Error("Error should have been returned")
+			}
+			if !test.fails && err != nil {
+				t.Errorf("Exception thrown %v", err)
+			}
+			if attempts != test.attempts {
+				t.Errorf("Got %d attempts, want %d", attempts, test.attempts)
+			}
+			if info.Retries != test.attempts-1 {
+				t.Errorf("Got %d retries, want %d", info.Retries, test.attempts-1)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	for retry, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		backoff := policy.backoff(retry + 1)
+		if backoff < max/2 || backoff > max {
+			t.Errorf("Got %s for retry %d, want between %s and %s", backoff, retry+1, max/2, max)
+		}
+	}
+}
+
+func TestRetryPolicyStopsOnContextDone(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Hour
+	policy.MaxBackoff = time.Hour
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil, WithRetryPolicy(policy))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := sfClient.GetServiceDescription(ctx, "TestApplication~TestService")
+	if err == nil {
+		t.Error("Error should have been returned")
+	}
+	if attempts != 1 {
+		t.Errorf("Got %d attempts, want 1", attempts)
+	}
+}
+
+func TestRetryPolicyResendsOnlyIdempotentRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		call     func(sfClient *ServiceFabricClient) error
+		attempts int
+	}{
+		{
+			name: "mutation",
+			call: func(sfClient *ServiceFabricClient) error {
+				return sfClient.DeleteService(context.Background(), "TestApplication~TestService")
+			},
+			attempts: 1,
+		},
+		{
+			name: "health report",
+			call: func(sfClient *ServiceFabricClient) error {
+				return sfClient.ReportNodeHealth(context.Background(), "_Node_0", HealthInformation{SourceID: "test", Property: "test", HealthState: "Ok"})
+			},
+			attempts: 4,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				w.WriteHeader(http.StatusGatewayTimeout)
+			}))
+			defer server.Close()
+
+			sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil, WithRetryPolicy(testRetryPolicy()))
+
+			if err := test.call(sfClient); err == nil {
+				t.Error("Error should have been returned")
+			}
+			if attempts != test.attempts {
+				t.Errorf("Got %d attempts, want %d", attempts, test.attempts)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyRawReads(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil, WithRetryPolicy(testRetryPolicy()))
+
+	status, err := sfClient.getHTTPRaw(context.Background(), "$/GetClusterHealth")
+	if err != nil {
+		t.Errorf("Exception thrown %v", err)
+	}
+	if status != http.StatusOK || attempts != 2 {
+		t.Errorf("Got status %d after %d attempts, want %d after 2", status, attempts, http.StatusOK)
+	}
+}
+
+func TestRetryPolicyRetriesOnlyTransportErrors(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{name: "connection refused", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, retryable: true},
+		{name: "truncated response", err: errors.Wrap(io.ErrUnexpectedEOF, "failed reading response"), retryable: true},
+		{name: "token", err: errors.New("failed acquiring token"), retryable: false},
+		{name: "cancelled", err: context.Canceled, retryable: false},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			if actual := policy.retryable(true, 0, nil, test.err); actual != test.retryable {
+				t.Errorf("Got %v, want %v", actual, test.retryable)
+			}
+		})
+	}
+}
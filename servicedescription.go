@@ -0,0 +1,499 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Service kinds
+const (
+	ServiceKindStateless = "Stateless"
+	ServiceKindStateful  = "Stateful"
+)
+
+// Service correlation schemes
+const (
+	// CorrelationSchemeAffinity places the replicas of a service
+	// on the nodes holding the replicas of the service it is correlated with
+	CorrelationSchemeAffinity = "Affinity"
+	// CorrelationSchemeAlignedAffinity further places the primary
+	// replicas of both services on the same node
+	CorrelationSchemeAlignedAffinity = "AlignedAffinity"
+	// CorrelationSchemeNonAlignedAffinity places the replicas of both services
+	// on the same nodes without aligning their roles
+	CorrelationSchemeNonAlignedAffinity = "NonAlignedAffinity"
+)
+
+// Partition schemes
+const (
+	PartitionSchemeSingleton         = "Singleton"
+	PartitionSchemeNamed             = "Named"
+	PartitionSchemeUniformInt64Range = "UniformInt64Range"
+)
+
+// Move costs of a service
+const (
+	MoveCostZero   = "Zero"
+	MoveCostLow    = "Low"
+	MoveCostMedium = "Medium"
+	MoveCostHigh   = "High"
+)
+
+// Flags of a service update description, marking which of its fields are set
+const (
+	statelessFlagInstanceCount              = 1
+	statelessFlagPlacementConstraints       = 2
+	statelessFlagCorrelation                = 4
+	statelessFlagDefaultMoveCost            = 32
+	statelessFlagInstanceCloseDelayDuration = 128
+
+	statefulFlagTargetReplicaSetSize = 1
+	statefulFlagMinReplicaSetSize    = 16
+	statefulFlagPlacementConstraints = 32
+	statefulFlagCorrelation          = 128
+	statefulFlagDefaultMoveCost      = 512
+)
+
+// ServiceCorrelationDescription correlates a service with another service
+type ServiceCorrelationDescription struct {
+	Scheme      string `json:"Scheme"`
+	ServiceName string `json:"ServiceName"`
+}
+
+// PartitionDescription describes how a service is partitioned. Named
+// partitions are listed in Names, and UniformInt64Range partitions split
+// the keys from LowKey to HighKey, both included, in Count ranges.
+type PartitionDescription struct {
+	PartitionScheme string   `json:"PartitionScheme"`
+	Count           int      `json:"Count,omitempty"`
+	Names           []string `json:"Names,omitempty"`
+	LowKey          int64    `json:"LowKey,string"`
+	HighKey         int64    `json:"HighKey,string"`
+}
+
+// MarshalJSON writes the keys of range partitions even when zero,
+// and leaves them out for the other schemes
+func (d PartitionDescription) MarshalJSON() ([]byte, error) {
+	type partitionDescription PartitionDescription
+	if d.PartitionScheme == PartitionSchemeUniformInt64Range {
+		return json.Marshal(partitionDescription(d))
+	}
+	return json.Marshal(struct {
+		PartitionScheme string   `json:"PartitionScheme"`
+		Count           int      `json:"Count,omitempty"`
+		Names           []string `json:"Names,omitempty"`
+	}{d.PartitionScheme, d.Count, d.Names})
+}
+
+// validate checks the fields the partition scheme requires
+func (d *PartitionDescription) validate() error {
+	switch d.PartitionScheme {
+	case PartitionSchemeSingleton:
+		if d.Count != 0 || len(d.Names) > 0 || d.LowKey != 0 || d.HighKey != 0 {
+			return errors.New("singleton partition takes no count, names or keys")
+		}
+	case PartitionSchemeNamed:
+		if len(d.Names) == 0 || d.Count != len(d.Names) {
+			return fmt.Errorf("named partition count %d must match its %d names", d.Count, len(d.Names))
+		}
+		if len(sortedDistinct(d.Names)) != len(d.Names) {
+			return errors.New("named partition names must be distinct")
+		}
+	case PartitionSchemeUniformInt64Range:
+		if d.Count < 1 {
+			return fmt.Errorf("range partition count must be positive, got %d", d.Count)
+		}
+		if d.LowKey > d.HighKey {
+			return fmt.Errorf("range partition low key %d exceeds high key %d", d.LowKey, d.HighKey)
+		}
+		if uint64(d.HighKey-d.LowKey) < uint64(d.Count-1) {
+			return fmt.Errorf("range partition keys %d to %d cannot be split in %d partitions", d.LowKey, d.HighKey, d.Count)
+		}
+	default:
+		return fmt.Errorf("unknown partition scheme %q", d.PartitionScheme)
+	}
+	return nil
+}
+
+// ServiceDescription describes how a service was created
+type ServiceDescription struct {
+	ServiceKind     string `json:"ServiceKind"`
+	ApplicationName string `json:"ApplicationName,omitempty"`
+	ServiceName     string `json:"ServiceName"`
+	ServiceTypeName string `json:"ServiceTypeName"`
+	// PartitionDescription is a singleton partition when nil in CreateService
+	PartitionDescription *PartitionDescription `json:"PartitionDescription,omitempty"`
+	PlacementConstraints string                `json:"PlacementConstraints,omitempty"`
+	// ServiceDNSName is the name the service resolves as through the
+	// cluster DNS service, e.g. "backend.myapp"
+	ServiceDNSName string `json:"ServiceDnsName,omitempty"`
+	// CorrelationScheme lists the services this service is correlated with
+	CorrelationScheme []ServiceCorrelationDescription `json:"CorrelationScheme,omitempty"`
+	// InstanceCount is the number of instances of a stateless
+	// service, -1 placing one instance on every node
+	InstanceCount int `json:"InstanceCount,omitempty"`
+	// InstanceCloseDelayDurationSeconds is how long a stateless instance
+	// stays open after its endpoint was removed, so that clients such as
+	// proxies drain its connections before it closes
+	InstanceCloseDelayDurationSeconds *int64 `json:"InstanceCloseDelayDurationSeconds,omitempty"`
+	HasPersistedState                 bool   `json:"HasPersistedState,omitempty"`
+	TargetReplicaSetSize              int    `json:"TargetReplicaSetSize,omitempty"`
+	MinReplicaSetSize                 int    `json:"MinReplicaSetSize,omitempty"`
+}
+
+// GetServiceDescription returns the description of a service
+func (c ServiceFabricClient) GetServiceDescription(ctx context.Context, serviceID string) (description *ServiceDescription, err error) {
+	ctx, call := c.startCall(ctx, "GetServiceDescription")
+	defer func() { call.finish(err) }()
+
+	return c.getServiceDescription(ctx, serviceID)
+}
+
+func (c ServiceFabricClient) getServiceDescription(ctx context.Context, serviceID string) (*ServiceDescription, error) {
+	res, _, err := c.getHTTP(ctx, "Services/"+serviceID+"/$/GetDescription")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting service description")
+	}
+
+	var description ServiceDescription
+	err = json.Unmarshal(res, &description)
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return &description, nil
+}
+
+// GetServiceDNSNames maps the DNS name of every service that has one, in
+// lower case, to the fabric name of the service. Service descriptions are
+// queried in parallel, see WithConcurrency.
+func (c ServiceFabricClient) GetServiceDNSNames(ctx context.Context) (names map[string]string, err error) {
+	ctx, call := c.startCall(ctx, "GetServiceDNSNames")
+	defer func() { call.finish(err) }()
+
+	apps, err := c.getApplications(ctx, func(*ApplicationItem) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+
+	var serviceIDs []string
+	for _, app := range apps.Items {
+		services, err := c.getServices(ctx, app.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, service := range services.Items {
+			serviceIDs = append(serviceIDs, service.ID)
+		}
+	}
+
+	descriptions := make([]*ServiceDescription, len(serviceIDs))
+	err = c.forEach(len(serviceIDs), func(i int) error {
+		description, err := c.getServiceDescription(ctx, serviceIDs[i])
+		if err != nil {
+			return err
+		}
+		descriptions[i] = description
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	names = map[string]string{}
+	for _, description := range descriptions {
+		if description.ServiceDNSName != "" {
+			names[strings.ToLower(description.ServiceDNSName)] = description.ServiceName
+		}
+	}
+	return names, nil
+}
+
+// LookupServiceDNSName returns the fabric name of the service whose DNS name
+// is dnsName, failing with ErrResourceNotFound when no service has it
+func (c ServiceFabricClient) LookupServiceDNSName(ctx context.Context, dnsName string) (string, error) {
+	names, err := c.GetServiceDNSNames(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	name, ok := names[strings.ToLower(strings.TrimSuffix(dnsName, "."))]
+	if !ok {
+		return "", errors.Wrapf(ErrResourceNotFound, "service dns name %s", dnsName)
+	}
+	return name, nil
+}
+
+// ServiceUpdateDescription changes the fields of a service that are set,
+// leaving the others unchanged
+type ServiceUpdateDescription struct {
+	// ServiceKind is looked up by UpdateService when empty
+	ServiceKind string
+	// InstanceCount scales a stateless service, -1 placing
+	// one instance on every node
+	InstanceCount *int
+	// TargetReplicaSetSize and MinReplicaSetSize scale a stateful service
+	TargetReplicaSetSize *int
+	MinReplicaSetSize    *int
+	// PlacementConstraints replaces the placement constraints,
+	// an empty expression removing them
+	PlacementConstraints *string
+	// CorrelationScheme replaces the correlations of the service when not
+	// nil, an empty list removing them
+	CorrelationScheme []ServiceCorrelationDescription
+	// DefaultMoveCost is one of the MoveCost constants
+	DefaultMoveCost string
+	// InstanceCloseDelay is rounded down to the second, stateless services only
+	InstanceCloseDelay *time.Duration
+}
+
+// serviceUpdateBody is the request body of a service update
+type serviceUpdateBody struct {
+	ServiceKind                       string                           `json:"ServiceKind"`
+	Flags                             string                           `json:"Flags"`
+	InstanceCount                     *int                             `json:"InstanceCount,omitempty"`
+	TargetReplicaSetSize              *int                             `json:"TargetReplicaSetSize,omitempty"`
+	MinReplicaSetSize                 *int                             `json:"MinReplicaSetSize,omitempty"`
+	PlacementConstraints              *string                          `json:"PlacementConstraints,omitempty"`
+	CorrelationScheme                 *[]ServiceCorrelationDescription `json:"CorrelationScheme,omitempty"`
+	DefaultMoveCost                   string                           `json:"DefaultMoveCost,omitempty"`
+	InstanceCloseDelayDurationSeconds *int64                           `json:"InstanceCloseDelayDurationSeconds,omitempty"`
+}
+
+// body validates the update and returns its request body, with the
+// flags of the fields that are set
+func (u ServiceUpdateDescription) body() (*serviceUpdateBody, error) {
+	body := &serviceUpdateBody{
+		ServiceKind:          u.ServiceKind,
+		InstanceCount:        u.InstanceCount,
+		TargetReplicaSetSize: u.TargetReplicaSetSize,
+		MinReplicaSetSize:    u.MinReplicaSetSize,
+		PlacementConstraints: u.PlacementConstraints,
+		DefaultMoveCost:      u.DefaultMoveCost,
+	}
+	var flags int
+
+	switch u.ServiceKind {
+	case ServiceKindStateless:
+		if u.TargetReplicaSetSize != nil || u.MinReplicaSetSize != nil {
+			return nil, errors.New("stateless services take no replica set sizes")
+		}
+		if u.InstanceCount != nil {
+			if *u.InstanceCount == 0 || *u.InstanceCount < -1 {
+				return nil, fmt.Errorf("instance count must be positive or -1, got %d", *u.InstanceCount)
+			}
+			flags |= statelessFlagInstanceCount
+		}
+		if u.InstanceCloseDelay != nil {
+			if *u.InstanceCloseDelay < 0 {
+				return nil, fmt.Errorf("instance close delay must not be negative, got %s", *u.InstanceCloseDelay)
+			}
+			seconds := int64(*u.InstanceCloseDelay / time.Second)
+			body.InstanceCloseDelayDurationSeconds = &seconds
+			flags |= statelessFlagInstanceCloseDelayDuration
+		}
+		if u.PlacementConstraints != nil {
+			flags |= statelessFlagPlacementConstraints
+		}
+		if u.CorrelationScheme != nil {
+			flags |= statelessFlagCorrelation
+		}
+		if u.DefaultMoveCost != "" {
+			flags |= statelessFlagDefaultMoveCost
+		}
+	case ServiceKindStateful:
+		if u.InstanceCount != nil || u.InstanceCloseDelay != nil {
+			return nil, errors.New("stateful services take no instance count or instance close delay")
+		}
+		if u.TargetReplicaSetSize != nil {
+			if *u.TargetReplicaSetSize < 1 {
+				return nil, fmt.Errorf("target replica set size must be positive, got %d", *u.TargetReplicaSetSize)
+			}
+			flags |= statefulFlagTargetReplicaSetSize
+		}
+		if u.MinReplicaSetSize != nil {
+			if *u.MinReplicaSetSize < 1 {
+				return nil, fmt.Errorf("min replica set size must be positive, got %d", *u.MinReplicaSetSize)
+			}
+			flags |= statefulFlagMinReplicaSetSize
+		}
+		if u.TargetReplicaSetSize != nil && u.MinReplicaSetSize != nil && *u.MinReplicaSetSize > *u.TargetReplicaSetSize {
+			return nil, fmt.Errorf("min replica set size %d exceeds target %d", *u.MinReplicaSetSize, *u.TargetReplicaSetSize)
+		}
+		if u.PlacementConstraints != nil {
+			flags |= statefulFlagPlacementConstraints
+		}
+		if u.CorrelationScheme != nil {
+			flags |= statefulFlagCorrelation
+		}
+		if u.DefaultMoveCost != "" {
+			flags |= statefulFlagDefaultMoveCost
+		}
+	default:
+		return nil, fmt.Errorf("unknown service kind %q", u.ServiceKind)
+	}
+
+	switch u.DefaultMoveCost {
+	case "", MoveCostZero, MoveCostLow, MoveCostMedium, MoveCostHigh:
+	default:
+		return nil, fmt.Errorf("unknown move cost %q", u.DefaultMoveCost)
+	}
+	if u.CorrelationScheme != nil {
+		if err := validateCorrelations(u.CorrelationScheme); err != nil {
+			return nil, err
+		}
+		correlations := u.CorrelationScheme
+		body.CorrelationScheme = &correlations
+	}
+	if flags == 0 {
+		return nil, errors.New("service update changes nothing")
+	}
+	body.Flags = strconv.Itoa(flags)
+	return body, nil
+}
+
+// UpdateService changes the fields of a service the update sets, such as
+// its instance count or replica set sizes, its placement constraints or its
+// correlations. The service kind is looked up when the update does not set it.
+func (c ServiceFabricClient) UpdateService(ctx context.Context, serviceID string, update ServiceUpdateDescription) (err error) {
+	ctx, call := c.startCall(ctx, "UpdateService")
+	defer func() { call.finish(err) }()
+
+	return c.updateService(ctx, serviceID, update)
+}
+
+func (c ServiceFabricClient) updateService(ctx context.Context, serviceID string, update ServiceUpdateDescription) error {
+	if update.ServiceKind == "" {
+		description, err := c.getServiceDescription(ctx, serviceID)
+		if err != nil {
+			return err
+		}
+		update.ServiceKind = description.ServiceKind
+	}
+
+	updateBody, err := update.body()
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(updateBody)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.postHTTP(ctx, opUpdateService.on(serviceID), "Services/"+serviceID+"/$/Update", body)
+	if err != nil {
+		return errors.Wrap(err, "failed updating service")
+	}
+	return nil
+}
+
+// SetInstanceCloseDelay changes how long the instances of a stateless
+// service stay open after their endpoint was removed, rounded down to
+// the second. Zero closes instances immediately.
+func (c ServiceFabricClient) SetInstanceCloseDelay(ctx context.Context, serviceID string, delay time.Duration) (err error) {
+	ctx, call := c.startCall(ctx, "SetInstanceCloseDelay")
+	defer func() { call.finish(err) }()
+
+	return c.updateService(ctx, serviceID, ServiceUpdateDescription{
+		ServiceKind:        ServiceKindStateless,
+		InstanceCloseDelay: &delay,
+	})
+}
+
+// SetServiceCorrelations replaces the services a service is correlated with,
+// an empty list removing its correlations. Schemes must be one of the
+// CorrelationScheme constants.
+func (c ServiceFabricClient) SetServiceCorrelations(ctx context.Context, serviceID string, correlations []ServiceCorrelationDescription) (err error) {
+	ctx, call := c.startCall(ctx, "SetServiceCorrelations")
+	defer func() { call.finish(err) }()
+
+	if err := validateCorrelations(correlations); err != nil {
+		return err
+	}
+	if correlations == nil {
+		correlations = []ServiceCorrelationDescription{}
+	}
+	return c.updateService(ctx, serviceID, ServiceUpdateDescription{CorrelationScheme: correlations})
+}
+
+func validateCorrelations(correlations []ServiceCorrelationDescription) error {
+	for _, correlation := range correlations {
+		switch correlation.Scheme {
+		case CorrelationSchemeAffinity, CorrelationSchemeAlignedAffinity, CorrelationSchemeNonAlignedAffinity:
+		default:
+			return fmt.Errorf("unknown correlation scheme %q", correlation.Scheme)
+		}
+		if correlation.ServiceName == "" {
+			return errors.New("correlated service name is required")
+		}
+	}
+	return nil
+}
+
+// Validate checks that the description can create a service: its kind,
+// names, partitioning, instance count or replica set sizes and correlations
+func (d *ServiceDescription) Validate() error {
+	if !strings.HasPrefix(d.ServiceName, fabricScheme) {
+		return fmt.Errorf("service name %q must start with %s", d.ServiceName, fabricScheme)
+	}
+	if d.ServiceTypeName == "" {
+		return errors.New("service type name is required")
+	}
+
+	switch d.ServiceKind {
+	case ServiceKindStateless:
+		if d.InstanceCount == 0 || d.InstanceCount < -1 {
+			return fmt.Errorf("instance count must be positive or -1, got %d", d.InstanceCount)
+		}
+		if d.TargetReplicaSetSize != 0 || d.MinReplicaSetSize != 0 || d.HasPersistedState {
+			return errors.New("stateless services take no replica set sizes or persisted state")
+		}
+	case ServiceKindStateful:
+		if d.TargetReplicaSetSize < 1 || d.MinReplicaSetSize < 1 || d.MinReplicaSetSize > d.TargetReplicaSetSize {
+			return fmt.Errorf("replica set sizes must satisfy 1 <= min (%d) <= target (%d)", d.MinReplicaSetSize, d.TargetReplicaSetSize)
+		}
+		if d.InstanceCount != 0 || d.InstanceCloseDelayDurationSeconds != nil {
+			return errors.New("stateful services take no instance count or instance close delay")
+		}
+	default:
+		return fmt.Errorf("unknown service kind %q", d.ServiceKind)
+	}
+
+	if d.PartitionDescription != nil {
+		if err := d.PartitionDescription.validate(); err != nil {
+			return err
+		}
+	}
+	return validateCorrelations(d.CorrelationScheme)
+}
+
+// CreateService creates a service of the application appID as description
+// describes, see Validate. Services without a PartitionDescription get a
+// single partition.
+func (c ServiceFabricClient) CreateService(ctx context.Context, appID string, description ServiceDescription) (err error) {
+	ctx, call := c.startCall(ctx, "CreateService")
+	defer func() { call.finish(err) }()
+
+	if description.PartitionDescription == nil {
+		description.PartitionDescription = &PartitionDescription{PartitionScheme: PartitionSchemeSingleton}
+	}
+	if err := description.Validate(); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(description)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.postHTTP(ctx, opCreateService.on(description.ServiceName), "Applications/"+appID+"/$/GetServices/$/Create", body)
+	if err != nil {
+		return errors.Wrap(err, "failed creating service")
+	}
+	return nil
+}
@@ -0,0 +1,378 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetServiceDescription(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleServiceDescription))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetServiceDescription(context.Background(), "TestApplication~TestService")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	closeDelay := int64(30)
+	expected := &ServiceDescription{
+		ServiceKind:          ServiceKindStateless,
+		ApplicationName:      "fabric:/TestApplication",
+		ServiceName:          "fabric:/TestApplication/TestService",
+		ServiceTypeName:      "TestServiceType",
+		PartitionDescription: &PartitionDescription{PartitionScheme: PartitionSchemeSingleton},
+		PlacementConstraints: "NodeType == NodeType0",
+		ServiceDNSName:       "testservice.testapplication",
+		CorrelationScheme: []ServiceCorrelationDescription{
+			{Scheme: CorrelationSchemeAlignedAffinity, ServiceName: "fabric:/TestApplication/TestService2"},
+		},
+		InstanceCount:                     -1,
+		InstanceCloseDelayDurationSeconds: &closeDelay,
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+}
+
+func TestLookupServiceDNSName(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Applications/", handleApplications)
+	mux.HandleFunc("/Applications/TestApplication/$/GetServices", handleServices)
+	mux.HandleFunc("/Applications/TestApplication2/$/GetServices", func(w http.ResponseWriter, r *http.Request) {
+		writeFixture(w, "services_empty.json")
+	})
+	mux.HandleFunc("/Services/TestApplication/TestService/$/GetDescription", func(w http.ResponseWriter, r *http.Request) {
+		writeFixture(w, "service_description.json")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.LookupServiceDNSName(context.Background(), "TestService.TestApplication.")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if actual != "fabric:/TestApplication/TestService" {
+		t.Errorf("Got %+v, want %+v", actual, "fabric:/TestApplication/TestService")
+	}
+
+	_, err = sfClient.LookupServiceDNSName(context.Background(), "missing.testapplication")
+	if !errors.Is(err, ErrResourceNotFound) {
+		t.Errorf("Got %v, want %v", err, ErrResourceNotFound)
+	}
+}
+
+func TestSetInstanceCloseDelay(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/Services/TestApplication~TestService/$/Update" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Exception thrown %v", err)
+		}
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	err := sfClient.SetInstanceCloseDelay(context.Background(), "TestApplication~TestService", 45*time.Second)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"ServiceKind":                       "Stateless",
+		"Flags":                             "128",
+		"InstanceCloseDelayDurationSeconds": float64(45),
+	}
+	if !reflect.DeepEqual(body, expected) {
+		t.Errorf("Got %+v, want %+v", body, expected)
+	}
+
+	if err := sfClient.SetInstanceCloseDelay(context.Background(), "TestApplication~TestService", -time.Second); err == nil {
+		t.Error("Error should have been returned")
+	}
+}
+
+func TestUpgradeInstanceCloseDelay(t *testing.T) {
+	closeDelay := int64(0)
+	b, err := json.Marshal(RollingUpgradeUpdateDescription{
+		RollingUpgradeMode:                  UpgradeModeMonitored,
+		InstanceCloseDelayDurationInSeconds: &closeDelay,
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := `{"RollingUpgradeMode":"Monitored","InstanceCloseDelayDurationInSeconds":0}`
+	if string(b) != expected {
+		t.Errorf("Got %+v, want %+v", string(b), expected)
+	}
+}
+
+func TestSetServiceCorrelations(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			handleServiceDescription(w, r)
+			return
+		}
+		if r.URL.Path != "/Services/TestApplication~TestService/$/Update" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Exception thrown %v", err)
+		}
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	err := sfClient.SetServiceCorrelations(context.Background(), "TestApplication~TestService", []ServiceCorrelationDescription{
+		{Scheme: CorrelationSchemeNonAlignedAffinity, ServiceName: "fabric:/TestApplication/TestService3"},
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"ServiceKind": "Stateless",
+		"Flags":       "4",
+		"CorrelationScheme": []interface{}{
+			map[string]interface{}{"Scheme": "NonAlignedAffinity", "ServiceName": "fabric:/TestApplication/TestService3"},
+		},
+	}
+	if !reflect.DeepEqual(body, expected) {
+		t.Errorf("Got %+v, want %+v", body, expected)
+	}
+
+	err = sfClient.SetServiceCorrelations(context.Background(), "TestApplication~TestService", []ServiceCorrelationDescription{
+		{Scheme: "Colocated", ServiceName: "fabric:/TestApplication/TestService3"},
+	})
+	if err == nil {
+		t.Error("Error should have been returned")
+	}
+}
+
+func TestCreateService(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/Applications/TestApplication/$/GetServices/$/Create" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Exception thrown %v", err)
+		}
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	err := sfClient.CreateService(context.Background(), "TestApplication", ServiceDescription{
+		ServiceKind:     ServiceKindStateful,
+		ServiceName:     "fabric:/TestApplication/TestService",
+		ServiceTypeName: "TestServiceType",
+		PartitionDescription: &PartitionDescription{
+			PartitionScheme: PartitionSchemeUniformInt64Range,
+			Count:           2,
+			LowKey:          -10,
+			HighKey:         10,
+		},
+		TargetReplicaSetSize: 3,
+		MinReplicaSetSize:    2,
+		HasPersistedState:    true,
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"ServiceKind":     "Stateful",
+		"ServiceName":     "fabric:/TestApplication/TestService",
+		"ServiceTypeName": "TestServiceType",
+		"PartitionDescription": map[string]interface{}{
+			"PartitionScheme": "UniformInt64Range",
+			"Count":           float64(2),
+			"LowKey":          "-10",
+			"HighKey":         "10",
+		},
+		"HasPersistedState":    true,
+		"TargetReplicaSetSize": float64(3),
+		"MinReplicaSetSize":    float64(2),
+	}
+	if !reflect.DeepEqual(body, expected) {
+		t.Errorf("Got %+v, want %+v", body, expected)
+	}
+
+	body = nil
+	err = sfClient.CreateService(context.Background(), "TestApplication", ServiceDescription{
+		ServiceKind:     ServiceKindStateless,
+		ServiceName:     "fabric:/TestApplication/TestService2",
+		ServiceTypeName: "TestServiceType",
+		InstanceCount:   -1,
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	partition := map[string]interface{}{"PartitionScheme": "Singleton"}
+	if !reflect.DeepEqual(body["PartitionDescription"], partition) {
+		t.Errorf("Got %+v, want %+v", body["PartitionDescription"], partition)
+	}
+
+	body = nil
+	err = sfClient.CreateService(context.Background(), "TestApplication", ServiceDescription{
+		ServiceKind:     ServiceKindStateless,
+		ServiceName:     "fabric:/TestApplication/TestService3",
+		ServiceTypeName: "TestServiceType",
+		InstanceCount:   1,
+		PartitionDescription: &PartitionDescription{
+			PartitionScheme: PartitionSchemeUniformInt64Range,
+			Count:           4,
+			LowKey:          0,
+			HighKey:         99,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	partition = map[string]interface{}{"PartitionScheme": "UniformInt64Range", "Count": float64(4), "LowKey": "0", "HighKey": "99"}
+	if !reflect.DeepEqual(body["PartitionDescription"], partition) {
+		t.Errorf("Got %+v, want %+v", body["PartitionDescription"], partition)
+	}
+}
+
+func TestValidateServiceDescription(t *testing.T) {
+	valid := ServiceDescription{
+		ServiceKind:     ServiceKindStateless,
+		ServiceName:     "fabric:/TestApplication/TestService",
+		ServiceTypeName: "TestServiceType",
+		InstanceCount:   1,
+	}
+	tests := []struct {
+		name   string
+		modify func(d *ServiceDescription)
+	}{
+		{"relative name", func(d *ServiceDescription) { d.ServiceName = "TestService" }},
+		{"no type", func(d *ServiceDescription) { d.ServiceTypeName = "" }},
+		{"unknown kind", func(d *ServiceDescription) { d.ServiceKind = "Stateish" }},
+		{"no instances", func(d *ServiceDescription) { d.InstanceCount = 0 }},
+		{"stateless replicas", func(d *ServiceDescription) { d.TargetReplicaSetSize = 3 }},
+		{"min above target", func(d *ServiceDescription) {
+			d.ServiceKind, d.InstanceCount, d.TargetReplicaSetSize, d.MinReplicaSetSize = ServiceKindStateful, 0, 2, 3
+		}},
+		{"named count", func(d *ServiceDescription) {
+			d.PartitionDescription = &PartitionDescription{PartitionScheme: PartitionSchemeNamed, Count: 1, Names: []string{"a", "b"}}
+		}},
+		{"duplicate names", func(d *ServiceDescription) {
+			d.PartitionDescription = &PartitionDescription{PartitionScheme: PartitionSchemeNamed, Count: 2, Names: []string{"a", "a"}}
+		}},
+		{"inverted range", func(d *ServiceDescription) {
+			d.PartitionDescription = &PartitionDescription{PartitionScheme: PartitionSchemeUniformInt64Range, Count: 1, LowKey: 5, HighKey: 1}
+		}},
+		{"too many ranges", func(d *ServiceDescription) {
+			d.PartitionDescription = &PartitionDescription{PartitionScheme: PartitionSchemeUniformInt64Range, Count: 4, LowKey: 0, HighKey: 2}
+		}},
+		{"unknown correlation", func(d *ServiceDescription) {
+			d.CorrelationScheme = []ServiceCorrelationDescription{{Scheme: "Colocated", ServiceName: "fabric:/TestApplication/TestService2"}}
+		}},
+	}
+
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			d := valid
+			test.modify(&d)
+			if err := d.Validate(); err == nil {
+				t.Error("Error should have been returned")
+			}
+		})
+	}
+}
+
+func TestUpdateService(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			handleServiceDescription(w, r)
+			return
+		}
+		if r.URL.Path != "/Services/TestApplication~TestService/$/Update" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Exception thrown %v", err)
+		}
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	instances, constraints := 5, ""
+	err := sfClient.UpdateService(context.Background(), "TestApplication~TestService", ServiceUpdateDescription{
+		InstanceCount:        &instances,
+		PlacementConstraints: &constraints,
+		CorrelationScheme: []ServiceCorrelationDescription{
+			{Scheme: "Affinity", ServiceName: "fabric:/TestApplication/TestService2"},
+		},
+		DefaultMoveCost: MoveCostLow,
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"ServiceKind":          "Stateless",
+		"Flags":                "39",
+		"InstanceCount":        float64(5),
+		"PlacementConstraints": "",
+		"CorrelationScheme": []interface{}{
+			map[string]interface{}{"Scheme": "Affinity", "ServiceName": "fabric:/TestApplication/TestService2"},
+		},
+		"DefaultMoveCost": "Low",
+	}
+	if !reflect.DeepEqual(body, expected) {
+		t.Errorf("Got %+v, want %+v", body, expected)
+	}
+
+	target, min := 5, 3
+	err = sfClient.UpdateService(context.Background(), "TestApplication~TestService", ServiceUpdateDescription{
+		ServiceKind:          ServiceKindStateful,
+		TargetReplicaSetSize: &target,
+		MinReplicaSetSize:    &min,
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if body["Flags"] != "17" {
+		t.Errorf("Got %+v, want %+v", body["Flags"], "17")
+	}
+
+	invalid := []ServiceUpdateDescription{
+		{},
+		{ServiceKind: ServiceKindStateless, TargetReplicaSetSize: &target},
+		{ServiceKind: ServiceKindStateful, TargetReplicaSetSize: &min, MinReplicaSetSize: &target},
+		{ServiceKind: ServiceKindStateless, DefaultMoveCost: "Free"},
+	}
+	for _, update := range invalid {
+		if err := sfClient.UpdateService(context.Background(), "TestApplication~TestService", update); err == nil {
+			t.Errorf("Error should have been returned for %+v", update)
+		}
+	}
+}
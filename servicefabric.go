@@ -1,52 +1,171 @@
 package servicefabric
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"reflect"
 	"strings"
+	"time"
 
-	"github.com/ido50/requests"
 	"github.com/pkg/errors"
 )
 
 // DefaultAPIVersion is a default Service Fabric REST API version
 const DefaultAPIVersion = "6.0"
 
+// fabricScheme prefixes every Service Fabric name
+const fabricScheme = "fabric:/"
+
+// serviceIDFromName returns the id of the service named name, such as
+// App~Service for fabric:/App/Service
+func serviceIDFromName(name string) string {
+	return strings.Replace(strings.TrimPrefix(name, fabricScheme), "/", "~", -1)
+}
+
 var ErrResourceNotFound = errors.New("service fabric resourcenot found")
 var ErrResourceNotExists = errors.New("service fabric resource does not exist")
 
+// ErrParentNotFound is returned by listing calls whose parent entity, such as
+// the application of GetServices, does not exist, as opposed to an empty list
+var ErrParentNotFound = errors.New("service fabric parent resource not found")
+
+// ErrReadOnlyClient is returned by every mutating call on a client created WithReadOnly
+var ErrReadOnlyClient = errors.New("service fabric client is read-only")
+
 // Client for Service Fabric.
 type ServiceFabricClient struct {
 	// endpoint Service Fabric cluster management endpoint
-	endpoint string
+	endpoint *url.URL
 	// apiVersion Service Fabric API version
 	apiVersion string
-	// httpClient HTTP client
-	httpClient *requests.HTTPClient
+	// httpClient sends the requests
+	httpClient Doer
+	// managedCluster whether the cluster is a Service Fabric managed cluster
+	managedCluster bool
+	// readOnly whether mutating calls are refused
+	readOnly bool
+	// policy client side allow and deny lists for mutating calls
+	policy *OperationPolicy
+	// auditSink receives every mutating call made on behalf of auditCaller
+	auditSink   AuditSink
+	auditCaller string
+	// connectivityTimeout bounds the connectivity check, disabled when zero
+	connectivityTimeout time.Duration
+	// callObserver is notified of every completed call
+	callObserver CallObserver
+	// slowCallThreshold marks calls taking longer as slow, disabled when zero
+	slowCallThreshold time.Duration
+	// maxConcurrency bounds the requests aggregate queries send at once
+	maxConcurrency int
+	// tokens supplies the bearer token of every request, if set
+	tokens *tokenCache
+	// refresher renews rejected credentials, if set
+	refresher CredentialRefresher
+	// retryPolicy resends requests failing with a transient error, if set
+	retryPolicy *RetryPolicy
+	// failoverEndpoints and endpointCooldown configure endpoints, which
+	// spreads requests over every endpoint when several are configured
+	failoverEndpoints []string
+	endpointCooldown  time.Duration
+	endpoints         *endpointPool
+	// sessionHeader carries the session id of requests made WithSession, if set
+	sessionHeader string
+	// redactor masks secrets in audit events and debug dumps
+	redactor *Redactor
+	// debugDump receives every request and response, if set
+	debugDump io.Writer
+	// typeHooks and customTypeHooks replace the decoding of values
+	// by the type they decode into and by property custom type id
+	typeHooks       map[reflect.Type]UnmarshalHook
+	customTypeHooks map[string]UnmarshalHook
+	// serviceTypes caches the service types of prefetched application type versions
+	serviceTypes *serviceTypeCache
+	// restartGuard bounds the code package restarts of every call, if set
+	restartGuard *restartGuard
 }
 
-func NewServiceFabricClient(httpClient *requests.HTTPClient, endpoint, apiVersion string) (*ServiceFabricClient, error) {
+// NewServiceFabricClient creates a client sending requests to endpoint
+// through httpClient, see NewClient to start from an *http.Client
+func NewServiceFabricClient(httpClient Doer, endpoint, apiVersion string, opts ...ClientOption) (*ServiceFabricClient, error) {
 	if endpoint == "" {
 		return nil, errors.New("endpoint missing for httpClient configuration")
 	}
+	endpointURL, err := parseEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
 	if apiVersion == "" {
 		apiVersion = DefaultAPIVersion
 	}
 
-	return &ServiceFabricClient{
-		endpoint:   endpoint,
-		apiVersion: apiVersion,
-		httpClient: httpClient,
-	}, nil
+	c := &ServiceFabricClient{
+		endpoint:     endpointURL,
+		apiVersion:   apiVersion,
+		httpClient:   httpClient,
+		redactor:     NewRedactor(DefaultRedactionPatterns...),
+		serviceTypes: newServiceTypeCache(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if len(c.failoverEndpoints) > 0 {
+		endpoints := []*url.URL{endpointURL}
+		for _, endpoint := range c.failoverEndpoints {
+			u, err := parseEndpoint(endpoint)
+			if err != nil {
+				return nil, err
+			}
+			endpoints = append(endpoints, u)
+		}
+		c.endpoints = newEndpointPool(endpoints, c.endpointCooldown)
+	}
+	if c.connectivityTimeout > 0 {
+		if err := c.checkConnectivity(c.connectivityTimeout); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Endpoint returns the normalized cluster management endpoint
+func (c ServiceFabricClient) Endpoint() string {
+	return c.endpoint.String()
+}
+
+func (c ServiceFabricClient) GetApplications(ctx context.Context) (page *ApplicationItemsPage, err error) {
+	ctx, call := c.startCall(ctx, "GetApplications")
+	defer func() { call.finish(err) }()
+
+	return c.getApplications(ctx, func(*ApplicationItem) bool { return true })
 }
 
-func (c ServiceFabricClient) GetApplications() (*ApplicationItemsPage, error) {
+// GetApplicationsWithPrefix returns the applications whose fabric name lies
+// under prefix, e.g. "fabric:/Team1" or "Team1/" both match fabric:/Team1/App
+// but not fabric:/Team10/App. The application query API cannot filter by
+// name, so pages are filtered client side as they arrive.
+func (c ServiceFabricClient) GetApplicationsWithPrefix(ctx context.Context, prefix string) (page *ApplicationItemsPage, err error) {
+	if strings.Trim(strings.TrimPrefix(prefix, fabricScheme), "/") == "" {
+		return c.GetApplications(ctx)
+	}
+
+	ctx, call := c.startCall(ctx, "GetApplicationsWithPrefix")
+	defer func() { call.finish(err) }()
+
+	return c.getApplications(ctx, func(app *ApplicationItem) bool {
+		return hasNamePrefix(app.Name, prefix)
+	})
+}
+
+func (c ServiceFabricClient) getApplications(ctx context.Context, include func(*ApplicationItem) bool, paramsFuncs ...queryParamsFunc) (*ApplicationItemsPage, error) {
 	var aggregateAppItemsPages ApplicationItemsPage
 	var continueToken string
 	for {
-		res, _, err := c.getHTTP("Applications/", withContinue(continueToken))
+		res, _, err := c.getHTTP(ctx, "Applications/", append(paramsFuncs, withContinue(continueToken))...)
 		if err != nil {
 			return nil, err
 		}
@@ -57,7 +176,11 @@ func (c ServiceFabricClient) GetApplications() (*ApplicationItemsPage, error) {
 			return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
 		}
 
-		aggregateAppItemsPages.Items = append(aggregateAppItemsPages.Items, appItemsPage.Items...)
+		for _, app := range appItemsPage.Items {
+			if include(&app) {
+				aggregateAppItemsPages.Items = append(aggregateAppItemsPages.Items, app)
+			}
+		}
 
 		continueToken = getString(appItemsPage.ContinuationToken)
 		if continueToken == "" {
@@ -67,10 +190,11 @@ func (c ServiceFabricClient) GetApplications() (*ApplicationItemsPage, error) {
 	return &aggregateAppItemsPages, nil
 }
 
-func (c ServiceFabricClient) GetApplication(appName string) (*ApplicationItem, error) {
-	var app *ApplicationItem
+func (c ServiceFabricClient) GetApplication(ctx context.Context, appName string) (app *ApplicationItem, err error) {
+	ctx, call := c.startCall(ctx, "GetApplication")
+	defer func() { call.finish(err) }()
 
-	res, status, err := c.getHTTP("Applications/"+appName, withParam("api-version", c.apiVersion))
+	res, status, err := c.getHTTP(ctx, "Applications/"+appName, withParam("api-version", c.apiVersion))
 
 	if status == http.StatusNoContent {
 		return nil, ErrResourceNotExists
@@ -84,10 +208,11 @@ func (c ServiceFabricClient) GetApplication(appName string) (*ApplicationItem, e
 	return app, err
 }
 
-func (c ServiceFabricClient) GetDeployment(deploymentName string) (interface{}, error) {
-	var deployment interface{}
+func (c ServiceFabricClient) GetDeployment(ctx context.Context, deploymentName string) (deployment interface{}, err error) {
+	ctx, call := c.startCall(ctx, "GetDeployment")
+	defer func() { call.finish(err) }()
 
-	res, status, err := c.getHTTP("ComposeDeployments/"+deploymentName, withParam("api-version", c.apiVersion))
+	res, status, err := c.getHTTP(ctx, "ComposeDeployments/"+deploymentName, withParam("api-version", c.apiVersion))
 
 	if status == http.StatusNoContent {
 		return nil, ErrResourceNotExists
@@ -101,11 +226,21 @@ func (c ServiceFabricClient) GetDeployment(deploymentName string) (interface{},
 	return deployment, err
 }
 
-func (c ServiceFabricClient) GetServices(appName string) (*ServiceItemsPage, error) {
+func (c ServiceFabricClient) GetServices(ctx context.Context, appName string) (page *ServiceItemsPage, err error) {
+	ctx, call := c.startCall(ctx, "GetServices")
+	defer func() { call.finish(err) }()
+
+	return c.getServices(ctx, appName)
+}
+
+func (c ServiceFabricClient) getServices(ctx context.Context, appName string) (*ServiceItemsPage, error) {
 	var aggregateServiceItemsPages ServiceItemsPage
 	var continueToken string
 	for {
-		res, _, err := c.getHTTP("Applications/"+appName+"/$/GetServices", withContinue(continueToken))
+		res, status, err := c.getHTTP(ctx, "Applications/"+appName+"/$/GetServices", withContinue(continueToken))
+		if status == http.StatusNotFound {
+			return nil, errors.Wrapf(ErrParentNotFound, "application %s", appName)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -126,17 +261,167 @@ func (c ServiceFabricClient) GetServices(appName string) (*ServiceItemsPage, err
 	return &aggregateServiceItemsPages, nil
 }
 
-func (c ServiceFabricClient) GetClusterHealth() (bool, error) {
-	res, err := c.getHTTPRaw("$/GetClusterHealth?api-version=6.0&")
+// GetPartitions returns the partitions of a service, aggregating every page
+func (c ServiceFabricClient) GetPartitions(ctx context.Context, serviceID string) (page *PartitionItemsPage, err error) {
+	ctx, call := c.startCall(ctx, "GetPartitions")
+	defer func() { call.finish(err) }()
+
+	return c.getPartitions(ctx, serviceID)
+}
+
+func (c ServiceFabricClient) getPartitions(ctx context.Context, serviceID string) (*PartitionItemsPage, error) {
+	var aggregatePartitionItemsPages PartitionItemsPage
+	var continueToken string
+	for {
+		res, status, err := c.getHTTP(ctx, "Services/"+serviceID+"/$/GetPartitions", withContinue(continueToken))
+		if status == http.StatusNotFound {
+			return nil, errors.Wrapf(ErrParentNotFound, "service %s", serviceID)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var partitionItemsPage PartitionItemsPage
+		err = json.Unmarshal(res, &partitionItemsPage)
+		if err != nil {
+			return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+		}
+
+		aggregatePartitionItemsPages.Items = append(aggregatePartitionItemsPages.Items, partitionItemsPage.Items...)
+
+		continueToken = getString(partitionItemsPage.ContinuationToken)
+		if continueToken == "" {
+			break
+		}
+	}
+	return &aggregatePartitionItemsPages, nil
+}
+
+// GetReplicas returns the replicas of a partition of a stateful service,
+// aggregating every page. appName and serviceID only qualify errors.
+func (c ServiceFabricClient) GetReplicas(ctx context.Context, appName, serviceID, partitionID string) (page *ReplicaItemsPage, err error) {
+	ctx, call := c.startCall(ctx, "GetReplicas")
+	defer func() { call.finish(err) }()
+
+	var aggregateReplicaItemsPages ReplicaItemsPage
+	err = c.getPartitionReplicas(ctx, appName, serviceID, partitionID, func(res []byte) (*string, error) {
+		var replicaItemsPage ReplicaItemsPage
+		if err := json.Unmarshal(res, &replicaItemsPage); err != nil {
+			return nil, err
+		}
+		aggregateReplicaItemsPages.Items = append(aggregateReplicaItemsPages.Items, replicaItemsPage.Items...)
+		return replicaItemsPage.ContinuationToken, nil
+	})
 	if err != nil {
-		return false, fmt.Errorf("error getting cluster health")
+		return nil, err
 	}
+	return &aggregateReplicaItemsPages, nil
+}
+
+// GetInstances returns the instances of a partition of a stateless service,
+// aggregating every page. appName and serviceID only qualify errors.
+func (c ServiceFabricClient) GetInstances(ctx context.Context, appName, serviceID, partitionID string) (page *InstanceItemsPage, err error) {
+	ctx, call := c.startCall(ctx, "GetInstances")
+	defer func() { call.finish(err) }()
 
-	return res == http.StatusOK, nil
+	var aggregateInstanceItemsPages InstanceItemsPage
+	err = c.getPartitionReplicas(ctx, appName, serviceID, partitionID, func(res []byte) (*string, error) {
+		var instanceItemsPage InstanceItemsPage
+		if err := json.Unmarshal(res, &instanceItemsPage); err != nil {
+			return nil, err
+		}
+		aggregateInstanceItemsPages.Items = append(aggregateInstanceItemsPages.Items, instanceItemsPage.Items...)
+		return instanceItemsPage.ContinuationToken, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &aggregateInstanceItemsPages, nil
 }
 
-func (c ServiceFabricClient) GetClusterManifest() (m ClusterManifest, err error) {
-	res, _, err := c.getHTTP("/$/GetClusterManifest",
+// getPartitionReplicas queries every page of the replicas or instances of a
+// partition, which decodePage decodes, returning the continuation token
+func (c ServiceFabricClient) getPartitionReplicas(ctx context.Context, appName, serviceID, partitionID string, decodePage func([]byte) (*string, error)) error {
+	var continueToken string
+	for {
+		res, status, err := c.getHTTP(ctx, "Partitions/"+partitionID+"/$/GetReplicas", withContinue(continueToken))
+		if status == http.StatusNotFound {
+			return errors.Wrapf(ErrParentNotFound, "partition %s of service %s of application %s", partitionID, serviceID, appName)
+		}
+		if err != nil {
+			return err
+		}
+
+		token, err := decodePage(res)
+		if err != nil {
+			return fmt.Errorf("could not deserialise JSON response: %+v", err)
+		}
+
+		continueToken = getString(token)
+		if continueToken == "" {
+			break
+		}
+	}
+	return nil
+}
+
+// GetServicesForAllApplications returns every application together with its
+// services, in the order GetApplications returns them. Services are queried
+// in parallel, see WithConcurrency, and the first failure aborts the call.
+func (c ServiceFabricClient) GetServicesForAllApplications(ctx context.Context) ([]ApplicationServices, error) {
+	apps, err := c.GetApplications(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ApplicationServices, len(apps.Items))
+	err = c.forEach(len(apps.Items), func(i int) error {
+		app := apps.Items[i]
+		services, err := c.GetServices(ctx, app.ID)
+		if err != nil {
+			return errors.Wrapf(err, "failed getting services of %s", app.Name)
+		}
+		result[i] = ApplicationServices{Application: app, Services: services.Items}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetClusterHealth reports whether the cluster answered its health query.
+//
+// Deprecated: use CheckClusterHealth, whose error carries the status code.
+func (c ServiceFabricClient) GetClusterHealth(ctx context.Context) (bool, error) {
+	if err := c.CheckClusterHealth(ctx); err != nil {
+		return false, errors.Wrap(err, "error getting cluster health")
+	}
+	return true, nil
+}
+
+// CheckClusterHealth queries the cluster health and returns nil when the
+// cluster answers with 200 OK, and a *StatusError otherwise when a
+// response was received
+func (c ServiceFabricClient) CheckClusterHealth(ctx context.Context) (err error) {
+	ctx, call := c.startCall(ctx, "CheckClusterHealth")
+	defer func() { call.finish(err) }()
+
+	status, err := c.getHTTPRaw(ctx, "$/GetClusterHealth")
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return &StatusError{Method: "GET", Path: "$/GetClusterHealth", StatusCode: status, Err: errors.New("unexpected status")}
+	}
+	return nil
+}
+
+func (c ServiceFabricClient) GetClusterManifest(ctx context.Context) (m ClusterManifest, err error) {
+	ctx, call := c.startCall(ctx, "GetClusterManifest")
+	defer func() { call.finish(err) }()
+
+	res, _, err := c.getHTTP(ctx, "/$/GetClusterManifest",
 		withParam("api-version", c.apiVersion), withParam("ConfigurationApiVersion", "1.0"))
 	if err != nil {
 		return m, fmt.Errorf("error getting cluster configuration: %s", err)
@@ -153,8 +438,37 @@ func (c ServiceFabricClient) GetClusterManifest() (m ClusterManifest, err error)
 	return m, err
 }
 
-func (c ServiceFabricClient) DeleteService(serviceId string) error {
-	_, _, err := c.postHTTP("Services/"+serviceId+"/$/Delete", []byte{}, withParam("api-version", c.apiVersion))
+// DeleteResult is the outcome of a delete the cluster did not fail
+type DeleteResult string
+
+// Delete results
+const (
+	// DeleteAccepted the cluster accepted to delete the entity
+	DeleteAccepted DeleteResult = "Accepted"
+	// DeleteNotFound the entity did not exist
+	DeleteNotFound DeleteResult = "NotFound"
+)
+
+// DeleteOptions configures DeleteServiceWithOptions and DeleteApplicationWithOptions
+type DeleteOptions struct {
+	// ForceRemove removes the entity without waiting for its replicas to
+	// close gracefully, for services stuck in deletion. Replicas that do
+	// not close are abandoned, losing the state they did not persist.
+	ForceRemove bool
+}
+
+func (o *DeleteOptions) params() []queryParamsFunc {
+	if o == nil || !o.ForceRemove {
+		return nil
+	}
+	return []queryParamsFunc{withParam("ForceRemove", "true")}
+}
+
+func (c ServiceFabricClient) DeleteService(ctx context.Context, serviceId string) (err error) {
+	ctx, call := c.startCall(ctx, "DeleteService")
+	defer func() { call.finish(err) }()
+
+	_, _, err = c.postHTTP(ctx, opDeleteService.on(serviceId), "Services/"+serviceId+"/$/Delete", []byte{}, withParam("api-version", c.apiVersion))
 	if err != nil {
 		return errors.Wrap(err, "failed deleting service")
 	}
@@ -162,8 +476,22 @@ func (c ServiceFabricClient) DeleteService(serviceId string) error {
 	return nil
 }
 
-func (c ServiceFabricClient) DeleteApplication(applicationId string) error {
-	_, status, err := c.postHTTP("Applications/"+applicationId+"/$/Delete", []byte{}, withParam("api-version", c.apiVersion))
+// DeleteServiceWithOptions deletes a service as configured by opts, which may
+// be nil. A service that does not exist is reported as DeleteNotFound rather
+// than as an error.
+func (c ServiceFabricClient) DeleteServiceWithOptions(ctx context.Context, serviceID string, opts *DeleteOptions) (result DeleteResult, err error) {
+	ctx, call := c.startCall(ctx, "DeleteService")
+	defer func() { call.finish(err) }()
+
+	_, status, err := c.postHTTP(ctx, opDeleteService.on(serviceID), "Services/"+serviceID+"/$/Delete", []byte{}, opts.params()...)
+	return deleteResult(status, err, "failed deleting service")
+}
+
+func (c ServiceFabricClient) DeleteApplication(ctx context.Context, applicationId string) (err error) {
+	ctx, call := c.startCall(ctx, "DeleteApplication")
+	defer func() { call.finish(err) }()
+
+	_, status, err := c.postHTTP(ctx, opDeleteApplication.on(applicationId), "Applications/"+applicationId+"/$/Delete", []byte{}, withParam("api-version", c.apiVersion))
 
 	if err != nil {
 		// handle unexpected status
@@ -181,8 +509,57 @@ func (c ServiceFabricClient) DeleteApplication(applicationId string) error {
 	return nil
 }
 
-func (c ServiceFabricClient) DeleteComposeDeployment(deploymentName string) error {
-	_, status, err := c.postHTTP("ComposeDeployments/"+deploymentName+"/$/Delete", []byte{}, withParam("api-version", c.apiVersion))
+// DeleteApplicationWithOptions deletes an application and its services as
+// configured by opts, which may be nil. An application that does not exist
+// is reported as DeleteNotFound rather than as an error.
+func (c ServiceFabricClient) DeleteApplicationWithOptions(ctx context.Context, applicationID string, opts *DeleteOptions) (result DeleteResult, err error) {
+	ctx, call := c.startCall(ctx, "DeleteApplication")
+	defer func() { call.finish(err) }()
+
+	_, status, err := c.postHTTP(ctx, opDeleteApplication.on(applicationID), "Applications/"+applicationID+"/$/Delete", []byte{}, opts.params()...)
+	return deleteResult(status, err, "failed deleting application")
+}
+
+// CreateApplication creates an application of a provisioned application
+// type version, overriding the default values of the parameters listed.
+// An application that already exists fails with a status error
+// classified as ErrorClassConflict.
+func (c ServiceFabricClient) CreateApplication(ctx context.Context, description ApplicationDescription) (err error) {
+	ctx, call := c.startCall(ctx, "CreateApplication")
+	defer func() { call.finish(err) }()
+
+	if err := description.validate(); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(description)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.postHTTP(ctx, opCreateApplication.on(description.Name), "Applications/$/Create", body)
+	if err != nil {
+		return errors.Wrap(err, "failed creating application")
+	}
+	return nil
+}
+
+// deleteResult maps the outcome of a delete request to a DeleteResult
+func deleteResult(status int, err error, message string) (DeleteResult, error) {
+	if err == nil || (status > 200 && status < 300) {
+		return DeleteAccepted, nil
+	}
+	if status == http.StatusNotFound {
+		return DeleteNotFound, nil
+	}
+	return "", errors.Wrap(err, message)
+}
+
+func (c ServiceFabricClient) DeleteComposeDeployment(ctx context.Context, deploymentName string) (err error) {
+	ctx, call := c.startCall(ctx, "DeleteComposeDeployment")
+	defer func() { call.finish(err) }()
+
+	_, status, err := c.postHTTP(ctx, opDeleteComposeDeployment.on(deploymentName), "ComposeDeployments/"+deploymentName+"/$/Delete", []byte{}, withParam("api-version", c.apiVersion))
 	if err != nil {
 		// handle unexpected status
 		if status > 200 && status < 300 {
@@ -198,37 +575,101 @@ func (c ServiceFabricClient) DeleteComposeDeployment(deploymentName string) erro
 	return nil
 
 }
-func (c ServiceFabricClient) GetServiceExtension(appType, applicationVersion, serviceTypeName, extensionKey string, response interface{}) error {
-	res, _, err := c.getHTTP("ApplicationTypes/"+appType+"/$/GetServiceTypes", withParam("ApplicationTypeVersion", applicationVersion))
+func (c ServiceFabricClient) GetServiceExtension(ctx context.Context, appType, applicationVersion, serviceTypeName, extensionKey string, response interface{}) error {
+	value, err := c.GetServiceExtensionRaw(ctx, appType, applicationVersion, serviceTypeName, extensionKey)
 	if err != nil {
-		return fmt.Errorf("error requesting service extensions: %v", err)
+		return err
+	}
+	if value == "" {
+		return nil
 	}
 
-	var serviceTypes []ServiceType
-	err = json.Unmarshal(res, &serviceTypes)
+	err = c.unmarshal([]byte(value), response, xml.Unmarshal)
 	if err != nil {
-		return fmt.Errorf("could not deserialise JSON response: %+v", err)
+		return fmt.Errorf("could not deserialise extension's XML value: %+v", err)
+	}
+	return nil
+}
+
+// GetServiceExtensionRaw returns the undecoded XML value of a service type extension.
+// An empty string is returned when the service type has no such extension.
+func (c ServiceFabricClient) GetServiceExtensionRaw(ctx context.Context, appType, applicationVersion, serviceTypeName, extensionKey string) (value string, err error) {
+	ctx, call := c.startCall(ctx, "GetServiceExtensionRaw")
+	defer func() { call.finish(err) }()
+
+	serviceTypes, err := c.getServiceTypes(ctx, appType, applicationVersion)
+	if err != nil {
+		return "", err
 	}
 
 	for _, serviceTypeInfo := range serviceTypes {
 		if serviceTypeInfo.ServiceTypeDescription.ServiceTypeName == serviceTypeName {
 			for _, extension := range serviceTypeInfo.ServiceTypeDescription.Extensions {
 				if strings.EqualFold(extension.Key, extensionKey) {
-					err = xml.Unmarshal([]byte(extension.Value), &response)
-					if err != nil {
-						return fmt.Errorf("could not deserialise extension's XML value: %+v", err)
-					}
-					return nil
+					return extension.Value, nil
 				}
 			}
 		}
 	}
-	return nil
+	return "", nil
+}
+
+// getServiceTypes returns the service types of an application type
+// version, from the cache when PrefetchExtensions fetched them and ctx
+// does not bypass it
+func (c ServiceFabricClient) getServiceTypes(ctx context.Context, appType, applicationVersion string) ([]ServiceType, error) {
+	if cacheEnabled(ctx) {
+		if serviceTypes, ok := c.serviceTypes.get(appType, applicationVersion); ok {
+			return serviceTypes, nil
+		}
+	}
+	serviceTypes, err := c.fetchServiceTypes(ctx, appType, applicationVersion)
+	if errors.Is(err, ErrParentNotFound) {
+		// unprovisioned by another client
+		c.serviceTypes.drop(appType, applicationVersion)
+	}
+	return serviceTypes, err
+}
+
+func (c ServiceFabricClient) fetchServiceTypes(ctx context.Context, appType, applicationVersion string) ([]ServiceType, error) {
+	res, status, err := c.getHTTP(ctx, "ApplicationTypes/"+appType+"/$/GetServiceTypes", withParam("ApplicationTypeVersion", applicationVersion))
+	if status == http.StatusNotFound {
+		return nil, errors.Wrapf(ErrParentNotFound, "application type %s %s", appType, applicationVersion)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error requesting service types: %v", err)
+	}
+
+	var serviceTypes []ServiceType
+	err = json.Unmarshal(res, &serviceTypes)
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return serviceTypes, nil
+}
+
+// GetServiceExtensionAsMap decodes a service type extension of unknown schema
+// into nested maps, see decodeXMLMap for the layout. A nil map is returned
+// when the service type has no such extension.
+func (c ServiceFabricClient) GetServiceExtensionAsMap(ctx context.Context, appType, applicationVersion, serviceTypeName, extensionKey string) (map[string]interface{}, error) {
+	value, err := c.GetServiceExtensionRaw(ctx, appType, applicationVersion, serviceTypeName, extensionKey)
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		return nil, nil
+	}
+
+	m, err := decodeXMLMap(strings.NewReader(value))
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialise extension's XML value: %+v", err)
+	}
+	return m, nil
 }
 
-func (c ServiceFabricClient) GetServiceExtensionMap(service *ServiceItem, app *ApplicationItem, extensionKey string) (map[string]string, error) {
+func (c ServiceFabricClient) GetServiceExtensionMap(ctx context.Context, service *ServiceItem, app *ApplicationItem, extensionKey string) (map[string]string, error) {
 	extensionData := ServiceExtensionLabels{}
-	err := c.GetServiceExtension(app.TypeName, app.TypeVersion, service.TypeName, extensionKey, &extensionData)
+	err := c.GetServiceExtension(ctx, app.TypeName, app.TypeVersion, service.TypeName, extensionKey, &extensionData)
 	if err != nil {
 		return nil, err
 	}
@@ -243,29 +684,81 @@ func (c ServiceFabricClient) GetServiceExtensionMap(service *ServiceItem, app *A
 	return labels, nil
 }
 
-func (c ServiceFabricClient) GetProperties(name string) (bool, map[string]string, error) {
-	nameExists, err := c.nameExists(name)
+// ServiceExtension is an extension of a service type
+type ServiceExtension struct {
+	Key string
+	// Raw is the undecoded XML value of the extension
+	Raw string
+	// Labels holds the labels of extensions whose value is a Labels
+	// document, and is nil for any other extension
+	Labels map[string]string
+}
+
+// GetServiceExtensions returns every extension of a service type by key,
+// fetching the service types of the application type version once.
+// An empty map is returned when the service type does not exist.
+func (c ServiceFabricClient) GetServiceExtensions(ctx context.Context, appType, applicationVersion, serviceTypeName string) (extensions map[string]ServiceExtension, err error) {
+	ctx, call := c.startCall(ctx, "GetServiceExtensions")
+	defer func() { call.finish(err) }()
+
+	serviceTypes, err := c.getServiceTypes(ctx, appType, applicationVersion)
 	if err != nil {
-		return false, nil, err
+		return nil, err
 	}
 
-	if !nameExists {
-		return false, nil, nil
+	extensions = map[string]ServiceExtension{}
+	for _, serviceTypeInfo := range serviceTypes {
+		if serviceTypeInfo.ServiceTypeDescription.ServiceTypeName != serviceTypeName {
+			continue
+		}
+		for _, extension := range serviceTypeInfo.ServiceTypeDescription.Extensions {
+			extensions[extension.Key] = ServiceExtension{
+				Key:    extension.Key,
+				Raw:    extension.Value,
+				Labels: c.extensionLabels(extension.Value),
+			}
+		}
 	}
+	return extensions, nil
+}
+
+// extensionLabels decodes value as a Labels document,
+// returning nil when it is not one
+func (c ServiceFabricClient) extensionLabels(value string) map[string]string {
+	var extensionData ServiceExtensionLabels
+	if value == "" || c.unmarshal([]byte(value), &extensionData, xml.Unmarshal) != nil {
+		return nil
+	}
+
+	labels := map[string]string{}
+	for _, label := range extensionData.Label {
+		labels[label.Key] = label.Value
+	}
+	return labels
+}
+
+// GetProperties returns the string properties stored under a Service Fabric
+// name, failing with ErrParentNotFound when the name does not exist
+func (c ServiceFabricClient) GetProperties(ctx context.Context, name string) (properties map[string]string, err error) {
+	ctx, call := c.startCall(ctx, "GetProperties")
+	defer func() { call.finish(err) }()
 
-	properties := make(map[string]string)
+	properties = make(map[string]string)
 
 	var continueToken string
 	for {
-		res, _, err := c.getHTTP("Names/"+name+"/$/GetProperties", withContinue(continueToken), withParam("IncludeValues", "true"))
+		res, status, err := c.getHTTP(ctx, "Names/"+name+"/$/GetProperties", withContinue(continueToken), withParam("IncludeValues", "true"))
+		if status == http.StatusNotFound {
+			return nil, errors.Wrapf(ErrParentNotFound, "name %s", name)
+		}
 		if err != nil {
-			return false, nil, err
+			return nil, err
 		}
 
 		var propertiesListPage PropertiesListPage
 		err = json.Unmarshal(res, &propertiesListPage)
 		if err != nil {
-			return false, nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+			return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
 		}
 
 		for _, property := range propertiesListPage.Properties {
@@ -281,96 +774,97 @@ func (c ServiceFabricClient) GetProperties(name string) (bool, map[string]string
 		}
 	}
 
-	return true, properties, nil
+	return properties, nil
 }
 
-func (c ServiceFabricClient) nameExists(propertyName string) (bool, error) {
-	res, err := c.getHTTPRaw("Names/" + propertyName)
-	// Get http will return error for any non 200 response code.
+func (c ServiceFabricClient) getHTTP(ctx context.Context, basePath string, paramsFuncs ...queryParamsFunc) ([]byte, int, error) {
+	res, status, err := c.doRetrying(ctx, "GET", c.getURL(basePath, paramsFuncs...), nil, true)
 	if err != nil {
-		return false, err
+		return nil, status, requestError("GET", basePath, status, res, err)
 	}
 
-	return res == http.StatusOK, nil
-}
-
-func (c ServiceFabricClient) getHTTP(basePath string, paramsFuncs ...queryParamsFunc) ([]byte, int, error) {
-	if c.httpClient == nil {
-		return nil, 0, errors.New("invalid http client provided")
+	callTrackerFromContext(ctx).request(basePath, len(res))
+	if len(res) == 0 {
+		// bodiless answers such as 204 No Content decode as null
+		return []byte("null"), status, nil
 	}
+	return res, status, nil
+}
 
-	var text interface{}
-	var status int
-	url := c.getURL(basePath, paramsFuncs...)
-	err := c.httpClient.
-		NewRequest("GET", url).
-		Into(&text).
-		StatusInto(&status).
-		Run()
-
+// queryHTTP issues a read that takes its query description as a POST body,
+// it is neither authorized against the operation policy nor audited
+func (c ServiceFabricClient) queryHTTP(ctx context.Context, basePath string, body []byte, paramsFuncs ...queryParamsFunc) ([]byte, int, error) {
+	res, status, err := c.doRetrying(ctx, "POST", c.getURL(basePath, paramsFuncs...), body, true)
 	if err != nil {
-		return nil, status, fmt.Errorf("failed connecting to Service Fabric server, status code %d: %s", status, err)
+		return nil, status, requestError("POST", basePath, status, res, err)
 	}
 
-	b, err := json.Marshal(text)
-	return b, status, err
-
+	callTrackerFromContext(ctx).request(basePath, len(res))
+	return res, status, nil
 }
 
-func (c ServiceFabricClient) getHTTPRaw(basePath string) (int, error) {
-	if c.httpClient == nil {
-		return -1, fmt.Errorf("invalid http client provided")
-	}
-
-	url := c.getURL(basePath)
-
-	var text string
-	var status int
-	err := c.httpClient.NewRequest("GET", url).Into(&text).
-		StatusInto(&status).
-		Run()
+func (c ServiceFabricClient) getHTTPRaw(ctx context.Context, basePath string) (int, error) {
+	res, status, err := c.doRetrying(ctx, "GET", c.getURL(basePath), nil, true)
 	if err != nil {
-		return -1, fmt.Errorf("failed to connect to Service Fabric server: %s", err)
+		return -1, requestError("GET", basePath, status, res, err)
 	}
+	callTrackerFromContext(ctx).request(basePath, len(res))
 	return status, nil
 }
 
+// getURL returns the escaped path and query of basePath, relative to the endpoint
 func (c ServiceFabricClient) getURL(basePath string, paramsFuncs ...queryParamsFunc) string {
+	u := url.URL{
+		Path:     "/" + strings.TrimPrefix(basePath, "/"),
+		RawQuery: strings.Join(c.getParams(paramsFuncs...), "&"),
+	}
+	return u.String()
+}
+
+func (c ServiceFabricClient) getParams(paramsFuncs ...queryParamsFunc) []string {
 	params := []string{"api-version=" + c.apiVersion}
 
 	for _, paramsFunc := range paramsFuncs {
 		params = paramsFunc(params)
 	}
+	return params
+}
 
-	return fmt.Sprintf("/%s?%s", basePath, strings.Join(params, "&"))
+// postHTTP issues the mutating request op, reads must go through getHTTP
+func (c ServiceFabricClient) postHTTP(ctx context.Context, op Operation, basePath string, body []byte, paramsFuncs ...queryParamsFunc) ([]byte, int, error) {
+	return c.sendHTTP(ctx, "POST", op, basePath, body, paramsFuncs...)
 }
 
-func (c ServiceFabricClient) postHTTP(basePath string, body []byte, paramsFuncs ...queryParamsFunc) ([]byte, int, error) {
+// sendHTTP issues the mutating request op with method, see postHTTP
+func (c ServiceFabricClient) sendHTTP(ctx context.Context, method string, op Operation, basePath string, body []byte, paramsFuncs ...queryParamsFunc) ([]byte, int, error) {
+	res, status, err := c.send(ctx, method, op, basePath, body, paramsFuncs...)
+	c.audit(op, c.getParams(paramsFuncs...), body, status, err)
+	return res, status, err
+}
+
+func (c ServiceFabricClient) send(ctx context.Context, method string, op Operation, basePath string, body []byte, paramsFuncs ...queryParamsFunc) ([]byte, int, error) {
 	if c.httpClient == nil {
 		return nil, 0, errors.New("invalid http client provided")
 	}
 
-	url := c.getURL(basePath, paramsFuncs...)
-	var responseBody interface{}
-	var status int
-	err := c.
-		httpClient.
-		NewRequest("POST", url).
-		Into(&responseBody).
-		StatusInto(&status).
-		Run()
-
-	if err != nil {
-		return nil, status, fmt.Errorf("failed connecting to Service Fabric server, status code %d: %s", status, err)
+	if err := c.authorize(op); err != nil {
+		return nil, 0, err
 	}
 
-	if responseBody != nil {
-		b, err := json.Marshal(responseBody)
-		return b, status, err
+	if c.managedCluster && blockedOnManagedCluster(basePath) {
+		return nil, 0, errors.Wrap(ErrNotSupportedOnManagedCluster, basePath)
 	}
 
-	return []byte{}, status, nil
+	res, status, err := c.doRetrying(ctx, method, c.getURL(basePath, paramsFuncs...), body, op.idempotent)
+	if err != nil {
+		return nil, status, requestError(method, basePath, status, res, err)
+	}
 
+	callTrackerFromContext(ctx).request(basePath, len(res))
+	if res == nil {
+		res = []byte{}
+	}
+	return res, status, nil
 }
 
 func getString(str *string) string {
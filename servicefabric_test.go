@@ -1,11 +1,19 @@
 package servicefabric
 
 import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
 	"encoding/xml"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
 func TestGetApplications(t *testing.T) {
@@ -44,7 +52,141 @@ func TestGetApplications(t *testing.T) {
 		},
 	}
 
-	actual, err := sfClient.GetApplications()
+	actual, err := sfClient.GetApplications(context.Background())
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+}
+
+func TestGetApplicationsHonoursContextDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := sfClient.GetApplications(ctx)
+	if err == nil {
+		t.Fatal("Error should have been returned")
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Got %v, want the call to abort at the deadline", elapsed)
+	}
+}
+
+func TestGetApplicationsWithPrefix(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleApplications))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	testCases := []struct {
+		desc     string
+		prefix   string
+		expected []string
+	}{
+		{
+			desc:     "Matches Whole Path Segments Only",
+			prefix:   "fabric:/TestApplication",
+			expected: []string{"fabric:/TestApplication"},
+		},
+		{
+			desc:     "Without Scheme And With Trailing Slash",
+			prefix:   "TestApplication2/",
+			expected: []string{"fabric:/TestApplication2"},
+		},
+		{
+			desc:     "Root",
+			prefix:   "fabric:/",
+			expected: []string{"fabric:/TestApplication", "fabric:/TestApplication2"},
+		},
+		{
+			desc:   "No Match",
+			prefix: "fabric:/Team1",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			actual, err := sfClient.GetApplicationsWithPrefix(context.Background(), test.prefix)
+			if err != nil {
+				t.Fatalf("Exception thrown %v", err)
+			}
+
+			var names []string
+			for _, app := range actual.Items {
+				names = append(names, app.Name)
+			}
+
+			if !reflect.DeepEqual(names, test.expected) {
+				t.Errorf("Got %v, want %v", names, test.expected)
+			}
+		})
+	}
+}
+
+func TestGetApplicationsByType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleApplicationsByType))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetApplicationsByType(context.Background(), "TestApplicationType")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if len(actual.Applications) != 1 || actual.Applications[0].Name != "fabric:/TestApplication" {
+		t.Errorf("Got %+v, want only fabric:/TestApplication", actual.Applications)
+	}
+
+	expected := map[string][]string{"1.0.0": {"fabric:/TestApplication"}}
+	if !reflect.DeepEqual(actual.Versions, expected) {
+		t.Errorf("Got %+v, want %+v", actual.Versions, expected)
+	}
+}
+
+func TestTypeUsageReport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleApplicationTypes))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	expected := []TypeVersionUsage{
+		{
+			TypeName:    "TestApplicationType",
+			TypeVersion: "0.9.0",
+			Status:      "Available",
+		},
+		{
+			TypeName:     "TestApplicationType",
+			TypeVersion:  "1.0.0",
+			Status:       "Available",
+			Applications: []string{"fabric:/TestApplication"},
+		},
+		{
+			TypeName:     "TestApplication2Type",
+			TypeVersion:  "1.0.0",
+			Status:       "Available",
+			Applications: []string{"fabric:/TestApplication2"},
+		},
+	}
+
+	actual, err := sfClient.TypeUsageReport(context.Background())
 	if err != nil {
 		t.Fatalf("Exception thrown %v", err)
 	}
@@ -52,6 +194,10 @@ func TestGetApplications(t *testing.T) {
 	if !reflect.DeepEqual(actual, expected) {
 		t.Errorf("Got %+v, want %+v", actual, expected)
 	}
+
+	if actual[0].InUse() || !actual[1].InUse() {
+		t.Error("Only the 1.0.0 type versions should be in use")
+	}
 }
 
 func TestGetServices(t *testing.T) {
@@ -77,7 +223,7 @@ func TestGetServices(t *testing.T) {
 		},
 	}
 
-	actual, err := sfClient.GetServices("TestApplication")
+	actual, err := sfClient.GetServices(context.Background(), "TestApplication")
 	if err != nil {
 		t.Fatalf("Exception thrown %v", err)
 	}
@@ -87,13 +233,131 @@ func TestGetServices(t *testing.T) {
 	}
 }
 
+func TestGetServicesWithArmMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleArmServices))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	expected := &ArmMetadata{
+		ArmResourceID: "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg/providers/Microsoft.ServiceFabric/managedclusters/cluster/applications/ArmApplication/services/ArmService",
+	}
+
+	actual, err := sfClient.GetServices(context.Background(), "ArmApplication")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if len(actual.Items) != 1 {
+		t.Fatalf("Got %d services, want 1", len(actual.Items))
+	}
+
+	if !reflect.DeepEqual(actual.Items[0].ArmMetadata, expected) {
+		t.Errorf("Got %+v, want %+v", actual.Items[0].ArmMetadata, expected)
+	}
+}
+
 func TestGetServicesWithNonExistentApplicationReturnsError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(http.NotFound))
 	defer server.Close()
 
 	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
 
-	actual, err := sfClient.GetServices("TestApplicationNonExistent")
+	actual, err := sfClient.GetServices(context.Background(), "TestApplicationNonExistent")
+	if err == nil {
+		t.Fatal("Error should have been returned")
+	}
+
+	if actual != nil {
+		t.Errorf("Got %+v, want nil", actual)
+	}
+}
+
+func TestGetServicesWithNonExistentApplicationReturnsParentNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(http.NotFound))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	_, err := sfClient.GetServices(context.Background(), "TestApplicationNonExistent")
+	if errors.Cause(err) != ErrParentNotFound {
+		t.Errorf("Got %v, want %v", err, ErrParentNotFound)
+	}
+}
+
+func TestGetProperties(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleProperties))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	expected := map[string]string{"traefik.enable": "true"}
+
+	actual, err := sfClient.GetProperties(context.Background(), "TestApplication/TestService")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+}
+
+func TestGetPropertiesWithNonExistentNameReturnsParentNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleProperties))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetProperties(context.Background(), "TestApplication/NonExistent")
+	if errors.Cause(err) != ErrParentNotFound {
+		t.Errorf("Got %v, want %v", err, ErrParentNotFound)
+	}
+
+	if actual != nil {
+		t.Errorf("Got %+v, want nil", actual)
+	}
+}
+
+func TestGetServicesForAllApplications(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Applications/", handleApplications)
+	mux.HandleFunc("/Applications/TestApplication/$/GetServices", handleServices)
+	mux.HandleFunc("/Applications/TestApplication2/$/GetServices", func(w http.ResponseWriter, r *http.Request) {
+		writeFixture(w, "services_empty.json")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetServicesForAllApplications(context.Background())
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if len(actual) != 2 {
+		t.Fatalf("Got %d applications, want 2", len(actual))
+	}
+	if actual[0].Application.ID != "TestApplication" || len(actual[0].Services) != 1 ||
+		actual[0].Services[0].ID != "TestApplication/TestService" {
+		t.Errorf("Got %+v, want TestApplication with TestService", actual[0])
+	}
+	if actual[1].Application.ID != "TestApplication2" || len(actual[1].Services) != 0 {
+		t.Errorf("Got %+v, want TestApplication2 without services", actual[1])
+	}
+}
+
+func TestGetServicesForAllApplicationsReturnsError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Applications/", handleApplications)
+	mux.HandleFunc("/Applications/TestApplication/$/GetServices", handleServices)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetServicesForAllApplications(context.Background())
 	if err == nil {
 		t.Fatal("Error should have been returned")
 	}
@@ -140,7 +404,7 @@ func TestGetPartitions(t *testing.T) {
 		},
 	}
 
-	actual, err := sfClient.GetPartitions("TestApplication", "TestApplication/TestService")
+	actual, err := sfClient.GetPartitions(context.Background(), "TestApplication/TestService")
 	if err != nil {
 		t.Fatalf("Exception thrown %v", err)
 	}
@@ -157,19 +421,16 @@ func TestGetPartitionsReturnsError(t *testing.T) {
 	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
 
 	testCases := []struct {
-		desc        string
-		appName     string
-		serviceName string
+		desc      string
+		serviceID string
 	}{
 		{
-			desc:        "With Non Existent Application",
-			appName:     "TestApplicationNoneExistent",
-			serviceName: "TestApplication/TestService",
+			desc:      "With Non Existent Application",
+			serviceID: "TestApplicationNoneExistent/TestService",
 		},
 		{
-			desc:        "With Non Existent Service",
-			appName:     "TestApplicationNoneExistent",
-			serviceName: "TestApplication/TestServiceNonExistent",
+			desc:      "With Non Existent Service",
+			serviceID: "TestApplication/TestServiceNonExistent",
 		},
 	}
 
@@ -178,7 +439,7 @@ func TestGetPartitionsReturnsError(t *testing.T) {
 		t.Run(test.desc, func(t *testing.T) {
 			t.Parallel()
 
-			actual, err := sfClient.GetPartitions(test.appName, test.serviceName)
+			actual, err := sfClient.GetPartitions(context.Background(), test.serviceID)
 			if err == nil {
 				t.Fatal("Error should have been returned")
 			}
@@ -214,7 +475,7 @@ func TestGetReplicas(t *testing.T) {
 		},
 	}
 
-	actual, err := sfClient.GetReplicas("TestApplication", "TestApplication/TestService", "bce46a8c-b62d-4996-89dc-7ffc00a96902")
+	actual, err := sfClient.GetReplicas(context.Background(), "TestApplication", "TestApplication/TestService", "bce46a8c-b62d-4996-89dc-7ffc00a96902")
 	if err != nil {
 		t.Fatalf("Exception thrown %v", err)
 	}
@@ -261,7 +522,7 @@ func TestGetReplicasReturnsError(t *testing.T) {
 		t.Run(test.desc, func(t *testing.T) {
 			t.Parallel()
 
-			actual, err := sfClient.GetReplicas(test.appName, test.serviceName, test.partitionName)
+			actual, err := sfClient.GetReplicas(context.Background(), test.appName, test.serviceName, test.partitionName)
 			if err == nil {
 				t.Fatal("Error should have been returned")
 			}
@@ -296,7 +557,7 @@ func TestGetInstances(t *testing.T) {
 		},
 	}
 
-	actual, err := sfClient.GetInstances("TestApplication", "TestApplication/TestService", "824091ba-fa32-4e9c-9e9c-71738e018312")
+	actual, err := sfClient.GetInstances(context.Background(), "TestApplication", "TestApplication/TestService", "824091ba-fa32-4e9c-9e9c-71738e018312")
 	if err != nil {
 		t.Fatalf("Exception thrown %v", err)
 	}
@@ -343,7 +604,7 @@ func TestGetInstancesReturnsError(t *testing.T) {
 		t.Run(test.desc, func(t *testing.T) {
 			t.Parallel()
 
-			actual, err := sfClient.GetInstances(test.appName, test.serviceName, test.partitionName)
+			actual, err := sfClient.GetInstances(context.Background(), test.appName, test.serviceName, test.partitionName)
 			if err == nil {
 				t.Fatal("Error should have been returned")
 			}
@@ -373,7 +634,7 @@ func TestGetServiceExtension(t *testing.T) {
 		TypeName:          "Test",
 	}
 	var extension ServiceExtensionLabels
-	err := sfClient.GetServiceExtension("TestApplication", "1.0.0", "Test", service.TypeName, &extension)
+	err := sfClient.GetServiceExtension(context.Background(), "TestApplication", "1.0.0", "Test", service.TypeName, &extension)
 
 	if err != nil {
 		t.Fatalf("Exception thrown %v", err)
@@ -417,7 +678,7 @@ func TestGetServiceExtensionMap(t *testing.T) {
 		TypeVersion: "1.0.0",
 	}
 
-	res, err := sfClient.GetServiceExtensionMap(service, app, "Test")
+	res, err := sfClient.GetServiceExtensionMap(context.Background(), service, app, "Test")
 
 	if err != nil {
 		t.Fatalf("Exception thrown %v", err)
@@ -434,6 +695,37 @@ func TestGetServiceExtensionMap(t *testing.T) {
 	}
 }
 
+func TestGetServiceExtensions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleExtensionA))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	extensions, err := sfClient.GetServiceExtensions(context.Background(), "TestApplication", "1.0.0", "Test")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	extension, exists := extensions["Test"]
+	if !exists {
+		t.Fatalf("Got %+v, want the Test extension", extensions)
+	}
+	if !strings.HasPrefix(extension.Raw, "<Labels") {
+		t.Errorf("Got %q, want the raw Labels document", extension.Raw)
+	}
+	if !reflect.DeepEqual(extension.Labels, map[string]string{"key1": "value1"}) {
+		t.Errorf("Got %+v, want %+v", extension.Labels, map[string]string{"key1": "value1"})
+	}
+
+	extensions, err = sfClient.GetServiceExtensions(context.Background(), "TestApplication", "1.0.0", "Other")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if len(extensions) != 0 {
+		t.Errorf("Got %+v, want no extensions", extensions)
+	}
+}
+
 func TestGetServiceExtensionNoMatchingServiceTypeName(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(handleExtensionA))
 	defer server.Close()
@@ -453,7 +745,7 @@ func TestGetServiceExtensionNoMatchingServiceTypeName(t *testing.T) {
 	}
 
 	var extension, initial ResponseType
-	err := sfClient.GetServiceExtension("TestApplication", "1.0.0", "MissingKey", service.TypeName, &extension)
+	err := sfClient.GetServiceExtension(context.Background(), "TestApplication", "1.0.0", "MissingKey", service.TypeName, &extension)
 	if err != nil {
 		t.Fatalf("Should not have thrown: %v", err)
 	}
@@ -481,7 +773,7 @@ func TestGetServiceExtensionNoMatchingExtensions(t *testing.T) {
 	}
 
 	var extension, initial ResponseType
-	err := sfClient.GetServiceExtension("TestApplication", "1.0.1", "Test", service.TypeName, &extension)
+	err := sfClient.GetServiceExtension(context.Background(), "TestApplication", "1.0.1", "Test", service.TypeName, &extension)
 	if err != nil {
 		t.Fatalf("Exception thrown %v", err)
 	}
@@ -510,7 +802,7 @@ func TestGetServiceExtensionWrongType(t *testing.T) {
 	}
 
 	var extension, initial WrongType
-	err := sfClient.GetServiceExtension("TestApplication", "1.0.0", "Test", service.TypeName, &extension)
+	err := sfClient.GetServiceExtension(context.Background(), "TestApplication", "1.0.0", "Test", service.TypeName, &extension)
 	if err != nil {
 		t.Fatalf("Exception thrown %v", err)
 	}
@@ -537,12 +829,448 @@ func TestGetServiceExtensionWithNonExistentApplicationReturnsErrorName(t *testin
 		TypeName:          "Test",
 	}
 
-	err := sfClient.GetServiceExtension("TestApplicationNonExistent", "1.0.0", "Test", service.TypeName, &ResponseType{})
+	err := sfClient.GetServiceExtension(context.Background(), "TestApplicationNonExistent", "1.0.0", "Test", service.TypeName, &ResponseType{})
 	if err == nil {
 		t.Fatal("Error should have thrown")
 	}
 }
 
+func TestGetServiceExtensionRaw(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleExtensionA))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	expected := `<Labels xmlns="http://schemas.microsoft.com/2015/03/fabact-no-schema"><Label Key="key1">value1</Label></Labels>`
+
+	actual, err := sfClient.GetServiceExtensionRaw(context.Background(), "TestApplication", "1.0.0", "Test", "Test")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if actual != expected {
+		t.Errorf("Got %q, want %q", actual, expected)
+	}
+}
+
+func TestGetServiceExtensionAsMap(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleExtensionA))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	expected := map[string]interface{}{
+		"Labels": map[string]interface{}{
+			"@xmlns": "http://schemas.microsoft.com/2015/03/fabact-no-schema",
+			"Label": map[string]interface{}{
+				"@Key":  "key1",
+				"#text": "value1",
+			},
+		},
+	}
+
+	actual, err := sfClient.GetServiceExtensionAsMap(context.Background(), "TestApplication", "1.0.0", "Test", "Test")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+}
+
+func TestGetServiceExtensionAsMapNoMatchingExtensions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleExtensionB))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetServiceExtensionAsMap(context.Background(), "TestApplication", "1.0.1", "Test", "Test")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if actual != nil {
+		t.Errorf("Got %+v, want nil", actual)
+	}
+}
+
+func TestDecodeXMLMapRepeatedElements(t *testing.T) {
+	doc := `<Config Version="2"><Endpoint>a</Endpoint><Endpoint>b</Endpoint><Owner><Name>team</Name></Owner></Config>`
+
+	expected := map[string]interface{}{
+		"Config": map[string]interface{}{
+			"@Version": "2",
+			"Endpoint": []interface{}{"a", "b"},
+			"Owner": map[string]interface{}{
+				"Name": "team",
+			},
+		},
+	}
+
+	actual, err := decodeXMLMap(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+}
+
+func TestReadOnlyClientRefusesMutations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected request to %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+	WithReadOnly()(sfClient)
+
+	testCases := []struct {
+		desc string
+		call func() error
+	}{
+		{
+			desc: "DeleteService",
+			call: func() error { return sfClient.DeleteService(context.Background(), "TestApplication~TestService") },
+		},
+		{
+			desc: "DeleteApplication",
+			call: func() error { return sfClient.DeleteApplication(context.Background(), "TestApplication") },
+		},
+		{
+			desc: "DeleteComposeDeployment",
+			call: func() error { return sfClient.DeleteComposeDeployment(context.Background(), "TestDeployment") },
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			err := test.call()
+			if errors.Cause(err) != ErrReadOnlyClient {
+				t.Errorf("Got %v, want %v", err, ErrReadOnlyClient)
+			}
+		})
+	}
+}
+
+func TestOperationPolicyPermits(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		policy   OperationPolicy
+		op       Operation
+		expected bool
+	}{
+		{
+			desc:     "Empty Policy",
+			op:       opDeleteService,
+			expected: true,
+		},
+		{
+			desc:     "Allowed By Name",
+			policy:   OperationPolicy{Allow: []string{"deleteservice"}},
+			op:       opDeleteService,
+			expected: true,
+		},
+		{
+			desc:   "Not In Allow List",
+			policy: OperationPolicy{Allow: []string{"DeleteService"}},
+			op:     opDeleteApplication,
+		},
+		{
+			desc:   "Denied By Category",
+			policy: OperationPolicy{Deny: []string{"Delete"}},
+			op:     opDeleteApplication,
+		},
+		{
+			desc:   "Deny Wins Over Allow",
+			policy: OperationPolicy{Allow: []string{"*"}, Deny: []string{"DeleteApplication"}},
+			op:     opDeleteApplication,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			actual := test.policy.Permits(test.op)
+			if actual != test.expected {
+				t.Errorf("Got %v, want %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestOperationPolicyRefusesDeniedCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/Services/TestApplication~TestService/$/Delete" {
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+	WithOperationPolicy(OperationPolicy{Allow: []string{"DeleteService"}})(sfClient)
+
+	if err := sfClient.DeleteService(context.Background(), "TestApplication~TestService"); err != nil {
+		t.Errorf("Should not have thrown: %v", err)
+	}
+
+	err := sfClient.DeleteApplication(context.Background(), "TestApplication")
+	if errors.Cause(err) != ErrOperationNotPermitted {
+		t.Errorf("Got %v, want %v", err, ErrOperationNotPermitted)
+	}
+}
+
+func TestAuditSinkReceivesMutatingCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/Services/TestApplication~TestService/$/Delete" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var events []AuditEvent
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+	WithAuditSink(AuditSinkFunc(func(event AuditEvent) {
+		events = append(events, event)
+	}), "deploy-bot")(sfClient)
+	WithOperationPolicy(OperationPolicy{Deny: []string{"DeleteComposeDeployment"}})(sfClient)
+
+	if err := sfClient.DeleteService(context.Background(), "TestApplication~TestService"); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if err := sfClient.DeleteApplication(context.Background(), "TestApplication"); err != ErrResourceNotFound {
+		t.Fatalf("Got %v, want %v", err, ErrResourceNotFound)
+	}
+	if err := sfClient.DeleteComposeDeployment(context.Background(), "TestDeployment"); err == nil {
+		t.Fatal("Error should have been returned")
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("Got %d audit events, want 3", len(events))
+	}
+
+	testCases := []struct {
+		operation string
+		target    string
+		status    int
+		failed    bool
+	}{
+		{operation: "DeleteService", target: "TestApplication~TestService", status: http.StatusOK},
+		{operation: "DeleteApplication", target: "TestApplication", status: http.StatusNotFound, failed: true},
+		{operation: "DeleteComposeDeployment", target: "TestDeployment", failed: true},
+	}
+
+	for i, test := range testCases {
+		event := events[i]
+		if event.Operation.Name != test.operation || event.Operation.Target != test.target {
+			t.Errorf("Got %s on %s, want %s on %s", event.Operation.Name, event.Operation.Target, test.operation, test.target)
+		}
+		if event.Caller != "deploy-bot" {
+			t.Errorf("Got caller %q, want deploy-bot", event.Caller)
+		}
+		if event.StatusCode != test.status {
+			t.Errorf("Got status %d, want %d", event.StatusCode, test.status)
+		}
+		if (event.Err != nil) != test.failed {
+			t.Errorf("Got error %v, want failure %v", event.Err, test.failed)
+		}
+	}
+}
+
+func TestManagedClusterBlockedOperation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected request to %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+	WithManagedCluster()(sfClient)
+
+	err := sfClient.StartClusterUpgrade(context.Background(), ClusterUpgradeDescription{CodeVersion: "7.2.457.9590"})
+	if errors.Cause(err) != ErrNotSupportedOnManagedCluster {
+		t.Errorf("Got %v, want %v", err, ErrNotSupportedOnManagedCluster)
+	}
+}
+
+func TestCreateApplication(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/Applications/$/Create" {
+			http.NotFound(w, r)
+			return
+		}
+		if body != nil {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Exception thrown %v", err)
+		}
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	description := ApplicationDescription{
+		Name:          "fabric:/TestApplication",
+		TypeName:      "TestApplicationType",
+		TypeVersion:   "1.0.0",
+		ParameterList: []AppParameter{{Key: "InstanceCount", Value: "3"}},
+	}
+	if err := sfClient.CreateApplication(context.Background(), description); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"Name":        "fabric:/TestApplication",
+		"TypeName":    "TestApplicationType",
+		"TypeVersion": "1.0.0",
+		"ParameterList": []interface{}{
+			map[string]interface{}{"Key": "InstanceCount", "Value": "3"},
+		},
+	}
+	if !reflect.DeepEqual(body, expected) {
+		t.Errorf("Got %+v, want %+v", body, expected)
+	}
+
+	err := sfClient.CreateApplication(context.Background(), description)
+	if ClassifyError(err) != ErrorClassConflict {
+		t.Errorf("Got %v, want %v", ClassifyError(err), ErrorClassConflict)
+	}
+
+	description.ParameterList = append(description.ParameterList, AppParameter{Key: "InstanceCount", Value: "5"})
+	if err := sfClient.CreateApplication(context.Background(), description); err == nil {
+		t.Error("Error should have been returned")
+	}
+}
+
+func TestDeleteWithOptions(t *testing.T) {
+	var forced []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forced = append(forced, r.URL.Query().Get("ForceRemove"))
+		if versions := r.URL.Query()["api-version"]; len(versions) != 1 {
+			t.Errorf("Got api-version %v, want it once", versions)
+		}
+		switch r.URL.Path {
+		case "/Services/TestApplication~TestService/$/Delete":
+		case "/Applications/TestApplication/$/Delete":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	result, err := sfClient.DeleteServiceWithOptions(context.Background(), "TestApplication~TestService", &DeleteOptions{ForceRemove: true})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if result != DeleteAccepted {
+		t.Errorf("Got %+v, want %+v", result, DeleteAccepted)
+	}
+
+	result, err = sfClient.DeleteApplicationWithOptions(context.Background(), "TestApplication", nil)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if result != DeleteNotFound {
+		t.Errorf("Got %+v, want %+v", result, DeleteNotFound)
+	}
+
+	if _, err := sfClient.DeleteApplicationWithOptions(context.Background(), "TestApplication2", nil); err == nil {
+		t.Error("Error should have been returned")
+	}
+
+	expected := []string{"true", "", ""}
+	if !reflect.DeepEqual(forced, expected) {
+		t.Errorf("Got %+v, want %+v", forced, expected)
+	}
+}
+
+func TestManagedClusterForbiddenReturnsStatusError(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil, WithManagedCluster())
+
+	err := sfClient.DeleteService(context.Background(), "TestApplication~TestService")
+	if err == nil || errors.Cause(err) == ErrNotSupportedOnManagedCluster {
+		t.Errorf("Got %v, want a plain status error", err)
+	}
+	if class := ClassifyError(err); class != ErrorClassAuthFailure {
+		t.Errorf("Got %v, want %v", class, ErrorClassAuthFailure)
+	}
+
+	err = sfClient.UpdateClusterUpgrade(context.Background(), ClusterUpgradeUpdateDescription{UpgradeKind: "Rolling"})
+	if errors.Cause(err) != ErrNotSupportedOnManagedCluster {
+		t.Errorf("Got %v, want %v", err, ErrNotSupportedOnManagedCluster)
+	}
+	if requests != 1 {
+		t.Errorf("Got %d requests, want the reserved operation refused up front", requests)
+	}
+}
+
+func TestManagedClusterTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	sum := sha1.Sum(server.Certificate().Raw)
+	thumbprint := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	testCases := []struct {
+		desc       string
+		thumbprint string
+		valid      bool
+	}{
+		{
+			desc:       "Matching Thumbprint",
+			thumbprint: thumbprint,
+			valid:      true,
+		},
+		{
+			desc:       "Other Thumbprint",
+			thumbprint: "0000000000000000000000000000000000000000",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: ManagedClusterTLSConfig(test.thumbprint)}}
+
+			res, err := client.Get(server.URL)
+			if err == nil {
+				res.Body.Close()
+			}
+
+			if test.valid && err != nil {
+				t.Errorf("Should not have thrown: %v", err)
+			}
+			if !test.valid && err == nil {
+				t.Error("Error should have been returned")
+			}
+		})
+	}
+}
+
+func TestManagedClusterEndpoint(t *testing.T) {
+	expected := "https://cluster.westus.cloudapp.azure.com:19080"
+
+	actual := ManagedClusterEndpoint("cluster.westus.cloudapp.azure.com")
+	if actual != expected {
+		t.Errorf("Got %q, want %q", actual, expected)
+	}
+}
+
 type ResponseType struct {
 	XMLName xml.Name `xml:"Tests"`
 	Test    struct {
@@ -0,0 +1,67 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ServiceGroupMember describes a member service of a service group,
+// whose name is the group name followed by #member
+type ServiceGroupMember struct {
+	ServiceName     string `json:"ServiceName"`
+	ServiceTypeName string `json:"ServiceTypeName"`
+}
+
+// ServiceGroupDescription describes how a service group was created
+type ServiceGroupDescription struct {
+	ServiceKind          string               `json:"ServiceKind"`
+	ApplicationName      string               `json:"ApplicationName"`
+	ServiceName          string               `json:"ServiceName"`
+	ServiceTypeName      string               `json:"ServiceTypeName"`
+	HasPersistedState    bool                 `json:"HasPersistedState,omitempty"`
+	TargetReplicaSetSize int                  `json:"TargetReplicaSetSize,omitempty"`
+	MinReplicaSetSize    int                  `json:"MinReplicaSetSize,omitempty"`
+	InstanceCount        int                  `json:"InstanceCount,omitempty"`
+	Members              []ServiceGroupMember `json:"ServiceGroupMemberDescription"`
+}
+
+// GetServiceGroupMembers returns the members of a service group,
+// see ServiceItem.IsServiceGroup
+func (c ServiceFabricClient) GetServiceGroupMembers(ctx context.Context, appName, serviceName string) (members []ServiceGroupMember, err error) {
+	ctx, call := c.startCall(ctx, "GetServiceGroupMembers")
+	defer func() { call.finish(err) }()
+
+	res, _, err := c.getHTTP(ctx, "Applications/"+appName+"/$/GetServiceGroups/"+serviceName)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting service group members")
+	}
+
+	var group struct {
+		Members []ServiceGroupMember `json:"ServiceGroupMemberDescription"`
+	}
+	err = json.Unmarshal(res, &group)
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return group.Members, nil
+}
+
+// GetServiceGroupDescription returns the description of a service group
+func (c ServiceFabricClient) GetServiceGroupDescription(ctx context.Context, serviceID string) (description *ServiceGroupDescription, err error) {
+	ctx, call := c.startCall(ctx, "GetServiceGroupDescription")
+	defer func() { call.finish(err) }()
+
+	res, _, err := c.getHTTP(ctx, "Services/"+serviceID+"/$/GetServiceGroupDescription")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting service group description")
+	}
+
+	err = json.Unmarshal(res, &description)
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialise JSON response: %+v", err)
+	}
+	return description, nil
+}
@@ -0,0 +1,66 @@
+package servicefabric
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+var testServiceGroupMembers = []ServiceGroupMember{
+	{ServiceName: "fabric:/TestApplication/TestGroup#Frontend", ServiceTypeName: "FrontendType"},
+	{ServiceName: "fabric:/TestApplication/TestGroup#Backend", ServiceTypeName: "BackendType"},
+}
+
+func TestGetServiceGroupMembers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleServiceGroups))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.GetServiceGroupMembers(context.Background(), "TestApplication", "TestApplication~TestGroup")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if !reflect.DeepEqual(actual, testServiceGroupMembers) {
+		t.Errorf("Got %+v, want %+v", actual, testServiceGroupMembers)
+	}
+}
+
+func TestGetServiceGroupDescription(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleServiceGroups))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	expected := &ServiceGroupDescription{
+		ServiceKind:     "Stateless",
+		ApplicationName: "fabric:/TestApplication",
+		ServiceName:     "fabric:/TestApplication/TestGroup",
+		ServiceTypeName: "TestGroupType",
+		InstanceCount:   -1,
+		Members:         testServiceGroupMembers,
+	}
+
+	actual, err := sfClient.GetServiceGroupDescription(context.Background(), "TestApplication~TestGroup")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+}
+
+func TestGetServiceGroupMembersReturnsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleServiceGroups))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	if _, err := sfClient.GetServiceGroupMembers(context.Background(), "TestApplication", "TestApplication~TestService"); err == nil {
+		t.Error("Error should have been returned")
+	}
+}
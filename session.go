@@ -0,0 +1,70 @@
+package servicefabric
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+)
+
+// session pins the requests made with a context to one gateway
+type session struct {
+	id string
+
+	mu sync.Mutex
+	// endpoint is the index of the pinned endpoint, -1 until a request succeeded
+	endpoint int
+}
+
+type sessionKey struct{}
+
+// WithSession returns a copy of ctx whose requests form one logical
+// sequence, such as an upload session or an upgrade orchestration. When the
+// client has several endpoints, see WithFailoverEndpoints, every request of
+// the sequence is sent to the gateway that served its first one, and only
+// fails over to another gateway, which is then kept, when it is unreachable.
+// The session id is also sent in the header set WithSessionHeader, for load
+// balancers in front of the gateways.
+func WithSession(ctx context.Context) context.Context {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+	return context.WithValue(ctx, sessionKey{}, &session{id: hex.EncodeToString(id), endpoint: -1})
+}
+
+// SessionID returns the id of the session of ctx, or "" outside of one
+func SessionID(ctx context.Context) string {
+	if s := sessionFromContext(ctx); s != nil {
+		return s.id
+	}
+	return ""
+}
+
+func sessionFromContext(ctx context.Context) *session {
+	s, _ := ctx.Value(sessionKey{}).(*session)
+	return s
+}
+
+// pin moves the pinned endpoint, if any, to the front of order
+func (s *session) pin(order []int) []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.endpoint < 0 {
+		return order
+	}
+	pinned := []int{s.endpoint}
+	for _, i := range order {
+		if i != s.endpoint {
+			pinned = append(pinned, i)
+		}
+	}
+	return pinned
+}
+
+// succeeded pins the session to endpoint i
+func (s *session) succeeded(i int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.endpoint = i
+}
@@ -0,0 +1,117 @@
+package servicefabric
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CallObserver is notified once every client call has completed
+type CallObserver interface {
+	OnCallComplete(info CallInfo)
+}
+
+// CallObserverFunc adapts a function to the CallObserver interface
+type CallObserverFunc func(info CallInfo)
+
+// OnCallComplete calls f(info)
+func (f CallObserverFunc) OnCallComplete(info CallInfo) {
+	f(info)
+}
+
+// CallInfo describes the requests a client call made to the cluster.
+// Calls built on other calls, such as TypeUsageReport, are reported
+// through the calls they are built on.
+type CallInfo struct {
+	// Operation is the client method, e.g. "GetServices"
+	Operation string
+	// Endpoint is the REST path of the first request, e.g. "Applications/"
+	Endpoint string
+	// Duration covers every request and decoding step of the call
+	Duration time.Duration
+	// Bytes is the size of the response bodies received
+	Bytes int
+	// Pages counts the requests sent, one per page for paged queries
+	Pages int
+	// Retries counts the requests resent after a transient failure
+	Retries int
+	// Slow is set when Duration exceeds the WithSlowCallThreshold threshold
+	Slow bool
+	// Err is nil when the call succeeded
+	Err error
+}
+
+type callTrackerKey struct{}
+
+// callTracker accumulates the CallInfo of one call as its requests complete
+type callTracker struct {
+	mu       sync.Mutex
+	info     CallInfo
+	start    time.Time
+	observer CallObserver
+	slow     time.Duration
+}
+
+// startCall begins tracking operation when an observer is configured,
+// the returned tracker is nil otherwise
+func (c ServiceFabricClient) startCall(ctx context.Context, operation string) (context.Context, *callTracker) {
+	if c.callObserver == nil {
+		return ctx, nil
+	}
+
+	t := &callTracker{
+		info:     CallInfo{Operation: operation},
+		start:    time.Now(),
+		observer: c.callObserver,
+		slow:     c.slowCallThreshold,
+	}
+	return context.WithValue(ctx, callTrackerKey{}, t), t
+}
+
+func callTrackerFromContext(ctx context.Context) *callTracker {
+	t, _ := ctx.Value(callTrackerKey{}).(*callTracker)
+	return t
+}
+
+// request records one request sent to basePath and the bytes it returned
+func (t *callTracker) request(basePath string, bytes int) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.info.Pages == 0 {
+		t.info.Endpoint = basePath
+	}
+	t.info.Pages++
+	t.info.Bytes += bytes
+}
+
+// retry records a request resent after a transient failure
+func (t *callTracker) retry() {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.info.Retries++
+}
+
+// finish reports the call, err being the error the call returned
+func (t *callTracker) finish(err error) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	info := t.info
+	t.mu.Unlock()
+
+	info.Duration = time.Since(t.start)
+	info.Err = err
+	info.Slow = t.slow > 0 && info.Duration > t.slow
+
+	t.observer.OnCallComplete(info)
+}
@@ -0,0 +1,65 @@
+package servicefabric
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCallObserverReceivesCallInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(handleApplications))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	var calls []CallInfo
+	WithCallObserver(CallObserverFunc(func(info CallInfo) {
+		calls = append(calls, info)
+	}))(sfClient)
+	WithSlowCallThreshold(time.Nanosecond)(sfClient)
+
+	if _, err := sfClient.GetApplications(context.Background()); err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if len(calls) != 1 {
+		t.Fatalf("Got %d calls, want 1", len(calls))
+	}
+
+	info := calls[0]
+	if info.Operation != "GetApplications" || info.Endpoint != "Applications/" {
+		t.Errorf("Got %s %s, want GetApplications Applications/", info.Operation, info.Endpoint)
+	}
+	if info.Pages != 2 || info.Bytes == 0 || info.Duration == 0 {
+		t.Errorf("Got %+v, want two pages with a body and a duration", info)
+	}
+	if !info.Slow {
+		t.Error("Call should have been flagged slow")
+	}
+	if info.Err != nil {
+		t.Errorf("Should not have thrown: %v", info.Err)
+	}
+}
+
+func TestCallObserverReceivesCallError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(http.NotFound))
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	var calls []CallInfo
+	WithCallObserver(CallObserverFunc(func(info CallInfo) {
+		calls = append(calls, info)
+	}))(sfClient)
+
+	_, err := sfClient.GetServices(context.Background(), "TestApplicationNonExistent")
+	if err == nil {
+		t.Fatal("Error should have been returned")
+	}
+
+	if len(calls) != 1 || calls[0].Err != err || calls[0].Slow {
+		t.Errorf("Got %+v, want one call failing with %v", calls, err)
+	}
+}
@@ -0,0 +1,193 @@
+package servicefabric
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Doer sends HTTP requests. *http.Client implements it, and so can
+// wrappers adding proxies, retries or instrumentation to a transport.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Health pings of the HTTP/2 connections of the transports NewClient
+// builds with Go 1.24 or later
+const (
+	// DefaultHTTP2SendPingTimeout is how long a connection stays silent
+	// before it is pinged, so that connections a load balancer dropped
+	// while idle are found before the next call is sent on them
+	DefaultHTTP2SendPingTimeout = 30 * time.Second
+	// DefaultHTTP2PingTimeout is how long a ping waits for its answer
+	// before the connection is closed
+	DefaultHTTP2PingTimeout = 15 * time.Second
+)
+
+// NewClient creates a ServiceFabricClient sending requests through
+// httpClient, http.DefaultClient when nil. A non nil tlsConfig is applied
+// to a copy of the client transport, leaving httpClient untouched. The
+// copy also attempts HTTP/2 and, built with Go 1.24 or later, pings idle
+// HTTP/2 connections unless its HTTP2 field sets SendPingTimeout. Callers
+// passing their own transport without tlsConfig set ForceAttemptHTTP2 and
+// HTTP2 on it likewise, see http.HTTP2Config.
+func NewClient(httpClient *http.Client, endpoint, apiVersion string, tlsConfig *tls.Config, opts ...ClientOption) (*ServiceFabricClient, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	if tlsConfig != nil {
+		client := *httpClient
+		transport, err := transportWithTLS(client.Transport, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		client.Transport = transport
+		httpClient = &client
+	}
+	return NewServiceFabricClient(httpClient, endpoint, apiVersion, opts...)
+}
+
+// transportWithTLS returns a copy of rt, or of http.DefaultTransport
+// when nil, using tlsConfig
+func transportWithTLS(rt http.RoundTripper, tlsConfig *tls.Config) (http.RoundTripper, error) {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	transport, ok := rt.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("cannot apply tls config to transport %T, configure it on the transport instead", rt)
+	}
+	transport = transport.Clone()
+	transport.TLSClientConfig = tlsConfig
+	// a custom TLS config disables HTTP/2 unless attempted explicitly
+	transport.ForceAttemptHTTP2 = true
+	enableHTTP2Pings(transport)
+	return transport, nil
+}
+
+// do sends a request to the path and query of target, relative to the
+// endpoint, and returns the response body once the status is successful.
+// Unreachable endpoints fail over to the next one, see WithFailoverEndpoints.
+// Requests whose credentials are rejected are sent once more after the
+// credentials were refreshed, see CredentialRefresher.
+func (c ServiceFabricClient) do(ctx context.Context, method, target string, body []byte) ([]byte, int, error) {
+	var generation int
+	if c.tokens != nil {
+		generation = c.tokens.generation()
+	}
+
+	res, status, err := c.doOnce(ctx, method, target, body)
+	if (status == http.StatusUnauthorized || status == http.StatusForbidden) && c.canRefreshCredentials() {
+		if c.refreshCredentials(ctx, generation) == nil {
+			return c.doOnce(ctx, method, target, body)
+		}
+	}
+	return res, status, err
+}
+
+// doOnce sends a request as do does, without refreshing rejected credentials
+func (c ServiceFabricClient) doOnce(ctx context.Context, method, target string, body []byte) ([]byte, int, error) {
+	if c.httpClient == nil {
+		return nil, 0, errors.New("invalid http client provided")
+	}
+
+	var token string
+	if c.tokens != nil {
+		var err error
+		token, err = c.tokens.get(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if c.endpoints == nil {
+		res, err := c.roundTrip(ctx, method, c.endpoint, target, body, token)
+		if err != nil {
+			return nil, 0, err
+		}
+		return c.readResponse(res)
+	}
+
+	order := c.endpoints.order()
+	s := sessionFromContext(ctx)
+	if s != nil {
+		order = s.pin(order)
+	}
+	for n, i := range order {
+		res, err := c.roundTrip(ctx, method, c.endpoints.endpoints[i], target, body, token)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, 0, err
+			}
+			c.endpoints.failed(i, err)
+			if n == len(order)-1 || !canFailOver(method, err) {
+				return nil, 0, err
+			}
+			continue
+		}
+		c.endpoints.succeeded(i)
+		if s != nil {
+			s.succeeded(i)
+		}
+		return c.readResponse(res)
+	}
+	return nil, 0, errors.New("no endpoint configured")
+}
+
+// roundTrip sends a request to target, relative to endpoint
+func (c ServiceFabricClient) roundTrip(ctx context.Context, method string, endpoint *url.URL, target string, body []byte, token string) (*http.Response, error) {
+	var reader io.Reader
+	if len(body) > 0 {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, strings.TrimRight(endpoint.String(), "/")+target, reader)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, values := range headersFromContext(ctx) {
+		req.Header.Set(name, strings.Join(values, ", "))
+	}
+	if id := SessionID(ctx); id != "" && c.sessionHeader != "" {
+		req.Header.Set(c.sessionHeader, id)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	c.dumpRequest(req, body)
+	res, err := c.httpClient.Do(req)
+	if urlErr, ok := err.(*url.Error); ok {
+		// the error message quotes the URL, query parameters included
+		urlErr.URL = c.redactor.URL(urlErr.URL)
+	}
+	return res, err
+}
+
+// readResponse reads and closes the body of res, failing unsuccessful statuses
+func (c ServiceFabricClient) readResponse(res *http.Response) ([]byte, int, error) {
+	defer res.Body.Close()
+
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, res.StatusCode, err
+	}
+	c.dumpResponse(res, b)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return b, res.StatusCode, fmt.Errorf("server returned %s", res.Status)
+	}
+	return b, res.StatusCode, nil
+}
@@ -0,0 +1,22 @@
+//go:build go1.24
+
+package servicefabric
+
+import "net/http"
+
+// enableHTTP2Pings pings the idle HTTP/2 connections of transport,
+// unless health pings are configured already
+func enableHTTP2Pings(transport *http.Transport) {
+	var config http.HTTP2Config
+	if transport.HTTP2 != nil {
+		if transport.HTTP2.SendPingTimeout > 0 {
+			return
+		}
+		config = *transport.HTTP2
+	}
+	config.SendPingTimeout = DefaultHTTP2SendPingTimeout
+	if config.PingTimeout <= 0 {
+		config.PingTimeout = DefaultHTTP2PingTimeout
+	}
+	transport.HTTP2 = &config
+}
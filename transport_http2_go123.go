@@ -0,0 +1,9 @@
+//go:build !go1.24
+
+package servicefabric
+
+import "net/http"
+
+// enableHTTP2Pings leaves transport as it is, HTTP/2 settings
+// requiring Go 1.24
+func enableHTTP2Pings(transport *http.Transport) {}
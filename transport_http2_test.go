@@ -0,0 +1,37 @@
+//go:build go1.24
+
+package servicefabric
+
+import (
+	"crypto/tls"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewClientEnablesHTTP2Pings(t *testing.T) {
+	sfClient, err := NewClient(nil, "https://localhost:19080", "1.0", &tls.Config{})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	transport := sfClient.httpClient.(*http.Client).Transport.(*http.Transport)
+	if !transport.ForceAttemptHTTP2 {
+		t.Error("HTTP/2 should have been attempted")
+	}
+	expected := &http.HTTP2Config{SendPingTimeout: DefaultHTTP2SendPingTimeout, PingTimeout: DefaultHTTP2PingTimeout}
+	if !reflect.DeepEqual(transport.HTTP2, expected) {
+		t.Errorf("Got %+v, want %+v", transport.HTTP2, expected)
+	}
+
+	// settings of the caller are kept
+	custom := &http.HTTP2Config{SendPingTimeout: time.Minute, PingTimeout: time.Second}
+	sfClient, err = NewClient(&http.Client{Transport: &http.Transport{HTTP2: custom}}, "https://localhost:19080", "1.0", &tls.Config{})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+	if actual := sfClient.httpClient.(*http.Client).Transport.(*http.Transport).HTTP2; !reflect.DeepEqual(actual, custom) {
+		t.Errorf("Got %+v, want %+v", actual, custom)
+	}
+}
@@ -0,0 +1,82 @@
+package servicefabric
+
+import (
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type recordingDoer struct {
+	requests []*http.Request
+}
+
+func (d *recordingDoer) Do(req *http.Request) (*http.Response, error) {
+	d.requests = append(d.requests, req)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Body:       ioutil.NopCloser(strings.NewReader(`{"Items":[]}`)),
+	}, nil
+}
+
+func TestCustomDoer(t *testing.T) {
+	doer := &recordingDoer{}
+	sfClient, err := NewServiceFabricClient(doer, "http://localhost:19080/", "1.0")
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	_, err = sfClient.GetApplications(context.Background())
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if len(doer.requests) != 1 {
+		t.Fatalf("Got %d requests, want 1", len(doer.requests))
+	}
+
+	expected := "http://localhost:19080/Applications/?api-version=1.0"
+	if actual := doer.requests[0].URL.String(); actual != expected {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+	if actual := doer.requests[0].Header.Get("Accept"); actual != "application/json" {
+		t.Errorf("Got %+v, want %+v", actual, "application/json")
+	}
+}
+
+func TestNewClientAppliesTLSConfigToCopy(t *testing.T) {
+	httpClient := &http.Client{}
+	tlsConfig := &tls.Config{ServerName: "cluster"}
+
+	sfClient, err := NewClient(httpClient, "https://localhost:19080", "1.0", tlsConfig)
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	if httpClient.Transport != nil {
+		t.Error("The http client passed in should not have been modified")
+	}
+
+	transport := sfClient.httpClient.(*http.Client).Transport.(*http.Transport)
+	if transport.TLSClientConfig != tlsConfig {
+		t.Errorf("Got %+v, want %+v", transport.TLSClientConfig, tlsConfig)
+	}
+}
+
+func TestNewClientRejectsTLSConfigOnCustomTransport(t *testing.T) {
+	httpClient := &http.Client{Transport: roundTripperFunc(http.DefaultTransport.RoundTrip)}
+
+	_, err := NewClient(httpClient, "https://localhost:19080", "1.0", &tls.Config{})
+	if err == nil {
+		t.Fatal("Error should have been returned")
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
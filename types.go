@@ -18,6 +18,7 @@ type AppParameter struct {
 // ApplicationItem encapsulates the embedded model for
 // ApplicationItems within the ApplicationItemsPage model
 type ApplicationItem struct {
+	ArmMetadata *ArmMetadata    `json:"ArmMetadata,omitempty"`
 	HealthState string          `json:"HealthState"`
 	ID          string          `json:"Id"`
 	Name        string          `json:"Name"`
@@ -27,6 +28,51 @@ type ApplicationItem struct {
 	TypeVersion string          `json:"TypeVersion"`
 }
 
+// ApplicationTypeItemsPage encapsulates the paged response
+// model for ApplicationTypes in the Service Fabric API
+type ApplicationTypeItemsPage struct {
+	ContinuationToken *string               `json:"ContinuationToken"`
+	Items             []ApplicationTypeItem `json:"Items"`
+}
+
+// ApplicationTypeItem describes one provisioned
+// version of an application type
+type ApplicationTypeItem struct {
+	DefaultParameterList []*AppParameter `json:"DefaultParameterList"`
+	Name                 string          `json:"Name"`
+	Status               string          `json:"Status"`
+	StatusDetails        string          `json:"StatusDetails"`
+	Version              string          `json:"Version"`
+}
+
+// ApplicationsByType groups the applications of one application type
+type ApplicationsByType struct {
+	TypeName     string
+	Applications []ApplicationItem
+	// Versions maps each type version in use to the names of its applications
+	Versions map[string][]string
+}
+
+// ApplicationServices pairs an application with its services
+type ApplicationServices struct {
+	Application ApplicationItem
+	Services    []ServiceItem
+}
+
+// TypeVersionUsage reports which applications run
+// a provisioned application type version
+type TypeVersionUsage struct {
+	TypeName     string
+	TypeVersion  string
+	Status       string
+	Applications []string
+}
+
+// InUse reports whether any application runs the type version
+func (u TypeVersionUsage) InUse() bool {
+	return len(u.Applications) > 0
+}
+
 // ServiceItemsPage encapsulates the paged response
 // model for Services in the Service Fabric API
 type ServiceItemsPage struct {
@@ -37,15 +83,44 @@ type ServiceItemsPage struct {
 // ServiceItem encapsulates the embedded model for
 // ServiceItems within the ServiceItemsPage model
 type ServiceItem struct {
-	HasPersistedState bool   `json:"HasPersistedState"`
-	HealthState       string `json:"HealthState"`
-	ID                string `json:"Id"`
-	IsServiceGroup    bool   `json:"IsServiceGroup"`
-	ManifestVersion   string `json:"ManifestVersion"`
-	Name              string `json:"Name"`
-	ServiceKind       string `json:"ServiceKind"`
-	ServiceStatus     string `json:"ServiceStatus"`
-	TypeName          string `json:"TypeName"`
+	ArmMetadata       *ArmMetadata `json:"ArmMetadata,omitempty"`
+	HasPersistedState bool         `json:"HasPersistedState"`
+	HealthState       string       `json:"HealthState"`
+	ID                string       `json:"Id"`
+	IsServiceGroup    bool         `json:"IsServiceGroup"`
+	ManifestVersion   string       `json:"ManifestVersion"`
+	Name              string       `json:"Name"`
+	ServiceKind       string       `json:"ServiceKind"`
+	ServiceStatus     string       `json:"ServiceStatus"`
+	TypeName          string       `json:"TypeName"`
+}
+
+// ArmMetadata links an entity to the Azure Resource Manager resource
+// that manages it. It is only reported by managed clusters, or for
+// entities deployed through ARM, on recent API versions.
+type ArmMetadata struct {
+	ArmResourceID string `json:"ArmResourceId"`
+}
+
+// NodeItemsPage encapsulates the paged response
+// model for Nodes in the Service Fabric API
+type NodeItemsPage struct {
+	ContinuationToken *string    `json:"ContinuationToken"`
+	Items             []NodeItem `json:"Items"`
+}
+
+// NodeItem encapsulates the node model in the Service Fabric API
+type NodeItem struct {
+	Name            string `json:"Name"`
+	IPAddressOrFQDN string `json:"IpAddressOrFQDN"`
+	Type            string `json:"Type"`
+	CodeVersion     string `json:"CodeVersion"`
+	ConfigVersion   string `json:"ConfigVersion"`
+	NodeStatus      string `json:"NodeStatus"`
+	HealthState     string `json:"HealthState"`
+	IsSeedNode      bool   `json:"IsSeedNode"`
+	UpgradeDomain   string `json:"UpgradeDomain"`
+	FaultDomain     string `json:"FaultDomain"`
 }
 
 // PartitionItemsPage encapsulates the paged response
@@ -204,12 +279,31 @@ type ClusterManifestWrapper struct {
 }
 
 type ClusterManifest struct {
-	XMLName        xml.Name       `xml:"ClusterManifest"`
-	FabricSettings FabricSettings `xml:"FabricSettings"`
-	//NodeTypes
+	XMLName        xml.Name                  `xml:"ClusterManifest"`
+	FabricSettings FabricSettings            `xml:"FabricSettings"`
+	NodeTypes      []ClusterManifestNodeType `xml:"NodeTypes>NodeType"`
 	//Infrastructure
 }
 
+// ClusterManifestNodeType is a node type declared by the cluster manifest
+type ClusterManifestNodeType struct {
+	Name         string `xml:"Name,attr"`
+	Certificates struct {
+		// Items are the ClusterCertificate, ServerCertificate
+		// and ClientCertificate of the node type
+		Items []ClusterManifestCertificate `xml:",any"`
+	} `xml:"Certificates"`
+}
+
+// ClusterManifestCertificate declares a certificate of a node type
+type ClusterManifestCertificate struct {
+	XMLName                xml.Name
+	X509FindType           string `xml:"X509FindType,attr"`
+	X509FindValue          string `xml:"X509FindValue,attr"`
+	X509FindValueSecondary string `xml:"X509FindValueSecondary,attr"`
+	X509StoreName          string `xml:"X509StoreName,attr"`
+}
+
 type FabricSettings struct {
 	XMLName  xml.Name `xml:"FabricSettings"`
 	Sections []struct {
@@ -0,0 +1,191 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultUnprovisionPollInterval = 5 * time.Second
+	defaultUnprovisionTimeout      = 10 * time.Minute
+)
+
+// UnprovisionApplicationType removes a provisioned application type version.
+// When async is set the call returns once the cluster has accepted the
+// request and the version reports the Unprovisioning status until it is gone.
+func (c ServiceFabricClient) UnprovisionApplicationType(ctx context.Context, typeName, version string, async bool) (err error) {
+	ctx, call := c.startCall(ctx, "UnprovisionApplicationType")
+	defer func() { call.finish(err) }()
+
+	body, err := json.Marshal(struct {
+		ApplicationTypeVersion string `json:"ApplicationTypeVersion"`
+		Async                  bool   `json:"Async"`
+	}{version, async})
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.postHTTP(ctx, opUnprovisionApplicationType.on(typeName+"@"+version), "ApplicationTypes/"+typeName+"/$/Unprovision", body)
+	if err != nil {
+		return errors.Wrap(err, "failed unprovisioning application type")
+	}
+	c.serviceTypes.drop(typeName, version)
+	return nil
+}
+
+// UnprovisionOptions tunes UnprovisionUnusedTypeVersions
+type UnprovisionOptions struct {
+	// DryRun reports what would be unprovisioned without doing so
+	DryRun bool
+	// PollInterval between progress checks, defaults to 5 seconds
+	PollInterval time.Duration
+	// Timeout bounds the wait for each version, defaults to 10 minutes
+	Timeout time.Duration
+	// Progress, if set, is called with the status of a version
+	// every time it is polled, and with "Unprovisioned" once it is gone
+	Progress func(version, status string)
+}
+
+// UnprovisionResult reports what UnprovisionUnusedTypeVersions did,
+// or would have done in a dry run
+type UnprovisionResult struct {
+	TypeName string
+	// Kept lists the latest versions retained regardless of use
+	Kept []string
+	// InUse lists older versions skipped because applications still run them
+	InUse []string
+	// Unprovisioned lists the versions removed
+	Unprovisioned []string
+	DryRun        bool
+}
+
+// UnprovisionUnusedTypeVersions unprovisions the versions of an application
+// type that no application runs, keeping the latest keepLatestN versions.
+// Versions are ordered numerically by dot separated segments where possible.
+// Each version is unprovisioned asynchronously and polled until it is gone,
+// one at a time to keep the load on the image store predictable.
+func (c ServiceFabricClient) UnprovisionUnusedTypeVersions(ctx context.Context, typeName string, keepLatestN int, opts UnprovisionOptions) (*UnprovisionResult, error) {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultUnprovisionPollInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultUnprovisionTimeout
+	}
+
+	types, err := c.GetApplicationTypeVersions(ctx, typeName)
+	if err != nil {
+		return nil, err
+	}
+	byType, err := c.GetApplicationsByType(ctx, typeName)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]string, 0, len(types.Items))
+	for _, appType := range types.Items {
+		if appType.Name == typeName {
+			versions = append(versions, appType.Version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j]) > 0
+	})
+
+	result := &UnprovisionResult{TypeName: typeName, DryRun: opts.DryRun}
+	var candidates []string
+	for i, version := range versions {
+		switch {
+		case i < keepLatestN:
+			result.Kept = append(result.Kept, version)
+		case len(byType.Versions[version]) > 0:
+			result.InUse = append(result.InUse, version)
+		default:
+			candidates = append(candidates, version)
+		}
+	}
+
+	if opts.DryRun {
+		result.Unprovisioned = candidates
+		return result, nil
+	}
+
+	for _, version := range candidates {
+		err = c.UnprovisionApplicationType(ctx, typeName, version, true)
+		if err != nil {
+			return result, err
+		}
+
+		err = c.waitForUnprovision(ctx, typeName, version, opts)
+		if err != nil {
+			return result, err
+		}
+		result.Unprovisioned = append(result.Unprovisioned, version)
+	}
+	return result, nil
+}
+
+func (c ServiceFabricClient) waitForUnprovision(ctx context.Context, typeName, version string, opts UnprovisionOptions) error {
+	deadline := time.Now().Add(opts.Timeout)
+	for {
+		types, err := c.GetApplicationTypeVersions(ctx, typeName)
+		if err != nil {
+			return err
+		}
+
+		status := ""
+		for _, appType := range types.Items {
+			if appType.Name == typeName && appType.Version == version {
+				status = appType.Status
+			}
+		}
+
+		switch status {
+		case "":
+			if opts.Progress != nil {
+				opts.Progress(version, "Unprovisioned")
+			}
+			return nil
+		case "Failed":
+			return fmt.Errorf("unprovisioning %s version %s failed", typeName, version)
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(version, status)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for %s version %s to unprovision, last status %s", typeName, version, status)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(opts.PollInterval):
+		}
+	}
+}
+
+// compareVersions orders dot separated versions segment by segment,
+// numerically when both segments are numbers and lexically otherwise
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.ParseInt(as[i], 10, 64)
+		bn, bErr := strconv.ParseInt(bs[i], 10, 64)
+		switch {
+		case aErr == nil && bErr == nil && an != bn:
+			if an < bn {
+				return -1
+			}
+			return 1
+		case (aErr != nil || bErr != nil) && as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	return len(as) - len(bs)
+}
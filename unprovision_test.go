@@ -0,0 +1,153 @@
+package servicefabric
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// typeVersionServer simulates asynchronous unprovisioning of TestApplicationType
+type typeVersionServer struct {
+	sync.Mutex
+	statuses      map[string]string
+	unprovisioned []string
+}
+
+func (s *typeVersionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	switch r.URL.Path {
+	case "/Applications/":
+		handleApplicationsByType(w, r)
+	case "/ApplicationTypes/TestApplicationType":
+		page := ApplicationTypeItemsPage{}
+		for version, status := range s.statuses {
+			page.Items = append(page.Items, ApplicationTypeItem{Name: "TestApplicationType", Version: version, Status: status})
+			if status == "Unprovisioning" {
+				// gone by the next poll
+				delete(s.statuses, version)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	case "/ApplicationTypes/TestApplicationType/$/Unprovision":
+		var body struct {
+			ApplicationTypeVersion string
+			Async                  bool
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &body)
+		if !body.Async {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.statuses[body.ApplicationTypeVersion] = "Unprovisioning"
+		s.unprovisioned = append(s.unprovisioned, body.ApplicationTypeVersion)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTypeVersionServer() *typeVersionServer {
+	return &typeVersionServer{statuses: map[string]string{
+		"0.8.0":  "Available",
+		"0.9.0":  "Available",
+		"0.10.0": "Available",
+		"1.0.0":  "Available",
+		"1.1.0":  "Available",
+	}}
+}
+
+func TestUnprovisionUnusedTypeVersions(t *testing.T) {
+	handler := newTypeVersionServer()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	var progress []string
+	actual, err := sfClient.UnprovisionUnusedTypeVersions(context.Background(), "TestApplicationType", 1, UnprovisionOptions{
+		PollInterval: time.Millisecond,
+		Progress: func(version, status string) {
+			progress = append(progress, version+" "+status)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := &UnprovisionResult{
+		TypeName:      "TestApplicationType",
+		Kept:          []string{"1.1.0"},
+		InUse:         []string{"1.0.0"},
+		Unprovisioned: []string{"0.10.0", "0.9.0", "0.8.0"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+
+	if !reflect.DeepEqual(handler.unprovisioned, expected.Unprovisioned) {
+		t.Errorf("Got unprovision calls %v, want %v", handler.unprovisioned, expected.Unprovisioned)
+	}
+
+	expectedProgress := []string{
+		"0.10.0 Unprovisioning", "0.10.0 Unprovisioned",
+		"0.9.0 Unprovisioning", "0.9.0 Unprovisioned",
+		"0.8.0 Unprovisioning", "0.8.0 Unprovisioned",
+	}
+	if !reflect.DeepEqual(progress, expectedProgress) {
+		t.Errorf("Got progress %v, want %v", progress, expectedProgress)
+	}
+}
+
+func TestUnprovisionUnusedTypeVersionsDryRun(t *testing.T) {
+	handler := newTypeVersionServer()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	sfClient, _ := NewClient(http.DefaultClient, server.URL, "1.0", nil)
+
+	actual, err := sfClient.UnprovisionUnusedTypeVersions(context.Background(), "TestApplicationType", 2, UnprovisionOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Exception thrown %v", err)
+	}
+
+	expected := &UnprovisionResult{
+		TypeName:      "TestApplicationType",
+		Kept:          []string{"1.1.0", "1.0.0"},
+		Unprovisioned: []string{"0.10.0", "0.9.0", "0.8.0"},
+		DryRun:        true,
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, want %+v", actual, expected)
+	}
+
+	if len(handler.unprovisioned) != 0 {
+		t.Errorf("Dry run unprovisioned %v", handler.unprovisioned)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.10.0", "1.9.0", 1},
+		{"1.0", "1.0.1", -1},
+		{"1.0.0-beta", "1.0.0-alpha", 1},
+	}
+
+	for _, test := range testCases {
+		actual := compareVersions(test.a, test.b)
+		if (actual > 0) != (test.expected > 0) || (actual < 0) != (test.expected < 0) {
+			t.Errorf("compareVersions(%q, %q) = %d, want sign of %d", test.a, test.b, actual, test.expected)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package servicefabric
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// xmlAttrPrefix prefixes attribute keys in decoded XML maps
+	xmlAttrPrefix = "@"
+	// xmlTextKey holds the character data of elements that also
+	// carry attributes or child elements in decoded XML maps
+	xmlTextKey = "#text"
+)
+
+// decodeXMLMap decodes an arbitrary XML document into nested maps keyed by
+// the root element name. Within an element, attributes are stored under
+// "@name", child elements under their local name and character data under
+// "#text". Elements holding nothing but text collapse to a plain string, and
+// repeated child elements are gathered into a []interface{}.
+func decodeXMLMap(r io.Reader) (map[string]interface{}, error) {
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, errors.New("no root element found")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			value, err := decodeXMLElement(decoder, start)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{start.Name.Local: value}, nil
+		}
+	}
+}
+
+func decodeXMLElement(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	element := map[string]interface{}{}
+	for _, attr := range start.Attr {
+		name := attr.Name.Local
+		if attr.Name.Space == "xmlns" {
+			name = "xmlns:" + name
+		}
+		element[xmlAttrPrefix+name] = attr.Value
+	}
+
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := decodeXMLElement(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			addXMLChild(element, t.Name.Local, child)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			data := strings.TrimSpace(text.String())
+			if len(element) == 0 {
+				return data, nil
+			}
+			if data != "" {
+				element[xmlTextKey] = data
+			}
+			return element, nil
+		}
+	}
+}
+
+func addXMLChild(element map[string]interface{}, name string, child interface{}) {
+	existing, ok := element[name]
+	if !ok {
+		element[name] = child
+		return
+	}
+
+	if siblings, ok := existing.([]interface{}); ok {
+		element[name] = append(siblings, child)
+		return
+	}
+	element[name] = []interface{}{existing, child}
+}